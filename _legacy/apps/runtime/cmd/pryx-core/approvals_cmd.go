@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"pryx-core/internal/server"
+	"pryx-core/internal/store"
+)
+
+func runApprovals(args []string) int {
+	if len(args) < 1 {
+		approvalsUsage()
+		return 2
+	}
+
+	switch args[0] {
+	case "list", "ls":
+		return runApprovalsList(args[1:])
+	case "revoke", "rm":
+		return runApprovalsRevoke(args[1:])
+	case "help", "-h", "--help":
+		approvalsUsage()
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", args[0])
+		approvalsUsage()
+		return 2
+	}
+}
+
+func runApprovalsList(args []string) int {
+	jsonOutput := false
+	sessionID := ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--json", "-j":
+			jsonOutput = true
+		case "--session":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "Error: --session requires a value\n")
+				return 2
+			}
+			sessionID = args[i+1]
+			i++
+		}
+	}
+
+	endpoint := "/api/v1/approvals/grants"
+	if sessionID != "" {
+		endpoint += "?session_id=" + url.QueryEscape(sessionID)
+	}
+	resp, err := runtimeRequest(http.MethodGet, endpoint)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Grants []store.ApprovalGrant `json:"grants"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid runtime response: %v\n", err)
+		return 1
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(out.Grants, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to marshal grants: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+
+	fmt.Printf("Remembered Approvals (%d)\n", len(out.Grants))
+	fmt.Println(strings.Repeat("=", 50))
+	if len(out.Grants) == 0 {
+		fmt.Println("No remembered approvals.")
+		return 0
+	}
+	for _, g := range out.Grants {
+		decision := "allow"
+		if !g.Approved {
+			decision = "deny"
+		}
+		fmt.Printf("• %s %s [%s]\n", decision, g.Tool, g.Scope)
+		fmt.Printf("  ID: %s\n", g.ID)
+		if g.SessionID != "" {
+			fmt.Printf("  Session: %s\n", g.SessionID)
+		}
+		if g.ExpiresAt != nil {
+			fmt.Printf("  Expires: %s\n", g.ExpiresAt.Local().Format(time.RFC3339))
+		}
+	}
+	return 0
+}
+
+func runApprovalsRevoke(args []string) int {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: grant ID required\n")
+		return 2
+	}
+
+	resp, err := runtimeRequest(http.MethodDelete, "/api/v1/approvals/grants/"+url.PathEscape(args[0]))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		fmt.Fprintf(os.Stderr, "Error: grant '%s' not found\n", args[0])
+		return 1
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		fmt.Fprintf(os.Stderr, "Error: runtime returned %s\n", resp.Status)
+		return 1
+	}
+	fmt.Printf("✓ Revoked %s\n", args[0])
+	return 0
+}
+
+// runtimeRequest sends a request to the running pryx-core runtime
+func runtimeRequest(method, path string) (*http.Response, error) {
+	return runtimeRequestBody(method, path, nil)
+}
+
+// runtimeRequestBody sends a request with a JSON body to the running
+// pryx-core runtime
+func runtimeRequestBody(method, path string, body io.Reader) (*http.Response, error) {
+	port, err := server.ReadPortFile()
+	if err != nil {
+		return nil, fmt.Errorf("pryx runtime is not running (%v)", err)
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("http://127.0.0.1:%d%s", port, path), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach runtime: %w", err)
+	}
+	return resp, nil
+}
+
+func approvalsUsage() {
+	fmt.Println("pryx-core approvals - Manage remembered tool approvals")
+	fmt.Println("")
+	fmt.Println("Commands:")
+	fmt.Println("  list [--session <id>] [--json]   List remembered approval decisions")
+	fmt.Println("  revoke <id>                      Forget a remembered decision")
+}
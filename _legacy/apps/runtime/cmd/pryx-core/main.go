@@ -58,6 +58,10 @@ func main() {
 			os.Exit(runChannel(os.Args[2:]))
 		case "session":
 			os.Exit(runSession(os.Args[2:]))
+		case "approvals":
+			os.Exit(runApprovals(os.Args[2:]))
+		case "policy":
+			os.Exit(runPolicy(os.Args[2:]))
 		case "login":
 			os.Exit(runLogin())
 		case "install-service":
@@ -354,6 +358,14 @@ func usage() {
 	log.Println("    test <name>                         Test MCP server")
 	log.Println("    auth <name>                         Manage authentication")
 	log.Println("")
+	log.Println("  approvals")
+	log.Println("    list [--session <id>] [--json]      List remembered tool approvals")
+	log.Println("    revoke <id>                         Revoke a remembered approval")
+	log.Println("")
+	log.Println("  policy")
+	log.Println("    explain <tool> [--args <json>]      Explain the policy decision for a call")
+	log.Println("    test <suite.yaml> [--json]          Check expected decisions from a suite")
+	log.Println("")
 	log.Println("  channel")
 	log.Println("    list [--json]                        List all channels")
 	log.Println("    add <type> <name>                  Add a new channel")
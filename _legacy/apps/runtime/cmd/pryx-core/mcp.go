@@ -1,13 +1,26 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
+	"pryx-core/internal/config"
+	"pryx-core/internal/keychain"
 	"pryx-core/internal/mcp"
+	"pryx-core/internal/server"
+	"pryx-core/internal/store"
 )
 
 func runMCP(args []string) int {
@@ -29,6 +42,10 @@ func runMCP(args []string) int {
 		return runMCPTest(args[1:])
 	case "auth":
 		return runMCPAuth(args[1:])
+	case "status":
+		return runMCPStatus(args[1:])
+	case "serve":
+		return runMCPServe(args[1:])
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", cmd)
 		mcpUsage()
@@ -103,6 +120,10 @@ func runMCPAdd(args []string) int {
 	}
 
 	name := args[0]
+	if !mcp.ValidServerName(name) {
+		fmt.Fprintf(os.Stderr, "Error: invalid server name %q (names may not contain ':', '/' or '__')\n", name)
+		return 2
+	}
 	serverURL := ""
 	var command []string
 	var authType string
@@ -284,7 +305,7 @@ func runMCPAuth(args []string) int {
 
 	name := args[0]
 
-	cfg, _, err := mcp.LoadServersConfigFromFirstExisting(mcp.DefaultServersConfigPaths())
+	cfg, path, err := mcp.LoadServersConfigFromFirstExisting(mcp.DefaultServersConfigPaths())
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
 		return 1
@@ -296,6 +317,23 @@ func runMCPAuth(args []string) int {
 		return 1
 	}
 
+	if len(args) > 1 {
+		switch args[1] {
+		case "login":
+			return runMCPAuthLogin(name, server, cfg, path)
+		case "logout":
+			if err := keychain.New("pryx").Delete(mcp.OAuthKeychainKey(name)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to remove credentials: %v\n", err)
+				return 1
+			}
+			fmt.Printf("✓ Removed OAuth credentials for %s\n", name)
+			return 0
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown auth command: %s (expected login or logout)\n", args[1])
+			return 2
+		}
+	}
+
 	fmt.Printf("MCP Server Authentication: %s\n", name)
 	fmt.Println(strings.Repeat("=", 40))
 
@@ -305,7 +343,18 @@ func runMCPAuth(args []string) int {
 		fmt.Println("  Types: bearer, basic, api_key")
 	} else {
 		fmt.Printf("Type: %s\n", server.Auth.Type)
-		if server.Auth.TokenRef != "" {
+		if server.Auth.Type == "oauth" && server.Auth.TokenRef == "" {
+			creds, err := mcp.LoadOAuthCredentials(keychain.New("pryx"), name)
+			if err != nil {
+				fmt.Println("Status: not authorized")
+				fmt.Printf("Run: pryx-core mcp auth %s login\n", name)
+			} else {
+				fmt.Println("Status: authorized (tokens stored in OS keychain)")
+				if !creds.ExpiresAt.IsZero() {
+					fmt.Printf("Access token expires: %s\n", creds.ExpiresAt.Local().Format(time.RFC3339))
+				}
+			}
+		} else if server.Auth.TokenRef != "" {
 			fmt.Printf("Token Reference: %s\n", server.Auth.TokenRef)
 			fmt.Println("(Token stored in OS keychain)")
 		} else {
@@ -316,6 +365,207 @@ func runMCPAuth(args []string) int {
 	return 0
 }
 
+func runMCPAuthLogin(name string, server mcp.ServerConfig, cfg *mcp.ServersConfig, path string) int {
+	if server.Transport != "http" && server.Transport != "sse" {
+		fmt.Fprintf(os.Stderr, "Error: OAuth is only supported for remote (http/sse) servers\n")
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	ctx, timeoutCancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer timeoutCancel()
+
+	fmt.Printf("Authorizing MCP server %s...\n", name)
+	creds, err := mcp.AuthorizeServer(ctx, keychain.New("pryx"), name, server)
+	if err != nil {
+		fmt.Printf("✗ OAuth failed: %v\n", err)
+		return 1
+	}
+
+	if server.Auth == nil || server.Auth.Type != "oauth" || server.Auth.TokenRef != "" {
+		server.Auth = &mcp.AuthConfig{Type: "oauth"}
+		cfg.Servers[name] = server
+		if path == "" {
+			path = getDefaultMCPServerPath()
+		}
+		if err := saveMCPServerConfig(path, cfg); err != nil {
+			fmt.Printf("✗ Failed to update config: %v\n", err)
+			return 1
+		}
+	}
+
+	fmt.Println("✓ OAuth completed successfully!")
+	fmt.Println("✓ Tokens saved securely in keychain")
+	if !creds.ExpiresAt.IsZero() {
+		fmt.Printf("Access token expires: %s\n", creds.ExpiresAt.Local().Format(time.RFC3339))
+	}
+	return 0
+}
+
+func runMCPStatus(args []string) int {
+	jsonOutput := false
+	for _, arg := range args {
+		if arg == "--json" || arg == "-j" {
+			jsonOutput = true
+		}
+	}
+
+	port, err := server.ReadPortFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: pryx runtime is not running (%v)\n", err)
+		return 1
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/mcp/servers", port))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to reach runtime: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Servers []mcp.ServerState `json:"servers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid runtime response: %v\n", err)
+		return 1
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(out.Servers, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to marshal servers: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+
+	fmt.Printf("MCP Server Status (%d)\n", len(out.Servers))
+	fmt.Println(strings.Repeat("=", 50))
+	if len(out.Servers) == 0 {
+		fmt.Println("No MCP servers running.")
+		return 0
+	}
+
+	for _, st := range out.Servers {
+		icon := "•"
+		switch st.Status {
+		case mcp.ServerStatusReady:
+			icon = "✓"
+		case mcp.ServerStatusFailed:
+			icon = "✗"
+		}
+		fmt.Printf("%s %s [%s] %s\n", icon, st.Name, st.Transport, st.Status)
+		if st.Error != "" {
+			fmt.Printf("  Error: %s\n", st.Error)
+		}
+		if st.Attempts > 1 {
+			fmt.Printf("  Attempts: %d\n", st.Attempts)
+		}
+		if st.NextRetryAt != nil {
+			fmt.Printf("  Next retry: %s\n", st.NextRetryAt.Local().Format(time.RFC3339))
+		}
+	}
+
+	return 0
+}
+
+func runMCPServe(args []string) int {
+	httpAddr := ""
+	httpOpts := mcp.HTTPServeOptions{Token: strings.TrimSpace(os.Getenv("PRYX_MCP_TOKEN"))}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--http":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "Error: --http requires an address (e.g. 127.0.0.1:8765)\n")
+				return 2
+			}
+			httpAddr = args[i+1]
+			i++
+		case "--allow-origin":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "Error: --allow-origin requires an origin\n")
+				return 2
+			}
+			httpOpts.AllowedOrigins = append(httpOpts.AllowedOrigins, args[i+1])
+			i++
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown option: %s\n", args[i])
+			return 2
+		}
+	}
+
+	if httpAddr != "" && httpOpts.Token == "" && !loopbackAddr(httpAddr) {
+		fmt.Fprintf(os.Stderr, "Error: refusing to serve on non-loopback address %s without PRYX_MCP_TOKEN\n", httpAddr)
+		return 2
+	}
+
+	// stdout carries the protocol in stdio mode, so keep logs on stderr.
+	log.SetOutput(os.Stderr)
+
+	cfg := config.Load()
+	st, err := store.New(cfg.DatabasePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open store: %v\n", err)
+		return 1
+	}
+	defer st.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	srv := server.NewMCPHost(ctx, cfg, st.DB, keychain.New("pryx"))
+	defer srv.MCP().Close()
+
+	// Waits for each server's first attempt, so the initial tools/list is
+	// complete.
+	connectCtx, connectCancel := context.WithTimeout(ctx, 10*time.Second)
+	if _, err := srv.MCP().LoadAndConnect(connectCtx); err != nil {
+		log.Printf("mcp serve: some servers failed to connect: %v", err)
+	}
+	connectCancel()
+	go srv.MCP().WatchConfig(ctx)
+
+	provider := mcp.NewAggregateProvider(srv.MCP(), Version)
+
+	if httpAddr == "" {
+		if err := mcp.ServeStdio(ctx, provider); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, context.Canceled) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	handler := mcp.NewStreamableHTTPHandler(provider, httpOpts)
+	go handler.Run(ctx)
+	httpServer := &http.Server{Addr: httpAddr, Handler: handler}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+	log.Printf("mcp serve: listening on http://%s", httpAddr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// loopbackAddr reports whether a listen address only accepts local
+// connections; an empty host listens on every interface.
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	return mcp.IsLoopbackHost(host)
+}
+
 func mcpUsage() {
 	fmt.Println("pryx-core mcp - Manage MCP servers")
 	fmt.Println("")
@@ -325,7 +575,11 @@ func mcpUsage() {
 	fmt.Println("  add <name> --cmd <command>    Add stdio MCP server")
 	fmt.Println("  remove <name>                 Remove an MCP server")
 	fmt.Println("  test <name>                   Test MCP server connection")
-	fmt.Println("  auth <name>                   Manage authentication")
+	fmt.Println("  auth <name> [login|logout]    Show or manage authentication (OAuth login)")
+	fmt.Println("  status                        Show live server status from the runtime")
+	fmt.Println("  serve [--http <addr>]         Serve all MCP servers and pryx tools as one MCP server")
+	fmt.Println("                                (non-loopback --http addresses require PRYX_MCP_TOKEN;")
+	fmt.Println("                                --allow-origin <origin> admits a browser origin)")
 	fmt.Println("")
 	fmt.Println("Options:")
 	fmt.Println("  --url, -u <url>               Server URL (for HTTP transport)")
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"pryx-core/internal/policy"
+	"pryx-core/internal/store"
+)
+
+func runPolicy(args []string) int {
+	if len(args) < 1 {
+		policyUsage()
+		return 2
+	}
+
+	switch args[0] {
+	case "explain":
+		return runPolicyExplain(args[1:])
+	case "test":
+		return runPolicyTest(args[1:])
+	case "help", "-h", "--help":
+		policyUsage()
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", args[0])
+		policyUsage()
+		return 2
+	}
+}
+
+// policyEvaluation is the response of /api/v1/policies/evaluate
+type policyEvaluation struct {
+	Explanation policy.Explanation    `json:"explanation"`
+	Policies    []policy.LayerSummary `json:"policies"`
+	Policy      *policy.Policy        `json:"policy"`
+	Grant       *store.ApprovalGrant  `json:"grant,omitempty"`
+}
+
+func runPolicyExplain(args []string) int {
+	jsonOutput := false
+	tool := ""
+	rawArgs := ""
+	var caller policy.Caller
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--json", "-j":
+			jsonOutput = true
+		case "--args", "--session", "--surface", "--source", "--channel", "--sender", "--trust":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "Error: %s requires a value\n", args[i])
+				return 2
+			}
+			value := args[i+1]
+			switch args[i] {
+			case "--args":
+				rawArgs = value
+			case "--session":
+				caller.SessionID = value
+			case "--surface":
+				caller.Surface = value
+			case "--source":
+				caller.Source = value
+			case "--channel":
+				caller.ChannelID = value
+			case "--sender":
+				caller.Sender = value
+			case "--trust":
+				caller.Trust = policy.Trust(value)
+			}
+			i++
+		default:
+			if tool != "" || strings.HasPrefix(args[i], "-") {
+				fmt.Fprintf(os.Stderr, "Error: unexpected argument %s\n", args[i])
+				return 2
+			}
+			tool = args[i]
+		}
+	}
+	if tool == "" {
+		fmt.Fprintf(os.Stderr, "Error: tool name required\n")
+		return 2
+	}
+	var callArgs map[string]interface{}
+	if rawArgs != "" {
+		if err := json.Unmarshal([]byte(rawArgs), &callArgs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --args must be a JSON object: %v\n", err)
+			return 2
+		}
+	}
+
+	out, local, err := evaluatePolicy(tool, callArgs, caller)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to marshal explanation: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+
+	x := out.Explanation
+	if local {
+		fmt.Println("Runtime not running; evaluated the policy files directly.")
+		if caller.SessionID != "" {
+			fmt.Println("Remembered approvals for the session were not checked.")
+		}
+		fmt.Println("")
+	}
+	fmt.Printf("Caller: %s\n", describeCaller(x.Caller))
+	fmt.Printf("Decision: %s\n", x.Decision)
+	if x.Default {
+		fmt.Println("Decided by: default policy (no rule matched)")
+	} else {
+		fmt.Printf("Decided by: rule %s%s\n", ruleLabel(x.RuleID, x.Rules[len(x.Rules)-1].Index), layerLabel(x.Layer))
+	}
+	if x.Reason != "" {
+		fmt.Printf("Reason: %s\n", x.Reason)
+	}
+	for _, l := range x.Limits {
+		state := fmt.Sprintf("%d of %d used", l.Used, l.Max)
+		switch {
+		case l.Error != "":
+			state = "unavailable: " + l.Error
+		case l.Reached:
+			state += ", reached"
+		}
+		fmt.Printf("Limit: %d calls per %s per %s (%s)\n", l.Max, l.Window, l.Per, state)
+	}
+	if out.Grant != nil {
+		decision := "allow"
+		if !out.Grant.Approved {
+			decision = "deny"
+		}
+		fmt.Printf("Remembered approval: %s [%s] (%s)\n", decision, out.Grant.Scope, out.Grant.ID)
+	}
+
+	fmt.Println("")
+	fmt.Printf("Rules considered (%d)\n", len(x.Rules))
+	fmt.Println(strings.Repeat("=", 50))
+	for _, r := range x.Rules {
+		mark := "✗"
+		if r.Matched {
+			mark = "✓"
+		}
+		fmt.Printf("%s %s%s %s → %s\n", mark, ruleLabel(r.ID, r.Index), layerLabel(r.Layer), r.Tool, r.Decision)
+		if r.Reason != "" {
+			fmt.Printf("  %s\n", r.Reason)
+		}
+	}
+
+	fmt.Println("")
+	fmt.Println("Policy layers")
+	fmt.Println(strings.Repeat("=", 50))
+	for _, l := range out.Policies {
+		switch {
+		case l.Error != "":
+			fmt.Printf("• %s: invalid, using last accepted version (%s)\n", l.ID, l.Error)
+		case !l.Exists:
+			fmt.Printf("• %s: none\n", l.ID)
+		default:
+			fmt.Printf("• %s: %d rules\n", l.ID, l.Rules)
+		}
+		if l.Path != "" {
+			fmt.Printf("  %s\n", l.Path)
+		}
+		for _, ignored := range l.Ignored {
+			fmt.Printf("  ignored: %s\n", ignored)
+		}
+	}
+	if out.Policy != nil {
+		fmt.Printf("Effective: %d rules, default %s\n", len(out.Policy.Rules), out.Policy.Default)
+	}
+	return 0
+}
+
+// evaluatePolicy asks the runtime to explain the call. When the runtime is
+// not running it evaluates the policy files itself and reports local.
+func evaluatePolicy(tool string, args map[string]interface{}, caller policy.Caller) (*policyEvaluation, bool, error) {
+	body, err := json.Marshal(map[string]interface{}{"tool": tool, "args": args, "session_id": caller.SessionID, "caller": caller})
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := runtimeRequestBody(http.MethodPost, "/api/v1/policies/evaluate", bytes.NewReader(body))
+	if err != nil {
+		loader := policy.NewDefaultLoader(policy.NewEngine(nil))
+		if err := loader.Load(); err != nil {
+			return nil, false, err
+		}
+		return &policyEvaluation{
+			Explanation: loader.Engine().Explain(tool, args, caller),
+			Policies:    loader.Summaries(),
+			Policy:      loader.Effective(),
+		}, true, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, false, fmt.Errorf("runtime returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var out policyEvaluation
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, false, fmt.Errorf("invalid runtime response: %v", err)
+	}
+	return &out, false, nil
+}
+
+func runPolicyTest(args []string) int {
+	jsonOutput := false
+	path := ""
+	for _, arg := range args {
+		switch arg {
+		case "--json", "-j":
+			jsonOutput = true
+		default:
+			if path != "" || strings.HasPrefix(arg, "-") {
+				fmt.Fprintf(os.Stderr, "Error: unexpected argument %s\n", arg)
+				return 2
+			}
+			path = arg
+		}
+	}
+	if path == "" {
+		fmt.Fprintf(os.Stderr, "Error: suite file required\n")
+		return 2
+	}
+
+	suite, err := policy.LoadSuite(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	engine, err := suite.Engine()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if engine == nil {
+		// No policy files named: check the ones the runtime would load here
+		loader := policy.NewDefaultLoader(policy.NewEngine(nil))
+		if err := loader.Load(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		engine = loader.Engine()
+	}
+
+	results := suite.Run(engine)
+	failed := 0
+	for _, r := range results {
+		if !r.Passed {
+			failed++
+		}
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"passed":  len(results) - failed,
+			"failed":  failed,
+			"results": results,
+		}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to marshal results: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, r := range results {
+			name := r.Case.Name
+			if name == "" {
+				name = r.Case.Tool
+			}
+			if r.Passed {
+				fmt.Printf("✓ %s\n", name)
+				continue
+			}
+			fmt.Printf("✗ %s: %s\n", name, r.Failure)
+			if n := len(r.Explanation.Rules); n > 0 && !r.Explanation.Default {
+				last := r.Explanation.Rules[n-1]
+				fmt.Printf("  decided by %s%s\n", ruleLabel(last.ID, last.Index), layerLabel(last.Layer))
+			}
+		}
+		fmt.Printf("\n%d passed, %d failed\n", len(results)-failed, failed)
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// describeCaller summarises who a call was evaluated for
+func describeCaller(c policy.Caller) string {
+	surface := c.Surface
+	if surface == "" {
+		surface = "(none)"
+	}
+	parts := []string{"surface " + surface}
+	for _, f := range []struct{ name, value string }{
+		{"source", c.Source}, {"channel", c.ChannelID}, {"sender", c.Sender}, {"session", c.SessionID},
+	} {
+		if f.value != "" {
+			parts = append(parts, f.name+" "+f.value)
+		}
+	}
+	return strings.Join(parts, ", ") + ", trust " + string(c.Trust)
+}
+
+// ruleLabel names a rule by ID, or by position when it has none
+func ruleLabel(id string, index int) string {
+	if id != "" {
+		return id
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+func layerLabel(layer string) string {
+	if layer == "" {
+		return ""
+	}
+	return " [" + layer + "]"
+}
+
+func policyUsage() {
+	fmt.Println("pryx-core policy - Inspect and test tool policies")
+	fmt.Println("")
+	fmt.Println("Commands:")
+	fmt.Println("  explain <tool> [--args <json>] [--session <id>] [--json]")
+	fmt.Println("          [--surface <name>] [--source <id>] [--channel <id>] [--sender <id>] [--trust <level>]")
+	fmt.Println("                                   Explain the decision for a tool call")
+	fmt.Println("  test <suite.yaml> [--json]       Check a suite of expected decisions")
+}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"pryx-core/internal/audit"
 	"pryx-core/internal/hostrpc"
+	"pryx-core/internal/policy"
 	"pryx-core/internal/server"
 )
 
@@ -65,13 +67,76 @@ func setupAdminHandlers(srv *server.Server) *hostrpc.Registry {
 
 	// --- Policies ---
 	reg.Register("admin.policies.list", func(method string, params map[string]interface{}) (interface{}, error) {
+		effective := srv.Policies().Effective()
 		return map[string]interface{}{
-			"policies": []map[string]string{
-				{"id": "default", "name": "Default", "description": "Default system policy"},
+			"policies": srv.Policies().Summaries(),
+			"effective": map[string]interface{}{
+				"rules":   len(effective.Rules),
+				"default": effective.Default,
 			},
 		}, nil
 	})
 
+	reg.Register("admin.policies.get", func(method string, params map[string]interface{}) (interface{}, error) {
+		id, _ := params["id"].(string)
+		if id == "" || id == "effective" {
+			return policy.Layer{ID: "effective", Exists: true, Policy: srv.Policies().Effective()}, nil
+		}
+		layer, ok := srv.Policies().Layer(id)
+		if !ok {
+			return nil, fmt.Errorf("unknown policy: %s", id)
+		}
+		return layer, nil
+	})
+
+	reg.Register("admin.policies.validate", func(method string, params map[string]interface{}) (interface{}, error) {
+		content, _ := params["content"].(string)
+		if _, err := policy.Parse([]byte(content)); err != nil {
+			return map[string]interface{}{"valid": false, "error": err.Error()}, nil
+		}
+		return map[string]interface{}{"valid": true}, nil
+	})
+
+	reg.Register("admin.policies.update", func(method string, params map[string]interface{}) (interface{}, error) {
+		id, _ := params["id"].(string)
+		content, _ := params["content"].(string)
+		if id == "" {
+			return nil, fmt.Errorf("id required")
+		}
+		if err := srv.Policies().Write(id, []byte(content)); err != nil {
+			return nil, err
+		}
+		layer, _ := srv.Policies().Layer(id)
+		return layer, nil
+	})
+
+	reg.Register("admin.policies.reload", func(method string, params map[string]interface{}) (interface{}, error) {
+		if err := srv.Policies().Load(); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"policies": srv.Policies().Summaries()}, nil
+	})
+
+	reg.Register("admin.policies.evaluate", func(method string, params map[string]interface{}) (interface{}, error) {
+		tool, _ := params["tool"].(string)
+		if tool == "" {
+			return nil, fmt.Errorf("tool required")
+		}
+		args, _ := params["args"].(map[string]interface{})
+		var caller policy.Caller
+		if raw, ok := params["caller"]; ok {
+			b, _ := json.Marshal(raw)
+			if err := json.Unmarshal(b, &caller); err != nil {
+				return nil, fmt.Errorf("invalid caller: %w", err)
+			}
+		}
+		return map[string]interface{}{
+			"explanation": srv.Policies().Engine().Explain(tool, args, caller),
+			"policies":    srv.Policies().Summaries(),
+			"policy":      srv.Policies().Effective(),
+		}, nil
+	})
+
 	// --- Audit ---
 	reg.Register("admin.audit.list", func(method string, params map[string]interface{}) (interface{}, error) {
 		limit := 50
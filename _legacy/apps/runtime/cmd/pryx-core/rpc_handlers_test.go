@@ -37,6 +37,24 @@ func TestRPCHandlers(t *testing.T) {
 			params: nil,
 			want:   "openai",
 		},
+		{
+			name:   "Policies list",
+			method: "admin.policies.list",
+			params: nil,
+			want:   `"id":"builtin"`,
+		},
+		{
+			name:   "Policy validate",
+			method: "admin.policies.validate",
+			params: map[string]interface{}{"content": "rules:\n  - tool: x\n    decision: maybe\n"},
+			want:   `"valid":false`,
+		},
+		{
+			name:   "Policy evaluate",
+			method: "admin.policies.evaluate",
+			params: map[string]interface{}{"tool": "mcp.unknown.tool"},
+			want:   `"reason":"Default policy","default":true`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -95,8 +95,11 @@ func New(cfg *config.Config, eventBus *bus.Bus, kc *keychain.Keychain, catalog *
 
 // Run starts the agent's main event loop, listening for chat requests and channel messages.
 func (a *Agent) Run(ctx context.Context) error {
-	// Subscribe to incoming messages
-	events, cancel := a.bus.Subscribe(bus.EventChatRequest, bus.EventChannelMessage)
+	// Subscribe to incoming messages. None may be lost while a reply is
+	// generated, so a backlog spills over rather than dropping.
+	events, cancel := a.bus.SubscribeDelivery(bus.Filter{
+		Topics: []bus.EventType{bus.EventChatRequest, bus.EventChannelMessage, bus.EventElicitationRequested},
+	}, bus.Delivery{Mode: bus.DeliverSpill})
 	defer cancel()
 
 	log.Println("Agent: Started listening for messages...")
@@ -133,9 +136,31 @@ func (a *Agent) handleEvent(ctx context.Context, evt bus.Event) {
 		a.handleChatRequest(ctx, evt)
 	case bus.EventChannelMessage:
 		a.handleChannelMessage(ctx, evt)
+	case bus.EventElicitationRequested:
+		a.handleElicitationRequest(evt)
 	}
 }
 
+// handleElicitationRequest asks a channel user for the input an MCP server
+// requested. Other surfaces render the request themselves.
+func (a *Agent) handleElicitationRequest(evt bus.Event) {
+	_, source, chatID, ok := channels.ParseSurface(evt.Surface)
+	if !ok {
+		return
+	}
+	req, err := bus.DecodePayload[mcp.ElicitationRequested](evt)
+	if err != nil {
+		log.Printf("Agent: Invalid elicitation payload: %v", err)
+		return
+	}
+
+	a.bus.Publish(bus.NewEvent(bus.EventChannelOutboundMessage, "", channels.Message{
+		Source:    source,
+		ChannelID: chatID,
+		Content:   mcp.ElicitationPrompt(req.Message, req.Fields),
+	}))
+}
+
 func (a *Agent) handleChatRequest(ctx context.Context, evt bus.Event) {
 	// Panic recovery at handler level
 	defer func() {
@@ -148,13 +173,13 @@ func (a *Agent) handleChatRequest(ctx context.Context, evt bus.Event) {
 		}
 	}()
 
-	payload, ok := evt.Payload.(map[string]interface{})
-	if !ok {
-		log.Println("Agent: Invalid chat request payload")
+	payload, err := bus.DecodePayload[bus.ChatRequest](evt)
+	if err != nil {
+		log.Printf("Agent: Invalid chat request payload: %v", err)
 		return
 	}
 
-	content, _ := payload["content"].(string)
+	content := payload.Content
 	sessionID := evt.SessionID
 
 	if content == "" {
@@ -202,9 +227,9 @@ func (a *Agent) handleChatRequest(ctx context.Context, evt bus.Event) {
 		fullResponse.WriteString(chunk.Content)
 
 		// Publish delta to TUI
-		a.bus.Publish(bus.NewEvent(bus.EventSessionMessage, sessionID, map[string]interface{}{
-			"content": chunk.Content,
-			"done":    chunk.Done,
+		a.bus.Publish(bus.NewEvent(bus.EventSessionMessage, sessionID, bus.SessionMessage{
+			Content: chunk.Content,
+			Done:    chunk.Done,
 		}))
 
 		if chunk.Done {
@@ -227,14 +252,32 @@ func (a *Agent) handleChannelMessage(ctx context.Context, evt bus.Event) {
 		}
 	}()
 
-	msg, ok := evt.Payload.(channels.Message)
-	if !ok {
-		log.Println("Agent: Invalid channel message payload")
+	msg, err := bus.DecodePayload[channels.Message](evt)
+	if err != nil {
+		log.Printf("Agent: Invalid channel message payload: %v", err)
 		return
 	}
 
 	log.Printf("Agent: Processing channel message from %s (chat: %s): %s", msg.Source, msg.ChannelID, msg.Content)
 
+	// Tool calls made for the message are the sender's, from their chat
+	ctx = mcp.WithCaller(mcp.WithSurface(ctx, msg.Surface()), msg.Caller())
+
+	// A reply to a pending elicitation answers it instead of starting a turn
+	if a.mcp != nil {
+		handled, err := a.mcp.AnswerElicitationText(msg.Surface(), msg.Content)
+		if handled {
+			if err != nil {
+				a.bus.Publish(bus.NewEvent(bus.EventChannelOutboundMessage, "", channels.Message{
+					Source:    msg.Source,
+					ChannelID: msg.ChannelID,
+					Content:   err.Error() + `. Try again, or reply "decline" to skip.`,
+				}))
+			}
+			return
+		}
+	}
+
 	systemPrompt, err := a.buildSystemPrompt("")
 	if err != nil {
 		log.Printf("Agent: Failed to build system prompt: %v", err)
@@ -262,10 +305,10 @@ func (a *Agent) handleChannelMessage(ctx context.Context, evt bus.Event) {
 
 	log.Printf("Agent: Sending channel response (%d chars)", len(resp.Content))
 
-	a.bus.Publish(bus.NewEvent(bus.EventChannelOutboundMessage, "", map[string]interface{}{
-		"source":     msg.Source,
-		"channel_id": msg.ChannelID,
-		"content":    resp.Content,
+	a.bus.Publish(bus.NewEvent(bus.EventChannelOutboundMessage, "", channels.Message{
+		Source:    msg.Source,
+		ChannelID: msg.ChannelID,
+		Content:   resp.Content,
 	}))
 }
 
@@ -13,6 +13,8 @@ import (
 	"pryx-core/internal/keychain"
 	"pryx-core/internal/llm"
 	"pryx-core/internal/llm/factory"
+	"pryx-core/internal/mcp"
+	"pryx-core/internal/policy"
 	"pryx-core/internal/store"
 )
 
@@ -95,7 +97,8 @@ func (s *Spawner) Spawn(ctx context.Context, parentID, sessionID, task, systemCo
 		return nil, fmt.Errorf("failed to create LLM provider: %w", err)
 	}
 
-	agentCtx, cancel := context.WithCancel(ctx)
+	// Tools the sub-agent calls are made for it, not for whoever spawned it
+	agentCtx, cancel := context.WithCancel(mcp.WithCaller(ctx, policy.Caller{Surface: policy.SurfaceSubagent, SessionID: sessionID}))
 
 	agent := &SubAgent{
 		ID:        agentID,
@@ -277,11 +280,11 @@ func (a *SubAgent) publishResult(result Result) {
 
 	// Also notify parent session
 	if a.ParentID != "" {
-		a.bus.Publish(bus.NewEvent(bus.EventSessionMessage, a.ParentID, map[string]interface{}{
-			"role":     "subagent",
-			"agent_id": a.ID,
-			"status":   result.Status,
-			"content":  result.Output,
+		a.bus.Publish(bus.NewEvent(bus.EventSessionMessage, a.ParentID, bus.SessionMessage{
+			Role:    "subagent",
+			AgentID: a.ID,
+			Status:  string(result.Status),
+			Content: result.Output,
 		}))
 	}
 }
@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"pryx-core/internal/bus"
+)
+
+// recordedEvents maps bus events to the audit action they are persisted as
+var recordedEvents = map[bus.EventType]AuditAction{
+	bus.EventSandboxViolation: ActionSandboxViolation,
+	bus.EventToolRequest:      ActionToolRequest,
+	bus.EventToolComplete:     ActionToolComplete,
+	bus.EventApprovalResolved: ActionApprovalGrant,
+	bus.EventApprovalRevoked:  ActionApprovalRevoke,
+	bus.EventNetworkRequest:   ActionNetworkRequest,
+}
+
+// Recorder persists security-relevant bus events to the audit log
+type Recorder struct {
+	repo *AuditRepository
+	bus  *bus.Bus
+}
+
+// NewRecorder creates a recorder writing to repo
+func NewRecorder(repo *AuditRepository, b *bus.Bus) *Recorder {
+	return &Recorder{repo: repo, bus: b}
+}
+
+// Run records events until ctx is cancelled
+func (r *Recorder) Run(ctx context.Context) {
+	topics := make([]bus.EventType, 0, len(recordedEvents))
+	for t := range recordedEvents {
+		topics = append(topics, t)
+	}
+	// Spill rather than drop so slow writes do not leave gaps in the log
+	events, cancel := r.bus.SubscribeDelivery(bus.Filter{Topics: topics}, bus.Delivery{Mode: bus.DeliverSpill})
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := r.Record(evt); err != nil {
+				log.Printf("audit: failed to record %s: %v", evt.Event, err)
+			}
+		}
+	}
+}
+
+// Record writes a single event to the audit log
+func (r *Recorder) Record(evt bus.Event) error {
+	action, ok := recordedEvents[evt.Event]
+	if !ok {
+		return fmt.Errorf("unrecorded event type: %s", evt.Event)
+	}
+
+	entry := &AuditEntry{
+		Timestamp: evt.Timestamp,
+		SessionID: evt.SessionID,
+		Surface:   evt.Surface,
+		Action:    action,
+		Payload:   evt.Payload,
+		Success:   true,
+	}
+
+	// Payloads are typed by their publishers; the audit log reads them
+	// generically
+	payload, err := bus.DecodePayload[map[string]interface{}](evt)
+	if err == nil && payload != nil {
+		entry.Payload = payload
+		if tool, ok := payload["tool"].(string); ok {
+			entry.Tool = tool
+		}
+		if msg, ok := payload["error"].(string); ok {
+			entry.ErrorMsg = msg
+		}
+		if desc, ok := payload["description"].(string); ok {
+			entry.Description = desc
+		}
+		if approved, ok := payload["approved"].(bool); ok && !approved && action == ActionApprovalGrant {
+			entry.Action = ActionApprovalDeny
+		}
+		// Tool output can be large and is not needed to reconstruct what ran
+		if _, ok := payload["result"]; ok && action == ActionToolComplete {
+			trimmed := make(map[string]interface{}, len(payload))
+			for k, v := range payload {
+				if k != "result" {
+					trimmed[k] = v
+				}
+			}
+			entry.Payload = trimmed
+		}
+	}
+
+	if action == ActionNetworkRequest {
+		entry.Success = entry.ErrorMsg == ""
+		if payload != nil && entry.Description == "" {
+			method, _ := payload["method"].(string)
+			url, _ := payload["url"].(string)
+			entry.Description = strings.TrimSpace(method + " " + url)
+		}
+	}
+
+	if action == ActionSandboxViolation {
+		entry.Success = false
+		if entry.Description == "" {
+			entry.Description = "sandbox violation"
+		}
+	}
+
+	return r.repo.Create(entry)
+}
@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"os"
+	"testing"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/store"
+)
+
+func TestRecorderRecordSandboxViolation(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "audit_recorder_test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	s, err := store.New(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	repo := NewAuditRepository(s.DB)
+	rec := NewRecorder(repo, bus.New())
+
+	evt := bus.NewEvent(bus.EventSandboxViolation, "session-1", map[string]interface{}{
+		"server": "shell",
+		"tool":   "mcp.shell.exec",
+		"kind":   "sudo",
+		"error":  "sandbox violation (sudo): privilege escalation is blocked: sudo",
+	})
+	if err := rec.Record(evt); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	entries, err := repo.Query(QueryOptions{Action: ActionSandboxViolation})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	if entries[0].Tool != "mcp.shell.exec" || entries[0].Success {
+		t.Errorf("Unexpected entry: %+v", entries[0])
+	}
+
+	if err := rec.Record(bus.NewEvent(bus.EventSessionTyping, "", nil)); err == nil {
+		t.Error("Expected error for unrecorded event type")
+	}
+}
+
+func TestRecorderRecordApprovalDeny(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "audit_recorder_test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	s, err := store.New(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	repo := NewAuditRepository(s.DB)
+	rec := NewRecorder(repo, bus.New())
+
+	evt := bus.NewEvent(bus.EventApprovalResolved, "session-1", map[string]interface{}{
+		"approval_id": "a-1",
+		"tool":        "mcp.shell.exec",
+		"approved":    false,
+	})
+	evt.Surface = "mcp"
+	if err := rec.Record(evt); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	entries, err := repo.Query(QueryOptions{Action: ActionApprovalDeny})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Surface != "mcp" {
+		t.Fatalf("Unexpected entries: %+v", entries)
+	}
+}
+
+func TestRecorderRecordNetworkRequest(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "audit_recorder_test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	s, err := store.New(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	repo := NewAuditRepository(s.DB)
+	rec := NewRecorder(repo, bus.New())
+
+	for _, payload := range []map[string]interface{}{
+		{"method": "GET", "url": "https://example.com/", "host": "example.com", "status": 200},
+		{"method": "GET", "url": "http://127.0.0.1/", "host": "127.0.0.1", "blocked": true, "error": "sandbox violation (network): private address not allowed: 127.0.0.1"},
+	} {
+		if err := rec.Record(bus.NewEvent(bus.EventNetworkRequest, "session-1", payload)); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	entries, err := repo.Query(QueryOptions{Action: ActionNetworkRequest})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	failed := 0
+	for _, e := range entries {
+		if !e.Success {
+			failed++
+		}
+		if e.Description == "" {
+			t.Errorf("Expected description: %+v", e)
+		}
+	}
+	if failed != 1 {
+		t.Errorf("Expected 1 failed request, got %d", failed)
+	}
+}
@@ -13,21 +13,24 @@ import (
 type AuditAction string
 
 const (
-	ActionSessionCreate   AuditAction = "session.create"
-	ActionSessionUpdate   AuditAction = "session.update"
-	ActionSessionDelete   AuditAction = "session.delete"
-	ActionMessageSend     AuditAction = "message.send"
-	ActionToolRequest     AuditAction = "tool.request"
-	ActionToolExecute     AuditAction = "tool.execute"
-	ActionToolComplete    AuditAction = "tool.complete"
-	ActionToolError       AuditAction = "tool.error"
-	ActionApprovalRequest AuditAction = "approval.request"
-	ActionApprovalGrant   AuditAction = "approval.grant"
-	ActionApprovalDeny    AuditAction = "approval.deny"
-	ActionChannelMessage  AuditAction = "channel.message"
-	ActionChannelStatus   AuditAction = "channel.status"
-	ActionErrorOccurred   AuditAction = "error.occurred"
-	ActionUserAction      AuditAction = "user.action"
+	ActionSessionCreate    AuditAction = "session.create"
+	ActionSessionUpdate    AuditAction = "session.update"
+	ActionSessionDelete    AuditAction = "session.delete"
+	ActionMessageSend      AuditAction = "message.send"
+	ActionToolRequest      AuditAction = "tool.request"
+	ActionToolExecute      AuditAction = "tool.execute"
+	ActionToolComplete     AuditAction = "tool.complete"
+	ActionToolError        AuditAction = "tool.error"
+	ActionApprovalRequest  AuditAction = "approval.request"
+	ActionApprovalGrant    AuditAction = "approval.grant"
+	ActionApprovalDeny     AuditAction = "approval.deny"
+	ActionApprovalRevoke   AuditAction = "approval.revoke"
+	ActionChannelMessage   AuditAction = "channel.message"
+	ActionChannelStatus    AuditAction = "channel.status"
+	ActionErrorOccurred    AuditAction = "error.occurred"
+	ActionUserAction       AuditAction = "user.action"
+	ActionSandboxViolation AuditAction = "sandbox.violation"
+	ActionNetworkRequest   AuditAction = "network.request"
 )
 
 // AuditEntry represents a single audit log entry
@@ -12,6 +12,9 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
 	"time"
 
 	"pryx-core/internal/keychain"
@@ -29,12 +32,15 @@ func NewProviderOAuth(kc *keychain.Keychain) *ProviderOAuth {
 
 // ProviderConfig holds OAuth configuration for a provider
 type ProviderConfig struct {
-	Name        string
-	ClientID    string
-	AuthURL     string
-	TokenURL    string
-	Scopes      []string
-	PKCEEnabled bool
+	Name         string
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	Scopes       []string
+	PKCEEnabled  bool
+	// AuthParams are extra query parameters for the authorization request
+	AuthParams map[string]string
 }
 
 // ProviderConfigs defines OAuth configurations for supported providers
@@ -46,9 +52,81 @@ var ProviderConfigs = map[string]ProviderConfig{
 		TokenURL:    "https://oauth2.googleapis.com/token",
 		Scopes:      []string{"https://www.googleapis.com/auth/generative-language.retroactive"},
 		PKCEEnabled: true,
+		AuthParams:  map[string]string{"access_type": "offline", "prompt": "consent"},
 	},
 }
 
+// OpenBrowser opens a URL in the user's browser. Tests replace it to follow
+// the authorization redirect without a browser.
+var OpenBrowser = openBrowser
+
+// CallbackServer receives the OAuth authorization redirect on a loopback port
+type CallbackServer struct {
+	listener net.Listener
+	server   *http.Server
+	state    string
+	codeChan chan string
+	errChan  chan error
+}
+
+// StartCallbackServer listens for the authorization redirect on a random
+// loopback port. It must be started before the authorization URL is built so
+// the redirect URI is known (and can be registered with the server).
+func StartCallbackServer() (*CallbackServer, error) {
+	state, err := generateRandomState()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start callback server: %w", err)
+	}
+
+	cb := &CallbackServer{
+		listener: listener,
+		state:    state,
+		codeChan: make(chan string, 1),
+		errChan:  make(chan error, 1),
+	}
+	cb.server = &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handleOAuthCallback(w, r, cb.state, cb.codeChan, cb.errChan)
+		}),
+	}
+	go cb.server.Serve(listener)
+	return cb, nil
+}
+
+// RedirectURI returns the URI the authorization server should redirect to
+func (c *CallbackServer) RedirectURI() string {
+	return fmt.Sprintf("http://localhost:%d/oauth/callback", c.listener.Addr().(*net.TCPAddr).Port)
+}
+
+// State returns the anti-CSRF state the redirect must carry
+func (c *CallbackServer) State() string {
+	return c.state
+}
+
+// Wait blocks until the redirect delivers an authorization code
+func (c *CallbackServer) Wait(ctx context.Context) (string, error) {
+	select {
+	case code := <-c.codeChan:
+		return code, nil
+	case err := <-c.errChan:
+		return "", err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-time.After(5 * time.Minute):
+		return "", errors.New("OAuth timeout - user did not complete authorization")
+	}
+}
+
+// Close stops the callback server
+func (c *CallbackServer) Close() error {
+	return c.server.Close()
+}
+
 // StartOAuthFlow initiates OAuth flow with local callback server
 func (p *ProviderOAuth) StartOAuthFlow(ctx context.Context, providerID string) (*TokenResponse, error) {
 	config, ok := ProviderConfigs[providerID]
@@ -56,15 +134,19 @@ func (p *ProviderOAuth) StartOAuthFlow(ctx context.Context, providerID string) (
 		return nil, fmt.Errorf("unsupported provider: %s", providerID)
 	}
 
-	// Start local callback server
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	cb, err := StartCallbackServer()
 	if err != nil {
-		return nil, fmt.Errorf("failed to start callback server: %w", err)
+		return nil, err
 	}
-	defer listener.Close()
+	defer cb.Close()
 
-	callbackURL := fmt.Sprintf("http://localhost:%d/oauth/callback", listener.Addr().(*net.TCPAddr).Port)
+	return p.AuthorizeCode(ctx, config, cb, nil)
+}
 
+// AuthorizeCode runs the authorization code flow for config through the
+// browser and exchanges the returned code for tokens. extra is sent with
+// both the authorization and token requests (e.g. an RFC 8707 resource).
+func (p *ProviderOAuth) AuthorizeCode(ctx context.Context, config ProviderConfig, cb *CallbackServer, extra url.Values) (*TokenResponse, error) {
 	// Generate PKCE parameters
 	var codeChallenge, codeVerifier string
 	if config.PKCEEnabled {
@@ -76,57 +158,43 @@ func (p *ProviderOAuth) StartOAuthFlow(ctx context.Context, providerID string) (
 		codeChallenge = generateCodeChallenge(verifier)
 	}
 
-	state, err := generateRandomState()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate state: %w", err)
-	}
+	callbackURL := cb.RedirectURI()
 
 	// Build authorization URL
-	authURL, err := buildAuthURL(config, callbackURL, state, codeChallenge)
+	authURL, err := buildAuthURL(config, callbackURL, cb.State(), codeChallenge, extra)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build auth URL: %w", err)
 	}
 
-	// Start HTTP server to handle callback
-	codeChan := make(chan string, 1)
-	errorChan := make(chan error, 1)
-
-	server := &http.Server{
-		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			handleOAuthCallback(w, r, state, codeChan, errorChan)
-		}),
-	}
-
-	go server.Serve(listener)
-	defer server.Close()
-
-	// Open browser
 	fmt.Printf("Opening browser for %s OAuth...\n", config.Name)
 	fmt.Printf("URL: %s\n", authURL)
+	if err := OpenBrowser(authURL); err != nil {
+		fmt.Println("Could not open a browser; open the URL above manually.")
+	}
 
-	// Wait for callback or timeout
-	select {
-	case code := <-codeChan:
-		return p.exchangeCode(ctx, config, code, callbackURL, codeVerifier)
-	case err := <-errorChan:
+	code, err := cb.Wait(ctx)
+	if err != nil {
 		return nil, err
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case <-time.After(5 * time.Minute):
-		return nil, errors.New("OAuth timeout - user did not complete authorization")
 	}
+	return p.exchangeCode(ctx, config, code, callbackURL, codeVerifier, extra)
 }
 
 // buildAuthURL constructs the OAuth authorization URL
-func buildAuthURL(config ProviderConfig, redirectURI, state, codeChallenge string) (string, error) {
+func buildAuthURL(config ProviderConfig, redirectURI, state, codeChallenge string, extra url.Values) (string, error) {
 	params := url.Values{
 		"client_id":     {config.ClientID},
 		"redirect_uri":  {redirectURI},
 		"response_type": {"code"},
-		"scope":         {joinScopes(config.Scopes)},
 		"state":         {state},
-		"access_type":   {"offline"},
-		"prompt":        {"consent"},
+	}
+	if len(config.Scopes) > 0 {
+		params.Set("scope", joinScopes(config.Scopes))
+	}
+	for k, v := range config.AuthParams {
+		params.Set(k, v)
+	}
+	for k, v := range extra {
+		params[k] = v
 	}
 
 	if config.PKCEEnabled && codeChallenge != "" {
@@ -134,7 +202,16 @@ func buildAuthURL(config ProviderConfig, redirectURI, state, codeChallenge strin
 		params.Set("code_challenge_method", "S256")
 	}
 
-	return config.AuthURL + "?" + params.Encode(), nil
+	u, err := url.Parse(config.AuthURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
 }
 
 // handleOAuthCallback processes the OAuth callback
@@ -176,9 +253,8 @@ func handleOAuthCallback(w http.ResponseWriter, r *http.Request, expectedState s
 }
 
 // exchangeCode exchanges authorization code for tokens
-func (p *ProviderOAuth) exchangeCode(ctx context.Context, config ProviderConfig, code, redirectURI, codeVerifier string) (*TokenResponse, error) {
+func (p *ProviderOAuth) exchangeCode(ctx context.Context, config ProviderConfig, code, redirectURI, codeVerifier string, extra url.Values) (*TokenResponse, error) {
 	params := url.Values{
-		"client_id":    {config.ClientID},
 		"code":         {code},
 		"grant_type":   {"authorization_code"},
 		"redirect_uri": {redirectURI},
@@ -188,30 +264,66 @@ func (p *ProviderOAuth) exchangeCode(ctx context.Context, config ProviderConfig,
 		params.Set("code_verifier", codeVerifier)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", config.TokenURL, nil)
+	tokenResp, err := requestTokens(ctx, config, params, extra)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("token exchange failed: %w", err)
+	}
+	return tokenResp, nil
+}
+
+// RefreshWithConfig exchanges a refresh token for new tokens at config's
+// token endpoint. Servers that do not rotate refresh tokens return none, in
+// which case the old one is kept.
+func (p *ProviderOAuth) RefreshWithConfig(ctx context.Context, config ProviderConfig, refreshToken string, extra url.Values) (*TokenResponse, error) {
+	params := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}
+	tokenResp, err := requestTokens(ctx, config, params, extra)
+	if err != nil {
+		return nil, fmt.Errorf("refresh failed: %w", err)
+	}
+	if tokenResp.RefreshToken == "" {
+		tokenResp.RefreshToken = refreshToken
 	}
+	return tokenResp, nil
+}
 
+// requestTokens posts a form-encoded token request
+func requestTokens(ctx context.Context, config ProviderConfig, params, extra url.Values) (*TokenResponse, error) {
+	params.Set("client_id", config.ClientID)
+	if config.ClientSecret != "" {
+		params.Set("client_secret", config.ClientSecret)
+	}
+	for k, v := range extra {
+		params[k] = v
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", config.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.URL.RawQuery = params.Encode()
+	req.Header.Set("Accept", "application/json")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("token exchange failed: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("token exchange failed: HTTP %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
 	}
 
 	var tokenResp TokenResponse
 	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
 		return nil, fmt.Errorf("failed to decode token response: %w", err)
 	}
-
+	if tokenResp.AccessToken == "" {
+		return nil, errors.New("token response has no access_token")
+	}
 	return &tokenResp, nil
 }
 
@@ -262,38 +374,13 @@ func (p *ProviderOAuth) RefreshToken(ctx context.Context, providerID string) err
 		return fmt.Errorf("no refresh token available: %w", err)
 	}
 
-	params := url.Values{
-		"client_id":     {config.ClientID},
-		"grant_type":    {"refresh_token"},
-		"refresh_token": {refreshToken},
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", config.TokenURL, nil)
+	tokenResp, err := p.RefreshWithConfig(ctx, config, refreshToken, nil)
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.URL.RawQuery = params.Encode()
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("refresh failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("refresh failed: HTTP %d: %s", resp.StatusCode, string(body))
-	}
-
-	var tokenResp TokenResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return fmt.Errorf("failed to decode refresh response: %w", err)
-	}
-
 	// Update stored tokens
-	return p.SaveTokens(providerID, &tokenResp)
+	return p.SaveTokens(providerID, tokenResp)
 }
 
 // IsTokenExpired checks if token needs refresh
@@ -327,6 +414,20 @@ func generateCodeChallenge(verifier string) string {
 	return base64.RawURLEncoding.EncodeToString(h[:])
 }
 
+// openBrowser launches the platform URL handler
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
+
 // joinScopes joins OAuth scopes with spaces
 func joinScopes(scopes []string) string {
 	result := ""
@@ -1,6 +1,7 @@
 package bus
 
 import (
+	"sort"
 	"sync"
 	"sync/atomic"
 
@@ -14,10 +15,28 @@ type Handler func(Event)
 // Subscription represents a subscription to the event bus.
 // It contains the channel for receiving events and metadata about the subscription.
 type Subscription struct {
-	id     string
-	ch     chan Event
-	topics []EventType
-	closer func()
+	id        string
+	ch        chan Event
+	topics    []EventType
+	sessionID string
+	surface   string
+	where     func(Event) bool
+	closer    func()
+	// keys are the subscription's entries in the bus's topic index
+	keys     []indexKey
+	delivery Delivery
+	// spill is set for DeliverSpill subscriptions
+	spill *spillQueue
+	// deadLetters is set when the subscription names EventDeadLetter
+	deadLetters bool
+	// dropped counts events the subscriber lost
+	dropped atomic.Uint64
+	// lagged, if set, is signalled after each drop
+	lagged chan struct{}
+	// closing is closed when a DeliverBlock subscription is removed, and
+	// sendMu is held by publishers waiting on it outside the bus's lock
+	closing chan struct{}
+	sendMu  sync.RWMutex
 }
 
 // Bus is the central event bus for pub/sub communication.
@@ -27,55 +46,216 @@ type Bus struct {
 	mu   sync.RWMutex
 	subs map[string]*Subscription
 	ver  int64
+
+	// The topic index files each subscription under the exact topics it
+	// names, the first segment of its patterns, or as a wildcard when it
+	// takes every topic or its pattern starts with a wildcard, so
+	// Publish only checks subscriptions that may want the event.
+	exact map[string]map[string]*Subscription
+	roots map[string]map[string]*Subscription
+	wild  map[string]*Subscription
+	// deadSubs counts subscriptions taking dead letters; none are made
+	// while it is zero
+	deadSubs int
+
+	// pubMu orders version assignment with queueing events for the
+	// journal, so the journal holds events in version order.
+	pubMu   sync.Mutex
+	journal Journal
+	writer  *journalWriter
+	dropped atomic.Uint64
 }
 
 // New creates a new event Bus with no subscribers.
 // Use Subscribe to add subscribers and Publish to send events.
 func New() *Bus {
 	return &Bus{
-		subs: make(map[string]*Subscription),
+		subs:  make(map[string]*Subscription),
+		exact: make(map[string]map[string]*Subscription),
+		roots: make(map[string]map[string]*Subscription),
+		wild:  make(map[string]*Subscription),
 	}
 }
 
 // Subscribe subscribes to events. If topics is empty, it subscribes to all events.
+// Topics may be patterns such as "tool.*"; see MatchTopic.
 // Returns a channel that receives events. The bus owns the channel; use the closer to unsubscribe.
 func (b *Bus) Subscribe(topics ...EventType) (<-chan Event, func()) {
+	return b.SubscribeFilter(Filter{Topics: topics})
+}
+
+// SubscribeFilter subscribes to the events f accepts, like Subscribe.
+func (b *Bus) SubscribeFilter(f Filter) (<-chan Event, func()) {
+	sub := b.subscribe(f, Delivery{}, false)
+	return sub.ch, sub.closer
+}
+
+func (b *Bus) subscribe(f Filter, d Delivery, lagged bool) *Subscription {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	d = d.withDefaults()
 	id := uuid.New().String()
-	ch := make(chan Event, 100) // Buffer events
+	ch := make(chan Event, d.Buffer)
 
 	sub := &Subscription{
-		id:     id,
-		ch:     ch,
-		topics: topics,
+		id:        id,
+		ch:        ch,
+		topics:    f.Topics,
+		sessionID: f.SessionID,
+		surface:   f.Surface,
+		where:     f.Where,
+		keys:      indexKeys(f.Topics),
+		delivery:  d,
 		closer: func() {
 			b.Unsubscribe(id)
 		},
 	}
 
+	if lagged {
+		sub.lagged = make(chan struct{}, 1)
+	}
+	switch d.Mode {
+	case DeliverSpill:
+		sub.spill = newSpillQueue(ch)
+	case DeliverBlock:
+		sub.closing = make(chan struct{})
+	}
+	if len(f.Topics) > 0 && b.matches(sub, EventDeadLetter) {
+		sub.deadLetters = true
+		b.deadSubs++
+	}
 	b.subs[id] = sub
-	return ch, sub.closer
+	for _, k := range sub.keys {
+		switch k.kind {
+		case 'e':
+			addIndex(b.exact, k.name, sub)
+		case 'r':
+			addIndex(b.roots, k.name, sub)
+		default:
+			b.wild[id] = sub
+		}
+	}
+	return sub
 }
 
 // Publish publishes an event to all matching subscribers.
 // The event is assigned a monotonically increasing version number.
-// Events are dropped if a subscriber's channel is full (non-blocking).
+// What happens when a subscriber's channel is full depends on its
+// delivery mode; see SubscribeDelivery. Events a subscriber loses are
+// counted in Stats and republished as EventDeadLetter. With a journal
+// set, the event is also queued for it so SubscribeFrom can replay it;
+// dead letters are not journaled.
 func (b *Bus) Publish(event Event) {
+	b.pubMu.Lock()
 	event.Version = int(atomic.AddInt64(&b.ver, 1))
+	if b.writer != nil && event.Event != EventDeadLetter {
+		b.writer.add(event)
+	}
+	b.pubMu.Unlock()
 
+	var dead []Event
+	var waits []*Subscription
 	b.mu.RLock()
-	defer b.mu.RUnlock()
+	b.candidates(event.Event, func(sub *Subscription) {
+		if !b.accepts(sub, event) {
+			return
+		}
+		lost, reason, wait := sub.deliver(event)
+		switch {
+		case wait:
+			waits = append(waits, sub)
+		case reason != "":
+			dead = b.lose(sub, lost, reason, dead)
+		}
+	})
+	b.mu.RUnlock()
 
-	for _, sub := range b.subs {
-		if b.matches(sub, event.Event) {
-			select {
-			case sub.ch <- event:
-			default:
-				// Drop event if subscriber is too slow to prevent blocking
-				// In a real system we might want metrics here
+	// Blocking subscribers are waited for outside the lock, so they hold
+	// up this publisher but not subscribing, unsubscribing or others
+	for _, sub := range waits {
+		if lost, reason := sub.wait(event); reason != "" {
+			b.mu.RLock()
+			dead = b.lose(sub, lost, reason, dead)
+			b.mu.RUnlock()
+		}
+	}
+
+	// Published once delivery is done, as Publish cannot nest in the lock
+	for _, e := range dead {
+		b.Publish(e)
+	}
+}
+
+// lose counts an event sub lost and adds a dead letter for it to dead,
+// unless sub catches up from the journal; the caller must hold mu.
+func (b *Bus) lose(sub *Subscription, lost Event, reason string, dead []Event) []Event {
+	sub.dropped.Add(1)
+	b.dropped.Add(1)
+	switch {
+	case sub.lagged != nil:
+		// Replaying subscribers catch up from the journal
+		select {
+		case sub.lagged <- struct{}{}:
+		default:
+		}
+	case b.deadSubs > 0 && lost.Event != EventDeadLetter:
+		dead = append(dead, deadLetter(sub, lost, reason))
+	}
+	return dead
+}
+
+// deadLetter wraps an event sub lost. It keeps the event's session and
+// surface so filtered subscribers only see their own.
+func deadLetter(sub *Subscription, lost Event, reason string) Event {
+	e := NewEvent(EventDeadLetter, lost.SessionID, DeadLetter{
+		Subscriber: sub.id,
+		Mode:       sub.delivery.Mode,
+		Reason:     reason,
+		Event:      lost,
+	})
+	e.Surface = lost.Surface
+	return e
+}
+
+// candidates calls fn once for each subscription the topic index files
+// under topic; the caller must hold mu.
+func (b *Bus) candidates(topic EventType, fn func(*Subscription)) {
+	var seen map[string]bool
+	visit := func(subs map[string]*Subscription) {
+		for id, sub := range subs {
+			if len(sub.keys) > 1 {
+				// Filed more than once, so it may have been visited
+				if seen[id] {
+					continue
+				}
+				if seen == nil {
+					seen = make(map[string]bool)
+				}
+				seen[id] = true
 			}
+			fn(sub)
+		}
+	}
+	visit(b.exact[string(topic)])
+	visit(b.roots[topicRoot(topic)])
+	visit(b.wild)
+}
+
+func addIndex(index map[string]map[string]*Subscription, name string, sub *Subscription) {
+	subs := index[name]
+	if subs == nil {
+		subs = make(map[string]*Subscription)
+		index[name] = subs
+	}
+	subs[sub.id] = sub
+}
+
+func removeIndex(index map[string]map[string]*Subscription, name, id string) {
+	if subs := index[name]; subs != nil {
+		delete(subs, id)
+		if len(subs) == 0 {
+			delete(index, name)
 		}
 	}
 }
@@ -88,21 +268,129 @@ func (b *Bus) Unsubscribe(id string) {
 	defer b.mu.Unlock()
 
 	if sub, ok := b.subs[id]; ok {
+		if sub.spill != nil {
+			sub.spill.close()
+		}
+		if sub.closing != nil {
+			// Wake publishers waiting for room and let them leave
+			close(sub.closing)
+			sub.sendMu.Lock()
+			defer sub.sendMu.Unlock()
+		}
+		if sub.deadLetters {
+			b.deadSubs--
+		}
 		close(sub.ch)
 		delete(b.subs, id)
+		for _, k := range sub.keys {
+			switch k.kind {
+			case 'e':
+				removeIndex(b.exact, k.name, id)
+			case 'r':
+				removeIndex(b.roots, k.name, id)
+			default:
+				delete(b.wild, id)
+			}
+		}
 	}
 }
 
 // matches checks if a subscription matches a given topic.
-// Returns true if the subscription has no topics (subscribes to all) or if the topic is in the subscription's topic list.
+// Returns true if the subscription has no topics (subscribes to all) or if the topic is in the subscription's topic list
+// or matches one of its patterns.
 func (b *Bus) matches(sub *Subscription, topic EventType) bool {
 	if len(sub.topics) == 0 {
 		return true // Subscribe to all
 	}
 	for _, t := range sub.topics {
-		if t == topic {
+		if t == topic || (IsTopicPattern(t) && MatchTopic(t, topic)) {
 			return true
 		}
 	}
 	return false
 }
+
+// accepts checks an event against all of a subscription's filters. Dead
+// letters only go to subscriptions that name them, not to those taking
+// every topic.
+func (b *Bus) accepts(sub *Subscription, event Event) bool {
+	if event.Event == EventDeadLetter && !sub.deadLetters {
+		return false
+	}
+	if sub.sessionID != "" && event.SessionID != sub.sessionID {
+		return false
+	}
+	if sub.surface != "" && event.Surface != sub.surface {
+		return false
+	}
+	if !b.matches(sub, event.Event) {
+		return false
+	}
+	return sub.where == nil || sub.where(event)
+}
+
+// SubscriberStats describes one subscriber's queue.
+type SubscriberStats struct {
+	ID     string       `json:"id"`
+	Topics []EventType  `json:"topics,omitempty"`
+	Mode   DeliveryMode `json:"mode"`
+	// Queued counts events waiting for the subscriber, including
+	// Spilled, those beyond its channel's capacity.
+	Queued   int `json:"queued"`
+	Capacity int `json:"capacity"`
+	Spilled  int `json:"spilled,omitempty"`
+	// MaxSpill is how many events may be spilled before they are dropped
+	MaxSpill int    `json:"max_spill,omitempty"`
+	Dropped  uint64 `json:"dropped"`
+}
+
+// Stats is a snapshot of the bus.
+type Stats struct {
+	Version int  `json:"version"`
+	Journal bool `json:"journal"`
+	// JournalQueued counts events waiting to be journaled and
+	// JournalLost those that never were.
+	JournalQueued int               `json:"journal_queued,omitempty"`
+	JournalLost   uint64            `json:"journal_lost,omitempty"`
+	Dropped       uint64            `json:"dropped"`
+	Subscribers   []SubscriberStats `json:"subscribers"`
+}
+
+// Stats reports the current version, the journal's backlog and losses,
+// events dropped in total and each subscriber's delivery mode, queue
+// depth and drops.
+func (b *Bus) Stats() Stats {
+	b.pubMu.Lock()
+	w := b.writer
+	b.pubMu.Unlock()
+
+	st := Stats{Journal: w != nil}
+	if w != nil {
+		st.JournalQueued = w.queued()
+		st.JournalLost = w.lost.Load()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	st.Version = b.Version()
+	st.Dropped = b.dropped.Load()
+	st.Subscribers = make([]SubscriberStats, 0, len(b.subs))
+	for _, sub := range b.subs {
+		ss := SubscriberStats{
+			ID:       sub.id,
+			Topics:   sub.topics,
+			Mode:     sub.delivery.Mode,
+			Queued:   len(sub.ch),
+			Capacity: cap(sub.ch),
+			Dropped:  sub.dropped.Load(),
+		}
+		if sub.spill != nil {
+			ss.Spilled = sub.spill.len()
+			ss.MaxSpill = sub.delivery.MaxSpill
+			ss.Queued += ss.Spilled
+		}
+		st.Subscribers = append(st.Subscribers, ss)
+	}
+	sort.Slice(st.Subscribers, func(i, j int) bool { return st.Subscribers[i].ID < st.Subscribers[j].ID })
+	return st
+}
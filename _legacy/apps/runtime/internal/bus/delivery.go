@@ -0,0 +1,226 @@
+package bus
+
+import (
+	"sync"
+	"time"
+)
+
+// DeliveryMode is what a subscription does with an event when its queue
+// is full.
+type DeliveryMode string
+
+const (
+	// DeliverDropNewest drops the event being published. Publishers never
+	// wait; this is the default.
+	DeliverDropNewest DeliveryMode = "drop_newest"
+	// DeliverDropOldest drops the oldest queued event to make room, for
+	// subscribers that only care about recent state.
+	DeliverDropOldest DeliveryMode = "drop_oldest"
+	// DeliverBlock makes the publisher wait for room, up to the
+	// subscription's timeout, then drops the event. Only that publisher
+	// waits: other publishers and subscribers carry on.
+	DeliverBlock DeliveryMode = "block"
+	// DeliverSpill queues events beyond the buffer in memory, up to
+	// MaxSpill, so publishers never wait and bursts are not lost. Events
+	// beyond the cap are dropped.
+	DeliverSpill DeliveryMode = "spill"
+)
+
+const (
+	defaultBuffer       = 100
+	defaultBlockTimeout = time.Second
+	defaultMaxSpill     = 10000
+)
+
+// Reasons a subscriber lost an event, reported in DeadLetter.
+const (
+	DropQueueFull = "queue_full"
+	DropTimeout   = "timeout"
+	DropSpillFull = "spill_full"
+)
+
+// Delivery configures how a subscription receives events.
+type Delivery struct {
+	Mode DeliveryMode
+	// Buffer is the capacity of the subscription's channel; defaults to
+	// 100.
+	Buffer int
+	// Timeout is how long DeliverBlock waits for room; defaults to one
+	// second.
+	Timeout time.Duration
+	// MaxSpill is how many events DeliverSpill queues beyond the buffer;
+	// defaults to 10000.
+	MaxSpill int
+}
+
+func (d Delivery) withDefaults() Delivery {
+	if d.Mode == "" {
+		d.Mode = DeliverDropNewest
+	}
+	if d.Buffer <= 0 {
+		d.Buffer = defaultBuffer
+	}
+	if d.Mode == DeliverBlock && d.Timeout <= 0 {
+		d.Timeout = defaultBlockTimeout
+	}
+	if d.Mode == DeliverSpill && d.MaxSpill <= 0 {
+		d.MaxSpill = defaultMaxSpill
+	}
+	return d
+}
+
+// SubscribeDelivery subscribes to the events f accepts like
+// SubscribeFilter, delivering them as d says.
+func (b *Bus) SubscribeDelivery(f Filter, d Delivery) (<-chan Event, func()) {
+	sub := b.subscribe(f, d, false)
+	return sub.ch, sub.closer
+}
+
+// deliver queues event for the subscriber without waiting. When the
+// subscriber loses an event, it returns that event and why; with
+// DeliverDropOldest that is an older event rather than this one. When a
+// DeliverBlock subscriber has no room it reports wait instead, and the
+// publisher calls wait once it has released the bus's lock. The caller
+// must hold the bus's read lock, so the channel is not closed meanwhile.
+func (s *Subscription) deliver(event Event) (lost Event, reason string, wait bool) {
+	if s.spill != nil {
+		lost, reason = s.spill.push(s.ch, event, s.delivery.MaxSpill)
+		return lost, reason, false
+	}
+	select {
+	case s.ch <- event:
+		return Event{}, "", false
+	default:
+	}
+
+	switch s.delivery.Mode {
+	case DeliverDropOldest:
+		select {
+		case lost = <-s.ch:
+			reason = DropQueueFull
+		default:
+			// The subscriber caught up meanwhile
+		}
+		select {
+		case s.ch <- event:
+			return lost, reason, false
+		default:
+			// Refilled by another publisher; keep what it queued
+			return event, DropQueueFull, false
+		}
+	case DeliverBlock:
+		return Event{}, "", true
+	default:
+		return event, DropQueueFull, false
+	}
+}
+
+// wait waits for room for event, up to the subscription's timeout. It
+// runs without the bus's lock; Unsubscribe closes closing before the
+// channel, and waits for sendMu so the channel is not closed under it.
+func (s *Subscription) wait(event Event) (lost Event, reason string) {
+	s.sendMu.RLock()
+	defer s.sendMu.RUnlock()
+	select {
+	case <-s.closing:
+		return Event{}, ""
+	default:
+	}
+	timer := time.NewTimer(s.delivery.Timeout)
+	defer timer.Stop()
+	select {
+	case s.ch <- event:
+		return Event{}, ""
+	case <-s.closing:
+		return Event{}, ""
+	case <-timer.C:
+		return event, DropTimeout
+	}
+}
+
+// spillQueue holds the events of a DeliverSpill subscription that did not
+// fit in its channel, and feeds them in as the subscriber reads.
+type spillQueue struct {
+	mu sync.Mutex
+	// events are waiting in order; events[0] is being sent and stays
+	// queued until it is, so new events cannot overtake it.
+	events []Event
+	wake   chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func newSpillQueue(ch chan Event) *spillQueue {
+	q := &spillQueue{
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go q.pump(ch)
+	return q
+}
+
+// push sends event straight to ch when nothing is waiting and there is
+// room, and queues it otherwise. It drops event when limit are queued.
+func (q *spillQueue) push(ch chan Event, event Event, limit int) (lost Event, reason string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.events) == 0 {
+		select {
+		case ch <- event:
+			return Event{}, ""
+		default:
+		}
+	}
+	if len(q.events) >= limit {
+		return event, DropSpillFull
+	}
+	q.events = append(q.events, event)
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return Event{}, ""
+}
+
+func (q *spillQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.events)
+}
+
+func (q *spillQueue) pump(ch chan Event) {
+	defer close(q.done)
+	for {
+		q.mu.Lock()
+		if len(q.events) == 0 {
+			q.events = nil
+			q.mu.Unlock()
+			select {
+			case <-q.wake:
+				continue
+			case <-q.stop:
+				return
+			}
+		}
+		e := q.events[0]
+		q.mu.Unlock()
+
+		select {
+		case ch <- e:
+		case <-q.stop:
+			return
+		}
+
+		q.mu.Lock()
+		q.events[0] = Event{}
+		q.events = q.events[1:]
+		q.mu.Unlock()
+	}
+}
+
+// close stops the pump; the queued events are discarded.
+func (q *spillQueue) close() {
+	close(q.stop)
+	<-q.done
+}
@@ -0,0 +1,218 @@
+package bus
+
+import (
+	"testing"
+	"time"
+)
+
+func publishN(b *Bus, n int) {
+	for i := 0; i < n; i++ {
+		b.Publish(NewEvent(EventTraceEvent, "", i))
+	}
+}
+
+func drain(ch <-chan Event) []Event {
+	var out []Event
+	for {
+		select {
+		case e := <-ch:
+			out = append(out, e)
+		default:
+			return out
+		}
+	}
+}
+
+func TestSubscribeDelivery_DropNewest(t *testing.T) {
+	b := New()
+	events, cancel := b.SubscribeDelivery(Filter{Topics: []EventType{EventTraceEvent}}, Delivery{Buffer: 2})
+	defer cancel()
+
+	publishN(b, 4)
+	got := drain(events)
+	if len(got) != 2 || got[0].Payload != 0 || got[1].Payload != 1 {
+		t.Fatalf("expected the first two events, got %v", got)
+	}
+}
+
+func TestSubscribeDelivery_DropOldest(t *testing.T) {
+	b := New()
+	events, cancel := b.SubscribeDelivery(Filter{Topics: []EventType{EventTraceEvent}}, Delivery{Mode: DeliverDropOldest, Buffer: 2})
+	defer cancel()
+
+	publishN(b, 4)
+	got := drain(events)
+	if len(got) != 2 || got[0].Payload != 2 || got[1].Payload != 3 {
+		t.Fatalf("expected the last two events, got %v", got)
+	}
+	if st := b.Stats(); st.Subscribers[0].Dropped != 2 || st.Subscribers[0].Mode != DeliverDropOldest {
+		t.Errorf("unexpected stats: %+v", st.Subscribers[0])
+	}
+}
+
+func TestSubscribeDelivery_Block(t *testing.T) {
+	b := New()
+	events, cancel := b.SubscribeDelivery(Filter{Topics: []EventType{EventTraceEvent}}, Delivery{Mode: DeliverBlock, Buffer: 1, Timeout: 500 * time.Millisecond})
+	defer cancel()
+
+	// A subscriber that reads in time gets everything
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		<-events
+	}()
+	publishN(b, 2)
+	if st := b.Stats(); st.Dropped != 0 {
+		t.Fatalf("expected no drops, got %d", st.Dropped)
+	}
+
+	// One that does not holds the publisher up to the timeout
+	dead, cancelDead := b.Subscribe(EventDeadLetter)
+	defer cancelDead()
+	start := time.Now()
+	publishN(b, 1)
+	if waited := time.Since(start); waited < 400*time.Millisecond {
+		t.Errorf("expected Publish to wait for the timeout, returned after %s", waited)
+	}
+	select {
+	case e := <-dead:
+		dl, ok := e.Payload.(DeadLetter)
+		if !ok || dl.Reason != DropTimeout || dl.Event.Payload != 0 || dl.Mode != DeliverBlock {
+			t.Errorf("unexpected dead letter %+v", e.Payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a dead letter")
+	}
+}
+
+func TestSubscribeDelivery_BlockOutsideLock(t *testing.T) {
+	b := New()
+	_, cancel := b.SubscribeDelivery(Filter{Topics: []EventType{EventTraceEvent}}, Delivery{Mode: DeliverBlock, Buffer: 1, Timeout: 5 * time.Second})
+	publishN(b, 1)
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		publishN(b, 1)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// While that publisher waits, others publish, subscribe and
+	// unsubscribe freely
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		other, cancelOther := b.Subscribe(EventErrorOccurred)
+		b.Publish(NewEvent(EventErrorOccurred, "", nil))
+		<-other
+		cancelOther()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the bus was held by a blocked publisher")
+	}
+
+	// Unsubscribing releases the waiting publisher without a send on a
+	// closed channel
+	cancel()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("the publisher was not released")
+	}
+}
+
+func TestSubscribeDelivery_SpillCap(t *testing.T) {
+	b := New()
+	_, cancel := b.SubscribeDelivery(Filter{Topics: []EventType{EventTraceEvent}}, Delivery{Mode: DeliverSpill, Buffer: 2, MaxSpill: 3})
+	defer cancel()
+	dead, cancelDead := b.Subscribe(EventDeadLetter)
+	defer cancelDead()
+
+	publishN(b, 10)
+	st := b.Stats()
+	var ss SubscriberStats
+	for _, s := range st.Subscribers {
+		if s.Mode == DeliverSpill {
+			ss = s
+		}
+	}
+	if ss.Spilled != 3 || ss.MaxSpill != 3 || ss.Dropped != 5 {
+		t.Fatalf("unexpected stats: %+v", ss)
+	}
+	got := drain(dead)
+	if len(got) != 5 || got[0].Payload.(DeadLetter).Reason != DropSpillFull || got[0].Payload.(DeadLetter).Event.Payload != 5 {
+		t.Fatalf("expected dead letters for the events over the cap, got %v", got)
+	}
+}
+
+func TestSubscribeDelivery_Spill(t *testing.T) {
+	b := New()
+	events, cancel := b.SubscribeDelivery(Filter{Topics: []EventType{EventTraceEvent}}, Delivery{Mode: DeliverSpill, Buffer: 4})
+
+	publishN(b, 50)
+	st := b.Stats()
+	if st.Dropped != 0 || st.Subscribers[0].Queued != 50 || st.Subscribers[0].Spilled != 46 {
+		t.Fatalf("unexpected stats: %+v", st.Subscribers[0])
+	}
+	for i := 0; i < 50; i++ {
+		select {
+		case e := <-events:
+			if e.Payload != i {
+				t.Fatalf("event %d: got payload %v", i, e.Payload)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+
+	// Unsubscribing with events still spilled closes the channel cleanly
+	publishN(b, 10)
+	cancel()
+	for range events {
+	}
+}
+
+func TestPublish_DeadLetter(t *testing.T) {
+	b := New()
+	_, cancel := b.SubscribeDelivery(Filter{Topics: []EventType{EventTraceEvent}}, Delivery{Buffer: 1})
+	defer cancel()
+	dead, cancelDead := b.SubscribeFilter(Filter{Topics: []EventType{EventDeadLetter}, SessionID: "s1"})
+	defer cancelDead()
+
+	b.Publish(NewEvent(EventTraceEvent, "s1", "kept"))
+	b.Publish(NewEvent(EventTraceEvent, "s2", "lost elsewhere"))
+	b.Publish(NewEvent(EventTraceEvent, "s1", "lost"))
+
+	got := drain(dead)
+	if len(got) != 1 {
+		t.Fatalf("expected one dead letter for s1, got %v", got)
+	}
+	dl := got[0].Payload.(DeadLetter)
+	if dl.Reason != DropQueueFull || dl.Event.Payload != "lost" || got[0].SessionID != "s1" {
+		t.Errorf("unexpected dead letter %+v", dl)
+	}
+
+	// Subscribers taking every topic do not get dead letters
+	all, cancelAll := b.Subscribe()
+	defer cancelAll()
+	b.Publish(NewEvent(EventTraceEvent, "s1", "lost"))
+	for _, e := range drain(all) {
+		if e.Event == EventDeadLetter {
+			t.Errorf("unexpected dead letter for a subscriber to every topic")
+		}
+	}
+	if len(drain(dead)) != 1 {
+		t.Error("expected a dead letter")
+	}
+
+	// Lost dead letters are not dead-lettered again
+	_, cancelWild := b.SubscribeDelivery(Filter{Topics: []EventType{"**"}}, Delivery{Buffer: 1})
+	defer cancelWild()
+	v := b.Version()
+	b.Publish(NewEvent(EventErrorOccurred, "", nil))
+	b.Publish(NewEvent(EventErrorOccurred, "", nil))
+	if got := b.Version() - v; got != 3 {
+		t.Errorf("expected two events and one dead letter, got %d versions", got)
+	}
+}
@@ -14,6 +14,8 @@ const (
 	EventEnvelope EventType = "event"
 	// EventSessionMessage is emitted when a new message is added to a session.
 	EventSessionMessage EventType = "session.message"
+	// EventSessionEnded is emitted when a session is deleted.
+	EventSessionEnded EventType = "session.ended"
 	// EventSessionTyping is emitted when typing indicators change.
 	EventSessionTyping EventType = "session.typing"
 	// EventToolRequest is emitted when a tool execution is requested.
@@ -26,6 +28,12 @@ const (
 	EventApprovalNeeded EventType = "approval.needed"
 	// EventApprovalResolved is emitted when an approval is resolved.
 	EventApprovalResolved EventType = "approval.resolved"
+	// EventApprovalRevoked is emitted when a remembered approval decision is revoked.
+	EventApprovalRevoked EventType = "approval.revoked"
+	// EventElicitationRequested is emitted when an MCP server asks the user for input.
+	EventElicitationRequested EventType = "elicitation.requested"
+	// EventElicitationResolved is emitted when an elicitation is answered, declined or times out.
+	EventElicitationResolved EventType = "elicitation.resolved"
 	// EventTraceEvent is emitted for trace/debug events.
 	EventTraceEvent EventType = "trace.event"
 	// EventErrorOccurred is emitted when an error occurs.
@@ -38,6 +46,15 @@ const (
 	EventChannelOutboundMessage EventType = "channel.outbound_message"
 	// EventChatRequest is emitted when a chat request is made.
 	EventChatRequest EventType = "chat.request"
+	// EventSandboxViolation is emitted when a sandbox refuses to launch or limit a process.
+	EventSandboxViolation EventType = "sandbox.violation"
+	// EventNetworkRequest is emitted for every outbound request a bundled tool makes.
+	EventNetworkRequest EventType = "network.request"
+	// EventDeadLetter is emitted for each event a subscriber lost; see DeadLetter.
+	EventDeadLetter EventType = "bus.dead_letter"
+	// EventReplayGap tells a replaying subscriber that events are missing
+	// from the journal; see ReplayGap.
+	EventReplayGap EventType = "bus.replay_gap"
 )
 
 // Event represents a single event in the system.
@@ -0,0 +1,385 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoJournal is returned by SubscribeFrom when the bus keeps no journal.
+var ErrNoJournal = errors.New("bus: no event journal")
+
+// replayPage is how many events SubscribeFrom reads from the journal at once
+const replayPage = 500
+
+const (
+	// journalBatch is the most events appended to the journal at once
+	journalBatch = 500
+	// journalQueue is the most events waiting to be appended. Publish
+	// does not wait for the journal, so events beyond it are lost to it.
+	journalQueue = 10000
+	// maxGaps is how many lost ranges are remembered for replays
+	maxGaps = 100
+)
+
+// Journal is an append-only log of published events, keyed by version.
+type Journal interface {
+	// AppendEvents records published events, in version order, all or
+	// none of them.
+	AppendEvents(events []Event) error
+	// EventsAfter returns up to limit events with a version above version,
+	// oldest first.
+	EventsAfter(version, limit int) ([]Event, error)
+	// LastEventVersion returns the highest version recorded, or 0.
+	LastEventVersion() (int, error)
+	// PruneEvents deletes events older than before, and all but the
+	// newest keep events when keep is positive. It returns how many were
+	// deleted.
+	PruneEvents(before time.Time, keep int) (int64, error)
+}
+
+// Retention bounds how much of the journal is kept. Zero fields are not
+// enforced.
+type Retention struct {
+	MaxAge    time.Duration
+	MaxEvents int
+	// Interval is how often the journal is pruned; defaults to ten minutes.
+	Interval time.Duration
+}
+
+// SetJournal makes the bus record every published event in j. Events
+// are appended in batches in the background, so a slow journal does not
+// hold up Publish. Versions continue from the last one in the journal, so
+// they stay unique across restarts; only one bus may write a journal.
+func (b *Bus) SetJournal(j Journal) error {
+	last, err := j.LastEventVersion()
+	if err != nil {
+		return err
+	}
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+	if b.journal != nil {
+		return errors.New("bus: journal already set")
+	}
+	if int64(last) > atomic.LoadInt64(&b.ver) {
+		atomic.StoreInt64(&b.ver, int64(last))
+	}
+	b.journal = j
+	b.writer = newJournalWriter(j)
+	return nil
+}
+
+// Close appends the events still queued for the journal and stops writing
+// to it, so the journal can be closed after. Events published later are
+// still delivered to subscribers but not journaled.
+func (b *Bus) Close() {
+	b.pubMu.Lock()
+	w := b.writer
+	b.pubMu.Unlock()
+	if w != nil {
+		w.close()
+	}
+}
+
+// journalWriter appends published events to the journal off the
+// publishing path. It remembers the versions it could not journal, so
+// replays can report them instead of skipping them silently.
+type journalWriter struct {
+	j    Journal
+	mu   sync.Mutex
+	cond *sync.Cond
+	// queue waits to be appended and batch is being appended
+	queue []Event
+	batch []Event
+	gaps  []ReplayGap
+	// overflow is set while events are lost to a full queue
+	overflow bool
+	lost     atomic.Uint64
+	// closed stops run once the queue is drained; done is closed when it
+	// has returned
+	closed bool
+	done   chan struct{}
+}
+
+func newJournalWriter(j Journal) *journalWriter {
+	w := &journalWriter{j: j, done: make(chan struct{})}
+	w.cond = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// add queues e. Publish calls it holding pubMu, so events are queued in
+// version order.
+func (w *journalWriter) add(e Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		w.lost.Add(1)
+		return
+	}
+	if len(w.queue) < journalQueue {
+		w.queue = append(w.queue, e)
+		w.overflow = false
+		w.cond.Broadcast()
+		return
+	}
+	w.lost.Add(1)
+	if w.overflow {
+		w.gaps[len(w.gaps)-1].To = e.Version
+		return
+	}
+	w.addGap(ReplayGap{From: e.Version, To: e.Version, Reason: "journal queue full"})
+	w.overflow = true
+}
+
+func (w *journalWriter) run() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for {
+		for len(w.queue) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 {
+			close(w.done)
+			return
+		}
+		n := min(len(w.queue), journalBatch)
+		batch := w.queue[:n:n]
+		w.batch, w.queue = batch, w.queue[n:]
+		w.mu.Unlock()
+		err := w.j.AppendEvents(batch)
+		w.mu.Lock()
+		if err != nil {
+			w.lost.Add(uint64(n))
+			w.addGap(ReplayGap{From: batch[0].Version, To: batch[n-1].Version, Reason: "journal append failed: " + err.Error()})
+			w.overflow = false
+		}
+		w.batch = nil
+		w.cond.Broadcast()
+	}
+}
+
+// close appends what is queued, then stops run
+func (w *journalWriter) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	<-w.done
+}
+
+// addGap records a lost range; the caller holds mu
+func (w *journalWriter) addGap(g ReplayGap) {
+	log.Printf("bus: events from version %d not journaled: %s", g.From, g.Reason)
+	w.gaps = append(w.gaps, g)
+	if len(w.gaps) > maxGaps {
+		w.gaps = w.gaps[len(w.gaps)-maxGaps:]
+	}
+}
+
+// flush waits until every event up to version has been appended or lost
+func (w *journalWriter) flush(version int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for {
+		oldest := 0
+		switch {
+		case len(w.batch) > 0:
+			oldest = w.batch[0].Version
+		case len(w.queue) > 0:
+			oldest = w.queue[0].Version
+		}
+		if oldest == 0 || oldest > version {
+			return
+		}
+		w.cond.Wait()
+	}
+}
+
+// gapsIn returns the lost ranges with versions above after, up to upto
+func (w *journalWriter) gapsIn(after, upto int) []ReplayGap {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var out []ReplayGap
+	for _, g := range w.gaps {
+		if g.To > after && g.From <= upto {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// queued returns how many events wait to be journaled
+func (w *journalWriter) queued() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.queue) + len(w.batch)
+}
+
+// Version returns the version of the last published event.
+func (b *Bus) Version() int {
+	return int(atomic.LoadInt64(&b.ver))
+}
+
+// SubscribeFrom subscribes like Subscribe, first replaying the journaled
+// events after version. Events arrive in version order with no gaps or
+// repeats between the replay and live events. A subscriber that falls
+// behind catches up from the journal instead of losing events, as long as
+// they have not been pruned. Events the journal lost are reported in
+// their place with an EventReplayGap, whatever the topics.
+func (b *Bus) SubscribeFrom(version int, topics ...EventType) (<-chan Event, func(), error) {
+	return b.SubscribeFilterFrom(version, Filter{Topics: topics})
+}
+
+// SubscribeFilterFrom subscribes to the events f accepts like
+// SubscribeFrom.
+func (b *Bus) SubscribeFilterFrom(version int, f Filter) (<-chan Event, func(), error) {
+	b.pubMu.Lock()
+	if b.journal == nil {
+		b.pubMu.Unlock()
+		return nil, nil, ErrNoJournal
+	}
+	// Events up to cut are in the journal; later ones reach sub
+	cut := b.Version()
+	sub := b.subscribe(f, Delivery{}, true)
+	b.pubMu.Unlock()
+
+	out := make(chan Event, cap(sub.ch))
+	done := make(chan struct{})
+	var once sync.Once
+	closer := func() {
+		once.Do(func() {
+			close(done)
+			sub.closer()
+		})
+	}
+	go b.replay(sub, out, version, cut, done)
+	return out, closer, nil
+}
+
+// replay feeds out from the journal up to cut, then from sub, going back
+// to the journal whenever sub has dropped events.
+func (b *Bus) replay(sub *Subscription, out chan<- Event, last, cut int, done <-chan struct{}) {
+	defer close(out)
+
+	send := func(e Event) bool {
+		select {
+		case out <- e:
+			return true
+		case <-done:
+			return false
+		}
+	}
+
+	// catchUp sends the journaled events after last up to upto, and the
+	// gaps among them in their place
+	catchUp := func(upto int) bool {
+		b.writer.flush(upto)
+		gaps := b.writer.gapsIn(last, upto)
+		// gapsBefore sends the gaps that start before version
+		gapsBefore := func(version int) bool {
+			for len(gaps) > 0 && gaps[0].From < version {
+				if !send(NewEvent(EventReplayGap, "", gaps[0])) {
+					return false
+				}
+				gaps = gaps[1:]
+			}
+			return true
+		}
+		for last < upto {
+			events, err := b.journal.EventsAfter(last, replayPage)
+			if err != nil {
+				log.Printf("bus: failed to replay events after %d: %v", last, err)
+				gap := ReplayGap{From: last + 1, To: upto, Reason: "journal read failed: " + err.Error()}
+				last = upto
+				return send(NewEvent(EventReplayGap, "", gap))
+			}
+			if len(events) == 0 {
+				break
+			}
+			for _, e := range events {
+				if e.Version > upto {
+					return gapsBefore(upto + 1)
+				}
+				if !gapsBefore(e.Version) {
+					return false
+				}
+				last = e.Version
+				if b.accepts(sub, e) && !send(e) {
+					return false
+				}
+			}
+		}
+		return gapsBefore(upto + 1)
+	}
+
+	if !catchUp(cut) {
+		return
+	}
+	if last < cut {
+		last = cut
+	}
+	for {
+		select {
+		case <-sub.lagged:
+			// Whatever was dropped is journaled once the writer gets
+			// to the current version
+			if !catchUp(b.Version()) {
+				return
+			}
+		case e, ok := <-sub.ch:
+			if !ok {
+				return
+			}
+			if e.Version <= last {
+				continue
+			}
+			last = e.Version
+			if !send(e) {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// RetainJournal prunes the journal to r until ctx is done.
+func (b *Bus) RetainJournal(ctx context.Context, r Retention) {
+	if r.MaxAge <= 0 && r.MaxEvents <= 0 {
+		return
+	}
+	interval := r.Interval
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		b.pruneJournal(r)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *Bus) pruneJournal(r Retention) {
+	b.pubMu.Lock()
+	j := b.journal
+	b.pubMu.Unlock()
+	if j == nil {
+		return
+	}
+	var before time.Time
+	if r.MaxAge > 0 {
+		before = time.Now().UTC().Add(-r.MaxAge)
+	}
+	if _, err := j.PruneEvents(before, r.MaxEvents); err != nil {
+		log.Printf("bus: failed to prune event journal: %v", err)
+	}
+}
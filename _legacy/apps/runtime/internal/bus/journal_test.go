@@ -0,0 +1,338 @@
+package bus
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memJournal is an in-memory Journal. Appends wait for hold when it is
+// set, and fail with appendErr or readErr when they are.
+type memJournal struct {
+	mu        sync.Mutex
+	events    []Event
+	hold      chan struct{}
+	appendErr error
+	readErr   error
+}
+
+func (j *memJournal) AppendEvents(events []Event) error {
+	if j.hold != nil {
+		<-j.hold
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.appendErr != nil {
+		return j.appendErr
+	}
+	j.events = append(j.events, events...)
+	return nil
+}
+
+func (j *memJournal) EventsAfter(version, limit int) ([]Event, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.readErr != nil {
+		return nil, j.readErr
+	}
+	var out []Event
+	for _, e := range j.events {
+		if e.Version > version && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (j *memJournal) LastEventVersion() (int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.events) == 0 {
+		return 0, nil
+	}
+	return j.events[len(j.events)-1].Version, nil
+}
+
+func (j *memJournal) PruneEvents(before time.Time, keep int) (int64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var kept []Event
+	for i, e := range j.events {
+		if e.Timestamp.Before(before) || (keep > 0 && i < len(j.events)-keep) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	n := int64(len(j.events) - len(kept))
+	j.events = kept
+	return n, nil
+}
+
+func receive(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return Event{}
+	}
+}
+
+func TestBus_SubscribeFromReplays(t *testing.T) {
+	b := New()
+	if _, _, err := b.SubscribeFrom(0); err != ErrNoJournal {
+		t.Fatalf("expected ErrNoJournal, got %v", err)
+	}
+
+	j := &memJournal{}
+	j.events = []Event{{Event: EventTraceEvent, Version: 41}}
+	if err := b.SetJournal(j); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		b.Publish(NewEvent(EventTraceEvent, "s1", map[string]interface{}{"n": i}))
+		b.Publish(NewEvent(EventErrorOccurred, "s1", nil))
+	}
+	if b.Version() != 51 {
+		t.Fatalf("expected versions to continue from the journal, got %d", b.Version())
+	}
+
+	ch, cancel, err := b.SubscribeFrom(44, EventTraceEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	b.Publish(NewEvent(EventTraceEvent, "s1", nil))
+
+	// Trace events are the even versions after 41
+	for _, want := range []int{46, 48, 50, 52} {
+		if e := receive(t, ch); e.Version != want || e.Event != EventTraceEvent {
+			t.Fatalf("expected trace event %d, got %s %d", want, e.Event, e.Version)
+		}
+	}
+	select {
+	case e := <-ch:
+		t.Fatalf("unexpected event %d", e.Version)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestBus_SubscribeFromNoGapsUnderLoad(t *testing.T) {
+	b := New()
+	if err := b.SetJournal(&memJournal{}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		b.Publish(NewEvent(EventTraceEvent, "", nil))
+	}
+
+	ch, cancel, err := b.SubscribeFrom(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	// Publish far more than the buffer holds while the subscriber is
+	// not reading; drops are made up from the journal
+	const total = 400
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 50; i < total; i++ {
+			b.Publish(NewEvent(EventTraceEvent, "", nil))
+		}
+	}()
+	<-done
+
+	for want := 1; want <= total; want++ {
+		if e := receive(t, ch); e.Version != want {
+			t.Fatalf("expected version %d, got %d", want, e.Version)
+		}
+	}
+}
+
+func TestBus_JournalDoesNotHoldUpPublish(t *testing.T) {
+	b := New()
+	j := &memJournal{hold: make(chan struct{})}
+	if err := b.SetJournal(j); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetJournal(&memJournal{}); err == nil {
+		t.Fatal("expected a second journal to be refused")
+	}
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := 0; i < 10; i++ {
+			b.Publish(NewEvent(EventTraceEvent, "", nil))
+		}
+	}()
+	select {
+	case <-published:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Publish waited for the journal")
+	}
+	if st := b.Stats(); !st.Journal || st.JournalQueued == 0 {
+		t.Fatalf("expected events waiting for the journal: %+v", st)
+	}
+
+	// A replay waits for what was published before it to be journaled
+	ch, cancel, err := b.SubscribeFrom(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	close(j.hold)
+	for want := 1; want <= 10; want++ {
+		if e := receive(t, ch); e.Version != want {
+			t.Fatalf("expected version %d, got %d", want, e.Version)
+		}
+	}
+}
+
+func TestBus_CloseFlushesJournal(t *testing.T) {
+	b := New()
+	j := &memJournal{hold: make(chan struct{})}
+	if err := b.SetJournal(j); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		b.Publish(NewEvent(EventTraceEvent, "", nil))
+	}
+	time.AfterFunc(20*time.Millisecond, func() { close(j.hold) })
+	b.Close()
+	if n, _ := j.LastEventVersion(); n != 20 {
+		t.Fatalf("expected Close to journal all 20 events, last version is %d", n)
+	}
+	b.Publish(NewEvent(EventTraceEvent, "", nil))
+	if st := b.Stats(); st.JournalQueued != 0 || st.JournalLost != 1 {
+		t.Fatalf("expected events after Close not to be journaled: %+v", st)
+	}
+
+	// A bus reopened on the journal continues after the last version
+	reopened := New()
+	if err := reopened.SetJournal(j); err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	reopened.Publish(NewEvent(EventTraceEvent, "", nil))
+	if v := reopened.Version(); v != 21 {
+		t.Fatalf("expected version 21 after reopening, got %d", v)
+	}
+}
+
+func TestBus_SubscribeFromReportsGaps(t *testing.T) {
+	b := New()
+	j := &memJournal{}
+	if err := b.SetJournal(j); err != nil {
+		t.Fatal(err)
+	}
+	publish := func(n int) {
+		for i := 0; i < n; i++ {
+			b.Publish(NewEvent(EventTraceEvent, "", nil))
+		}
+		b.writer.flush(b.Version())
+	}
+	publish(2)
+	j.mu.Lock()
+	j.appendErr = errors.New("disk full")
+	j.mu.Unlock()
+	publish(2)
+	j.mu.Lock()
+	j.appendErr = nil
+	j.mu.Unlock()
+	publish(1)
+	if st := b.Stats(); st.JournalLost != 2 || st.JournalQueued != 0 {
+		t.Fatalf("expected two lost events: %+v", st)
+	}
+
+	ch, cancel, err := b.SubscribeFrom(0, EventTraceEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	for _, want := range []int{1, 2, 0, 5} {
+		e := receive(t, ch)
+		if want != 0 {
+			if e.Version != want {
+				t.Fatalf("expected version %d, got %s %d", want, e.Event, e.Version)
+			}
+			continue
+		}
+		gap, ok := e.Payload.(ReplayGap)
+		if e.Event != EventReplayGap || !ok || gap.From != 3 || gap.To != 4 || gap.Reason == "" {
+			t.Fatalf("expected a gap for versions 3 and 4, got %+v", e)
+		}
+	}
+
+	// A journal that cannot be read is a gap too, not a silent skip
+	j.mu.Lock()
+	j.readErr = errors.New("locked")
+	j.mu.Unlock()
+	ch2, cancel2, err := b.SubscribeFrom(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel2()
+	if e := receive(t, ch2); e.Event != EventReplayGap || e.Payload.(ReplayGap).From != 1 || e.Payload.(ReplayGap).To != 5 {
+		t.Fatalf("expected a gap for the whole replay, got %+v", e)
+	}
+}
+
+func TestBus_StatsCountsDrops(t *testing.T) {
+	b := New()
+	_, cancel := b.Subscribe(EventTraceEvent)
+	defer cancel()
+	other, cancelOther := b.Subscribe(EventErrorOccurred)
+	defer cancelOther()
+
+	for i := 0; i < 105; i++ {
+		b.Publish(NewEvent(EventTraceEvent, "", nil))
+	}
+	b.Publish(NewEvent(EventErrorOccurred, "", nil))
+	<-other
+
+	st := b.Stats()
+	if st.Version != 106 || st.Journal || st.Dropped != 5 || len(st.Subscribers) != 2 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+	for _, sub := range st.Subscribers {
+		switch sub.Topics[0] {
+		case EventTraceEvent:
+			if sub.Queued != 100 || sub.Capacity != 100 || sub.Dropped != 5 {
+				t.Errorf("unexpected trace subscriber stats: %+v", sub)
+			}
+		case EventErrorOccurred:
+			if sub.Queued != 0 || sub.Dropped != 0 {
+				t.Errorf("unexpected error subscriber stats: %+v", sub)
+			}
+		}
+	}
+}
+
+func TestBus_PruneJournal(t *testing.T) {
+	b := New()
+	j := &memJournal{}
+	if err := b.SetJournal(j); err != nil {
+		t.Fatal(err)
+	}
+	old := NewEvent(EventTraceEvent, "", nil)
+	old.Timestamp = time.Now().Add(-2 * time.Hour)
+	b.Publish(old)
+	for i := 0; i < 5; i++ {
+		b.Publish(NewEvent(EventTraceEvent, "", nil))
+	}
+	b.writer.flush(b.Version())
+
+	b.pruneJournal(Retention{MaxAge: time.Hour})
+	if n := len(j.events); n != 5 {
+		t.Fatalf("expected the old event to be pruned, %d left", n)
+	}
+	b.pruneJournal(Retention{MaxEvents: 3})
+	if n := len(j.events); n != 3 || j.events[0].Version != 4 {
+		t.Fatalf("expected the newest 3 events to remain, got %+v", j.events)
+	}
+}
@@ -0,0 +1,181 @@
+package bus
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var (
+	payloadMu    sync.RWMutex
+	payloadTypes = map[EventType]reflect.Type{}
+)
+
+// RegisterPayload records the payload type published with an event type,
+// given a zero value of it; nil records an event without a payload. The
+// package that publishes an event registers its payload from init. It
+// panics if the event type is registered twice.
+func RegisterPayload(t EventType, sample interface{}) {
+	payloadMu.Lock()
+	defer payloadMu.Unlock()
+	if _, dup := payloadTypes[t]; dup {
+		panic(fmt.Sprintf("bus: payload for %s registered twice", t))
+	}
+	var rt reflect.Type
+	if sample != nil {
+		rt = reflect.TypeOf(sample)
+		for rt.Kind() == reflect.Pointer {
+			rt = rt.Elem()
+		}
+	}
+	payloadTypes[t] = rt
+}
+
+// PayloadType returns the payload type registered for an event type. The
+// type is nil for events without a payload; ok is false for unregistered
+// events.
+func PayloadType(t EventType) (rt reflect.Type, ok bool) {
+	payloadMu.RLock()
+	defer payloadMu.RUnlock()
+	rt, ok = payloadTypes[t]
+	return rt, ok
+}
+
+// RegisteredEvents returns the event types with a registered payload,
+// sorted.
+func RegisteredEvents() []EventType {
+	payloadMu.RLock()
+	defer payloadMu.RUnlock()
+	out := make([]EventType, 0, len(payloadTypes))
+	for t := range payloadTypes {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Notice is the payload of trace.event and error.occurred: a kind naming
+// what happened, the error for failures, and fields that depend on the
+// kind. It is encoded as one flat object, as publishers that build maps
+// produce.
+type Notice struct {
+	Kind   string
+	Error  string
+	Fields map[string]interface{}
+}
+
+// MarshalJSON flattens the notice's fields next to kind and error.
+func (n Notice) MarshalJSON() ([]byte, error) {
+	out := make(map[string]interface{}, len(n.Fields)+2)
+	for k, v := range n.Fields {
+		out[k] = v
+	}
+	if n.Kind != "" {
+		out["kind"] = n.Kind
+	}
+	if n.Error != "" {
+		out["error"] = n.Error
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON splits kind and error from the other fields.
+func (n *Notice) UnmarshalJSON(data []byte) error {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*n = Notice{}
+	if kind, ok := fields["kind"].(string); ok {
+		n.Kind = kind
+		delete(fields, "kind")
+	}
+	if msg, ok := fields["error"].(string); ok {
+		n.Error = msg
+		delete(fields, "error")
+	}
+	if len(fields) > 0 {
+		n.Fields = fields
+	}
+	return nil
+}
+
+// JSONSchema describes the flattened encoding.
+func (Notice) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"kind":  map[string]interface{}{"type": "string"},
+			"error": map[string]interface{}{"type": "string"},
+		},
+		"additionalProperties": true,
+	}
+}
+
+// SessionMessage is the payload of session.message: a chunk of an
+// assistant reply, or a whole message from a subagent.
+type SessionMessage struct {
+	Content string `json:"content"`
+	// Done marks the last chunk of a streamed reply.
+	Done    bool   `json:"done,omitempty"`
+	Role    string `json:"role,omitempty"`
+	AgentID string `json:"agent_id,omitempty"`
+	Status  string `json:"status,omitempty"`
+}
+
+// SessionTyping is the payload of session.typing.
+type SessionTyping struct {
+	Typing bool `json:"typing"`
+}
+
+// ChatRequest is the payload of chat.request. Requests from a messaging
+// channel name the channel instance, chat and sender; they start a
+// conversation without content.
+type ChatRequest struct {
+	Content   string `json:"content,omitempty"`
+	Source    string `json:"source,omitempty"`
+	ChannelID string `json:"channel_id,omitempty"`
+	SenderID  string `json:"sender_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+}
+
+// ChannelStatus is the payload of channel.status.
+type ChannelStatus struct {
+	ChannelID   string `json:"channel_id"`
+	ChannelType string `json:"channel_type,omitempty"`
+	Status      string `json:"status"`
+	// Data holds details that depend on the channel and status.
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// DeadLetter is the payload of bus.dead_letter: an event a subscriber
+// lost and why, with the subscriber's delivery mode.
+type DeadLetter struct {
+	Subscriber string       `json:"subscriber"`
+	Mode       DeliveryMode `json:"mode"`
+	Reason     string       `json:"reason"`
+	Event      Event        `json:"event"`
+}
+
+// ReplayGap is the payload of bus.replay_gap: the versions From to To,
+// some of which the journal lost, and why. SubscribeFrom sends it in
+// their place.
+type ReplayGap struct {
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+	Reason string `json:"reason"`
+}
+
+func init() {
+	RegisterPayload(EventTraceEvent, Notice{})
+	RegisterPayload(EventErrorOccurred, Notice{})
+	RegisterPayload(EventSessionMessage, SessionMessage{})
+	RegisterPayload(EventSessionEnded, nil)
+	RegisterPayload(EventSessionTyping, SessionTyping{})
+	RegisterPayload(EventChatRequest, ChatRequest{})
+	RegisterPayload(EventChannelStatus, ChannelStatus{})
+	RegisterPayload(EventDeadLetter, DeadLetter{})
+	RegisterPayload(EventReplayGap, ReplayGap{})
+}
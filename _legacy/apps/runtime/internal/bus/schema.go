@@ -0,0 +1,146 @@
+package bus
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaURI is the JSON Schema dialect of generated schemas.
+const SchemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaProvider is implemented by payload types whose JSON encoding
+// reflection cannot describe, such as Notice.
+type schemaProvider interface {
+	JSONSchema() map[string]interface{}
+}
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	rawMessageType     = reflect.TypeOf(json.RawMessage(nil))
+	schemaProviderType = reflect.TypeOf((*schemaProvider)(nil)).Elem()
+)
+
+// Schemas returns a JSON Schema document for the event envelope and every
+// registered payload, for WebSocket clients. Payload schemas are under
+// $defs, keyed by event type.
+func Schemas() map[string]interface{} {
+	defs := map[string]interface{}{}
+	for _, t := range RegisteredEvents() {
+		defs[string(t)] = PayloadSchema(t)
+	}
+	envelope := TypeSchema(reflect.TypeOf(Event{}))
+	envelope["$schema"] = SchemaURI
+	envelope["title"] = "Pryx event"
+	envelope["$defs"] = defs
+	return envelope
+}
+
+// PayloadSchema returns the JSON Schema of an event type's payload, or nil
+// if it is not registered.
+func PayloadSchema(t EventType) map[string]interface{} {
+	rt, ok := PayloadType(t)
+	if !ok {
+		return nil
+	}
+	if rt == nil {
+		return map[string]interface{}{"type": "null"}
+	}
+	return TypeSchema(rt)
+}
+
+// TypeSchema describes the JSON encoding of a Go type, following the
+// encoding/json rules for field names, omitempty and embedded structs.
+func TypeSchema(rt reflect.Type) map[string]interface{} {
+	return typeSchema(rt, map[reflect.Type]bool{})
+}
+
+func typeSchema(rt reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	if rt.Kind() != reflect.Pointer && rt.Implements(schemaProviderType) {
+		return reflect.Zero(rt).Interface().(schemaProvider).JSONSchema()
+	}
+	switch rt {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch rt.Kind() {
+	case reflect.Pointer:
+		return typeSchema(rt.Elem(), seen)
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if rt.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": typeSchema(rt.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(rt.Elem(), seen)}
+	case reflect.Struct:
+		if seen[rt] {
+			// Recursive types are left open rather than expanded forever
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[rt] = true
+		defer delete(seen, rt)
+
+		props := map[string]interface{}{}
+		var required []string
+		addFields(rt, props, &required, seen)
+		s := map[string]interface{}{
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	default:
+		// interface{} and anything else encoding/json accepts as is
+		return map[string]interface{}{}
+	}
+}
+
+// addFields adds the encoded fields of a struct, including those promoted
+// from embedded structs.
+func addFields(rt reflect.Type, props map[string]interface{}, required *[]string, seen map[reflect.Type]bool) {
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addFields(ft, props, required, seen)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = typeSchema(f.Type, seen)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
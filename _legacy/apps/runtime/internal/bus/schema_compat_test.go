@@ -0,0 +1,63 @@
+package bus_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pryx-core/internal/bus"
+
+	// Packages that register the payloads of their events
+	_ "pryx-core/internal/channels"
+	_ "pryx-core/internal/mcp"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/payload_schemas.json")
+
+var predefined = []bus.EventType{
+	bus.EventSessionMessage, bus.EventSessionEnded, bus.EventSessionTyping,
+	bus.EventToolRequest, bus.EventToolExecuting, bus.EventToolComplete,
+	bus.EventApprovalNeeded, bus.EventApprovalResolved, bus.EventApprovalRevoked,
+	bus.EventElicitationRequested, bus.EventElicitationResolved,
+	bus.EventTraceEvent, bus.EventErrorOccurred,
+	bus.EventChannelStatus, bus.EventChannelMessage, bus.EventChannelOutboundMessage,
+	bus.EventChatRequest, bus.EventSandboxViolation, bus.EventNetworkRequest,
+	bus.EventDeadLetter, bus.EventReplayGap,
+}
+
+func TestPayloads_Registered(t *testing.T) {
+	for _, et := range predefined {
+		if _, ok := bus.PayloadType(et); !ok {
+			t.Errorf("%s has no registered payload", et)
+		}
+	}
+}
+
+// TestPayloads_SchemaUnchanged fails when a payload's JSON shape changes.
+// WS clients depend on these shapes: if the change is intended, run the
+// test with -update and review the diff.
+func TestPayloads_SchemaUnchanged(t *testing.T) {
+	got, err := json.MarshalIndent(bus.Schemas(), "", "  ")
+	if err != nil {
+		t.Fatalf("marshal schemas: %v", err)
+	}
+	got = append(got, '\n')
+
+	golden := filepath.Join("testdata", "payload_schemas.json")
+	if *update {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatalf("write %s: %v", golden, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read %s: %v", golden, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("event payload schemas changed; run go test ./internal/bus -run SchemaUnchanged -update if intended\n%s", got)
+	}
+}
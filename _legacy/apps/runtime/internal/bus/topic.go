@@ -0,0 +1,124 @@
+package bus
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Filter selects the events a subscription receives. Zero fields accept
+// every event.
+type Filter struct {
+	// Topics are event types or topic patterns; see MatchTopic. An event
+	// must match one of them.
+	Topics []EventType
+	// SessionID and Surface, when set, must equal the event's.
+	SessionID string
+	Surface   string
+	// Where, when set, must accept the event. It runs on the publisher's
+	// goroutine, so it must be quick and must not use the bus.
+	Where func(Event) bool
+}
+
+// IsTopicPattern reports whether t contains wildcards rather than naming
+// a single event type.
+func IsTopicPattern(t EventType) bool {
+	return strings.ContainsAny(string(t), "*?[")
+}
+
+// MatchTopic reports whether topic matches pattern. Topics are
+// hierarchical, with dot-separated segments. Each pattern segment is a
+// glob matched against one topic segment, so "tool.*" matches
+// "tool.complete" and "tool.exec*" matches "tool.executing"; a "**"
+// segment matches any number of segments, so "agentbus.**" matches every
+// agentbus event. A malformed glob matches nothing.
+func MatchTopic(pattern, topic EventType) bool {
+	return matchSegments(string(pattern), string(topic))
+}
+
+// ValidateTopic checks that t is an event type or a well-formed topic
+// pattern.
+func ValidateTopic(t EventType) error {
+	if t == "" {
+		return fmt.Errorf("empty topic")
+	}
+	for _, seg := range strings.Split(string(t), ".") {
+		if seg == "" {
+			return fmt.Errorf("topic %q has an empty segment", t)
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("topic %q: invalid pattern %q", t, seg)
+		}
+	}
+	return nil
+}
+
+func matchSegments(p, t string) bool {
+	for {
+		pseg, prest, pmore := strings.Cut(p, ".")
+		if pseg == "**" {
+			if !pmore {
+				return true
+			}
+			// Try the rest of the pattern against every suffix of t
+			for {
+				if matchSegments(prest, t) {
+					return true
+				}
+				var tmore bool
+				if _, t, tmore = strings.Cut(t, "."); !tmore {
+					return false
+				}
+			}
+		}
+		tseg, trest, tmore := strings.Cut(t, ".")
+		if ok, _ := path.Match(pseg, tseg); !ok {
+			return false
+		}
+		switch {
+		case !pmore && !tmore:
+			return true
+		case !tmore:
+			// Only a trailing "**" matches no further segments
+			return prest == "**"
+		case !pmore:
+			return false
+		}
+		p, t = prest, trest
+	}
+}
+
+// topicRoot returns the first segment of t
+func topicRoot(t EventType) string {
+	root, _, _ := strings.Cut(string(t), ".")
+	return root
+}
+
+// indexKey is where a subscription is filed in the bus's topic index
+type indexKey struct {
+	kind byte // 'e' exact topic, 'r' pattern root, 'w' wildcard
+	name string
+}
+
+// indexKeys returns the index entries for a subscription to topics
+func indexKeys(topics []EventType) []indexKey {
+	if len(topics) == 0 {
+		return []indexKey{{kind: 'w'}}
+	}
+	seen := make(map[indexKey]bool, len(topics))
+	keys := make([]indexKey, 0, len(topics))
+	for _, t := range topics {
+		k := indexKey{kind: 'e', name: string(t)}
+		if IsTopicPattern(t) {
+			k = indexKey{kind: 'r', name: topicRoot(t)}
+			if IsTopicPattern(EventType(k.name)) || k.name == "**" {
+				k = indexKey{kind: 'w'}
+			}
+		}
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
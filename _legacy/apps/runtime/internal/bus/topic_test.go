@@ -0,0 +1,121 @@
+package bus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchTopic(t *testing.T) {
+	cases := []struct {
+		pattern, topic EventType
+		want           bool
+	}{
+		{"tool.*", "tool.complete", true},
+		{"tool.*", "tool", false},
+		{"tool.*", "tool.a.b", false},
+		{"tool.exec*", "tool.executing", true},
+		{"tool.exec*", "tool.complete", false},
+		{"*.message", "session.message", true},
+		{"*.message", "channel.outbound_message", false},
+		{"agentbus.**", "agentbus.agent.registered", true},
+		{"agentbus.**", "agentbus", true},
+		{"agentbus.**", "agentbusy.started", false},
+		{"**.started", "agentbus.detection.started", true},
+		{"**", "anything.at.all", true},
+		{"a.**.z", "a.z", true},
+		{"a.**.z", "a.b.c.z", true},
+		{"a.**.z", "a.b.c", false},
+		{"tool.[", "tool.[", false},
+	}
+	for _, tc := range cases {
+		if got := MatchTopic(tc.pattern, tc.topic); got != tc.want {
+			t.Errorf("MatchTopic(%q, %q) = %v, want %v", tc.pattern, tc.topic, got, tc.want)
+		}
+	}
+}
+
+func TestValidateTopic(t *testing.T) {
+	for _, ok := range []EventType{"tool.complete", "tool.*", "agentbus.**", "*.failed"} {
+		if err := ValidateTopic(ok); err != nil {
+			t.Errorf("%s: %v", ok, err)
+		}
+	}
+	for _, bad := range []EventType{"", "tool.", ".tool", "tool..x", "tool.[a"} {
+		if ValidateTopic(bad) == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestBus_SubscribePatterns(t *testing.T) {
+	b := New()
+	tools, cancelTools := b.Subscribe("tool.*")
+	defer cancelTools()
+	// Named both exactly and by pattern, so filed twice in the index
+	mixed, cancelMixed := b.Subscribe(EventToolComplete, "tool.*", "**.occurred")
+	defer cancelMixed()
+
+	b.Publish(NewEvent(EventToolRequest, "", nil))
+	b.Publish(NewEvent(EventToolComplete, "", nil))
+	b.Publish(NewEvent(EventErrorOccurred, "", nil))
+	b.Publish(NewEvent(EventSessionMessage, "", nil))
+
+	expectTopics(t, tools, EventToolRequest, EventToolComplete)
+	expectTopics(t, mixed, EventToolRequest, EventToolComplete, EventErrorOccurred)
+}
+
+func TestBus_SubscribeFilter(t *testing.T) {
+	b := New()
+	events, cancel := b.SubscribeFilter(Filter{
+		Topics:    []EventType{"session.*"},
+		SessionID: "s1",
+		Surface:   "tui",
+		Where:     func(e Event) bool { return e.Payload != nil },
+	})
+	defer cancel()
+
+	publish := func(topic EventType, session, surface string, payload interface{}) {
+		e := NewEvent(topic, session, payload)
+		e.Surface = surface
+		b.Publish(e)
+	}
+	publish(EventSessionMessage, "s2", "tui", "other session")
+	publish(EventSessionMessage, "s1", "cli", "other surface")
+	publish(EventSessionMessage, "s1", "tui", nil)
+	publish(EventToolComplete, "s1", "tui", "other topic")
+	publish(EventSessionTyping, "s1", "tui", "wanted")
+
+	e := receive(t, events)
+	if e.Event != EventSessionTyping || e.Payload != "wanted" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	expectTopics(t, events)
+}
+
+func TestBus_UnsubscribeClearsIndex(t *testing.T) {
+	b := New()
+	_, c1 := b.Subscribe(EventToolComplete, "tool.*")
+	_, c2 := b.Subscribe("*.complete")
+	_, c3 := b.Subscribe()
+	c1()
+	c2()
+	c3()
+	if len(b.subs) != 0 || len(b.exact) != 0 || len(b.roots) != 0 || len(b.wild) != 0 {
+		t.Fatalf("index not cleared: %d subs, %d exact, %d roots, %d wild", len(b.subs), len(b.exact), len(b.roots), len(b.wild))
+	}
+}
+
+// expectTopics checks the events waiting on ch are exactly topics, in order
+func expectTopics(t *testing.T, ch <-chan Event, topics ...EventType) {
+	t.Helper()
+	for _, want := range topics {
+		if e := receive(t, ch); e.Event != want {
+			t.Fatalf("expected %s, got %s", want, e.Event)
+		}
+	}
+	select {
+	case e := <-ch:
+		t.Fatalf("unexpected event %s", e.Event)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
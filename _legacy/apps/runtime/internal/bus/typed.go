@@ -0,0 +1,79 @@
+package bus
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Typed is an event with its payload decoded into T.
+type Typed[T any] struct {
+	Event
+	Data T
+}
+
+// DecodePayload converts an event's payload to T. Payloads published as
+// T or *T are returned as is; others, such as maps or payloads replayed
+// from the journal, are converted through JSON.
+func DecodePayload[T any](e Event) (T, error) {
+	var out T
+	switch p := e.Payload.(type) {
+	case T:
+		return p, nil
+	case *T:
+		if p != nil {
+			return *p, nil
+		}
+		return out, nil
+	}
+	data, err := json.Marshal(e.Payload)
+	if err != nil {
+		return out, fmt.Errorf("encode %s payload: %w", e.Event, err)
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return out, fmt.Errorf("decode %s payload as %T: %w", e.Event, out, err)
+	}
+	return out, nil
+}
+
+// SubscribeTyped subscribes to the events f accepts and decodes their
+// payloads into T. Events whose payload does not decode are logged and
+// skipped. The channel is closed after the closer is called.
+func SubscribeTyped[T any](b *Bus, f Filter) (<-chan Typed[T], func()) {
+	events, cancel := b.SubscribeFilter(f)
+	out := make(chan Typed[T], cap(events))
+	done := make(chan struct{})
+	var once sync.Once
+	closer := func() {
+		once.Do(func() {
+			close(done)
+			cancel()
+		})
+	}
+
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-done:
+				return
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				data, err := DecodePayload[T](e)
+				if err != nil {
+					log.Printf("bus: skipping event %d: %v", e.Version, err)
+					continue
+				}
+				select {
+				case out <- Typed[T]{Event: e, Data: data}:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+	return out, closer
+}
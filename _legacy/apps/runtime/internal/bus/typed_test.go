@@ -0,0 +1,62 @@
+package bus
+
+import (
+	"testing"
+	"time"
+)
+
+type toolDone struct {
+	Tool     string `json:"tool"`
+	Duration int    `json:"duration_ms"`
+}
+
+func TestSubscribeTyped(t *testing.T) {
+	b := New()
+	events, cancel := SubscribeTyped[toolDone](b, Filter{Topics: []EventType{"tool.*"}})
+
+	b.Publish(NewEvent(EventToolComplete, "s1", map[string]interface{}{"tool": "shell", "duration_ms": 12}))
+	b.Publish(NewEvent(EventToolComplete, "s1", "not an object"))
+	b.Publish(NewEvent(EventToolComplete, "s1", toolDone{Tool: "fetch"}))
+	b.Publish(NewEvent(EventToolComplete, "s1", &toolDone{Tool: "read"}))
+
+	for _, want := range []toolDone{{"shell", 12}, {"fetch", 0}, {"read", 0}} {
+		select {
+		case e := <-events:
+			if e.Data != want || e.SessionID != "s1" {
+				t.Fatalf("expected %+v, got %+v", want, e)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for an event")
+		}
+	}
+
+	cancel()
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("expected the channel to be closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestDecodePayload_Notice(t *testing.T) {
+	evt := NewEvent(EventTraceEvent, "", map[string]interface{}{"kind": "mcp.cache.hit", "tool": "fetch"})
+	n, err := DecodePayload[Notice](evt)
+	if err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if n.Kind != "mcp.cache.hit" || n.Error != "" || n.Fields["tool"] != "fetch" {
+		t.Fatalf("unexpected notice %+v", n)
+	}
+
+	back, err := DecodePayload[map[string]interface{}](NewEvent(EventErrorOccurred, "", Notice{Kind: "x", Error: "boom"}))
+	if err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if len(back) != 2 || back["kind"] != "x" || back["error"] != "boom" {
+		t.Errorf("expected a flat object, got %v", back)
+	}
+}
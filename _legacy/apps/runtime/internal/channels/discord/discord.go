@@ -67,9 +67,11 @@ func (d *DiscordChannel) Connect(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	d.cancel = cancel
 
-	// Subscribe to outbound messages
+	// Subscribe to outbound messages; replies must not be dropped
 	if d.eventBus != nil {
-		outbound, unsub := d.eventBus.Subscribe(bus.EventChannelOutboundMessage)
+		outbound, unsub := d.eventBus.SubscribeDelivery(bus.Filter{
+			Topics: []bus.EventType{bus.EventChannelOutboundMessage},
+		}, bus.Delivery{Mode: bus.DeliverSpill})
 		go d.handleOutbound(ctx, outbound, unsub)
 	}
 
@@ -168,6 +170,7 @@ func (d *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 		ID:        m.ID,
 		Content:   m.Content,
 		Source:    d.id,
+		Type:      d.Type(),
 		ChannelID: m.ChannelID,
 		SenderID:  m.Author.ID,
 		Metadata: map[string]string{
@@ -181,10 +184,7 @@ func (d *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 
 	// Publish to event bus
 	if d.eventBus != nil {
-		d.eventBus.Publish(bus.Event{
-			Type:    bus.EventChannelMessage,
-			Payload: msg,
-		})
+		d.eventBus.Publish(bus.NewEvent(bus.EventChannelMessage, "", msg))
 	}
 }
 
@@ -196,7 +196,7 @@ func (d *DiscordChannel) handleOutbound(ctx context.Context, outbound <-chan bus
 		case <-ctx.Done():
 			return
 		case event := <-outbound:
-			if msg, ok := event.Payload.(channels.Message); ok {
+			if msg, err := bus.DecodePayload[channels.Message](event); err == nil {
 				if msg.Source == d.id {
 					// This is our message to send
 					if err := d.Send(ctx, msg); err != nil {
@@ -371,6 +371,7 @@ func (h *Handler) publishMessage(msg *Message, isDM, isMention bool) {
 		ID:        msg.ID,
 		Content:   content,
 		Source:    h.config.ID,
+		Type:      "discord",
 		ChannelID: msg.ChannelID,
 		SenderID:  "",
 		CreatedAt: msg.Timestamp,
@@ -401,12 +402,11 @@ func (h *Handler) publishChatRequest(interaction *Interaction) {
 		username = interaction.Member.User.Username
 	}
 
-	h.eventBus.Publish(bus.NewEvent(bus.EventChatRequest, "", map[string]interface{}{
-		"channel_id": h.config.ID,
-		"guild_id":   interaction.GuildID,
-		"channel":    interaction.ChannelID,
-		"user_id":    userID,
-		"username":   username,
+	h.eventBus.Publish(bus.NewEvent(bus.EventChatRequest, "", bus.ChatRequest{
+		Source:    h.config.ID,
+		ChannelID: interaction.ChannelID,
+		SenderID:  userID,
+		Username:  username,
 	}))
 }
 
@@ -416,17 +416,12 @@ func (h *Handler) publishStatus(status string, data map[string]interface{}) {
 		return
 	}
 
-	payload := map[string]interface{}{
-		"channel_id":   h.config.ID,
-		"channel_type": "discord",
-		"status":       status,
-	}
-
-	for k, v := range data {
-		payload[k] = v
-	}
-
-	h.eventBus.Publish(bus.NewEvent(bus.EventChannelStatus, "", payload))
+	h.eventBus.Publish(bus.NewEvent(bus.EventChannelStatus, "", bus.ChannelStatus{
+		ChannelID:   h.config.ID,
+		ChannelType: "discord",
+		Status:      status,
+		Data:        data,
+	}))
 }
 
 // publishError publishes an error event
@@ -113,9 +113,10 @@ func (m *ChannelManager) publishError(c Channel, err error) {
 
 func (m *ChannelManager) publishStatus(c Channel, status Status) {
 	if m.eventBus != nil {
-		m.eventBus.Publish(bus.NewEvent(bus.EventChannelStatus, "", map[string]interface{}{
-			"channel_id": c.ID(),
-			"status":     status,
+		m.eventBus.Publish(bus.NewEvent(bus.EventChannelStatus, "", bus.ChannelStatus{
+			ChannelID:   c.ID(),
+			ChannelType: c.Type(),
+			Status:      string(status),
 		}))
 	}
 }
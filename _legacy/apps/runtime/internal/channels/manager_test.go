@@ -97,12 +97,12 @@ func TestManager_AutoConnect(t *testing.T) {
 		if event.Event != bus.EventChannelStatus {
 			t.Errorf("Expected status event, got %v", event.Type)
 		}
-		data := event.Payload.(map[string]interface{})
-		if data["channel_id"] != "connect-test" {
-			t.Errorf("Expected channel_id connect-test, got %v", data["channel_id"])
+		data := event.Payload.(bus.ChannelStatus)
+		if data.ChannelID != "connect-test" {
+			t.Errorf("Expected channel_id connect-test, got %v", data.ChannelID)
 		}
-		if data["status"] != StatusConnected {
-			t.Errorf("Expected status connected, got %v", data["status"])
+		if data.Status != string(StatusConnected) {
+			t.Errorf("Expected status connected, got %v", data.Status)
 		}
 	case <-time.After(1 * time.Second):
 		t.Error("Timeout waiting for status event")
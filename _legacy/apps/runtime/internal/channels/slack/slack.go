@@ -70,9 +70,11 @@ func (s *SlackChannel) Connect(ctx context.Context) error {
 	// Start socket mode in background
 	go s.runSocketMode(ctx)
 
-	// Subscribe to outbound messages
+	// Subscribe to outbound messages; replies must not be dropped
 	if s.eventBus != nil {
-		outbound, unsub := s.eventBus.Subscribe(bus.EventChannelOutboundMessage)
+		outbound, unsub := s.eventBus.SubscribeDelivery(bus.Filter{
+			Topics: []bus.EventType{bus.EventChannelOutboundMessage},
+		}, bus.Delivery{Mode: bus.DeliverSpill})
 		go s.handleOutbound(ctx, outbound, unsub)
 	}
 
@@ -141,6 +143,7 @@ func (s *SlackChannel) handleEventsAPI(event slackevents.EventsAPIEvent) {
 				ID:        messageEvent.TimeStamp,
 				Content:   messageEvent.Text,
 				Source:    s.id,
+				Type:      s.Type(),
 				ChannelID: messageEvent.Channel,
 				SenderID:  messageEvent.User,
 				Metadata: map[string]string{
@@ -151,10 +154,7 @@ func (s *SlackChannel) handleEventsAPI(event slackevents.EventsAPIEvent) {
 
 			// Publish to event bus
 			if s.eventBus != nil {
-				s.eventBus.Publish(bus.Event{
-					Type:    bus.EventChannelMessage,
-					Payload: msg,
-				})
+				s.eventBus.Publish(bus.NewEvent(bus.EventChannelMessage, "", msg))
 			}
 		}
 
@@ -164,6 +164,7 @@ func (s *SlackChannel) handleEventsAPI(event slackevents.EventsAPIEvent) {
 				ID:        mentionEvent.TimeStamp,
 				Content:   mentionEvent.Text,
 				Source:    s.id,
+				Type:      s.Type(),
 				ChannelID: mentionEvent.Channel,
 				SenderID:  mentionEvent.User,
 				Metadata: map[string]string{
@@ -174,10 +175,7 @@ func (s *SlackChannel) handleEventsAPI(event slackevents.EventsAPIEvent) {
 			}
 
 			if s.eventBus != nil {
-				s.eventBus.Publish(bus.Event{
-					Type:    bus.EventChannelMessage,
-					Payload: msg,
-				})
+				s.eventBus.Publish(bus.NewEvent(bus.EventChannelMessage, "", msg))
 			}
 		}
 	}
@@ -191,7 +189,7 @@ func (s *SlackChannel) handleOutbound(ctx context.Context, outbound <-chan bus.E
 		case <-ctx.Done():
 			return
 		case event := <-outbound:
-			if msg, ok := event.Payload.(channels.Message); ok {
+			if msg, err := bus.DecodePayload[channels.Message](event); err == nil {
 				if msg.Source == s.id {
 					// This is our message to send
 					if err := s.Send(ctx, msg); err != nil {
@@ -315,16 +315,16 @@ func (ch *Channel) publishStatus(status string, botInfo *User) {
 		return
 	}
 
-	payload := map[string]interface{}{
-		"channel_id":   ch.id,
-		"channel_type": ch.Type(),
-		"status":       status,
-		"mode":         ch.config.Mode,
+	payload := bus.ChannelStatus{
+		ChannelID:   ch.id,
+		ChannelType: ch.Type(),
+		Status:      status,
+		Data:        map[string]interface{}{"mode": ch.config.Mode},
 	}
 
 	if botInfo != nil {
-		payload["bot_username"] = botInfo.Username
-		payload["bot_name"] = botInfo.FirstName
+		payload.Data["bot_username"] = botInfo.Username
+		payload.Data["bot_name"] = botInfo.FirstName
 	}
 
 	ch.eventBus.Publish(bus.NewEvent(bus.EventChannelStatus, "", payload))
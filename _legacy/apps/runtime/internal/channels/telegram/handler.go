@@ -235,12 +235,16 @@ func (h *Handler) handleCallbackQuery(ctx context.Context, query *CallbackQuery)
 func (h *Handler) handleChatMemberUpdate(ctx context.Context, update *ChatMemberUpdated) error {
 	// Log chat member changes
 	if h.eventBus != nil {
-		h.eventBus.Publish(bus.NewEvent(bus.EventChannelStatus, "", map[string]interface{}{
-			"channel_id": h.config.ID,
-			"chat_id":    update.Chat.ID,
-			"old_status": update.OldChatMember.Status,
-			"new_status": update.NewChatMember.Status,
-			"user":       update.From,
+		h.eventBus.Publish(bus.NewEvent(bus.EventChannelStatus, "", bus.ChannelStatus{
+			ChannelID:   h.config.ID,
+			ChannelType: "telegram",
+			Status:      "member_updated",
+			Data: map[string]interface{}{
+				"chat_id":    update.Chat.ID,
+				"old_status": update.OldChatMember.Status,
+				"new_status": update.NewChatMember.Status,
+				"user":       update.From,
+			},
 		}))
 	}
 	return nil
@@ -275,6 +279,7 @@ func (h *Handler) publishMessage(msg *Message) {
 		ID:        strconv.Itoa(msg.MessageID),
 		Content:   content,
 		Source:    h.config.ID,
+		Type:      "telegram",
 		ChannelID: strconv.FormatInt(msg.Chat.ID, 10),
 		SenderID:  h.formatSenderID(msg.From),
 		CreatedAt: time.Unix(int64(msg.Date), 0),
@@ -290,12 +295,11 @@ func (h *Handler) publishChatRequest(msg *Message) {
 		return
 	}
 
-	h.eventBus.Publish(bus.NewEvent(bus.EventChatRequest, "", map[string]interface{}{
-		"channel_id": h.config.ID,
-		"chat_id":    msg.Chat.ID,
-		"user_id":    h.formatSenderID(msg.From),
-		"username":   h.getUsername(msg.From),
-		"message":    msg.Text,
+	h.eventBus.Publish(bus.NewEvent(bus.EventChatRequest, "", bus.ChatRequest{
+		Source:    h.config.ID,
+		ChannelID: strconv.FormatInt(msg.Chat.ID, 10),
+		SenderID:  h.formatSenderID(msg.From),
+		Username:  h.getUsername(msg.From),
 	}))
 }
 
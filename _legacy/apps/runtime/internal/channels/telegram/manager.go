@@ -211,22 +211,17 @@ func (m *Manager) IsChannelConnected(channelID string) bool {
 }
 
 // publishStatus publishes a status event
-func (m *Manager) publishStatus(channelID, status string, data interface{}) {
+func (m *Manager) publishStatus(channelID, status string, data map[string]interface{}) {
 	if m.eventBus == nil {
 		return
 	}
 
-	payload := map[string]interface{}{
-		"channel_id": channelID,
-		"status":     status,
-		"type":       "telegram",
-	}
-
-	if data != nil {
-		payload["data"] = data
-	}
-
-	m.eventBus.Publish(bus.NewEvent(bus.EventChannelStatus, "", payload))
+	m.eventBus.Publish(bus.NewEvent(bus.EventChannelStatus, "", bus.ChannelStatus{
+		ChannelID:   channelID,
+		ChannelType: "telegram",
+		Status:      status,
+		Data:        data,
+	}))
 }
 
 // publishError publishes an error event
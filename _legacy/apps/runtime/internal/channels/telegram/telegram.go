@@ -52,9 +52,11 @@ func (t *TelegramChannel) Connect(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	t.cancel = cancel
 
-	// Subscribe to outbound messages
+	// Subscribe to outbound messages; replies must not be dropped
 	if t.eventBus != nil {
-		outbound, unsub := t.eventBus.Subscribe(bus.EventChannelOutboundMessage)
+		outbound, unsub := t.eventBus.SubscribeDelivery(bus.Filter{
+			Topics: []bus.EventType{bus.EventChannelOutboundMessage},
+		}, bus.Delivery{Mode: bus.DeliverSpill})
 		go t.handleOutbound(ctx, outbound, unsub)
 	}
 
@@ -163,6 +165,7 @@ func (t *TelegramChannel) handleMessage(msg *tgbotapi.Message) {
 		ID:        strconv.Itoa(msg.MessageID),
 		Content:   content,
 		Source:    t.id,
+		Type:      t.Type(),
 		ChannelID: strconv.FormatInt(msg.Chat.ID, 10),
 		SenderID:  strconv.FormatInt(msg.From.ID, 10),
 		CreatedAt: time.Unix(int64(msg.Date), 0),
@@ -186,22 +189,17 @@ func (t *TelegramChannel) handleOutbound(ctx context.Context, events <-chan bus.
 			if !ok {
 				return
 			}
-			// Parse payload
-			if payload, ok := evt.Payload.(map[string]interface{}); ok {
-				source, _ := payload["source"].(string)
-				if source != t.id {
-					continue // Not for this channel instance
-				}
-				chatID, _ := payload["channel_id"].(string)
-				content, _ := payload["content"].(string)
-				if chatID != "" && content != "" {
-					// Send message
-					msg := channels.Message{
-						ChannelID: chatID,
-						Content:   content,
-					}
-					_ = t.Send(context.Background(), msg)
+			out, err := bus.DecodePayload[channels.Message](evt)
+			if err != nil || out.Source != t.id {
+				continue // Not for this channel instance
+			}
+			if out.ChannelID != "" && out.Content != "" {
+				// Send message
+				msg := channels.Message{
+					ChannelID: out.ChannelID,
+					Content:   out.Content,
 				}
+				_ = t.Send(context.Background(), msg)
 			}
 		}
 	}
@@ -2,7 +2,11 @@ package channels
 
 import (
 	"context"
+	"strings"
 	"time"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/policy"
 )
 
 type Status string
@@ -17,13 +21,56 @@ const (
 type Message struct {
 	ID        string            `json:"id"`
 	Content   string            `json:"content"`
-	Source    string            `json:"source"`     // Channel instance ID (e.g., "telegram-main")
-	ChannelID string            `json:"channel_id"` // External chat/conversation ID
+	Source    string            `json:"source"`         // Channel instance ID (e.g., "telegram-main")
+	Type      string            `json:"type,omitempty"` // Channel type (e.g., "telegram")
+	ChannelID string            `json:"channel_id"`     // External chat/conversation ID
 	SenderID  string            `json:"sender_id"`
 	Metadata  map[string]string `json:"metadata,omitempty"`
 	CreatedAt time.Time         `json:"created_at"`
 }
 
+// Surface names the chat a message came from, as recorded on the tool
+// calls and prompts made on its behalf
+func (m Message) Surface() string {
+	return "channel:" + m.Type + ":" + m.Source + ":" + m.ChannelID
+}
+
+// ParseSurface returns the channel type, instance and chat of a surface
+// made by Message.Surface
+func ParseSurface(surface string) (channelType, source, chatID string, ok bool) {
+	rest, ok := strings.CutPrefix(surface, "channel:")
+	if !ok {
+		return "", "", "", false
+	}
+	channelType, rest, ok = strings.Cut(rest, ":")
+	if !ok {
+		return "", "", "", false
+	}
+	source, chatID, ok = strings.Cut(rest, ":")
+	return channelType, source, chatID, ok && source != ""
+}
+
+// Caller returns who the tool calls made for the message are made for.
+// Its surface is the channel type, so policies can tell channels apart;
+// messages of unknown type are still never taken for the local user.
+func (m Message) Caller() policy.Caller {
+	surface := m.Type
+	if surface == "" {
+		surface = "channel"
+	}
+	return policy.Caller{
+		Surface:   surface,
+		Source:    m.Source,
+		ChannelID: m.ChannelID,
+		Sender:    m.SenderID,
+	}
+}
+
+func init() {
+	bus.RegisterPayload(bus.EventChannelMessage, Message{})
+	bus.RegisterPayload(bus.EventChannelOutboundMessage, Message{})
+}
+
 type Channel interface {
 	ID() string
 	Type() string
@@ -0,0 +1,43 @@
+package channels
+
+import (
+	"testing"
+
+	"pryx-core/internal/policy"
+)
+
+func TestMessage_SurfaceAndCaller(t *testing.T) {
+	msg := Message{Type: "telegram", Source: "telegram-main", ChannelID: "-100:7", SenderID: "42"}
+
+	channelType, source, chatID, ok := ParseSurface(msg.Surface())
+	if !ok || channelType != "telegram" || source != "telegram-main" || chatID != "-100:7" {
+		t.Fatalf("ParseSurface(%q) = %q, %q, %q, %v", msg.Surface(), channelType, source, chatID, ok)
+	}
+	if _, _, _, ok := ParseSurface("tui"); ok {
+		t.Error("expected a local surface not to parse")
+	}
+
+	p, err := policy.Parse([]byte(`
+default: deny
+rules:
+  - tool: "*"
+    from: {surface: [telegram], sender: ["42"]}
+    decision: allow
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	e := policy.NewEngine(p)
+	if got := e.Evaluate("mcp.echo.echo", nil, msg.Caller()).Decision; got != policy.DecisionAllow {
+		t.Errorf("expected the telegram sender to match, got %s", got)
+	}
+	other := msg
+	other.SenderID = "43"
+	if got := e.Evaluate("mcp.echo.echo", nil, other.Caller()).Decision; got != policy.DecisionDeny {
+		t.Errorf("expected another sender not to match, got %s", got)
+	}
+
+	if c := (Message{Source: "x", ChannelID: "1"}).Caller(); c.Surface == "" || policy.TrustFor(c.Surface) != policy.TrustUntrusted {
+		t.Errorf("expected a message of unknown type to be untrusted, got %+v", c)
+	}
+}
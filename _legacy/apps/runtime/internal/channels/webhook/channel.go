@@ -110,6 +110,7 @@ func (c *Channel) Receive(msg *IncomingWebhook) error {
 		ID:        msg.ID,
 		Content:   string(msg.Payload),
 		Source:    c.config.ID,
+		Type:      c.Type(),
 		ChannelID: msg.ChannelID,
 		SenderID:  "webhook",
 		Metadata:  msg.Headers,
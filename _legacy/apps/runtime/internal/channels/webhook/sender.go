@@ -9,8 +9,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
+
+	"pryx-core/internal/mcp/security"
 )
 
 type Sender struct {
@@ -19,15 +23,29 @@ type Sender struct {
 }
 
 func NewSender(config WebhookConfig) *Sender {
+	transport := &http.Transport{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	if config.PublicOnly {
+		// Dialled directly, so the check sees the real destination
+		dialer := &net.Dialer{Timeout: 10 * time.Second}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			d := *dialer
+			d.Control = security.RefusePrivateAddress(host)
+			return d.DialContext(ctx, network, addr)
+		}
+	}
 	return &Sender{
 		config: config,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
-			},
+			Timeout:   30 * time.Second,
+			Transport: transport,
 		},
 	}
 }
@@ -105,6 +123,11 @@ func (s *Sender) sendRequest(ctx context.Context, payload []byte) (int, error) {
 
 	if s.config.Secret != "" {
 		mac := hmac.New(sha256.New, []byte(s.config.Secret))
+		if s.config.SignTimestamp {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			req.Header.Set("X-Webhook-Timestamp", timestamp)
+			mac.Write([]byte(timestamp + "."))
+		}
 		mac.Write(payload)
 		signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
 		req.Header.Set("X-Webhook-Signature", "sha256="+signature)
@@ -28,9 +28,16 @@ type WebhookConfig struct {
 	Retries     int
 	Headers     map[string]string
 	RetryConfig RetryConfig
-	Enabled     bool
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// PublicOnly makes the sender refuse to connect to private addresses,
+	// checked after DNS resolution and on redirects.
+	PublicOnly bool
+	// SignTimestamp makes the sender sign "<timestamp>.<body>" and send
+	// the Unix timestamp in X-Webhook-Timestamp, so receivers can reject
+	// replayed deliveries.
+	SignTimestamp bool
+	Enabled       bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
 type WebhookChannel struct {
@@ -246,6 +253,7 @@ func (w *WebhookChannel) Receive(msg *IncomingWebhook) error {
 		ID:        msg.ID,
 		Content:   string(msg.Payload),
 		Source:    w.config.ID,
+		Type:      w.Type(),
 		ChannelID: msg.ChannelID,
 		SenderID:  "webhook",
 		Metadata:  msg.Headers,
@@ -329,6 +337,7 @@ func (w *WebhookChannel) handleWebhook(rw http.ResponseWriter, req *http.Request
 	msg := channels.Message{
 		ID:        fmt.Sprintf("web-%d", time.Now().UnixNano()),
 		Content:   content,
+		Type:      w.Type(),
 		ChannelID: w.config.ID,
 		SenderID:  "webhook",
 		CreatedAt: time.Now(),
@@ -68,6 +68,14 @@ type Config struct {
 	// MemoryFlushThresholdTokens triggers auto-flush when token count approaches this threshold.
 	MemoryFlushThresholdTokens int `yaml:"memory_flush_threshold_tokens"`
 
+	// Event Journal
+	// EventJournalEnabled records bus events in the database so WebSocket clients and mesh sync can replay what they missed.
+	EventJournalEnabled bool `yaml:"event_journal_enabled"`
+	// EventJournalMaxAge drops journaled events older than this (0 = no age limit).
+	EventJournalMaxAge time.Duration `yaml:"event_journal_max_age"`
+	// EventJournalMaxEvents keeps at most this many journaled events (0 = unlimited).
+	EventJournalMaxEvents int `yaml:"event_journal_max_events"`
+
 	// Security Configuration
 	// AllowedOrigins is a list of allowed CORS origins. Use specific origins in production.
 	// Defaults include localhost for development.
@@ -136,6 +144,9 @@ func Load() *Config {
 		MemoryEnabled:               true,
 		MemoryAutoFlush:             true,
 		MemoryFlushThresholdTokens:  100000,
+		EventJournalEnabled:         true,
+		EventJournalMaxAge:          24 * time.Hour,
+		EventJournalMaxEvents:       100000,
 		AllowedOrigins:              []string{}, // Defaults to localhost via middleware logic
 		MaxWebSocketConnections:     1000,
 		MaxWebSocketMessageSize:     10 * 1024 * 1024, // 10MB
@@ -0,0 +1,245 @@
+// Package eventhooks delivers bus events to the webhooks registered with
+// the event subscriptions API.
+package eventhooks
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"path"
+	"sync"
+	"time"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/channels/webhook"
+	"pryx-core/internal/store"
+)
+
+const (
+	// deliveryRetention is how long the delivery log is kept
+	deliveryRetention = 7 * 24 * time.Hour
+	pruneInterval     = time.Hour
+	// queueSize is how many events wait for a slow endpoint; beyond it
+	// the oldest are dropped and dead-lettered on the bus
+	queueSize = 1000
+)
+
+// Dispatcher sends the events each enabled subscription asks for to its
+// URL, one at a time and in order, signing them with the subscription's
+// secret and retrying failures with backoff. Every delivery is logged.
+// URLs that resolve to private addresses are refused.
+//
+// Failed deliveries are logged but not published, so a subscription to
+// error events cannot feed on its own failures.
+type Dispatcher struct {
+	store *store.Store
+	bus   *bus.Bus
+	retry webhook.RetryConfig
+	// allowPrivate lets tests deliver to local servers
+	allowPrivate bool
+
+	mu      sync.Mutex
+	ctx     context.Context
+	workers map[string]*worker
+}
+
+// worker delivers the events of one subscription
+type worker struct {
+	sub    *store.EventSubscription
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewDispatcher creates a dispatcher for the subscriptions in s
+func NewDispatcher(s *store.Store, b *bus.Bus) *Dispatcher {
+	return &Dispatcher{
+		store:   s,
+		bus:     b,
+		retry:   webhook.DefaultRetry(),
+		workers: make(map[string]*worker),
+	}
+}
+
+// Run delivers events until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.mu.Lock()
+	d.ctx = ctx
+	d.mu.Unlock()
+	if err := d.Reload(); err != nil {
+		log.Printf("eventhooks: failed to load subscriptions: %v", err)
+	}
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.store.PruneEventDeliveries(time.Now().Add(-deliveryRetention)); err != nil {
+			log.Printf("eventhooks: failed to prune delivery log: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			d.mu.Lock()
+			for id, w := range d.workers {
+				w.stop()
+				delete(d.workers, id)
+			}
+			d.ctx = nil
+			d.mu.Unlock()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reload brings the running deliveries in line with the stored
+// subscriptions after one is created, changed or deleted. Events queued
+// for a changed subscription are dropped.
+func (d *Dispatcher) Reload() error {
+	subs, err := d.store.ListEventSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.ctx == nil {
+		// Not running; Run loads the subscriptions when it starts
+		return nil
+	}
+	want := make(map[string]*store.EventSubscription, len(subs))
+	for _, sub := range subs {
+		switch {
+		case !sub.Enabled:
+		case len(sub.Topics) == 0:
+			// Would take every event, stream tokens included
+			log.Printf("eventhooks: subscription %s names no topics; not delivering", sub.ID)
+		default:
+			want[sub.ID] = sub
+		}
+	}
+	for id, w := range d.workers {
+		if sub, ok := want[id]; !ok || !sub.UpdatedAt.Equal(w.sub.UpdatedAt) {
+			w.stop()
+			delete(d.workers, id)
+		}
+	}
+	for id, sub := range want {
+		if _, ok := d.workers[id]; !ok {
+			d.workers[id] = d.start(sub)
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) start(sub *store.EventSubscription) *worker {
+	ctx, cancel := context.WithCancel(d.ctx)
+	w := &worker{sub: sub, cancel: cancel, done: make(chan struct{})}
+
+	// Events wait while a slow endpoint is retried, up to queueSize
+	events, unsubscribe := d.bus.SubscribeDelivery(filter(sub), bus.Delivery{Mode: bus.DeliverDropOldest, Buffer: queueSize})
+	sender := webhook.NewSender(webhook.WebhookConfig{
+		ID:            sub.ID,
+		TargetURL:     sub.URL,
+		Secret:        sub.Secret,
+		Headers:       sub.Headers,
+		RetryConfig:   d.retry,
+		PublicOnly:    !d.allowPrivate,
+		SignTimestamp: true,
+	})
+	go func() {
+		defer close(w.done)
+		defer unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				d.deliver(ctx, sender, sub, e)
+			}
+		}
+	}()
+	return w
+}
+
+func (w *worker) stop() {
+	w.cancel()
+	<-w.done
+}
+
+// deliver sends one event and logs the outcome. Deliveries cut short by
+// the subscription stopping are not logged.
+func (d *Dispatcher) deliver(ctx context.Context, sender *webhook.Sender, sub *store.EventSubscription, e bus.Event) {
+	rec := &store.EventDelivery{
+		SubscriptionID: sub.ID,
+		Event:          string(e.Event),
+		Version:        e.Version,
+		Status:         string(webhook.DeliveryStatusFailed),
+	}
+	start := time.Now()
+	body, err := json.Marshal(e)
+	if err == nil {
+		var res *webhook.DeliveryLog
+		res, err = sender.Send(ctx, body)
+		if ctx.Err() != nil {
+			return
+		}
+		if res != nil {
+			rec.Status = string(res.Status)
+			rec.Attempts = res.Attempt
+			rec.ResponseCode = res.ResponseCode
+			rec.Error = res.Error
+		}
+	}
+	if err != nil {
+		if rec.Error == "" {
+			rec.Error = err.Error()
+		}
+		log.Printf("eventhooks: failed to deliver %s (%d) to %s: %v", e.Event, e.Version, sub.URL, err)
+	}
+	rec.DurationMs = time.Since(start).Milliseconds()
+	if err := d.store.RecordEventDelivery(rec); err != nil {
+		log.Printf("eventhooks: failed to log delivery to %s: %v", sub.URL, err)
+	}
+}
+
+// filter returns the bus filter for a subscription. Kinds only restrict
+// events that carry a kind, such as trace and error events; others pass.
+func filter(sub *store.EventSubscription) bus.Filter {
+	f := bus.Filter{SessionID: sub.SessionID}
+	for _, t := range sub.Topics {
+		f.Topics = append(f.Topics, bus.EventType(t))
+	}
+	if len(sub.Kinds) > 0 {
+		kinds := sub.Kinds
+		f.Where = func(e bus.Event) bool {
+			kind, ok := eventKind(e)
+			if !ok {
+				return true
+			}
+			for _, k := range kinds {
+				if matched, _ := path.Match(k, kind); matched {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return f
+}
+
+// eventKind returns the kind of a trace or error event without decoding
+// its payload, as it runs on the publisher's goroutine
+func eventKind(e bus.Event) (string, bool) {
+	switch p := e.Payload.(type) {
+	case bus.Notice:
+		return p.Kind, true
+	case *bus.Notice:
+		return p.Kind, p != nil
+	case map[string]interface{}:
+		kind, ok := p["kind"].(string)
+		return kind, ok
+	}
+	return "", false
+}
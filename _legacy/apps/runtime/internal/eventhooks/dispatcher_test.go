@@ -0,0 +1,262 @@
+package eventhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/channels/webhook"
+	"pryx-core/internal/store"
+)
+
+// receiver records the events posted to it, failing the first fail
+// requests
+type receiver struct {
+	mu     sync.Mutex
+	fail   int
+	events []bus.Event
+	sigs   []string
+	stamps []string
+	bodies [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.fail > 0 {
+		rc.fail--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var e bus.Event
+	json.Unmarshal(body, &e)
+	rc.events = append(rc.events, e)
+	rc.sigs = append(rc.sigs, r.Header.Get("X-Webhook-Signature"))
+	rc.stamps = append(rc.stamps, r.Header.Get("X-Webhook-Timestamp"))
+	rc.bodies = append(rc.bodies, body)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rc *receiver) received() []bus.Event {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]bus.Event(nil), rc.events...)
+}
+
+func newTestDispatcher(t *testing.T) (*Dispatcher, *store.Store, *bus.Bus) {
+	t.Helper()
+	s, err := store.New(filepath.Join(t.TempDir(), "hooks.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	b := bus.New()
+	d := NewDispatcher(s, b)
+	d.retry = webhook.RetryConfig{MaxRetries: 2, BaseDelay: 10 * time.Millisecond, MaxDelay: 20 * time.Millisecond}
+	d.allowPrivate = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	// Wait for Run to take the context so Reload starts workers
+	waitFor(t, func() bool {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return d.ctx != nil
+	})
+	return d, s, b
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDispatcher_DeliversSignedEvents(t *testing.T) {
+	d, s, b := newTestDispatcher(t)
+	rc := &receiver{fail: 1}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	sub := &store.EventSubscription{
+		URL:     srv.URL,
+		Topics:  []string{"approval.needed", "trace.event"},
+		Kinds:   []string{"scheduler.*"},
+		Secret:  "s3cret",
+		Enabled: true,
+	}
+	if err := s.CreateEventSubscription(sub); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	b.Publish(bus.NewEvent(bus.EventTraceEvent, "", map[string]interface{}{"kind": "mcp.cache.hit"}))
+	b.Publish(bus.NewEvent(bus.EventApprovalNeeded, "s1", map[string]interface{}{"approval_id": "a1"}))
+	b.Publish(bus.NewEvent(bus.EventErrorOccurred, "", map[string]interface{}{"kind": "scheduler.failed"}))
+	b.Publish(bus.NewEvent(bus.EventTraceEvent, "", map[string]interface{}{"kind": "scheduler.task.executed"}))
+
+	waitFor(t, func() bool { return len(rc.received()) == 2 })
+	got := rc.received()
+	if got[0].Event != bus.EventApprovalNeeded || got[0].SessionID != "s1" || got[1].Event != bus.EventTraceEvent {
+		t.Fatalf("unexpected events %+v", got)
+	}
+	for i, body := range rc.bodies {
+		if ts, err := strconv.ParseInt(rc.stamps[i], 10, 64); err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+			t.Errorf("delivery %d: bad timestamp %q", i, rc.stamps[i])
+		}
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write([]byte(rc.stamps[i] + "."))
+		mac.Write(body)
+		if want := "sha256=" + base64.StdEncoding.EncodeToString(mac.Sum(nil)); rc.sigs[i] != want {
+			t.Errorf("delivery %d: signature %q, want %q", i, rc.sigs[i], want)
+		}
+	}
+
+	var deliveries []*store.EventDelivery
+	waitFor(t, func() bool {
+		deliveries, _ = s.ListEventDeliveries(sub.ID, 10)
+		return len(deliveries) == 2
+	})
+	for _, del := range deliveries {
+		if del.Status != string(webhook.DeliveryStatusDelivered) || del.ResponseCode != http.StatusNoContent {
+			t.Errorf("unexpected delivery %+v", del)
+		}
+		// The first request failed and was retried
+		if del.Event == string(bus.EventApprovalNeeded) && del.Attempts != 2 {
+			t.Errorf("expected a retry, got %d attempts", del.Attempts)
+		}
+	}
+}
+
+func TestDispatcher_LogsFailuresAndReloads(t *testing.T) {
+	d, s, b := newTestDispatcher(t)
+	rc := &receiver{fail: 100}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	sub := &store.EventSubscription{URL: srv.URL, Topics: []string{"error.*"}, Enabled: true}
+	if err := s.CreateEventSubscription(sub); err != nil {
+		t.Fatal(err)
+	}
+	d.Reload()
+
+	b.Publish(bus.NewEvent(bus.EventErrorOccurred, "", map[string]interface{}{"kind": "x"}))
+	var deliveries []*store.EventDelivery
+	waitFor(t, func() bool {
+		deliveries, _ = s.ListEventDeliveries(sub.ID, 10)
+		return len(deliveries) == 1
+	})
+	if del := deliveries[0]; del.Status != string(webhook.DeliveryStatusFailed) || del.Attempts != 3 || del.ResponseCode != 500 || del.Error == "" {
+		t.Errorf("unexpected delivery %+v", del)
+	}
+
+	// Disabling the subscription stops its deliveries
+	sub.Enabled = false
+	s.UpdateEventSubscription(sub)
+	d.Reload()
+	if n := len(b.Stats().Subscribers); n != 0 {
+		t.Errorf("expected the bus subscription to be closed, got %d", n)
+	}
+	b.Publish(bus.NewEvent(bus.EventErrorOccurred, "", map[string]interface{}{"kind": "x"}))
+	time.Sleep(50 * time.Millisecond)
+	if deliveries, _ := s.ListEventDeliveries(sub.ID, 10); len(deliveries) != 1 {
+		t.Errorf("expected no new deliveries, got %d", len(deliveries))
+	}
+}
+
+func TestDispatcher_RefusesPrivateAddresses(t *testing.T) {
+	d, s, b := newTestDispatcher(t)
+	d.allowPrivate = false
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	sub := &store.EventSubscription{URL: srv.URL, Topics: []string{"error.*"}, Enabled: true}
+	if err := s.CreateEventSubscription(sub); err != nil {
+		t.Fatal(err)
+	}
+	d.Reload()
+
+	b.Publish(bus.NewEvent(bus.EventErrorOccurred, "", map[string]interface{}{"kind": "x"}))
+	var deliveries []*store.EventDelivery
+	waitFor(t, func() bool {
+		deliveries, _ = s.ListEventDeliveries(sub.ID, 10)
+		return len(deliveries) == 1
+	})
+	if del := deliveries[0]; del.Status != string(webhook.DeliveryStatusFailed) || !strings.Contains(del.Error, "private address") {
+		t.Errorf("unexpected delivery %+v", del)
+	}
+	if len(rc.received()) != 0 {
+		t.Error("a private address was reached")
+	}
+}
+
+func TestDispatcher_BoundedQueue(t *testing.T) {
+	d, s, b := newTestDispatcher(t)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	// A subscription with no topics would take every event
+	if err := s.CreateEventSubscription(&store.EventSubscription{URL: srv.URL, Topics: []string{}, Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	sub := &store.EventSubscription{URL: srv.URL, Topics: []string{"trace.event"}, Enabled: true}
+	if err := s.CreateEventSubscription(sub); err != nil {
+		t.Fatal(err)
+	}
+	d.Reload()
+	if n := len(b.Stats().Subscribers); n != 1 {
+		t.Fatalf("expected only the subscription with topics to run, got %d", n)
+	}
+
+	dead, cancel := b.Subscribe(bus.EventDeadLetter)
+	defer cancel()
+	for i := 0; i < queueSize+10; i++ {
+		b.Publish(bus.NewEvent(bus.EventTraceEvent, "", nil))
+	}
+	for _, ss := range b.Stats().Subscribers {
+		if ss.Mode == bus.DeliverDropOldest && (ss.Queued > queueSize || ss.Dropped == 0) {
+			t.Errorf("expected a bounded queue that drops, got %+v", ss)
+		}
+	}
+	select {
+	case e := <-dead:
+		if dl := e.Payload.(bus.DeadLetter); dl.Reason != bus.DropQueueFull {
+			t.Errorf("unexpected dead letter %+v", dl)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected dropped events to be dead-lettered")
+	}
+}
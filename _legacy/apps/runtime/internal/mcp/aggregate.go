@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// aggregateSeparator joins server and tool names in the aggregated tool
+// list. MCP clients commonly restrict tool names to [A-Za-z0-9_-], so the
+// internal "server:tool" form cannot be exposed as-is.
+const aggregateSeparator = "__"
+
+// ValidServerName reports whether name can be routed both as "server:tool"
+// and in the aggregated "server__tool" form
+func ValidServerName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, ":/") && !strings.Contains(name, aggregateSeparator)
+}
+
+// aggregateSessionID is used for calls that arrive without a session
+const aggregateSessionID = "mcp-serve"
+
+// AggregateProvider exposes every server known to a Manager as a single
+// ToolProvider. Calls go through Manager.CallTool, so policy, approvals and
+// tool events apply exactly as they do for the agent.
+type AggregateProvider struct {
+	manager *Manager
+	info    map[string]interface{}
+}
+
+// NewAggregateProvider creates a provider serving all of m's tools
+func NewAggregateProvider(m *Manager, version string) *AggregateProvider {
+	if strings.TrimSpace(version) == "" {
+		version = "dev"
+	}
+	return &AggregateProvider{
+		manager: m,
+		info:    map[string]interface{}{"name": "pryx", "version": version},
+	}
+}
+
+func (p *AggregateProvider) ServerInfo() map[string]interface{} {
+	return p.info
+}
+
+func (p *AggregateProvider) ListTools(ctx context.Context) ([]Tool, error) {
+	perServer, err := p.manager.ListTools(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make([]string, 0, len(perServer))
+	for name := range perServer {
+		servers = append(servers, name)
+	}
+	sort.Strings(servers)
+
+	var out []Tool
+	for _, server := range servers {
+		for _, t := range perServer[server] {
+			t.Name = server + aggregateSeparator + t.Name
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (p *AggregateProvider) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (ToolResult, error) {
+	server, tool, ok := strings.Cut(name, aggregateSeparator)
+	if !ok || server == "" || tool == "" {
+		return ToolResult{}, errors.New("invalid tool name: " + name)
+	}
+
+	sessionID := SessionIDFromContext(ctx)
+	if sessionID == "" {
+		sessionID = aggregateSessionID
+	}
+	if SurfaceFromContext(ctx) == "" {
+		ctx = WithSurface(ctx, "mcp")
+	}
+	return p.manager.CallTool(ctx, sessionID, server+":"+tool, arguments)
+}
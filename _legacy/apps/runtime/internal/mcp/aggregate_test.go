@@ -0,0 +1,303 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/policy"
+)
+
+type echoProvider struct {
+	sessions []string
+}
+
+func (p *echoProvider) ServerInfo() map[string]interface{} {
+	return map[string]interface{}{"name": "echo", "version": "test"}
+}
+
+func (p *echoProvider) ListTools(ctx context.Context) ([]Tool, error) {
+	return []Tool{{Name: "echo", InputSchema: schemaRaw(`{"type":"object"}`)}}, nil
+}
+
+func (p *echoProvider) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (ToolResult, error) {
+	p.sessions = append(p.sessions, SessionIDFromContext(ctx))
+	return ToolResult{Content: []ToolContent{{Type: "text", Text: argString(arguments, "text")}}}, nil
+}
+
+func newAllowAllManager(t *testing.T, b *bus.Bus) *Manager {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	return NewManager(b, policy.NewEngine(&policy.Policy{Default: policy.DecisionAllow}), nil)
+}
+
+func TestManager_RegisterProvider(t *testing.T) {
+	m := newAllowAllManager(t, nil)
+	defer m.Close()
+
+	if err := m.RegisterProvider("pryx", &echoProvider{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := m.RegisterProvider("pryx", &echoProvider{}); err == nil {
+		t.Fatal("expected duplicate registration to fail")
+	}
+	if err := m.RegisterProvider("a:b", &echoProvider{}); err == nil {
+		t.Fatal("expected invalid name to fail")
+	}
+	if err := m.RegisterProvider("a__b", &echoProvider{}); err == nil {
+		t.Fatal("expected a name containing the aggregate separator to fail")
+	}
+
+	st, ok := m.ServerState("pryx")
+	if !ok || st.Status != ServerStatusReady || st.Transport != "native" {
+		t.Fatalf("unexpected state: %+v", st)
+	}
+
+	err := m.ApplyConfig(context.Background(), &ServersConfig{Servers: map[string]ServerConfig{
+		"pryx": {Transport: "bundled", Command: []string{"clipboard"}},
+	}})
+	if err == nil {
+		t.Fatal("expected config to be rejected for reserved name")
+	}
+	err = m.ApplyConfig(context.Background(), &ServersConfig{Servers: map[string]ServerConfig{
+		"my__server": {Transport: "bundled", Command: []string{"clipboard"}},
+	}})
+	if err == nil {
+		t.Fatal("expected config to be rejected for a name containing the aggregate separator")
+	}
+	if _, ok := m.ServerState("my__server"); ok {
+		t.Fatal("expected the misnamed server not to start")
+	}
+	if _, err := m.CallTool(context.Background(), "s1", "pryx:echo", nil); err != nil {
+		t.Fatalf("native provider removed by ApplyConfig: %v", err)
+	}
+	if err := m.RestartServer(context.Background(), "pryx"); err == nil {
+		t.Fatal("expected restart of native server to fail")
+	}
+}
+
+func TestAggregateProvider(t *testing.T) {
+	b := bus.New()
+	events, cancel := b.Subscribe(bus.EventToolRequest)
+	defer cancel()
+
+	m := newAllowAllManager(t, b)
+	defer m.Close()
+	echo := &echoProvider{}
+	if err := m.RegisterProvider("pryx", echo); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	p := NewAggregateProvider(m, "")
+	tools, err := p.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "pryx__echo" {
+		t.Fatalf("unexpected tools: %+v", tools)
+	}
+
+	res, err := p.CallTool(context.Background(), "pryx__echo", map[string]interface{}{"text": "hi"})
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if len(res.Content) != 1 || res.Content[0].Text != "hi" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if echo.sessions[0] != aggregateSessionID {
+		t.Errorf("expected default session, got %q", echo.sessions[0])
+	}
+
+	evt := <-events
+	if evt.Surface != "mcp" {
+		t.Errorf("expected surface mcp, got %q", evt.Surface)
+	}
+
+	if _, err := p.CallTool(context.Background(), "echo", nil); err == nil {
+		t.Error("expected error for unqualified tool name")
+	}
+}
+
+func TestManager_CallToolCaller(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := NewManager(bus.New(), policy.NewEngine(&policy.Policy{
+		Default: policy.DecisionAllow,
+		Rules: []policy.Rule{{
+			ID:       "strangers",
+			Tool:     "mcp.pryx.*",
+			From:     &policy.CallerMatch{Trust: []policy.Trust{policy.TrustUntrusted}},
+			Decision: policy.DecisionDeny,
+		}},
+	}), nil)
+	defer m.Close()
+	if err := m.RegisterProvider("pryx", &echoProvider{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		ctx     context.Context
+		allowed bool
+	}{
+		{"no surface", context.Background(), false},
+		{"tui", WithCaller(context.Background(), policy.Caller{Surface: policy.SurfaceTUI}), true},
+		{"telegram", WithCaller(context.Background(), policy.Caller{Surface: "telegram", Sender: "7"}), false},
+		{"chat surface", WithSurface(context.Background(), "channel:telegram:telegram-main:9"), false},
+		{"mcp client", WithSurface(context.Background(), "mcp"), true},
+	}
+	for _, tc := range cases {
+		_, err := m.CallTool(tc.ctx, "s1", "pryx:echo", map[string]interface{}{"_scope": "global"})
+		if (err == nil) != tc.allowed {
+			t.Errorf("%s: err = %v", tc.name, err)
+		}
+	}
+
+	caller := CallerFromContext(WithSurface(context.Background(), "channel:telegram:telegram-main:9"), "s1")
+	if caller.Surface != "telegram" || caller.Source != "telegram-main" || caller.ChannelID != "9" || caller.SessionID != "s1" {
+		t.Errorf("unexpected caller: %+v", caller)
+	}
+}
+
+func TestStreamableHTTPHandler(t *testing.T) {
+	srv := httptest.NewServer(NewStreamableHTTPHandler(&echoProvider{}, HTTPServeOptions{}))
+	defer srv.Close()
+
+	post := func(session string, body string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if session != "" {
+			req.Header.Set(sessionHeader, session)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		return resp
+	}
+
+	resp := post("", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 without session, got %d", resp.StatusCode)
+	}
+
+	resp = post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-11-25"}}`)
+	resp.Body.Close()
+	session := resp.Header.Get(sessionHeader)
+	if session == "" {
+		t.Fatal("expected session id on initialize")
+	}
+
+	resp = post(session, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202 for notification, got %d", resp.StatusCode)
+	}
+
+	resp = post(session, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hello"}}}`)
+	var out RPCResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	resp.Body.Close()
+	if out.Error != nil {
+		t.Fatalf("unexpected error: %+v", out.Error)
+	}
+	var res ToolResult
+	_ = json.Unmarshal(out.Result, &res)
+	if len(res.Content) != 1 || res.Content[0].Text != "hello" {
+		t.Fatalf("unexpected result: %s", out.Result)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL, nil)
+	req.Header.Set(sessionHeader, session)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", resp.StatusCode)
+	}
+
+	resp = post(session, `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", resp.StatusCode)
+	}
+}
+
+func TestStreamableHTTPHandler_Access(t *testing.T) {
+	h := NewStreamableHTTPHandler(&echoProvider{}, HTTPServeOptions{
+		Token:          "secret",
+		AllowedOrigins: []string{"https://app.example.com"},
+		SessionTTL:     time.Minute,
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	initialize := func(origin, token string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, bytes.NewBufferString(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`))
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	cases := []struct {
+		name   string
+		origin string
+		token  string
+		status int
+	}{
+		{"no token", "", "", http.StatusUnauthorized},
+		{"wrong token", "", "nope", http.StatusUnauthorized},
+		{"rebinding origin", "http://attacker.example", "secret", http.StatusForbidden},
+		{"loopback origin", "http://localhost:5173", "secret", http.StatusOK},
+		{"allowed origin", "https://app.example.com", "secret", http.StatusOK},
+		{"no origin", "", "secret", http.StatusOK},
+	}
+	for _, tc := range cases {
+		if resp := initialize(tc.origin, tc.token); resp.StatusCode != tc.status {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.status, resp.StatusCode)
+		}
+	}
+
+	resp := initialize("", "secret")
+	id := resp.Header.Get(sessionHeader)
+	h.mu.Lock()
+	live := len(h.sessions)
+	h.opts.MaxSessions = live
+	h.mu.Unlock()
+	if resp := initialize("", "secret"); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 at the session cap, got %d", resp.StatusCode)
+	}
+	h.mu.Lock()
+	h.opts.MaxSessions = DefaultMaxSessions
+	h.mu.Unlock()
+
+	if h.session(id) == nil {
+		t.Fatal("expected live session")
+	}
+	h.mu.Lock()
+	h.sessions[id].lastUsed = time.Now().Add(-2 * time.Minute)
+	h.mu.Unlock()
+	if h.session(id) != nil {
+		t.Fatal("expected idle session to expire")
+	}
+}
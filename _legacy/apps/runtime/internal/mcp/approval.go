@@ -0,0 +1,254 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/store"
+)
+
+// ApprovalScope controls how long an answer to an approval prompt applies
+type ApprovalScope string
+
+const (
+	// ApprovalOnce applies to the pending call only
+	ApprovalOnce ApprovalScope = "once"
+	// ApprovalSession applies to the tool for the rest of the session
+	ApprovalSession ApprovalScope = store.GrantScopeSession
+	// ApprovalArgs applies to the tool called with the same arguments
+	ApprovalArgs ApprovalScope = store.GrantScopeArgs
+	// ApprovalAlways applies to every call of the tool
+	ApprovalAlways ApprovalScope = store.GrantScopeAlways
+)
+
+// ParseApprovalScope validates a scope name; empty means ApprovalOnce
+func ParseApprovalScope(s string) (ApprovalScope, error) {
+	switch ApprovalScope(s) {
+	case "", ApprovalOnce:
+		return ApprovalOnce, nil
+	case ApprovalSession, ApprovalArgs, ApprovalAlways:
+		return ApprovalScope(s), nil
+	default:
+		return "", fmt.Errorf("invalid approval scope: %s", s)
+	}
+}
+
+// ApprovalResolution is an answer to a pending approval
+type ApprovalResolution struct {
+	Approved bool
+	Scope    ApprovalScope
+	// TTL limits how long a remembered decision applies; zero never expires
+	TTL time.Duration
+}
+
+// GrantStore persists remembered approval decisions
+type GrantStore interface {
+	CreateApprovalGrant(g *store.ApprovalGrant) error
+	FindApprovalGrant(tool, sessionID, argsHash, surface string) (*store.ApprovalGrant, error)
+	ListApprovalGrants(sessionID string) ([]*store.ApprovalGrant, error)
+	GetApprovalGrant(id string) (*store.ApprovalGrant, error)
+	DeleteApprovalGrant(id string) (bool, error)
+}
+
+// SetGrantStore enables remembered approval decisions
+func (m *Manager) SetGrantStore(gs GrantStore) {
+	m.approvalMu.Lock()
+	m.grants = gs
+	m.approvalMu.Unlock()
+}
+
+// DisableApprovals is for hosts with no UI to answer approval prompts: calls
+// the policy would ask about are denied unless a remembered grant covers them.
+func (m *Manager) DisableApprovals() {
+	m.approvalMu.Lock()
+	m.noApprover = true
+	m.approvalMu.Unlock()
+}
+
+func (m *Manager) approvalsDisabled() bool {
+	m.approvalMu.Lock()
+	defer m.approvalMu.Unlock()
+	return m.noApprover
+}
+
+func (m *Manager) grantStore() GrantStore {
+	m.approvalMu.Lock()
+	defer m.approvalMu.Unlock()
+	return m.grants
+}
+
+// ResolveApprovalWith answers a pending approval and, for scopes other than
+// ApprovalOnce, remembers the decision for later calls. It reports whether
+// the approval was pending; the error is set if remembering failed.
+func (m *Manager) ResolveApprovalWith(approvalID string, res ApprovalResolution) (bool, error) {
+	if res.Scope == "" {
+		res.Scope = ApprovalOnce
+	}
+
+	m.approvalMu.Lock()
+	pa, ok := m.pendingApprovals[approvalID]
+	if ok {
+		delete(m.pendingApprovals, approvalID)
+	}
+	gs := m.grants
+	m.approvalMu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	var grant *store.ApprovalGrant
+	var err error
+	if res.Scope != ApprovalOnce {
+		if gs == nil {
+			err = fmt.Errorf("approval scope %s is not available", res.Scope)
+		} else {
+			grant = &store.ApprovalGrant{
+				Tool:     pa.tool,
+				Scope:    string(res.Scope),
+				Approved: res.Approved,
+				Surface:  pa.grantSurface,
+			}
+			switch res.Scope {
+			case ApprovalSession:
+				grant.SessionID = pa.sessionID
+			case ApprovalArgs:
+				grant.SessionID = pa.sessionID
+				grant.ArgsHash = argsHash(pa.args)
+			}
+			if res.TTL > 0 {
+				exp := time.Now().Add(res.TTL).UTC()
+				grant.ExpiresAt = &exp
+			}
+			if err = gs.CreateApprovalGrant(grant); err != nil {
+				grant = nil
+			}
+		}
+	}
+
+	// Release the waiting call only once the grant is stored, so the
+	// caller's next call already sees it.
+	select {
+	case pa.ch <- res.Approved:
+	default:
+	}
+
+	if m.bus != nil {
+		payload := ApprovalResolved{
+			ApprovalID: approvalID,
+			Tool:       pa.tool,
+			Approved:   res.Approved,
+			Scope:      string(res.Scope),
+		}
+		if grant != nil {
+			payload.GrantID = grant.ID
+			payload.ExpiresAt = grant.ExpiresAt
+		}
+		evt := bus.NewEvent(bus.EventApprovalResolved, pa.sessionID, payload)
+		evt.Surface = pa.surface
+		m.bus.Publish(evt)
+	}
+
+	return true, err
+}
+
+// ListGrants returns remembered decisions; sessionID filters to the grants
+// that apply to that session.
+func (m *Manager) ListGrants(sessionID string) ([]*store.ApprovalGrant, error) {
+	gs := m.grantStore()
+	if gs == nil {
+		return nil, nil
+	}
+	return gs.ListApprovalGrants(sessionID)
+}
+
+// RevokeGrant forgets a remembered decision. It reports whether the grant
+// existed.
+func (m *Manager) RevokeGrant(id string) (bool, error) {
+	gs := m.grantStore()
+	if gs == nil {
+		return false, nil
+	}
+	grant, err := gs.GetApprovalGrant(id)
+	if err != nil || grant == nil {
+		return false, err
+	}
+	ok, err := gs.DeleteApprovalGrant(id)
+	if err != nil || !ok {
+		return ok, err
+	}
+	if m.bus != nil {
+		m.bus.Publish(bus.NewEvent(bus.EventApprovalRevoked, grant.SessionID, ApprovalRevoked{
+			GrantID:  grant.ID,
+			Tool:     grant.Tool,
+			Scope:    grant.Scope,
+			Approved: grant.Approved,
+		}))
+	}
+	return true, nil
+}
+
+// FindGrant returns the remembered decision that would answer an approval
+// prompt for this call in the session on surface, without applying it.
+func (m *Manager) FindGrant(sessionID, surface, tool string, args map[string]interface{}) (*store.ApprovalGrant, error) {
+	gs := m.grantStore()
+	if gs == nil {
+		return nil, nil
+	}
+	return gs.FindApprovalGrant(tool, sessionID, argsHash(args), surface)
+}
+
+// rememberedDecision returns the grant that answers an approval prompt for
+// this call, if any. Applying a grant is published like a resolved approval
+// so it shows up in the audit log.
+func (m *Manager) rememberedDecision(ctx context.Context, sessionID, tool string, args map[string]interface{}) *store.ApprovalGrant {
+	gs := m.grantStore()
+	if gs == nil {
+		return nil
+	}
+	surface := CallerFromContext(ctx, sessionID).Surface
+	grant, err := gs.FindApprovalGrant(tool, sessionID, argsHash(args), surface)
+	if err != nil {
+		log.Printf("mcp: approval grant lookup failed: %v", err)
+		return nil
+	}
+	if grant == nil {
+		return nil
+	}
+	if m.bus != nil {
+		m.publishCall(ctx, bus.EventApprovalResolved, sessionID, ApprovalResolved{
+			Tool:       tool,
+			Approved:   grant.Approved,
+			Scope:      grant.Scope,
+			GrantID:    grant.ID,
+			Remembered: true,
+		})
+	}
+	return grant
+}
+
+func (m *Manager) approvalScopes() []string {
+	if m.grantStore() == nil {
+		return []string{string(ApprovalOnce)}
+	}
+	return []string{string(ApprovalOnce), string(ApprovalSession), string(ApprovalArgs), string(ApprovalAlways)}
+}
+
+// argsHash identifies a set of arguments independent of key order
+func argsHash(args map[string]interface{}) string {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	b, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
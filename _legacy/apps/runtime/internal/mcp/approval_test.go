@@ -0,0 +1,176 @@
+package mcp
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/policy"
+	"pryx-core/internal/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGrantManager(t *testing.T) (*Manager, *bus.Bus) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	st, err := store.New(filepath.Join(t.TempDir(), "grants.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { st.Close() })
+
+	b := bus.New()
+	m := NewManager(b, nil, nil)
+	t.Cleanup(func() { m.Close() })
+	m.SetGrantStore(st)
+	require.NoError(t, m.RegisterProvider("echo", &echoProvider{}))
+	return m, b
+}
+
+// approveNext answers the next approval prompt with res
+func approveNext(t *testing.T, b *bus.Bus, m *Manager, res ApprovalResolution) {
+	events, cancel := b.Subscribe(bus.EventApprovalNeeded)
+	go func() {
+		defer cancel()
+		evt := <-events
+		id := evt.Payload.(ApprovalNeeded).ApprovalID
+		_, err := m.ResolveApprovalWith(id, res)
+		assert.NoError(t, err)
+	}()
+}
+
+func TestManager_RememberedApproval_Session(t *testing.T) {
+	m, b := newGrantManager(t)
+	ctx := context.Background()
+
+	approveNext(t, b, m, ApprovalResolution{Approved: true, Scope: ApprovalSession})
+	_, err := m.CallTool(ctx, "s1", "echo:echo", map[string]interface{}{"text": "a"})
+	require.NoError(t, err)
+
+	// Same session: no prompt.
+	_, err = m.CallTool(ctx, "s1", "echo:echo", map[string]interface{}{"text": "b"})
+	require.NoError(t, err)
+
+	// Other session: prompts again.
+	short, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = m.CallTool(short, "s2", "echo:echo", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "approval timed out")
+
+	grants, err := m.ListGrants("s1")
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	assert.Equal(t, "mcp.echo.echo", grants[0].Tool)
+
+	revoked := make(chan bus.Event, 1)
+	events, unsub := b.Subscribe(bus.EventApprovalRevoked)
+	defer unsub()
+	go func() { revoked <- <-events }()
+
+	ok, err := m.RevokeGrant(grants[0].ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	select {
+	case <-revoked:
+	case <-time.After(time.Second):
+		t.Fatal("expected approval.revoked event")
+	}
+
+	short2, cancel2 := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel2()
+	_, err = m.CallTool(short2, "s1", "echo:echo", nil)
+	require.Error(t, err)
+}
+
+func TestManager_RememberedApproval_ArgsAndDeny(t *testing.T) {
+	m, b := newGrantManager(t)
+	ctx := context.Background()
+
+	approveNext(t, b, m, ApprovalResolution{Approved: true, Scope: ApprovalArgs, TTL: time.Hour})
+	_, err := m.CallTool(ctx, "s1", "echo:echo", map[string]interface{}{"text": "same"})
+	require.NoError(t, err)
+
+	_, err = m.CallTool(ctx, "s1", "echo:echo", map[string]interface{}{"text": "same"})
+	require.NoError(t, err)
+
+	// Args grants are bound to their session.
+	short, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = m.CallTool(short, "s2", "echo:echo", map[string]interface{}{"text": "same"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "approval timed out")
+
+	approveNext(t, b, m, ApprovalResolution{Approved: false, Scope: ApprovalAlways})
+	_, err = m.CallTool(ctx, "s1", "echo:echo", map[string]interface{}{"text": "other"})
+	require.Error(t, err)
+
+	_, err = m.CallTool(ctx, "s3", "echo:echo", map[string]interface{}{"text": "new"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "remembered")
+}
+
+func TestManager_RememberedApproval_Surface(t *testing.T) {
+	m, b := newGrantManager(t)
+	tui := WithCaller(context.Background(), policy.Caller{Surface: policy.SurfaceTUI})
+
+	approveNext(t, b, m, ApprovalResolution{Approved: true, Scope: ApprovalAlways})
+	_, err := m.CallTool(tui, "s1", "echo:echo", nil)
+	require.NoError(t, err)
+
+	_, err = m.CallTool(tui, "s2", "echo:echo", nil)
+	require.NoError(t, err)
+
+	// A grant made in the TUI does not answer a chat channel's prompt.
+	telegram, cancel := context.WithTimeout(WithCaller(context.Background(), policy.Caller{Surface: "telegram"}), 100*time.Millisecond)
+	defer cancel()
+	_, err = m.CallTool(telegram, "s1", "echo:echo", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "approval timed out")
+}
+
+func TestManager_DisableApprovals(t *testing.T) {
+	m, _ := newGrantManager(t)
+	m.DisableApprovals()
+
+	start := time.Now()
+	_, err := m.CallTool(context.Background(), "s1", "echo:echo", map[string]interface{}{"text": "a"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no approver")
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestManager_DeclinedAsksKeepQuota(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	p, err := policy.Parse([]byte("rules:\n  - {id: echo, tool: mcp.echo.echo, decision: ask, limits: [{per: global, max: 1, window: day}]}\n"))
+	require.NoError(t, err)
+	b := bus.New()
+	m := NewManager(b, policy.NewEngine(p), nil)
+	defer m.Close()
+	require.NoError(t, m.RegisterProvider("echo", &echoProvider{}))
+	ctx := context.Background()
+	args := map[string]interface{}{"text": "a"}
+
+	for i := 0; i < 2; i++ {
+		approveNext(t, b, m, ApprovalResolution{Approved: false, Scope: ApprovalOnce})
+		_, err := m.CallTool(ctx, "s1", "echo:echo", args)
+		require.Error(t, err)
+	}
+	approveNext(t, b, m, ApprovalResolution{Approved: true, Scope: ApprovalOnce})
+	_, err = m.CallTool(ctx, "s1", "echo:echo", args)
+	require.NoError(t, err, "declined calls used the quota")
+
+	_, err = m.CallTool(ctx, "s1", "echo:echo", args)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "denied by policy")
+}
+
+func TestParseApprovalScope(t *testing.T) {
+	s, err := ParseApprovalScope("")
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalOnce, s)
+	_, err = ParseApprovalScope("forever-ish")
+	assert.Error(t, err)
+}
@@ -6,10 +6,10 @@ import (
 )
 
 func BundledProvider(name string) (ToolProvider, error) {
-	switch strings.ToLower(strings.TrimSpace(name)) {
-	case "filesystem", "fs":
+	switch bundledKind(name) {
+	case "filesystem":
 		return NewFilesystemProvider(), nil
-	case "shell", "sh":
+	case "shell":
 		return NewShellProvider(), nil
 	case "clipboard":
 		return NewClipboardProvider(), nil
@@ -17,7 +17,48 @@ func BundledProvider(name string) (ToolProvider, error) {
 		return NewBrowserProvider(), nil
 	case "screen":
 		return NewScreenProvider(), nil
+	case "git":
+		return NewGitProvider(), nil
+	case "fetch":
+		return NewFetchProvider(), nil
+	case "data":
+		return NewDataProvider(), nil
 	default:
 		return nil, errors.New("unknown bundled server")
 	}
 }
+
+// bundledKind returns the canonical name of the bundled server name selects
+func bundledKind(name string) string {
+	switch n := strings.ToLower(strings.TrimSpace(name)); n {
+	case "fs":
+		return "filesystem"
+	case "sh":
+		return "shell"
+	default:
+		return n
+	}
+}
+
+// ToolProvider reports the implementation serving a tool for policy rules:
+// "bundled:<kind>" for bundled servers and "" for everything else, so
+// rules written for a bundled server never match a configured one that
+// merely shares its name.
+func (m *Manager) ToolProvider(toolName string) string {
+	rest, ok := strings.CutPrefix(toolName, "mcp.")
+	if !ok {
+		return ""
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	server := ""
+	for name := range m.servers {
+		if strings.HasPrefix(rest, name+".") && len(name) > len(server) {
+			server = name
+		}
+	}
+	if server == "" || !strings.EqualFold(strings.TrimSpace(m.servers[server].config.Transport), "bundled") {
+		return ""
+	}
+	return "bundled:" + bundledKind(server)
+}
@@ -0,0 +1,742 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"pryx-core/internal/mcp/security"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	dataDefaultLimit = 100
+	dataMaxLimit     = 1000
+	dataQueryTimeout = 30 * time.Second
+	dataMaxCSVSize   = 200 << 20
+	dataCachedCSVs   = 4
+	dataMaxCellWidth = 60
+)
+
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// readOnlyStatement matches the statements query accepts. It only gives a
+// friendly error: a query must also be a single statement SQLite reports as
+// read-only, and the connection's authorizer refuses everything but reads.
+var readOnlyStatement = regexp.MustCompile(`(?is)^\s*(select|with|values|explain)\b`)
+
+// dataDriver opens SQLite files for the data tools with every connection
+// locked down by lockDataConn.
+const dataDriver = "sqlite3_pryx_data"
+
+func init() {
+	sql.Register(dataDriver, &sqlite3.SQLiteDriver{ConnectHook: lockDataConn})
+}
+
+// lockDataConn stops conn from attaching databases and installs
+// dataAuthorizer, so nothing run on it can write, change pragmas or reach
+// files other than the one it was opened on.
+func lockDataConn(conn *sqlite3.SQLiteConn) error {
+	conn.SetLimit(sqlite3.SQLITE_LIMIT_ATTACHED, 0)
+	conn.RegisterAuthorizer(dataAuthorizer)
+	return nil
+}
+
+// sqliteRecursive is SQLITE_RECURSIVE, which go-sqlite3 does not export
+const sqliteRecursive = 33
+
+// introspectionPragmas are the pragmas describe reads through their
+// table-valued functions. Their argument names a table or index and cannot
+// change any setting.
+var introspectionPragmas = map[string]bool{
+	"table_info":       true,
+	"table_xinfo":      true,
+	"index_list":       true,
+	"index_info":       true,
+	"index_xinfo":      true,
+	"foreign_key_list": true,
+}
+
+// dataAuthorizer allows reading tables and calling functions and denies
+// everything else, including ATTACH, DETACH, writes, schema changes,
+// transactions and pragmas that set state.
+func dataAuthorizer(action int, arg1, _, _ string) int {
+	switch action {
+	case sqlite3.SQLITE_SELECT, sqlite3.SQLITE_READ, sqlite3.SQLITE_FUNCTION, sqliteRecursive:
+		return sqlite3.SQLITE_OK
+	case sqlite3.SQLITE_PRAGMA:
+		if introspectionPragmas[strings.ToLower(arg1)] {
+			return sqlite3.SQLITE_OK
+		}
+	}
+	return sqlite3.SQLITE_DENY
+}
+
+// hasTrailingStatement reports whether anything but whitespace and comments
+// follows the first statement in query. go-sqlite3 runs every statement in
+// a string, so query must not accept more than one.
+func hasTrailingStatement(query string) bool {
+	ended := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return false
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return false
+			}
+			i += end + 3
+		case c == ';':
+			ended = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+		case ended:
+			return true
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			// A doubled quote inside a string ends it and starts the next
+			end := strings.IndexByte(query[i+1:], closing)
+			if end < 0 {
+				return false
+			}
+			i += end + 1
+		}
+	}
+	return false
+}
+
+// DataProvider answers questions about local SQLite databases and CSV/TSV
+// files. CSVs are loaded into an in-memory SQLite database, so both are
+// queried with SQL.
+type DataProvider struct {
+	files *FilesystemProvider
+
+	mu   sync.Mutex
+	csvs []*csvTable
+}
+
+// csvTable is a CSV file loaded into its own in-memory database
+type csvTable struct {
+	path    string
+	size    int64
+	modTime time.Time
+	table   string
+	db      *sql.DB
+}
+
+func NewDataProvider() *DataProvider {
+	return &DataProvider{files: NewFilesystemProvider()}
+}
+
+// SetSandbox confines file access the same way as the filesystem provider.
+func (p *DataProvider) SetSandbox(sb *security.Sandbox) {
+	p.files.SetSandbox(sb)
+}
+
+// Close releases the in-memory databases of loaded CSVs.
+func (p *DataProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, t := range p.csvs {
+		_ = t.db.Close()
+	}
+	p.csvs = nil
+	return nil
+}
+
+func (p *DataProvider) ServerInfo() map[string]interface{} {
+	return map[string]interface{}{
+		"name":    "pryx-core/data",
+		"title":   "Pryx Data (Bundled)",
+		"version": "dev",
+	}
+}
+
+func (p *DataProvider) ListTools(ctx context.Context) ([]Tool, error) {
+	_ = ctx
+	readOnly := &ToolAnnotations{ReadOnlyHint: true}
+	return []Tool{
+		{Name: "list_tables", Title: "List Tables", Description: "List the tables and views of a SQLite database, or the single table a CSV/TSV file is loaded as.", Annotations: readOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"}},"required":["path"],"additionalProperties":false}`)},
+		{Name: "describe", Title: "Describe Table", Description: "Show a table's columns, types and row count, with a few sample rows.", Annotations: readOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"},"table":{"type":"string"},"sample":{"type":"integer","minimum":0,"maximum":20,"default":3}},"required":["path"],"additionalProperties":false}`)},
+		{Name: "query", Title: "Query Data", Description: "Run a read-only SQL query (SELECT, WITH, VALUES or EXPLAIN) against a SQLite database or CSV/TSV file. CSV files are queried as a table named after the file.", Annotations: readOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"},"sql":{"type":"string"},"params":{"type":"array"},"limit":{"type":"integer","minimum":1,"maximum":1000,"default":100}},"required":["path","sql"],"additionalProperties":false}`)},
+	}, nil
+}
+
+func (p *DataProvider) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (ToolResult, error) {
+	path, err := p.files.argPath(arguments, "path", false)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, dataQueryTimeout)
+	defer cancel()
+
+	switch name {
+	case "list_tables":
+		return p.listTables(ctx, path)
+	case "describe":
+		return p.describe(ctx, path, arguments)
+	case "query":
+		return p.query(ctx, path, arguments)
+	default:
+		return ToolResult{}, errors.New("unknown tool")
+	}
+}
+
+func (p *DataProvider) listTables(ctx context.Context, path string) (ToolResult, error) {
+	db, closeDB, csvName, err := p.open(path)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	defer closeDB()
+
+	rows, err := db.QueryContext(ctx, `SELECT name, type FROM sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	var tables [][]interface{}
+	for rows.Next() {
+		var name, kind string
+		if err := rows.Scan(&name, &kind); err != nil {
+			rows.Close()
+			return ToolResult{}, err
+		}
+		tables = append(tables, []interface{}{name, kind})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return ToolResult{}, err
+	}
+
+	out := make([]map[string]interface{}, 0, len(tables))
+	for i, t := range tables {
+		// A view over a missing table fails to count; report no count for it
+		var count interface{}
+		var n int64
+		if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+quoteIdent(t[0].(string))).Scan(&n); err == nil {
+			count = n
+		}
+		tables[i] = append(t, count)
+		out = append(out, map[string]interface{}{"name": t[0], "type": t[1], "rows": count})
+	}
+
+	structured := map[string]interface{}{"path": path, "tables": out}
+	if csvName != "" {
+		structured["csv_table"] = csvName
+	}
+	return ToolResult{
+		Content:           []ToolContent{{Type: "text", Text: formatTable([]string{"name", "type", "rows"}, tables, false)}},
+		StructuredContent: jsonRaw(structured),
+	}, nil
+}
+
+func (p *DataProvider) describe(ctx context.Context, path string, arguments map[string]interface{}) (ToolResult, error) {
+	db, closeDB, csvName, err := p.open(path)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	defer closeDB()
+
+	table := strings.TrimSpace(argString(arguments, "table"))
+	if table == "" {
+		if csvName == "" {
+			return ToolResult{}, errors.New("missing table")
+		}
+		table = csvName
+	}
+	var exists int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type IN ('table', 'view') AND name = ?`, table).Scan(&exists); err != nil {
+		return ToolResult{}, err
+	}
+	if exists == 0 {
+		return ToolResult{}, fmt.Errorf("no such table: %s", table)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT name, type, \"notnull\", dflt_value, pk FROM pragma_table_info(?)", table)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	var columns []map[string]interface{}
+	var lines [][]interface{}
+	for rows.Next() {
+		var name, typ string
+		var notNull, pk int
+		var def sql.NullString
+		if err := rows.Scan(&name, &typ, &notNull, &def, &pk); err != nil {
+			rows.Close()
+			return ToolResult{}, err
+		}
+		col := map[string]interface{}{"name": name, "type": typ, "nullable": notNull == 0, "primary_key": pk > 0}
+		if def.Valid {
+			col["default"] = def.String
+		}
+		columns = append(columns, col)
+		lines = append(lines, []interface{}{name, typ, notNull == 0, pk > 0})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return ToolResult{}, err
+	}
+
+	var count int64
+	_ = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+quoteIdent(table)).Scan(&count)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%d rows)\n\n", table, count)
+	b.WriteString(formatTable([]string{"column", "type", "nullable", "primary_key"}, lines, false))
+	structured := map[string]interface{}{"table": table, "columns": columns, "row_count": count}
+
+	if sample := argInt(arguments, "sample", 3); sample > 0 {
+		if sample > 20 {
+			sample = 20
+		}
+		res, err := queryRows(ctx, db, fmt.Sprintf("SELECT * FROM %s LIMIT %d", quoteIdent(table), sample), nil, sample)
+		if err == nil && len(res.rows) > 0 {
+			b.WriteString("\nsample rows:\n\n")
+			b.WriteString(formatTable(res.columns, res.rows, false))
+			structured["sample"] = res.rows
+		}
+	}
+
+	return ToolResult{
+		Content:           []ToolContent{{Type: "text", Text: b.String()}},
+		StructuredContent: jsonRaw(structured),
+	}, nil
+}
+
+func (p *DataProvider) query(ctx context.Context, path string, arguments map[string]interface{}) (ToolResult, error) {
+	query := strings.TrimSpace(argString(arguments, "sql"))
+	if query == "" {
+		return ToolResult{}, errors.New("missing sql")
+	}
+	if !readOnlyStatement.MatchString(query) {
+		return ToolResult{}, errors.New("only read-only SELECT, WITH, VALUES or EXPLAIN statements are allowed")
+	}
+	if hasTrailingStatement(query) {
+		return ToolResult{}, errors.New("only a single statement is allowed")
+	}
+	limit := argInt(arguments, "limit", dataDefaultLimit)
+	if limit <= 0 || limit > dataMaxLimit {
+		limit = dataDefaultLimit
+	}
+	var params []interface{}
+	if raw, ok := arguments["params"].([]interface{}); ok {
+		params = raw
+	}
+
+	db, closeDB, _, err := p.open(path)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	defer closeDB()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	defer conn.Close()
+	err = conn.Raw(func(dc interface{}) error {
+		stmt, err := dc.(*sqlite3.SQLiteConn).Prepare(query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		if !stmt.(*sqlite3.SQLiteStmt).Readonly() {
+			return errors.New("only read-only statements are allowed")
+		}
+		return nil
+	})
+	if err != nil {
+		return ToolResult{}, err
+	}
+
+	res, err := queryRows(ctx, conn, query, params, limit)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	return ToolResult{
+		Content: []ToolContent{{Type: "text", Text: formatTable(res.columns, res.rows, res.truncated)}},
+		StructuredContent: jsonRaw(map[string]interface{}{
+			"columns":   res.columns,
+			"rows":      res.rows,
+			"row_count": len(res.rows),
+			"truncated": res.truncated,
+		}),
+	}, nil
+}
+
+type rowSet struct {
+	columns   []string
+	rows      [][]interface{}
+	truncated bool
+}
+
+// rowQuerier is a *sql.DB or *sql.Conn
+type rowQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// queryRows runs query and collects up to limit rows, noting whether more
+// were available.
+func queryRows(ctx context.Context, db rowQuerier, query string, params []interface{}, limit int) (rowSet, error) {
+	rows, err := db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return rowSet{}, err
+	}
+	defer rows.Close()
+
+	var res rowSet
+	if res.columns, err = rows.Columns(); err != nil {
+		return rowSet{}, err
+	}
+	res.rows = [][]interface{}{}
+	for rows.Next() {
+		if len(res.rows) == limit {
+			res.truncated = true
+			break
+		}
+		values := make([]interface{}, len(res.columns))
+		ptrs := make([]interface{}, len(values))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return rowSet{}, err
+		}
+		for i, v := range values {
+			values[i] = jsonValue(v)
+		}
+		res.rows = append(res.rows, values)
+	}
+	return res, rows.Err()
+}
+
+// jsonValue converts a scanned value into something JSON can carry
+func jsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case []byte:
+		if utf8.Valid(t) {
+			return string(t)
+		}
+		return fmt.Sprintf("<blob %d bytes>", len(t))
+	case time.Time:
+		return t.UTC().Format(time.RFC3339Nano)
+	default:
+		return t
+	}
+}
+
+// open returns a read-only connection to path. For CSV files it also
+// returns the table name the file is loaded as.
+func (p *DataProvider) open(path string) (*sql.DB, func(), string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if info.IsDir() {
+		return nil, nil, "", errors.New("path is a directory")
+	}
+
+	if isSQLiteFile(path) {
+		dsn := "file:" + sqliteURIEscaper.Replace(path) + "?mode=ro&_query_only=1"
+		db, err := sql.Open(dataDriver, dsn)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		return db, func() { _ = db.Close() }, "", nil
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".tsv":
+	default:
+		return nil, nil, "", errors.New("unsupported file: expected a SQLite database or a .csv/.tsv file")
+	}
+	t, err := p.loadCSV(path, info)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return t.db, func() {}, t.table, nil
+}
+
+var sqliteURIEscaper = strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23")
+
+func isSQLiteFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	header := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(f, header); err != nil {
+		return false
+	}
+	return bytes.Equal(header, sqliteHeader)
+}
+
+// loadCSV returns the in-memory database for a CSV file, reusing it while
+// the file is unchanged.
+func (p *DataProvider) loadCSV(path string, info os.FileInfo) (*csvTable, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, t := range p.csvs {
+		if t.path != path {
+			continue
+		}
+		if t.size == info.Size() && t.modTime.Equal(info.ModTime()) {
+			return t, nil
+		}
+		_ = t.db.Close()
+		p.csvs = append(p.csvs[:i], p.csvs[i+1:]...)
+		break
+	}
+
+	if info.Size() > dataMaxCSVSize {
+		return nil, fmt.Errorf("file is too large to load (%d bytes, limit %d)", info.Size(), dataMaxCSVSize)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		r.Comma = '\t'
+	}
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	// An in-memory database lives as long as its single connection
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	table := tableName(path)
+	if err := fillCSVTable(db, table, records); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if _, err := db.Exec("PRAGMA query_only = 1"); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if err := lockCSVConn(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	t := &csvTable{path: path, size: info.Size(), modTime: info.ModTime(), table: table, db: db}
+	p.csvs = append(p.csvs, t)
+	if len(p.csvs) > dataCachedCSVs {
+		_ = p.csvs[0].db.Close()
+		p.csvs = p.csvs[1:]
+	}
+	return t, nil
+}
+
+// lockCSVConn locks down the single connection of a loaded CSV database. It
+// is opened with the plain driver so the table can be filled first.
+func lockCSVConn(db *sql.DB) error {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(dc interface{}) error {
+		return lockDataConn(dc.(*sqlite3.SQLiteConn))
+	})
+}
+
+// fillCSVTable creates table from the header row and inserts the rest,
+// typing each column INTEGER or REAL when all its values parse as such.
+func fillCSVTable(db *sql.DB, table string, records [][]string) error {
+	columns := columnNames(records[0])
+	data := records[1:]
+
+	types := make([]string, len(columns))
+	for i := range columns {
+		types[i] = inferColumnType(data, i)
+	}
+	defs := make([]string, len(columns))
+	for i, c := range columns {
+		defs[i] = quoteIdent(c) + " " + types[i]
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.Exec(fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdent(table), strings.Join(defs, ", "))); err != nil {
+		return err
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s VALUES (%s)", quoteIdent(table), placeholders))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	values := make([]interface{}, len(columns))
+	for _, rec := range data {
+		for i := range values {
+			values[i] = nil
+			if i < len(rec) && rec[i] != "" {
+				values[i] = typedValue(rec[i], types[i])
+			}
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func inferColumnType(data [][]string, col int) string {
+	typ := "INTEGER"
+	seen := false
+	for _, rec := range data {
+		if col >= len(rec) || rec[col] == "" {
+			continue
+		}
+		seen = true
+		v := strings.TrimSpace(rec[col])
+		if typ == "INTEGER" {
+			if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+				continue
+			}
+			typ = "REAL"
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return "TEXT"
+		}
+	}
+	if !seen {
+		return "TEXT"
+	}
+	return typ
+}
+
+func typedValue(s, typ string) interface{} {
+	switch typ {
+	case "INTEGER":
+		if n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+			return n
+		}
+	case "REAL":
+		if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			return f
+		}
+	}
+	return s
+}
+
+var nonIdent = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// tableName derives a SQL-friendly table name from a file name
+func tableName(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name := strings.Trim(nonIdent.ReplaceAllString(base, "_"), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "t_" + name
+	}
+	return strings.ToLower(name)
+}
+
+// columnNames cleans up a header row, filling blanks and de-duplicating
+func columnNames(header []string) []string {
+	out := make([]string, len(header))
+	seen := map[string]int{}
+	for i, h := range header {
+		name := strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+		if name == "" {
+			name = fmt.Sprintf("column_%d", i+1)
+		}
+		key := strings.ToLower(name)
+		if n := seen[key]; n > 0 {
+			name = fmt.Sprintf("%s_%d", name, n+1)
+		}
+		seen[key]++
+		out[i] = name
+	}
+	return out
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// formatTable renders rows as a Markdown-style table with long cells cut
+func formatTable(columns []string, rows [][]interface{}, truncated bool) string {
+	if len(columns) == 0 {
+		return "(no columns)\n"
+	}
+	cell := func(v interface{}) string {
+		var s string
+		switch t := v.(type) {
+		case nil:
+			s = "NULL"
+		case string:
+			s = t
+		default:
+			s = fmt.Sprint(t)
+		}
+		s = strings.NewReplacer("\n", " ", "\r", " ", "|", `\|`).Replace(s)
+		if utf8.RuneCountInString(s) > dataMaxCellWidth {
+			s = string([]rune(s)[:dataMaxCellWidth-3]) + "..."
+		}
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(columns)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			cells[i] = cell(v)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	switch {
+	case truncated:
+		fmt.Fprintf(&b, "\n%d rows shown; more rows were available (raise limit or narrow the query)\n", len(rows))
+	case len(rows) == 1:
+		b.WriteString("\n1 row\n")
+	default:
+		fmt.Fprintf(&b, "\n%d rows\n", len(rows))
+	}
+	return b.String()
+}
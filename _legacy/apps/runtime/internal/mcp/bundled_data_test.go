@@ -0,0 +1,217 @@
+package mcp
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type dataResult struct {
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	RowCount  int             `json:"row_count"`
+	Truncated bool            `json:"truncated"`
+}
+
+func newTestDatabase(t *testing.T) (*DataProvider, string) {
+	t.Helper()
+	root := t.TempDir()
+	t.Setenv("PRYX_WORKSPACE_ROOT", root)
+
+	db, err := sql.Open("sqlite3", filepath.Join(root, "app.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, email TEXT)`,
+		`INSERT INTO users (name, email) VALUES ('ada', 'ada@example.com'), ('bob', NULL), ('cy', 'cy@example.com')`,
+		`CREATE VIEW named AS SELECT name FROM users`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	p := NewDataProvider()
+	t.Cleanup(func() { _ = p.Close() })
+	return p, root
+}
+
+func TestDataProvider_SQLite(t *testing.T) {
+	p, _ := newTestDatabase(t)
+	ctx := context.Background()
+
+	res, err := p.CallTool(ctx, "list_tables", map[string]interface{}{"path": "app.db"})
+	if err != nil {
+		t.Fatalf("list_tables: %v", err)
+	}
+	text := res.Content[0].Text
+	if !strings.Contains(text, "| users | table | 3 |") || !strings.Contains(text, "| named | view | 3 |") {
+		t.Fatalf("unexpected tables:\n%s", text)
+	}
+
+	res, err = p.CallTool(ctx, "describe", map[string]interface{}{"path": "app.db", "table": "users"})
+	if err != nil {
+		t.Fatalf("describe: %v", err)
+	}
+	var desc struct {
+		Columns []map[string]interface{} `json:"columns"`
+		Rows    int                      `json:"row_count"`
+	}
+	_ = json.Unmarshal(res.StructuredContent, &desc)
+	if len(desc.Columns) != 3 || desc.Rows != 3 || desc.Columns[0]["primary_key"] != true || desc.Columns[1]["nullable"] != false {
+		t.Fatalf("unexpected description: %s", res.StructuredContent)
+	}
+
+	res, err = p.CallTool(ctx, "query", map[string]interface{}{
+		"path":   "app.db",
+		"sql":    "SELECT id, name, email FROM users WHERE id > ? ORDER BY id",
+		"params": []interface{}{float64(0)},
+		"limit":  2,
+	})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	var out dataResult
+	_ = json.Unmarshal(res.StructuredContent, &out)
+	if strings.Join(out.Columns, ",") != "id,name,email" || out.RowCount != 2 || !out.Truncated {
+		t.Fatalf("unexpected result: %s", res.StructuredContent)
+	}
+	if out.Rows[1][1] != "bob" || out.Rows[1][2] != nil {
+		t.Fatalf("unexpected rows: %v", out.Rows)
+	}
+	if !strings.Contains(res.Content[0].Text, "| 2 | bob | NULL |") {
+		t.Fatalf("unexpected text:\n%s", res.Content[0].Text)
+	}
+}
+
+func TestDataProvider_RejectsWrites(t *testing.T) {
+	p, root := newTestDatabase(t)
+	ctx := context.Background()
+
+	for _, q := range []string{
+		"DELETE FROM users",
+		"ATTACH DATABASE 'other.db' AS other",
+		"WITH x AS (SELECT 1) DELETE FROM users",
+		"SELECT 1; DELETE FROM users",
+	} {
+		if _, err := p.CallTool(ctx, "query", map[string]interface{}{"path": "app.db", "sql": q}); err == nil {
+			t.Errorf("expected %q to be rejected", q)
+		}
+	}
+
+	res, err := p.CallTool(ctx, "query", map[string]interface{}{"path": "app.db", "sql": "SELECT COUNT(*) AS n FROM users"})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	var out dataResult
+	_ = json.Unmarshal(res.StructuredContent, &out)
+	if len(out.Rows) != 1 || out.Rows[0][0] != float64(3) {
+		t.Fatalf("rows were modified: %s", res.StructuredContent)
+	}
+	if _, err := os.Stat(filepath.Join(root, "other.db")); err == nil {
+		t.Fatal("ATTACH created a database")
+	}
+
+	if _, err := p.CallTool(ctx, "query", map[string]interface{}{"path": "../outside.db", "sql": "SELECT 1"}); err == nil {
+		t.Fatal("expected path outside the workspace to be rejected")
+	}
+}
+
+func TestDataProvider_RejectsStackedStatements(t *testing.T) {
+	p, root := newTestDatabase(t)
+	if err := os.WriteFile(filepath.Join(root, "people.csv"), []byte("name\nada\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	outside := t.TempDir()
+	other := filepath.Join(outside, "other.db")
+	db, err := sql.Open("sqlite3", other)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := db.Exec(`CREATE TABLE t (secret TEXT); INSERT INTO t VALUES ('x')`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	_ = db.Close()
+	created := filepath.Join(outside, "path.db")
+	ctx := context.Background()
+
+	for _, path := range []string{"people.csv", "app.db"} {
+		for _, q := range []string{
+			"SELECT 1; PRAGMA query_only = 0",
+			"SELECT 1; ATTACH '" + created + "' AS e",
+			"SELECT 1; CREATE TABLE e.x(a)",
+			"SELECT 1; ATTACH '" + other + "' AS s",
+			"SELECT * FROM s.t",
+			"SELECT 1 /* ; */; -- trailing\n ATTACH '" + other + "' AS s",
+			"SELECT * FROM pragma_query_only(0)",
+		} {
+			if _, err := p.CallTool(ctx, "query", map[string]interface{}{"path": path, "sql": q}); err == nil {
+				t.Errorf("%s: expected %q to be rejected", path, q)
+			}
+		}
+		if _, err := os.Stat(created); err == nil {
+			t.Fatalf("%s: ATTACH created a database outside the workspace", path)
+		}
+	}
+
+	for _, q := range []string{"SELECT ';' AS s;", "SELECT 1 -- done; ATTACH", "SELECT 1; /* nothing */ ;"} {
+		if _, err := p.CallTool(ctx, "query", map[string]interface{}{"path": "people.csv", "sql": q}); err != nil {
+			t.Errorf("%q: %v", q, err)
+		}
+	}
+	if _, err := p.CallTool(ctx, "describe", map[string]interface{}{"path": "people.csv"}); err != nil {
+		t.Fatalf("describe after rejected queries: %v", err)
+	}
+}
+
+func TestDataProvider_CSV(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("PRYX_WORKSPACE_ROOT", root)
+	csvData := "Name,Age,Score,Age,\nada,36,9.5,x,\nbob,41,7,y,\n\"smith, cy\",,8.25,z,note\n"
+	if err := os.WriteFile(filepath.Join(root, "2024 People.csv"), []byte(csvData), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	p := NewDataProvider()
+	defer p.Close()
+	ctx := context.Background()
+
+	res, err := p.CallTool(ctx, "describe", map[string]interface{}{"path": "2024 People.csv", "sample": 0})
+	if err != nil {
+		t.Fatalf("describe: %v", err)
+	}
+	var desc struct {
+		Table   string                   `json:"table"`
+		Columns []map[string]interface{} `json:"columns"`
+	}
+	_ = json.Unmarshal(res.StructuredContent, &desc)
+	if desc.Table != "t_2024_people" {
+		t.Fatalf("unexpected table name %q", desc.Table)
+	}
+	var cols []string
+	for _, c := range desc.Columns {
+		cols = append(cols, c["name"].(string)+" "+c["type"].(string))
+	}
+	if got := strings.Join(cols, ", "); got != "Name TEXT, Age INTEGER, Score REAL, Age_2 TEXT, column_5 TEXT" {
+		t.Fatalf("unexpected columns: %s", got)
+	}
+
+	res, err = p.CallTool(ctx, "query", map[string]interface{}{"path": "2024 People.csv", "sql": "SELECT Name, Age FROM t_2024_people WHERE Score > 8 ORDER BY Score DESC"})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	var out dataResult
+	_ = json.Unmarshal(res.StructuredContent, &out)
+	if len(out.Rows) != 2 || out.Rows[0][0] != "ada" || out.Rows[0][1] != float64(36) || out.Rows[1][0] != "smith, cy" || out.Rows[1][1] != nil {
+		t.Fatalf("unexpected rows: %s", res.StructuredContent)
+	}
+
+	if _, err := p.CallTool(ctx, "query", map[string]interface{}{"path": "2024 People.csv", "sql": "WITH x AS (SELECT 1) DELETE FROM t_2024_people"}); err == nil {
+		t.Fatal("expected write to the loaded CSV to fail")
+	}
+}
@@ -0,0 +1,349 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/mcp/security"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	fetchDefaultMaxBytes = 1 << 20
+	fetchMaxBytes        = 10 << 20
+	fetchDefaultTimeout  = 30 * time.Second
+	fetchMaxTimeout      = 2 * time.Minute
+	fetchMaxRedirects    = 5
+	fetchUserAgent       = "pryx-fetch/1.0"
+)
+
+// FetchProvider performs HTTP requests on behalf of the agent. Requests to
+// loopback, private and link-local addresses are refused unless the host is
+// explicitly allowlisted in the server's sandbox config, and every request,
+// including redirects, is published as a network.request event.
+type FetchProvider struct {
+	sandbox *security.Sandbox
+	bus     *bus.Bus
+}
+
+func NewFetchProvider() *FetchProvider {
+	return &FetchProvider{}
+}
+
+// SetSandbox applies the sandbox's host allowlist, denylist and outbound
+// protocol settings to every request.
+func (p *FetchProvider) SetSandbox(sb *security.Sandbox) {
+	p.sandbox = sb
+}
+
+// SetBus enables network.request events.
+func (p *FetchProvider) SetBus(b *bus.Bus) {
+	p.bus = b
+}
+
+func (p *FetchProvider) ServerInfo() map[string]interface{} {
+	return map[string]interface{}{
+		"name":    "pryx-core/fetch",
+		"title":   "Pryx Fetch (Bundled)",
+		"version": "dev",
+	}
+}
+
+func (p *FetchProvider) ListTools(ctx context.Context) ([]Tool, error) {
+	_ = ctx
+	return []Tool{
+		{
+			Name:        "fetch",
+			Title:       "Fetch URL",
+			Description: "Fetch a URL over HTTP(S). HTML is converted to Markdown and JSON is pretty-printed unless format is raw.",
+			Annotations: &ToolAnnotations{OpenWorldHint: boolPtr(true)},
+			InputSchema: schemaRaw(`{"type":"object","properties":{"url":{"type":"string"},"method":{"type":"string","enum":["GET","POST","HEAD"],"default":"GET"},"headers":{"type":"object","additionalProperties":{"type":"string"}},"body":{"type":"string"},"format":{"type":"string","enum":["markdown","raw"],"default":"markdown"},"max_bytes":{"type":"integer","minimum":1,"maximum":10485760},"timeout_ms":{"type":"integer","minimum":1,"maximum":120000}},"required":["url"],"additionalProperties":false}`),
+		},
+	}, nil
+}
+
+func (p *FetchProvider) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (ToolResult, error) {
+	switch name {
+	case "fetch":
+		return p.fetch(ctx, arguments)
+	default:
+		return ToolResult{}, errors.New("unknown tool")
+	}
+}
+
+func (p *FetchProvider) fetch(ctx context.Context, arguments map[string]interface{}) (ToolResult, error) {
+	method := strings.ToUpper(strings.TrimSpace(argString(arguments, "method")))
+	if method == "" {
+		method = http.MethodGet
+	}
+	if method != http.MethodGet && method != http.MethodPost && method != http.MethodHead {
+		return ToolResult{}, fmt.Errorf("unsupported method: %s", method)
+	}
+	maxBytes := argInt(arguments, "max_bytes", fetchDefaultMaxBytes)
+	if maxBytes <= 0 || maxBytes > fetchMaxBytes {
+		maxBytes = fetchDefaultMaxBytes
+	}
+	timeout := time.Duration(argInt(arguments, "timeout_ms")) * time.Millisecond
+	if timeout <= 0 || timeout > fetchMaxTimeout {
+		timeout = fetchDefaultTimeout
+	}
+	format := strings.ToLower(strings.TrimSpace(argString(arguments, "format")))
+
+	u, err := url.Parse(strings.TrimSpace(argString(arguments, "url")))
+	if err != nil || (u.Scheme == "http" || u.Scheme == "https") && u.Host == "" {
+		return ToolResult{}, errors.New("invalid url")
+	}
+
+	var body io.Reader
+	payload := argString(arguments, "body")
+	if payload != "" {
+		if method != http.MethodPost {
+			return ToolResult{}, fmt.Errorf("%s requests cannot have a body", method)
+		}
+		body = strings.NewReader(payload)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	req.Header.Set("User-Agent", fetchUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/json,text/plain;q=0.9,*/*;q=0.8")
+	if raw, ok := arguments["headers"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			if s, ok := v.(string); ok {
+				req.Header.Set(k, s)
+			}
+		}
+	}
+	if payload != "" && req.Header.Get("Content-Type") == "" {
+		if json.Valid([]byte(payload)) {
+			req.Header.Set("Content-Type", "application/json")
+		} else {
+			req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		}
+	}
+
+	resp, err := p.client().Do(req)
+	if err != nil {
+		// Surface sandbox refusals unwrapped so they are reported as violations
+		if v, ok := security.AsViolation(err); ok {
+			return ToolResult{}, v
+		}
+		return ToolResult{}, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes)+1))
+	if err != nil {
+		return ToolResult{}, err
+	}
+	truncated := len(data) > maxBytes
+	if truncated {
+		data = data[:maxBytes]
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	out := map[string]interface{}{
+		"url":          resp.Request.URL.String(),
+		"status":       resp.StatusCode,
+		"content_type": contentType,
+		"bytes":        len(data),
+		"truncated":    truncated,
+	}
+
+	text, err := p.render(resp, mediaType, contentType, format, data, out)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	if truncated {
+		text += fmt.Sprintf("\n\n... response truncated at %d bytes", maxBytes)
+	}
+	return ToolResult{
+		Content:           []ToolContent{{Type: "text", Text: text}},
+		IsError:           resp.StatusCode >= 400,
+		StructuredContent: jsonRaw(out),
+	}, nil
+}
+
+// render turns a response body into text for the model according to its
+// content type.
+func (p *FetchProvider) render(resp *http.Response, mediaType, contentType, format string, data []byte, out map[string]interface{}) (string, error) {
+	header := fmt.Sprintf("HTTP %d %s\n", resp.StatusCode, mediaType)
+	if resp.Request.Method == http.MethodHead {
+		return header, nil
+	}
+	if !isTextMedia(mediaType, data) {
+		if format == "raw" {
+			out["body_base64"] = base64.StdEncoding.EncodeToString(data)
+			return header + fmt.Sprintf("binary content (%d bytes) returned as body_base64", len(data)), nil
+		}
+		return header + fmt.Sprintf("binary content (%d bytes) not shown; use format raw to retrieve it", len(data)), nil
+	}
+
+	decoded, err := charset.NewReader(bytes.NewReader(data), contentType)
+	if err != nil {
+		decoded = bytes.NewReader(data)
+	}
+	if format == "raw" {
+		b, err := io.ReadAll(decoded)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		base := resp.Request.URL
+		md, title, err := htmlToMarkdown(decoded, func(href string) string {
+			if ref, err := url.Parse(href); err == nil {
+				return base.ResolveReference(ref).String()
+			}
+			return href
+		})
+		if err != nil {
+			return "", err
+		}
+		if title != "" {
+			out["title"] = title
+		}
+		return md, nil
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		b, err := io.ReadAll(decoded)
+		if err != nil {
+			return "", err
+		}
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, b, "", "  ") == nil {
+			return pretty.String(), nil
+		}
+		return string(b), nil
+	default:
+		b, err := io.ReadAll(decoded)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
+
+func isTextMedia(mediaType string, data []byte) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json", strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/xml", strings.HasSuffix(mediaType, "+xml"),
+		mediaType == "application/javascript", mediaType == "application/x-www-form-urlencoded":
+		return true
+	case mediaType == "":
+		return strings.HasPrefix(http.DetectContentType(data), "text/")
+	}
+	return false
+}
+
+func (p *FetchProvider) client() *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	transport := &http.Transport{
+		// No proxy: the address check must see the real destination
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			d := *dialer
+			if p.sandbox == nil || !p.sandbox.HostAllowlisted(host) {
+				d.Control = security.RefusePrivateAddress(host)
+			}
+			return d.DialContext(ctx, network, addr)
+		},
+		TLSHandshakeTimeout:    10 * time.Second,
+		ResponseHeaderTimeout:  30 * time.Second,
+		MaxResponseHeaderBytes: 1 << 20,
+		ForceAttemptHTTP2:      true,
+	}
+	return &http.Client{
+		Transport: &fetchTransport{provider: p, base: transport},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= fetchMaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", fetchMaxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// checkURL applies the scheme and sandbox host rules to a request URL.
+func (p *FetchProvider) checkURL(u *url.URL) error {
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return &security.ViolationError{Kind: security.ViolationNetwork, Detail: "unsupported scheme: " + u.Scheme}
+	}
+	host := u.Hostname()
+	if p.sandbox != nil {
+		if err := p.sandbox.ValidateHost(host, scheme == "https"); err != nil {
+			return &security.ViolationError{Kind: security.ViolationNetwork, Detail: err.Error()}
+		}
+		if p.sandbox.HostAllowlisted(host) {
+			return nil
+		}
+	}
+	if ip := net.ParseIP(host); ip != nil && security.IsPrivateIP(ip) {
+		return &security.ViolationError{Kind: security.ViolationNetwork, Detail: "private address not allowed: " + host}
+	}
+	return nil
+}
+
+// fetchTransport checks and records every request, including redirects.
+type fetchTransport struct {
+	provider *FetchProvider
+	base     http.RoundTripper
+}
+
+func (t *fetchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	err := t.provider.checkURL(req.URL)
+	var resp *http.Response
+	if err == nil {
+		resp, err = t.base.RoundTrip(req)
+	}
+	t.provider.record(req, resp, err)
+	return resp, err
+}
+
+func (p *FetchProvider) record(req *http.Request, resp *http.Response, err error) {
+	if p.bus == nil {
+		return
+	}
+	payload := NetworkRequest{
+		Provider: "fetch",
+		Method:   req.Method,
+		URL:      req.URL.String(),
+		Host:     req.URL.Hostname(),
+	}
+	if resp != nil {
+		payload.Status = resp.StatusCode
+	}
+	if err != nil {
+		payload.Error = err.Error()
+		_, payload.Blocked = security.AsViolation(err)
+	}
+	evt := bus.NewEvent(bus.EventNetworkRequest, SessionIDFromContext(req.Context()), payload)
+	evt.Surface = SurfaceFromContext(req.Context())
+	p.bus.Publish(evt)
+}
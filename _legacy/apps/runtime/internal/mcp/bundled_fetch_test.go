@@ -0,0 +1,154 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/mcp/security"
+)
+
+func newLocalFetchProvider(t *testing.T) (*FetchProvider, *bus.Bus) {
+	t.Helper()
+	p := NewFetchProvider()
+	p.SetSandbox(security.NewSandbox(security.SandboxConfig{
+		AllowedHosts:      []string{"127.0.0.1"},
+		AllowOutboundHTTP: true,
+	}))
+	b := bus.New()
+	p.SetBus(b)
+	return p, b
+}
+
+func TestFetchProvider_HTMLToMarkdown(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/page", http.StatusFound)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(`<html><head><title>Hello</title><script>evil()</script></head>
+<body><h1>Welcome</h1><p>Read the <a href="/docs">docs</a> <strong>now</strong>.</p>
+<ul><li>one</li><li>two</li></ul></body></html>`))
+		case "/data":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"a":1}`))
+		}
+	}))
+	defer srv.Close()
+
+	p, b := newLocalFetchProvider(t)
+	events, cancel := b.Subscribe(bus.EventNetworkRequest)
+	defer cancel()
+
+	ctx := WithSessionID(context.Background(), "s1")
+	res, err := p.CallTool(ctx, "fetch", map[string]interface{}{"url": srv.URL + "/old"})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	text := res.Content[0].Text
+	for _, want := range []string{"# Welcome", "[docs](" + srv.URL + "/docs)", "**now**", "- one\n- two"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "evil") {
+		t.Fatalf("script content leaked:\n%s", text)
+	}
+	var out map[string]interface{}
+	_ = json.Unmarshal(res.StructuredContent, &out)
+	if out["title"] != "Hello" || out["url"] != srv.URL+"/page" {
+		t.Fatalf("unexpected structured content: %s", res.StructuredContent)
+	}
+
+	// The redirect and the final request are both recorded
+	for _, path := range []string{"/old", "/page"} {
+		select {
+		case evt := <-events:
+			payload, _ := evt.Payload.(NetworkRequest)
+			if evt.SessionID != "s1" || payload.URL != srv.URL+path {
+				t.Fatalf("unexpected event: %+v", evt)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no network.request event for %s", path)
+		}
+	}
+
+	res, err = p.CallTool(ctx, "fetch", map[string]interface{}{"url": srv.URL + "/data"})
+	if err != nil {
+		t.Fatalf("fetch json: %v", err)
+	}
+	if !strings.Contains(res.Content[0].Text, "\"a\": 1") {
+		t.Fatalf("json not pretty-printed: %s", res.Content[0].Text)
+	}
+}
+
+func TestFetchProvider_SizeCap(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer srv.Close()
+
+	p, _ := newLocalFetchProvider(t)
+	res, err := p.CallTool(context.Background(), "fetch", map[string]interface{}{"url": srv.URL, "max_bytes": 10})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	var out map[string]interface{}
+	_ = json.Unmarshal(res.StructuredContent, &out)
+	if out["truncated"] != true || out["bytes"] != float64(10) {
+		t.Fatalf("expected truncation: %s", res.StructuredContent)
+	}
+}
+
+func TestFetchProvider_EgressControls(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+
+	cases := []struct {
+		name    string
+		sandbox *security.SandboxConfig
+		url     string
+	}{
+		{"private ip", nil, srv.URL},
+		{"private hostname", nil, "http://localhost:" + port},
+		{"scheme", nil, "file:///etc/passwd"},
+		{"denylisted", &security.SandboxConfig{AllowOutboundHTTP: true, BlockedHosts: []string{"127.0.0.1"}}, srv.URL},
+		{"not allowlisted", &security.SandboxConfig{AllowOutboundHTTP: true, AllowedHosts: []string{"example.com"}}, srv.URL},
+		{"http disabled", &security.SandboxConfig{AllowOutboundHTTPS: true}, srv.URL},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewFetchProvider()
+			if tc.sandbox != nil {
+				p.SetSandbox(security.NewSandbox(*tc.sandbox))
+			}
+			b := bus.New()
+			p.SetBus(b)
+			events, cancel := b.Subscribe(bus.EventNetworkRequest)
+			defer cancel()
+
+			_, err := p.CallTool(context.Background(), "fetch", map[string]interface{}{"url": tc.url})
+			v, ok := security.AsViolation(err)
+			if !ok || v.Kind != security.ViolationNetwork {
+				t.Fatalf("expected network violation, got %v", err)
+			}
+			select {
+			case evt := <-events:
+				if payload, _ := evt.Payload.(NetworkRequest); !payload.Blocked {
+					t.Fatalf("expected blocked event, got %+v", evt.Payload)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("blocked request was not recorded")
+			}
+		})
+	}
+}
@@ -8,25 +8,26 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"pryx-core/internal/mcp/security"
+	"pryx-core/internal/policy"
 )
 
 type FilesystemProvider struct {
-	root string
+	root    string
+	sandbox *security.Sandbox
 }
 
 func NewFilesystemProvider() *FilesystemProvider {
-	root := strings.TrimSpace(os.Getenv("PRYX_WORKSPACE_ROOT"))
-	if root == "" {
-		if cwd, err := os.Getwd(); err == nil {
-			root = cwd
-		}
-	}
-	if root != "" {
-		if abs, err := filepath.Abs(root); err == nil {
-			root = abs
-		}
-	}
-	return &FilesystemProvider{root: root}
+	return &FilesystemProvider{root: policy.WorkspaceRoot()}
+}
+
+// SetSandbox confines every path to the sandbox's allowed directories,
+// instead of the workspace root, and applies its blocked directories and
+// read-only setting.
+func (p *FilesystemProvider) SetSandbox(sb *security.Sandbox) {
+	p.sandbox = sb
 }
 
 func (p *FilesystemProvider) ServerInfo() map[string]interface{} {
@@ -39,20 +40,25 @@ func (p *FilesystemProvider) ServerInfo() map[string]interface{} {
 
 func (p *FilesystemProvider) ListTools(ctx context.Context) ([]Tool, error) {
 	_ = ctx
+	readOnly := &ToolAnnotations{ReadOnlyHint: true}
 	return []Tool{
-		{Name: "read_file", Title: "Read File", InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"},"encoding":{"type":"string","enum":["text","base64"],"default":"text"}},"required":["path"],"additionalProperties":false}`)},
-		{Name: "write_file", Title: "Write File", InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"},"content":{"type":"string"},"encoding":{"type":"string","enum":["text","base64"],"default":"text"},"create_dirs":{"type":"boolean","default":false}},"required":["path","content"],"additionalProperties":false}`)},
-		{Name: "list_dir", Title: "List Directory", InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"},"recursive":{"type":"boolean","default":false}},"required":["path"],"additionalProperties":false}`)},
+		{Name: "read_file", Title: "Read File", Annotations: readOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"},"encoding":{"type":"string","enum":["text","base64"],"default":"text"}},"required":["path"],"additionalProperties":false}`)},
+		{Name: "read_range", Title: "Read Lines", Description: "Read a window of lines from a text file, with line numbers.", Annotations: readOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"},"start_line":{"type":"integer","minimum":1,"default":1},"end_line":{"type":"integer","minimum":1},"limit":{"type":"integer","minimum":1,"maximum":2000,"default":200}},"required":["path"],"additionalProperties":false}`)},
+		{Name: "write_file", Title: "Write File", Annotations: &ToolAnnotations{DestructiveHint: boolPtr(true)}, InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"},"content":{"type":"string"},"encoding":{"type":"string","enum":["text","base64"],"default":"text"},"create_dirs":{"type":"boolean","default":false}},"required":["path","content"],"additionalProperties":false}`)},
+		{Name: "apply_edit", Title: "Edit File", Description: "Edit a text file by exact string replacement or by applying a unified diff. Nothing is written if any edit or hunk conflicts.", Annotations: &ToolAnnotations{DestructiveHint: boolPtr(false)}, InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"},"edits":{"type":"array","items":{"type":"object","properties":{"old_string":{"type":"string"},"new_string":{"type":"string"},"replace_all":{"type":"boolean","default":false}},"required":["old_string","new_string"],"additionalProperties":false}},"diff":{"type":"string"},"dry_run":{"type":"boolean","default":false}},"required":["path"],"additionalProperties":false}`)},
+		{Name: "list_dir", Title: "List Directory", Annotations: readOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"},"recursive":{"type":"boolean","default":false}},"required":["path"],"additionalProperties":false}`)},
+		{Name: "search", Title: "Search Files", Description: "Search file contents under a directory with a regular expression. Honors .gitignore and skips hidden and binary files by default.", Annotations: readOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"pattern":{"type":"string"},"path":{"type":"string","default":"."},"include":{"type":"array","items":{"type":"string"}},"exclude":{"type":"array","items":{"type":"string"}},"literal":{"type":"boolean","default":false},"case_insensitive":{"type":"boolean","default":false},"context":{"type":"integer","minimum":0,"maximum":10,"default":0},"max_results":{"type":"integer","minimum":1,"maximum":1000,"default":100},"gitignore":{"type":"boolean","default":true},"hidden":{"type":"boolean","default":false}},"required":["pattern"],"additionalProperties":false}`)},
+		{Name: "stat", Title: "File Info", Annotations: readOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"}},"required":["path"],"additionalProperties":false}`)},
 		{Name: "mkdir", Title: "Make Directory", InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"},"parents":{"type":"boolean","default":true}},"required":["path"],"additionalProperties":false}`)},
-		{Name: "remove", Title: "Remove File or Directory", InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"},"recursive":{"type":"boolean","default":false}},"required":["path"],"additionalProperties":false}`)},
+		{Name: "move", Title: "Move or Rename", Annotations: &ToolAnnotations{DestructiveHint: boolPtr(true)}, InputSchema: schemaRaw(`{"type":"object","properties":{"source":{"type":"string"},"destination":{"type":"string"},"overwrite":{"type":"boolean","default":false},"create_dirs":{"type":"boolean","default":false}},"required":["source","destination"],"additionalProperties":false}`)},
+		{Name: "remove", Title: "Remove File or Directory", Annotations: &ToolAnnotations{DestructiveHint: boolPtr(true)}, InputSchema: schemaRaw(`{"type":"object","properties":{"path":{"type":"string"},"recursive":{"type":"boolean","default":false}},"required":["path"],"additionalProperties":false}`)},
 	}, nil
 }
 
 func (p *FilesystemProvider) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (ToolResult, error) {
-	_ = ctx
 	switch name {
 	case "read_file":
-		path, err := p.argPath(arguments, "path")
+		path, err := p.argPath(arguments, "path", false)
 		if err != nil {
 			return ToolResult{}, err
 		}
@@ -80,7 +86,7 @@ func (p *FilesystemProvider) CallTool(ctx context.Context, name string, argument
 		}, nil
 
 	case "write_file":
-		path, err := p.argPath(arguments, "path")
+		path, err := p.argPath(arguments, "path", true)
 		if err != nil {
 			return ToolResult{}, err
 		}
@@ -114,7 +120,7 @@ func (p *FilesystemProvider) CallTool(ctx context.Context, name string, argument
 		return ToolResult{Content: []ToolContent{{Type: "text", Text: "OK"}}}, nil
 
 	case "list_dir":
-		path, err := p.argPath(arguments, "path")
+		path, err := p.argPath(arguments, "path", false)
 		if err != nil {
 			return ToolResult{}, err
 		}
@@ -129,7 +135,7 @@ func (p *FilesystemProvider) CallTool(ctx context.Context, name string, argument
 		}, nil
 
 	case "mkdir":
-		path, err := p.argPath(arguments, "path")
+		path, err := p.argPath(arguments, "path", true)
 		if err != nil {
 			return ToolResult{}, err
 		}
@@ -149,7 +155,7 @@ func (p *FilesystemProvider) CallTool(ctx context.Context, name string, argument
 		return ToolResult{Content: []ToolContent{{Type: "text", Text: "OK"}}}, nil
 
 	case "remove":
-		path, err := p.argPath(arguments, "path")
+		path, err := p.argPath(arguments, "path", true)
 		if err != nil {
 			return ToolResult{}, err
 		}
@@ -172,24 +178,94 @@ func (p *FilesystemProvider) CallTool(ctx context.Context, name string, argument
 		}
 		return ToolResult{Content: []ToolContent{{Type: "text", Text: "OK"}}}, nil
 
+	case "read_range":
+		path, err := p.argPath(arguments, "path", false)
+		if err != nil {
+			return ToolResult{}, err
+		}
+		return p.readRange(path, arguments)
+
+	case "apply_edit":
+		path, err := p.argPath(arguments, "path", true)
+		if err != nil {
+			return ToolResult{}, err
+		}
+		return p.applyEdit(path, arguments)
+
+	case "search":
+		return p.search(ctx, arguments)
+
+	case "stat":
+		path, err := p.argPath(arguments, "path", false)
+		if err != nil {
+			return ToolResult{}, err
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			return ToolResult{}, err
+		}
+		out := map[string]interface{}{
+			"path":     path,
+			"name":     info.Name(),
+			"size":     info.Size(),
+			"isDir":    info.IsDir(),
+			"mode":     info.Mode().String(),
+			"modified": info.ModTime().UTC().Format(time.RFC3339),
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			out["symlink"] = true
+			if target, err := os.Readlink(path); err == nil {
+				out["target"] = target
+			}
+		}
+		return ToolResult{
+			Content:           []ToolContent{{Type: "text", Text: "OK"}},
+			StructuredContent: jsonRaw(out),
+		}, nil
+
+	case "move":
+		src, err := p.argPath(arguments, "source", true)
+		if err != nil {
+			return ToolResult{}, err
+		}
+		dst, err := p.argPath(arguments, "destination", true)
+		if err != nil {
+			return ToolResult{}, err
+		}
+		if _, err := os.Lstat(src); err != nil {
+			return ToolResult{}, err
+		}
+		if _, err := os.Lstat(dst); err == nil && !argBool(arguments, "overwrite") {
+			return ToolResult{}, errors.New("destination exists; set overwrite=true to replace it")
+		}
+		if argBool(arguments, "create_dirs") {
+			if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+				return ToolResult{}, err
+			}
+		}
+		if err := os.Rename(src, dst); err != nil {
+			return ToolResult{}, err
+		}
+		return ToolResult{Content: []ToolContent{{Type: "text", Text: "OK"}}}, nil
+
 	default:
 		return ToolResult{}, errors.New("unknown tool")
 	}
 }
 
-func (p *FilesystemProvider) argPath(args map[string]interface{}, key string) (string, error) {
+// argPath resolves a path argument and confines it to the workspace root, or
+// to the sandbox's allowed directories when those are configured. Symlinks
+// are followed for the check so they cannot point outside.
+func (p *FilesystemProvider) argPath(args map[string]interface{}, key string, write bool) (string, error) {
 	raw := strings.TrimSpace(argString(args, key))
 	if raw == "" {
-		return "", errors.New("missing path")
-	}
-
-	if strings.HasPrefix(raw, "~") {
-		if home, err := os.UserHomeDir(); err == nil {
-			raw = filepath.Join(home, strings.TrimPrefix(raw, "~"))
-		}
+		return "", errors.New("missing " + key)
 	}
+	return p.resolvePath(raw, write)
+}
 
-	abs := raw
+func (p *FilesystemProvider) resolvePath(raw string, write bool) (string, error) {
+	abs := policy.ExpandHome(raw)
 	if !filepath.IsAbs(abs) {
 		abs = filepath.Join(p.root, abs)
 	}
@@ -199,17 +275,74 @@ func (p *FilesystemProvider) argPath(args map[string]interface{}, key string) (s
 		return "", err
 	}
 
-	root := p.root
-	if root == "" {
-		return abs, nil
+	roots := p.roots()
+	if len(roots) > 0 {
+		if !withinAny(abs, roots) {
+			return "", errors.New("path escapes workspace root")
+		}
+		if real := resolveExisting(abs); !withinAny(real, roots) {
+			return "", errors.New("path escapes workspace root through a symlink")
+		}
 	}
-	root = filepath.Clean(root)
-	if !strings.HasPrefix(abs, root+string(filepath.Separator)) && abs != root {
-		return "", errors.New("path escapes workspace root")
+	if p.sandbox != nil {
+		// Check the symlink-resolved path too, so a link in an allowed
+		// dir cannot reach into a blocked one
+		for _, path := range []string{abs, resolveExisting(abs)} {
+			if err := p.sandbox.ValidateFilePath(path, write); err != nil {
+				return "", &security.ViolationError{Kind: security.ViolationPath, Detail: err.Error()}
+			}
+		}
 	}
 	return abs, nil
 }
 
+// roots returns the directories paths must stay within, including their
+// symlink-resolved forms.
+func (p *FilesystemProvider) roots() []string {
+	var dirs []string
+	if p.sandbox != nil {
+		dirs = p.sandbox.AllowedDirs()
+	}
+	if len(dirs) == 0 && p.root != "" {
+		dirs = []string{p.root}
+	}
+	var out []string
+	for _, d := range dirs {
+		abs, err := filepath.Abs(d)
+		if err != nil {
+			continue
+		}
+		out = append(out, abs)
+		if real, err := filepath.EvalSymlinks(abs); err == nil && real != abs {
+			out = append(out, real)
+		}
+	}
+	return out
+}
+
+func withinAny(path string, roots []string) bool {
+	for _, root := range roots {
+		if path == root || root == filepath.Dir(root) || strings.HasPrefix(path, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveExisting evaluates symlinks in the longest existing prefix of path
+func resolveExisting(path string) string {
+	rest := ""
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(real, rest)
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
 func (p *FilesystemProvider) listDir(path string, recursive bool) ([]map[string]interface{}, error) {
 	var out []map[string]interface{}
 	entries, err := os.ReadDir(path)
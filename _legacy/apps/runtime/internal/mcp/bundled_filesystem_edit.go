@@ -0,0 +1,373 @@
+package mcp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	readRangeDefault = 200
+	readRangeMax     = 2000
+)
+
+func (p *FilesystemProvider) readRange(path string, arguments map[string]interface{}) (ToolResult, error) {
+	start := argInt(arguments, "start_line", 1)
+	if start < 1 {
+		start = 1
+	}
+	limit := argInt(arguments, "limit", readRangeDefault)
+	if limit <= 0 || limit > readRangeMax {
+		limit = readRangeDefault
+	}
+	end := start + limit - 1
+	if e := argInt(arguments, "end_line"); e > 0 {
+		if e < start {
+			return ToolResult{}, errors.New("end_line is before start_line")
+		}
+		end = min(e, start+readRangeMax-1)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	var lines []string
+	total := 0
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 4<<20)
+	for sc.Scan() {
+		total++
+		if total < start || total > end {
+			continue
+		}
+		line := strings.TrimSuffix(sc.Text(), "\r")
+		lines = append(lines, line)
+		fmt.Fprintf(&b, "%6d\t%s\n", total, line)
+	}
+	if err := sc.Err(); err != nil {
+		return ToolResult{}, err
+	}
+	if start > total && total > 0 {
+		return ToolResult{}, fmt.Errorf("start_line %d is past the end of the file (%d lines)", start, total)
+	}
+	last := min(end, total)
+	if last < total {
+		fmt.Fprintf(&b, "... %d more lines; continue with start_line=%d\n", total-last, last+1)
+	}
+	return ToolResult{
+		Content: []ToolContent{{Type: "text", Text: b.String()}},
+		StructuredContent: jsonRaw(map[string]interface{}{
+			"path":        path,
+			"start_line":  start,
+			"end_line":    last,
+			"total_lines": total,
+			"lines":       lines,
+		}),
+	}, nil
+}
+
+// editConflict describes an edit or hunk that could not be applied
+type editConflict struct {
+	Edit   int    `json:"edit,omitempty"`
+	Hunk   int    `json:"hunk,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (c editConflict) String() string {
+	switch {
+	case c.Hunk > 0:
+		return fmt.Sprintf("hunk %d (line %d): %s", c.Hunk, c.Line, c.Reason)
+	case c.Edit > 0:
+		return fmt.Sprintf("edit %d: %s", c.Edit, c.Reason)
+	}
+	return c.Reason
+}
+
+// applyEdit changes a text file in place. Edits and hunks are applied in
+// memory first and the file is written only if all of them apply.
+func (p *FilesystemProvider) applyEdit(path string, arguments map[string]interface{}) (ToolResult, error) {
+	rawEdits, hasEdits := arguments["edits"].([]interface{})
+	diff := argString(arguments, "diff")
+	if hasEdits == (diff != "") {
+		return ToolResult{}, errors.New("provide exactly one of edits or diff")
+	}
+
+	info, statErr := os.Stat(path)
+	var original string
+	switch {
+	case statErr == nil:
+		if info.IsDir() {
+			return ToolResult{}, errors.New("path is a directory")
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return ToolResult{}, err
+		}
+		original = string(b)
+	case os.IsNotExist(statErr) && diff != "":
+		// A diff may create the file
+	default:
+		return ToolResult{}, statErr
+	}
+
+	// Work on LF text and restore CRLF on write, so edits written with
+	// plain newlines still match.
+	crlf := strings.Contains(original, "\r\n")
+	content := original
+	if crlf {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+
+	var updated string
+	var conflicts []editConflict
+	applied := 0
+	if hasEdits {
+		updated, applied, conflicts = applyReplacements(content, rawEdits)
+	} else {
+		hunks, err := parseUnifiedDiff(diff)
+		if err != nil {
+			return ToolResult{}, err
+		}
+		if statErr != nil && !createsFile(hunks) {
+			return ToolResult{}, statErr
+		}
+		updated, conflicts = applyHunks(content, hunks)
+		applied = len(hunks) - len(conflicts)
+	}
+
+	if len(conflicts) > 0 {
+		var b strings.Builder
+		b.WriteString("no changes written; conflicts:\n")
+		for _, c := range conflicts {
+			b.WriteString("- " + c.String() + "\n")
+		}
+		return ToolResult{
+			Content:           []ToolContent{{Type: "text", Text: b.String()}},
+			IsError:           true,
+			StructuredContent: jsonRaw(map[string]interface{}{"path": path, "conflicts": conflicts}),
+		}, nil
+	}
+
+	if crlf {
+		updated = strings.ReplaceAll(updated, "\n", "\r\n")
+	}
+	dryRun := argBool(arguments, "dry_run")
+	if !dryRun && updated != original {
+		mode := os.FileMode(0o600)
+		if statErr == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := os.WriteFile(path, []byte(updated), mode); err != nil {
+			return ToolResult{}, err
+		}
+	}
+
+	text := fmt.Sprintf("applied %d change(s)", applied)
+	if dryRun {
+		text += " (dry run, nothing written)"
+	}
+	return ToolResult{
+		Content: []ToolContent{{Type: "text", Text: text}},
+		StructuredContent: jsonRaw(map[string]interface{}{
+			"path":    path,
+			"applied": applied,
+			"dry_run": dryRun,
+			"changed": updated != original,
+		}),
+	}, nil
+}
+
+// applyReplacements applies exact string edits in order. An old_string must
+// match exactly once unless replace_all is set.
+func applyReplacements(content string, edits []interface{}) (string, int, []editConflict) {
+	var conflicts []editConflict
+	applied := 0
+	for i, raw := range edits {
+		e, ok := raw.(map[string]interface{})
+		if !ok {
+			conflicts = append(conflicts, editConflict{Edit: i + 1, Reason: "edit must be an object"})
+			continue
+		}
+		old := strings.ReplaceAll(argString(e, "old_string"), "\r\n", "\n")
+		repl := strings.ReplaceAll(argString(e, "new_string"), "\r\n", "\n")
+		if old == "" {
+			conflicts = append(conflicts, editConflict{Edit: i + 1, Reason: "old_string is empty"})
+			continue
+		}
+		n := strings.Count(content, old)
+		switch {
+		case n == 0:
+			conflicts = append(conflicts, editConflict{Edit: i + 1, Reason: "old_string not found"})
+			continue
+		case n > 1 && !argBool(e, "replace_all"):
+			conflicts = append(conflicts, editConflict{Edit: i + 1, Reason: fmt.Sprintf("old_string matches %d times; add surrounding context or set replace_all", n)})
+			continue
+		}
+		content = strings.ReplaceAll(content, old, repl)
+		applied++
+	}
+	return content, applied, conflicts
+}
+
+type diffHunk struct {
+	oldStart, oldCount int
+	newStart, newCount int
+	lines              []string // each prefixed with ' ', '-' or '+'
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parseUnifiedDiff reads the hunks of a single-file unified diff. File
+// headers are ignored; the path argument names the file.
+func parseUnifiedDiff(diff string) ([]diffHunk, error) {
+	var hunks []diffHunk
+	var cur *diffHunk
+	for _, line := range strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n") {
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			hunks = append(hunks, diffHunk{
+				oldStart: atoiDefault(m[1], 0),
+				oldCount: atoiDefault(m[2], 1),
+				newStart: atoiDefault(m[3], 0),
+				newCount: atoiDefault(m[4], 1),
+			})
+			cur = &hunks[len(hunks)-1]
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		switch {
+		case hunkComplete(cur) && (strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "diff ")):
+			return nil, errors.New("diff touches more than one file")
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file"
+		case line == "":
+			// Editors often strip the space from empty context lines
+			if !hunkComplete(cur) {
+				cur.lines = append(cur.lines, " ")
+			}
+		case line[0] == ' ' || line[0] == '-' || line[0] == '+':
+			cur.lines = append(cur.lines, line)
+		default:
+			return nil, fmt.Errorf("invalid diff line: %q", line)
+		}
+	}
+	if len(hunks) == 0 {
+		return nil, errors.New("diff has no hunks")
+	}
+	for i, h := range hunks {
+		old, added := hunkSides(h)
+		if len(old) != h.oldCount || len(added) != h.newCount {
+			return nil, fmt.Errorf("hunk %d: line counts do not match its header", i+1)
+		}
+	}
+	return hunks, nil
+}
+
+func hunkComplete(h *diffHunk) bool {
+	old, added := hunkSides(*h)
+	return len(old) >= h.oldCount && len(added) >= h.newCount
+}
+
+func hunkSides(h diffHunk) (old, added []string) {
+	for _, l := range h.lines {
+		switch l[0] {
+		case ' ':
+			old = append(old, l[1:])
+			added = append(added, l[1:])
+		case '-':
+			old = append(old, l[1:])
+		case '+':
+			added = append(added, l[1:])
+		}
+	}
+	return old, added
+}
+
+func createsFile(hunks []diffHunk) bool {
+	return len(hunks) == 1 && hunks[0].oldCount == 0
+}
+
+// applyHunks applies hunks in order. A hunk whose context is not at the
+// line its header names is searched for nearby, the way patch does, but
+// never before the end of the previous hunk.
+func applyHunks(content string, hunks []diffHunk) (string, []editConflict) {
+	trailingNewline := content == "" || strings.HasSuffix(content, "\n")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	var out []string
+	var conflicts []editConflict
+	pos, offset := 0, 0
+	for i, h := range hunks {
+		old, added := hunkSides(h)
+		at := h.oldStart - 1
+		if h.oldCount == 0 {
+			at = h.oldStart
+		}
+		idx := findBlock(lines, old, at+offset, pos)
+		if idx < 0 {
+			conflicts = append(conflicts, editConflict{Hunk: i + 1, Line: h.oldStart, Reason: "context does not match the file"})
+			continue
+		}
+		out = append(out, lines[pos:idx]...)
+		out = append(out, added...)
+		pos = idx + len(old)
+		offset = idx - at
+	}
+	out = append(out, lines[pos:]...)
+
+	result := strings.Join(out, "\n")
+	if trailingNewline && len(out) > 0 {
+		result += "\n"
+	}
+	return result, conflicts
+}
+
+// findBlock returns the index nearest want, at or after from, where block
+// occurs in lines, or -1.
+func findBlock(lines, block []string, want, from int) int {
+	matches := func(at int) bool {
+		if at < from || at+len(block) > len(lines) {
+			return false
+		}
+		for j, l := range block {
+			if lines[at+j] != l {
+				return false
+			}
+		}
+		return true
+	}
+	want = max(want, from)
+	for d := 0; want-d >= from || want+d <= len(lines); d++ {
+		if matches(want - d) {
+			return want - d
+		}
+		if d > 0 && matches(want+d) {
+			return want + d
+		}
+	}
+	return -1
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
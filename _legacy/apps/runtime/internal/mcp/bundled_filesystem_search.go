@@ -0,0 +1,383 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	searchDefaultResults = 100
+	searchMaxResults     = 1000
+	searchMaxFileSize    = 2 << 20
+	searchMaxFiles       = 50000
+	searchMaxLineLength  = 500
+)
+
+// searchMatch is one matching line returned by the search tool
+type searchMatch struct {
+	Path   string   `json:"path"`
+	Line   int      `json:"line"`
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+func (p *FilesystemProvider) search(ctx context.Context, arguments map[string]interface{}) (ToolResult, error) {
+	pattern := argString(arguments, "pattern")
+	if pattern == "" {
+		return ToolResult{}, errors.New("missing pattern")
+	}
+	if argBool(arguments, "literal") {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if argBool(arguments, "case_insensitive") {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return ToolResult{}, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	rawPath := strings.TrimSpace(argString(arguments, "path"))
+	if rawPath == "" {
+		rawPath = "."
+	}
+	base, err := p.resolvePath(rawPath, false)
+	if err != nil {
+		return ToolResult{}, err
+	}
+
+	include, err := compileGlobs(argStringSlice(arguments, "include"))
+	if err != nil {
+		return ToolResult{}, err
+	}
+	exclude, err := compileGlobs(argStringSlice(arguments, "exclude"))
+	if err != nil {
+		return ToolResult{}, err
+	}
+	maxResults := argInt(arguments, "max_results", searchDefaultResults)
+	if maxResults <= 0 || maxResults > searchMaxResults {
+		maxResults = searchDefaultResults
+	}
+	contextLines := argInt(arguments, "context")
+	if contextLines < 0 {
+		contextLines = 0
+	} else if contextLines > 10 {
+		contextLines = 10
+	}
+	useGitignore := true
+	if _, ok := arguments["gitignore"]; ok {
+		useGitignore = argBool(arguments, "gitignore")
+	}
+	hidden := argBool(arguments, "hidden")
+
+	var ignores gitignore
+	if useGitignore {
+		ignores = p.ancestorIgnores(base)
+	}
+
+	var matches []searchMatch
+	files, truncated := 0, false
+	errStop := errors.New("stop")
+	err = filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable entries are skipped rather than failing the search
+			if d != nil && d.IsDir() && path != base {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel, _ := filepath.Rel(base, path)
+		rel = filepath.ToSlash(rel)
+		name := d.Name()
+		// The base was checked by argPath; entries beneath it may still
+		// lie in a blocked dir
+		if path != base && p.sandbox != nil && p.sandbox.ValidateFilePath(path, false) != nil {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if path == base {
+				if useGitignore {
+					ignores.load(path)
+				}
+				return nil
+			}
+			if name == ".git" || (!hidden && strings.HasPrefix(name, ".")) || ignores.ignored(path, true) || matchesAny(exclude, rel, name) {
+				return fs.SkipDir
+			}
+			if useGitignore {
+				ignores.load(path)
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if path != base {
+			if (!hidden && strings.HasPrefix(name, ".")) || ignores.ignored(path, false) || matchesAny(exclude, rel, name) {
+				return nil
+			}
+			if len(include) > 0 && !matchesAny(include, rel, name) {
+				return nil
+			}
+		}
+
+		files++
+		if files > searchMaxFiles {
+			truncated = true
+			return errStop
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > searchMaxFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || isBinary(data) {
+			return nil
+		}
+
+		lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+		display := p.displayPath(path)
+		for i, line := range lines {
+			if !re.MatchString(line) {
+				continue
+			}
+			if len(matches) >= maxResults {
+				truncated = true
+				return errStop
+			}
+			m := searchMatch{Path: display, Line: i + 1, Text: clipLine(line)}
+			if contextLines > 0 {
+				for j := max(0, i-contextLines); j < i; j++ {
+					m.Before = append(m.Before, clipLine(lines[j]))
+				}
+				for j := i + 1; j < len(lines) && j <= i+contextLines; j++ {
+					m.After = append(m.After, clipLine(lines[j]))
+				}
+			}
+			matches = append(matches, m)
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return ToolResult{}, err
+	}
+
+	var b strings.Builder
+	for _, m := range matches {
+		fmt.Fprintf(&b, "%s:%d: %s\n", m.Path, m.Line, m.Text)
+	}
+	if len(matches) == 0 {
+		b.WriteString("no matches\n")
+	}
+	if truncated {
+		b.WriteString("... results truncated; narrow the pattern or path\n")
+	}
+	return ToolResult{
+		Content: []ToolContent{{Type: "text", Text: b.String()}},
+		StructuredContent: jsonRaw(map[string]interface{}{
+			"matches":   matches,
+			"files":     files,
+			"truncated": truncated,
+		}),
+	}, nil
+}
+
+// displayPath shows paths under the workspace root relative to it, so they
+// can be passed straight back to the other tools.
+func (p *FilesystemProvider) displayPath(path string) string {
+	if p.root != "" {
+		if rel, err := filepath.Rel(p.root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return path
+}
+
+// ancestorIgnores loads the .gitignore files between the enclosing root and
+// dir, so searching a subdirectory honors its parents' rules.
+func (p *FilesystemProvider) ancestorIgnores(dir string) gitignore {
+	var g gitignore
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return g
+	}
+	roots := p.roots()
+	var chain []string
+	for d := filepath.Dir(dir); withinAny(d, roots); d = filepath.Dir(d) {
+		chain = append(chain, d)
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil || filepath.Dir(d) == d {
+			break
+		}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		g.load(chain[i])
+	}
+	return g
+}
+
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+func clipLine(s string) string {
+	if len(s) > searchMaxLineLength {
+		return s[:searchMaxLineLength] + "..."
+	}
+	return s
+}
+
+// compileGlobs turns include/exclude globs into matchers. Globs without a
+// slash match the file name at any depth; others match the path relative
+// to the search directory. ** matches across directories.
+func compileGlobs(globs []string) ([]*regexp.Regexp, error) {
+	var out []*regexp.Regexp
+	for _, g := range globs {
+		g = strings.TrimSpace(g)
+		if g == "" {
+			continue
+		}
+		re, err := regexp.Compile("^" + globToRegex(strings.TrimPrefix(g, "./")) + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", g, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel, name string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) || re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func globToRegex(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// gitignore holds the rules of the .gitignore files loaded so far, in the
+// order git applies them: parents before children, later rules winning.
+type gitignore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	dir     string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// load reads dir/.gitignore, if present
+func (g *gitignore) load(dir string) {
+	data, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{dir: dir}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		expr := globToRegex(strings.TrimPrefix(line, "/"))
+		if !strings.Contains(line, "/") {
+			// Unanchored patterns match at any depth
+			expr = "(?:.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		g.rules = append(g.rules, rule)
+	}
+}
+
+func (g *gitignore) ignored(path string, isDir bool) bool {
+	ignored := false
+	for _, r := range g.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(r.dir, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if r.re.MatchString(filepath.ToSlash(rel)) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
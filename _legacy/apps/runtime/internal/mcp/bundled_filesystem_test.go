@@ -0,0 +1,260 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pryx-core/internal/mcp/security"
+)
+
+func newTestFilesystem(t *testing.T, files map[string]string) (*FilesystemProvider, string) {
+	t.Helper()
+	root := t.TempDir()
+	t.Setenv("PRYX_WORKSPACE_ROOT", root)
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	return NewFilesystemProvider(), root
+}
+
+func callFS(t *testing.T, p *FilesystemProvider, name string, args map[string]interface{}) ToolResult {
+	t.Helper()
+	res, err := p.CallTool(context.Background(), name, args)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return res
+}
+
+func TestFilesystemProvider_Search(t *testing.T) {
+	p, _ := newTestFilesystem(t, map[string]string{
+		".gitignore":         "build/\n*.log\n!keep.log\n",
+		"main.go":            "package main\n\nfunc main() {\n\tTODO()\n}\n",
+		"pkg/util.go":        "package pkg\n// TODO: tidy\n",
+		"pkg/util.txt":       "TODO in text\n",
+		"build/out.go":       "TODO generated\n",
+		"debug.log":          "TODO log\n",
+		"keep.log":           "TODO kept\n",
+		".hidden/secret.go":  "TODO hidden\n",
+		"pkg/.gitignore":     "util.txt\n",
+		"vendor/lib/lib.go":  "TODO vendored\n",
+		"docs/notes/todo.md": "todo lowercase\n",
+	})
+
+	var out struct {
+		Matches []searchMatch `json:"matches"`
+	}
+	res := callFS(t, p, "search", map[string]interface{}{"pattern": "TODO", "exclude": []interface{}{"vendor"}})
+	_ = json.Unmarshal(res.StructuredContent, &out)
+	got := map[string]bool{}
+	for _, m := range out.Matches {
+		got[m.Path] = true
+	}
+	want := map[string]bool{"main.go": true, "pkg/util.go": true, "keep.log": true}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected matches: %v", got)
+	}
+	for path := range want {
+		if !got[path] {
+			t.Fatalf("missing match in %s: %v", path, got)
+		}
+	}
+
+	res = callFS(t, p, "search", map[string]interface{}{"pattern": "todo", "case_insensitive": true, "include": []interface{}{"**/*.md"}, "context": 1})
+	_ = json.Unmarshal(res.StructuredContent, &out)
+	if len(out.Matches) != 1 || out.Matches[0].Path != "docs/notes/todo.md" || out.Matches[0].Line != 1 {
+		t.Fatalf("unexpected include matches: %+v", out.Matches)
+	}
+
+	res = callFS(t, p, "search", map[string]interface{}{"pattern": "TODO", "path": "pkg", "max_results": 1, "gitignore": false})
+	var capped struct {
+		Matches   []searchMatch `json:"matches"`
+		Truncated bool          `json:"truncated"`
+	}
+	_ = json.Unmarshal(res.StructuredContent, &capped)
+	if len(capped.Matches) != 1 || !capped.Truncated {
+		t.Fatalf("expected capped results: %s", res.StructuredContent)
+	}
+}
+
+func TestFilesystemProvider_ReadRange(t *testing.T) {
+	var b strings.Builder
+	for i := 1; i <= 10; i++ {
+		b.WriteString("line " + string(rune('0'+i%10)) + "\n")
+	}
+	p, _ := newTestFilesystem(t, map[string]string{"a.txt": b.String()})
+
+	res := callFS(t, p, "read_range", map[string]interface{}{"path": "a.txt", "start_line": 3, "limit": 2})
+	var out struct {
+		Lines      []string `json:"lines"`
+		EndLine    int      `json:"end_line"`
+		TotalLines int      `json:"total_lines"`
+	}
+	_ = json.Unmarshal(res.StructuredContent, &out)
+	if len(out.Lines) != 2 || out.Lines[0] != "line 3" || out.EndLine != 4 || out.TotalLines != 10 {
+		t.Fatalf("unexpected range: %s", res.StructuredContent)
+	}
+	if !strings.Contains(res.Content[0].Text, "start_line=5") {
+		t.Fatalf("expected continuation hint: %s", res.Content[0].Text)
+	}
+}
+
+func TestFilesystemProvider_ApplyEdit(t *testing.T) {
+	p, root := newTestFilesystem(t, map[string]string{
+		"a.go":     "one\ntwo\nthree\ntwo\n",
+		"crlf.txt": "alpha\r\nbeta\r\n",
+	})
+	read := func(name string) string {
+		b, _ := os.ReadFile(filepath.Join(root, name))
+		return string(b)
+	}
+
+	// An ambiguous edit conflicts and nothing is written, not even the
+	// edit that did apply
+	res := callFS(t, p, "apply_edit", map[string]interface{}{"path": "a.go", "edits": []interface{}{
+		map[string]interface{}{"old_string": "one", "new_string": "1"},
+		map[string]interface{}{"old_string": "two", "new_string": "2"},
+	}})
+	if !res.IsError || !strings.Contains(res.Content[0].Text, "matches 2 times") || read("a.go") != "one\ntwo\nthree\ntwo\n" {
+		t.Fatalf("expected conflict and no write: %+v", res)
+	}
+
+	callFS(t, p, "apply_edit", map[string]interface{}{"path": "a.go", "edits": []interface{}{
+		map[string]interface{}{"old_string": "one", "new_string": "1"},
+		map[string]interface{}{"old_string": "two", "new_string": "2", "replace_all": true},
+	}})
+	if got := read("a.go"); got != "1\n2\nthree\n2\n" {
+		t.Fatalf("unexpected content: %q", got)
+	}
+
+	diff := "--- a/a.go\n+++ b/a.go\n@@ -2,2 +2,3 @@\n 2\n-three\n+3\n+3.5\n"
+	callFS(t, p, "apply_edit", map[string]interface{}{"path": "a.go", "diff": diff})
+	if got := read("a.go"); got != "1\n2\n3\n3.5\n2\n" {
+		t.Fatalf("unexpected content after diff: %q", got)
+	}
+
+	// Hunks are found when the header's line number is off
+	res = callFS(t, p, "apply_edit", map[string]interface{}{"path": "a.go", "diff": "@@ -1,1 +1,1 @@\n-3.5\n+3.75\n", "dry_run": true})
+	if res.IsError || read("a.go") != "1\n2\n3\n3.5\n2\n" {
+		t.Fatalf("dry run should apply without writing: %+v", res)
+	}
+
+	res = callFS(t, p, "apply_edit", map[string]interface{}{"path": "a.go", "diff": "@@ -1,1 +1,1 @@\n-missing\n+x\n"})
+	var conflict struct {
+		Conflicts []editConflict `json:"conflicts"`
+	}
+	_ = json.Unmarshal(res.StructuredContent, &conflict)
+	if !res.IsError || len(conflict.Conflicts) != 1 || conflict.Conflicts[0].Hunk != 1 {
+		t.Fatalf("expected hunk conflict: %+v", res)
+	}
+
+	callFS(t, p, "apply_edit", map[string]interface{}{"path": "crlf.txt", "edits": []interface{}{
+		map[string]interface{}{"old_string": "alpha\nbeta", "new_string": "alpha\ngamma"},
+	}})
+	if got := read("crlf.txt"); got != "alpha\r\ngamma\r\n" {
+		t.Fatalf("line endings not preserved: %q", got)
+	}
+
+	callFS(t, p, "apply_edit", map[string]interface{}{"path": "new.txt", "diff": "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1,2 @@\n+hello\n+world\n"})
+	if got := read("new.txt"); got != "hello\nworld\n" {
+		t.Fatalf("unexpected new file: %q", got)
+	}
+}
+
+func TestFilesystemProvider_StatAndMove(t *testing.T) {
+	p, root := newTestFilesystem(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+
+	res := callFS(t, p, "stat", map[string]interface{}{"path": "a.txt"})
+	var info map[string]interface{}
+	_ = json.Unmarshal(res.StructuredContent, &info)
+	if info["size"] != float64(1) || info["isDir"] != false {
+		t.Fatalf("unexpected stat: %s", res.StructuredContent)
+	}
+
+	if _, err := p.CallTool(context.Background(), "move", map[string]interface{}{"source": "a.txt", "destination": "b.txt"}); err == nil {
+		t.Fatal("expected move onto an existing file to fail without overwrite")
+	}
+	callFS(t, p, "move", map[string]interface{}{"source": "a.txt", "destination": "sub/c.txt", "create_dirs": true})
+	if b, err := os.ReadFile(filepath.Join(root, "sub", "c.txt")); err != nil || string(b) != "a" {
+		t.Fatalf("move failed: %v %q", err, b)
+	}
+}
+
+func TestFilesystemProvider_Confinement(t *testing.T) {
+	p, root := newTestFilesystem(t, map[string]string{"a.txt": "a"})
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("s"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"path": "../escape"},
+		{"path": filepath.Join(outside, "secret")},
+		{"path": "link/secret"},
+	} {
+		if _, err := p.CallTool(context.Background(), "stat", args); err == nil || !strings.Contains(err.Error(), "escapes") {
+			t.Fatalf("expected %v to be refused, got %v", args, err)
+		}
+	}
+	if _, err := p.CallTool(context.Background(), "move", map[string]interface{}{"source": "a.txt", "destination": filepath.Join(outside, "a.txt")}); err == nil {
+		t.Fatal("expected move outside the root to be refused")
+	}
+
+	// Allowed directories replace the workspace root, and read-only
+	// sandboxes refuse writes
+	p.SetSandbox(security.NewSandbox(security.SandboxConfig{AllowedDirs: []string{outside}, ReadOnly: true}))
+	if _, err := p.CallTool(context.Background(), "read_range", map[string]interface{}{"path": filepath.Join(outside, "secret")}); err != nil {
+		t.Fatalf("read in allowed dir: %v", err)
+	}
+	_, err := p.CallTool(context.Background(), "apply_edit", map[string]interface{}{"path": filepath.Join(outside, "secret"), "edits": []interface{}{
+		map[string]interface{}{"old_string": "s", "new_string": "x"},
+	}})
+	if v, ok := security.AsViolation(err); !ok || v.Kind != security.ViolationPath {
+		t.Fatalf("expected path violation, got %v", err)
+	}
+	if _, err := p.CallTool(context.Background(), "stat", map[string]interface{}{"path": "a.txt"}); err == nil {
+		t.Fatal("expected workspace path outside allowed dirs to be refused")
+	}
+}
+
+func TestFilesystemProvider_BlockedDirs(t *testing.T) {
+	p, root := newTestFilesystem(t, map[string]string{
+		"open/notes.txt":   "TODO open\n",
+		"secret/keys.txt":  "TODO secret\n",
+		"secret/inner.txt": "TODO inner\n",
+	})
+	p.SetSandbox(security.NewSandbox(security.SandboxConfig{
+		AllowedDirs: []string{root},
+		BlockedDirs: []string{filepath.Join(root, "secret")},
+	}))
+
+	var out struct {
+		Matches []searchMatch `json:"matches"`
+	}
+	res := callFS(t, p, "search", map[string]interface{}{"pattern": "TODO"})
+	_ = json.Unmarshal(res.StructuredContent, &out)
+	if len(out.Matches) != 1 || out.Matches[0].Path != "open/notes.txt" {
+		t.Fatalf("expected only the unblocked match, got %+v", out.Matches)
+	}
+
+	if err := os.Symlink(filepath.Join(root, "secret"), filepath.Join(root, "open", "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	_, err := p.CallTool(context.Background(), "read_range", map[string]interface{}{"path": "open/link/keys.txt"})
+	if v, ok := security.AsViolation(err); !ok || v.Kind != security.ViolationPath {
+		t.Fatalf("expected a link into a blocked dir to be refused, got %v", err)
+	}
+}
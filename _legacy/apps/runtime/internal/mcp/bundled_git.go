@@ -0,0 +1,445 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"pryx-core/internal/policy"
+)
+
+// gitTimeout bounds every git invocation
+const gitTimeout = 60 * time.Second
+
+// GitProvider runs git against repositories inside the workspace root.
+// Commands are built from structured arguments rather than a command line,
+// so policy sees exactly which operation is requested.
+type GitProvider struct {
+	root string
+}
+
+func NewGitProvider() *GitProvider {
+	return &GitProvider{root: policy.WorkspaceRoot()}
+}
+
+func (p *GitProvider) ServerInfo() map[string]interface{} {
+	return map[string]interface{}{
+		"name":    "pryx-core/git",
+		"title":   "Pryx Git (Bundled)",
+		"version": "dev",
+	}
+}
+
+var (
+	gitReadOnly = &ToolAnnotations{ReadOnlyHint: true}
+	gitStaging  = &ToolAnnotations{IdempotentHint: true, DestructiveHint: boolPtr(false)}
+	gitCommit   = &ToolAnnotations{DestructiveHint: boolPtr(false)}
+)
+
+func (p *GitProvider) ListTools(ctx context.Context) ([]Tool, error) {
+	_ = ctx
+	return []Tool{
+		{Name: "status", Title: "Git Status", Description: "Show the current branch and changed files", Annotations: gitReadOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"repo":{"type":"string"}},"additionalProperties":false}`)},
+		{Name: "diff", Title: "Git Diff", Description: "Show unstaged changes, staged changes, or changes against a revision", Annotations: gitReadOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"repo":{"type":"string"},"staged":{"type":"boolean","default":false},"rev":{"type":"string"},"paths":{"type":"array","items":{"type":"string"}},"context_lines":{"type":"integer","minimum":0,"maximum":100}},"additionalProperties":false}`)},
+		{Name: "log", Title: "Git Log", Description: "List commits, newest first", Annotations: gitReadOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"repo":{"type":"string"},"rev":{"type":"string"},"max_count":{"type":"integer","minimum":1,"maximum":500,"default":20},"paths":{"type":"array","items":{"type":"string"}}},"additionalProperties":false}`)},
+		{Name: "show", Title: "Git Show", Description: "Show a commit, or a file as of a revision when path is set", Annotations: gitReadOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"repo":{"type":"string"},"rev":{"type":"string"},"path":{"type":"string"}},"required":["rev"],"additionalProperties":false}`)},
+		{Name: "blame", Title: "Git Blame", Description: "Show who last changed each line of a file", Annotations: gitReadOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"repo":{"type":"string"},"path":{"type":"string"},"rev":{"type":"string"},"start_line":{"type":"integer","minimum":1},"end_line":{"type":"integer","minimum":1}},"required":["path"],"additionalProperties":false}`)},
+		{Name: "branches", Title: "Git Branches", Description: "List local, and optionally remote, branches", Annotations: gitReadOnly, InputSchema: schemaRaw(`{"type":"object","properties":{"repo":{"type":"string"},"all":{"type":"boolean","default":false}},"additionalProperties":false}`)},
+		{Name: "add", Title: "Git Add", Description: "Stage files for the next commit", Annotations: gitStaging, InputSchema: schemaRaw(`{"type":"object","properties":{"repo":{"type":"string"},"paths":{"type":"array","items":{"type":"string"},"minItems":1}},"required":["paths"],"additionalProperties":false}`)},
+		{Name: "unstage", Title: "Git Unstage", Description: "Remove files from the index, keeping working tree changes", Annotations: gitStaging, InputSchema: schemaRaw(`{"type":"object","properties":{"repo":{"type":"string"},"paths":{"type":"array","items":{"type":"string"},"minItems":1}},"required":["paths"],"additionalProperties":false}`)},
+		{Name: "commit", Title: "Git Commit", Description: "Commit staged changes", Annotations: gitCommit, InputSchema: schemaRaw(`{"type":"object","properties":{"repo":{"type":"string"},"message":{"type":"string","minLength":1},"all":{"type":"boolean","default":false}},"required":["message"],"additionalProperties":false}`)},
+	}, nil
+}
+
+func (p *GitProvider) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (ToolResult, error) {
+	repo, err := p.resolvePath(argString(arguments, "repo"))
+	if err != nil {
+		return ToolResult{}, err
+	}
+
+	switch name {
+	case "status":
+		return p.status(ctx, repo)
+	case "diff":
+		args := []string{"diff", "--no-ext-diff", "--no-textconv", "--no-color"}
+		if argBool(arguments, "staged") {
+			args = append(args, "--cached")
+		}
+		if _, ok := arguments["context_lines"]; ok {
+			args = append(args, fmt.Sprintf("-U%d", argInt(arguments, "context_lines")))
+		}
+		return p.textResult(ctx, repo, args, argString(arguments, "rev"), argStringSlice(arguments, "paths"))
+	case "log":
+		return p.log(ctx, repo, arguments)
+	case "show":
+		rev, err := gitRev(argString(arguments, "rev"))
+		if err != nil {
+			return ToolResult{}, err
+		}
+		if rev == "" {
+			return ToolResult{}, errors.New("missing rev")
+		}
+		if path := strings.TrimSpace(argString(arguments, "path")); path != "" {
+			rel, err := p.relPath(repo, path)
+			if err != nil {
+				return ToolResult{}, err
+			}
+			out, err := p.run(ctx, repo, "show", "--no-textconv", rev+":"+filepath.ToSlash(rel))
+			if err != nil {
+				return ToolResult{}, err
+			}
+			return gitText(out), nil
+		}
+		return p.textResult(ctx, repo, []string{"show", "--no-ext-diff", "--no-textconv", "--no-color", "--stat", "--patch"}, rev, nil)
+	case "blame":
+		path := strings.TrimSpace(argString(arguments, "path"))
+		if path == "" {
+			return ToolResult{}, errors.New("missing path")
+		}
+		args := []string{"blame", "--no-progress", "--no-textconv"}
+		if start := argInt(arguments, "start_line"); start > 0 {
+			end := argInt(arguments, "end_line")
+			if end > 0 && end < start {
+				return ToolResult{}, errors.New("end_line must not be before start_line")
+			}
+			if end > 0 {
+				args = append(args, fmt.Sprintf("-L%d,%d", start, end))
+			} else {
+				args = append(args, fmt.Sprintf("-L%d,", start))
+			}
+		}
+		rev, err := gitRev(argString(arguments, "rev"))
+		if err != nil {
+			return ToolResult{}, err
+		}
+		if rev != "" {
+			args = append(args, rev)
+		}
+		rel, err := p.relPath(repo, path)
+		if err != nil {
+			return ToolResult{}, err
+		}
+		// blame takes a file name, not a pathspec
+		out, err := p.run(ctx, repo, append(args, "--", rel)...)
+		if err != nil {
+			return ToolResult{}, err
+		}
+		return gitText(out), nil
+	case "branches":
+		return p.branches(ctx, repo, argBool(arguments, "all"))
+	case "add":
+		return p.stage(ctx, repo, []string{"add"}, argStringSlice(arguments, "paths"))
+	case "unstage":
+		return p.stage(ctx, repo, []string{"restore", "--staged"}, argStringSlice(arguments, "paths"))
+	case "commit":
+		return p.commit(ctx, repo, arguments)
+	default:
+		return ToolResult{}, errors.New("unknown tool")
+	}
+}
+
+func (p *GitProvider) status(ctx context.Context, repo string) (ToolResult, error) {
+	out, err := p.run(ctx, repo, "status", "--porcelain=v1", "--branch", "--untracked-files=all")
+	if err != nil {
+		return ToolResult{}, err
+	}
+
+	branch := ""
+	files := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "## "):
+			branch = strings.TrimPrefix(line, "## ")
+		case len(line) > 3:
+			files = append(files, map[string]interface{}{
+				"index":    strings.TrimSpace(line[:1]),
+				"worktree": strings.TrimSpace(line[1:2]),
+				"path":     line[3:],
+			})
+		}
+	}
+	return ToolResult{
+		Content:           []ToolContent{{Type: "text", Text: out}},
+		StructuredContent: jsonRaw(map[string]interface{}{"branch": branch, "files": files, "clean": len(files) == 0}),
+	}, nil
+}
+
+func (p *GitProvider) log(ctx context.Context, repo string, arguments map[string]interface{}) (ToolResult, error) {
+	limit := argInt(arguments, "max_count", 20)
+	if limit <= 0 || limit > 500 {
+		limit = 20
+	}
+	rev, err := gitRev(argString(arguments, "rev"))
+	if err != nil {
+		return ToolResult{}, err
+	}
+	args := []string{"log", "--no-color", fmt.Sprintf("--max-count=%d", limit), "--format=%H%x1f%an%x1f%ae%x1f%aI%x1f%s%x1e"}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	pathspecs, err := p.pathspecs(repo, argStringSlice(arguments, "paths"))
+	if err != nil {
+		return ToolResult{}, err
+	}
+	out, err := p.run(ctx, repo, append(append(args, "--"), pathspecs...)...)
+	if err != nil {
+		return ToolResult{}, err
+	}
+
+	commits := []map[string]interface{}{}
+	var text strings.Builder
+	for _, rec := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(rec), "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		commits = append(commits, map[string]interface{}{
+			"hash":    fields[0],
+			"author":  fields[1],
+			"email":   fields[2],
+			"date":    fields[3],
+			"subject": fields[4],
+		})
+		fmt.Fprintf(&text, "%s %s %s %s\n", shortHash(fields[0]), fields[3], fields[1], fields[4])
+	}
+	return ToolResult{
+		Content:           []ToolContent{{Type: "text", Text: text.String()}},
+		StructuredContent: jsonRaw(map[string]interface{}{"commits": commits}),
+	}, nil
+}
+
+func (p *GitProvider) branches(ctx context.Context, repo string, all bool) (ToolResult, error) {
+	args := []string{"branch", "--no-color", "--format=%(HEAD)%00%(refname:short)%00%(objectname:short)%00%(upstream:short)"}
+	if all {
+		args = append(args, "--all")
+	}
+	out, err := p.run(ctx, repo, args...)
+	if err != nil {
+		return ToolResult{}, err
+	}
+
+	branches := []map[string]interface{}{}
+	var text strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		current := fields[0] == "*"
+		branches = append(branches, map[string]interface{}{
+			"name":     fields[1],
+			"commit":   fields[2],
+			"upstream": fields[3],
+			"current":  current,
+		})
+		marker := " "
+		if current {
+			marker = "*"
+		}
+		fmt.Fprintf(&text, "%s %s %s\n", marker, fields[1], fields[2])
+	}
+	return ToolResult{
+		Content:           []ToolContent{{Type: "text", Text: text.String()}},
+		StructuredContent: jsonRaw(map[string]interface{}{"branches": branches}),
+	}, nil
+}
+
+func (p *GitProvider) stage(ctx context.Context, repo string, args []string, paths []string) (ToolResult, error) {
+	if len(paths) == 0 {
+		return ToolResult{}, errors.New("missing paths")
+	}
+	pathspecs, err := p.pathspecs(repo, paths)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	if _, err := p.run(ctx, repo, append(append(args, "--"), pathspecs...)...); err != nil {
+		return ToolResult{}, err
+	}
+	return p.status(ctx, repo)
+}
+
+func (p *GitProvider) commit(ctx context.Context, repo string, arguments map[string]interface{}) (ToolResult, error) {
+	message := strings.TrimSpace(argString(arguments, "message"))
+	if message == "" {
+		return ToolResult{}, errors.New("missing message")
+	}
+	args := []string{"commit", "--no-edit", "--file=-"}
+	if argBool(arguments, "all") {
+		args = append(args, "--all")
+	}
+	if _, err := p.runInput(ctx, repo, message+"\n", args...); err != nil {
+		return ToolResult{}, err
+	}
+	hash, err := p.run(ctx, repo, "rev-parse", "HEAD")
+	if err != nil {
+		return ToolResult{}, err
+	}
+	hash = strings.TrimSpace(hash)
+	return ToolResult{
+		Content:           []ToolContent{{Type: "text", Text: "committed " + shortHash(hash)}},
+		StructuredContent: jsonRaw(map[string]interface{}{"commit": hash}),
+	}, nil
+}
+
+// textResult runs a read-only command that takes an optional revision and
+// pathspecs, returning its output as text.
+func (p *GitProvider) textResult(ctx context.Context, repo string, args []string, rev string, paths []string) (ToolResult, error) {
+	rev, err := gitRev(rev)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	pathspecs, err := p.pathspecs(repo, paths)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	out, err := p.run(ctx, repo, append(append(args, "--"), pathspecs...)...)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	return gitText(out), nil
+}
+
+func (p *GitProvider) run(ctx context.Context, repo string, args ...string) (string, error) {
+	return p.runInput(ctx, repo, "", args...)
+}
+
+// runInput runs git in repo. Settings that make git execute repository
+// controlled programs during read operations (fsmonitor, pagers) are
+// disabled; hooks still run for commits as they would for the user.
+func (p *GitProvider) runInput(ctx context.Context, repo, stdin string, args ...string) (string, error) {
+	execCtx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	full := append([]string{"--no-pager", "-c", "core.fsmonitor=false", "-c", "color.ui=false"}, args...)
+	cmd := exec.CommandContext(execCtx, "git", full...)
+	cmd.Dir = repo
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_OPTIONAL_LOCKS=0", "GIT_PAGER=cat")
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// pathspecs converts workspace paths into literal pathspecs relative to repo
+func (p *GitProvider) pathspecs(repo string, paths []string) ([]string, error) {
+	out := make([]string, 0, len(paths))
+	for _, raw := range paths {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		rel, err := p.relPath(repo, raw)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ":(literal)"+filepath.ToSlash(rel))
+	}
+	return out, nil
+}
+
+// relPath resolves a path given relative to repo and confines it to the
+// workspace.
+func (p *GitProvider) relPath(repo, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if !filepath.IsAbs(raw) {
+		raw = filepath.Join(repo, raw)
+	}
+	abs, err := p.resolvePath(raw)
+	if err != nil {
+		return "", err
+	}
+	// git does not follow a symlink in the last element of a pathspec, so
+	// name the link itself rather than its target.
+	if lexical, err := filepath.Abs(raw); err == nil {
+		abs = filepath.Join(resolveExisting(filepath.Dir(lexical)), filepath.Base(lexical))
+	}
+	rel, err := filepath.Rel(repo, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New("path is outside the repository")
+	}
+	return rel, nil
+}
+
+func (p *GitProvider) resolvePath(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		if p.root == "" {
+			return "", errors.New("no workspace root")
+		}
+		return resolveExisting(filepath.Clean(p.root)), nil
+	}
+	if strings.HasPrefix(raw, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			raw = filepath.Join(home, strings.TrimPrefix(raw, "~"))
+		}
+	}
+	abs := raw
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(p.root, abs)
+	}
+	abs, err := filepath.Abs(filepath.Clean(abs))
+	if err != nil {
+		return "", err
+	}
+	// Symlinks are resolved on both sides so a link inside the root cannot
+	// lead out of it.
+	abs = resolveExisting(abs)
+	root := p.root
+	if root == "" {
+		return abs, nil
+	}
+	root = resolveExisting(filepath.Clean(root))
+	if !strings.HasPrefix(abs, root+string(filepath.Separator)) && abs != root {
+		return "", errors.New("path escapes workspace root")
+	}
+	return abs, nil
+}
+
+// gitRev rejects revisions that git would parse as options
+func gitRev(rev string) (string, error) {
+	rev = strings.TrimSpace(rev)
+	if strings.HasPrefix(rev, "-") {
+		return "", fmt.Errorf("invalid revision: %s", rev)
+	}
+	return rev, nil
+}
+
+func gitText(out string) ToolResult {
+	if out == "" {
+		out = "(no output)"
+	}
+	return ToolResult{Content: []ToolContent{{Type: "text", Text: out}}}
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestGitRepo(t *testing.T) (*GitProvider, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skipf("git not available: %v", err)
+	}
+	root := t.TempDir()
+	t.Setenv("PRYX_WORKSPACE_ROOT", root)
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(root, ".gitconfig-none"))
+
+	repo := filepath.Join(root, "repo")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if out, err := exec.Command("git", "-C", repo, "init", "-q", "-b", "main").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	return NewGitProvider(), repo
+}
+
+func callGit(t *testing.T, p *GitProvider, name string, args map[string]interface{}) ToolResult {
+	t.Helper()
+	res, err := p.CallTool(context.Background(), name, args)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return res
+}
+
+func TestGitProvider_StageCommitAndInspect(t *testing.T) {
+	p, repo := newTestGitRepo(t)
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var status struct {
+		Branch string                   `json:"branch"`
+		Files  []map[string]interface{} `json:"files"`
+	}
+	res := callGit(t, p, "status", map[string]interface{}{"repo": "repo"})
+	_ = json.Unmarshal(res.StructuredContent, &status)
+	if len(status.Files) != 1 || status.Files[0]["worktree"] != "?" {
+		t.Fatalf("unexpected status: %s", res.StructuredContent)
+	}
+
+	callGit(t, p, "add", map[string]interface{}{"repo": "repo", "paths": []interface{}{"a.txt"}})
+	res = callGit(t, p, "commit", map[string]interface{}{"repo": "repo", "message": "add a"})
+	if !strings.HasPrefix(res.Content[0].Text, "committed ") {
+		t.Fatalf("unexpected commit result: %+v", res)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	res = callGit(t, p, "diff", map[string]interface{}{"repo": "repo", "paths": []interface{}{"a.txt"}})
+	if !strings.Contains(res.Content[0].Text, "+two") {
+		t.Fatalf("diff missing change: %s", res.Content[0].Text)
+	}
+
+	var log struct {
+		Commits []map[string]interface{} `json:"commits"`
+	}
+	res = callGit(t, p, "log", map[string]interface{}{"repo": "repo"})
+	_ = json.Unmarshal(res.StructuredContent, &log)
+	if len(log.Commits) != 1 || log.Commits[0]["subject"] != "add a" {
+		t.Fatalf("unexpected log: %s", res.StructuredContent)
+	}
+
+	res = callGit(t, p, "show", map[string]interface{}{"repo": "repo", "rev": "HEAD", "path": "a.txt"})
+	if res.Content[0].Text != "one\n" {
+		t.Fatalf("unexpected file at HEAD: %q", res.Content[0].Text)
+	}
+
+	res = callGit(t, p, "blame", map[string]interface{}{"repo": "repo", "path": "a.txt", "rev": "HEAD", "start_line": 1, "end_line": 1})
+	if !strings.Contains(res.Content[0].Text, "Test") {
+		t.Fatalf("unexpected blame: %s", res.Content[0].Text)
+	}
+
+	res = callGit(t, p, "branches", map[string]interface{}{"repo": "repo"})
+	if !strings.Contains(string(res.StructuredContent), `"name":"main"`) {
+		t.Fatalf("unexpected branches: %s", res.StructuredContent)
+	}
+}
+
+func TestGitProvider_Confinement(t *testing.T) {
+	p, _ := newTestGitRepo(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		tool string
+		args map[string]interface{}
+	}{
+		{"status", map[string]interface{}{"repo": "../.."}},
+		{"add", map[string]interface{}{"repo": "repo", "paths": []interface{}{"../../etc/passwd"}}},
+		{"show", map[string]interface{}{"repo": "repo", "rev": "--output=/tmp/x"}},
+		{"diff", map[string]interface{}{"repo": "repo", "rev": "-p"}},
+	}
+	for _, c := range cases {
+		if _, err := p.CallTool(ctx, c.tool, c.args); err == nil {
+			t.Errorf("%s %v: expected error", c.tool, c.args)
+		}
+	}
+}
+
+func TestGitProvider_SymlinkEscape(t *testing.T) {
+	p, repo := newTestGitRepo(t)
+	ctx := context.Background()
+
+	outside := t.TempDir()
+	if out, err := exec.Command("git", "-C", outside, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	if err := os.Symlink(outside, filepath.Join(repo, "escape")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	if _, err := p.CallTool(ctx, "status", map[string]interface{}{"repo": "repo/escape"}); err == nil {
+		t.Error("expected a repo behind a symlink out of the root to be rejected")
+	}
+	if _, err := p.CallTool(ctx, "add", map[string]interface{}{"repo": "repo", "paths": []interface{}{"escape/file"}}); err == nil {
+		t.Error("expected a path through a symlink out of the root to be rejected")
+	}
+}
+
+func TestBundledToolProvider(t *testing.T) {
+	// Servers are set up by hand, never started
+	m := NewManager(nil, nil, nil)
+	m.servers["git"] = &managedServer{name: "git", config: ServerConfig{Transport: "bundled"}}
+	m.servers["fs"] = &managedServer{name: "fs", config: ServerConfig{Transport: "bundled"}}
+	m.servers["tools"] = &managedServer{name: "tools", config: ServerConfig{Transport: "stdio"}}
+
+	cases := map[string]string{
+		"mcp.git.status":  "bundled:git",
+		"mcp.fs.read":     "bundled:filesystem",
+		"mcp.tools.git":   "",
+		"mcp.other.thing": "",
+	}
+	for tool, want := range cases {
+		if got := m.ToolProvider(tool); got != want {
+			t.Errorf("ToolProvider(%s) = %q, want %q", tool, got, want)
+		}
+	}
+
+	m.servers["git"].config.Transport = "stdio"
+	if got := m.ToolProvider("mcp.git.status"); got != "" {
+		t.Errorf("expected a configured server named git to have no provider, got %q", got)
+	}
+}
+
+func TestGitProvider_ReadOnlyAnnotations(t *testing.T) {
+	tools, err := NewGitProvider().ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	for _, tool := range tools {
+		readOnly := tool.Annotations != nil && tool.Annotations.ReadOnlyHint
+		mutating := tool.Name == "add" || tool.Name == "unstage" || tool.Name == "commit"
+		if readOnly == mutating {
+			t.Errorf("%s: readOnlyHint=%v", tool.Name, readOnly)
+		}
+	}
+}
@@ -10,26 +10,29 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
+
+	"pryx-core/internal/mcp/security"
+	"pryx-core/internal/policy"
 )
 
 type ShellProvider struct {
-	root string
+	root    string
+	sandbox *security.Sandbox
+
+	mu          sync.Mutex
+	sessions    map[string]*shellSession
+	unsubscribe func()
 }
 
 func NewShellProvider() *ShellProvider {
-	root := strings.TrimSpace(os.Getenv("PRYX_WORKSPACE_ROOT"))
-	if root == "" {
-		if cwd, err := os.Getwd(); err == nil {
-			root = cwd
-		}
-	}
-	if root != "" {
-		if abs, err := filepath.Abs(root); err == nil {
-			root = abs
-		}
-	}
-	return &ShellProvider{root: root}
+	return &ShellProvider{root: policy.WorkspaceRoot(), sessions: map[string]*shellSession{}}
+}
+
+// SetSandbox enforces sb on every command and shell session it launches.
+func (p *ShellProvider) SetSandbox(sb *security.Sandbox) {
+	p.sandbox = sb
 }
 
 func (p *ShellProvider) ServerInfo() map[string]interface{} {
@@ -44,6 +47,11 @@ func (p *ShellProvider) ListTools(ctx context.Context) ([]Tool, error) {
 	_ = ctx
 	return []Tool{
 		{Name: "exec", Title: "Execute Command", InputSchema: schemaRaw(`{"type":"object","properties":{"command":{"type":"string"},"args":{"type":"array","items":{"type":"string"}},"cwd":{"type":"string"},"timeout_ms":{"type":"integer"},"env":{"type":"object","additionalProperties":{"type":"string"}}},"additionalProperties":false}`)},
+		{Name: "open_session", Title: "Open Shell Session", Description: "Start a persistent interactive shell on a pseudo-terminal. It keeps its working directory, environment and background processes between calls and is closed after idle_timeout_s without use.", InputSchema: schemaRaw(`{"type":"object","properties":{"shell":{"type":"string"},"cwd":{"type":"string"},"env":{"type":"object","additionalProperties":{"type":"string"}},"cols":{"type":"integer","minimum":20,"maximum":500,"default":120},"rows":{"type":"integer","minimum":5,"maximum":200,"default":40},"idle_timeout_s":{"type":"integer","minimum":1,"maximum":86400,"default":1800}},"additionalProperties":false}`)},
+		{Name: "send_input", Title: "Send Shell Input", Description: "Write input to a shell session, followed by a newline unless newline is false. With wait_ms, also returns the output that arrives within that time.", InputSchema: schemaRaw(`{"type":"object","properties":{"session_id":{"type":"string"},"input":{"type":"string"},"newline":{"type":"boolean","default":true},"wait_ms":{"type":"integer","minimum":0,"maximum":30000}},"required":["session_id","input"],"additionalProperties":false}`)},
+		{Name: "read_output", Title: "Read Shell Output", Description: "Read output from a shell session. Without a cursor it continues where the last read stopped; wait_ms waits for new output.", Annotations: &ToolAnnotations{ReadOnlyHint: true}, InputSchema: schemaRaw(`{"type":"object","properties":{"session_id":{"type":"string"},"cursor":{"type":"integer","minimum":0},"wait_ms":{"type":"integer","minimum":0,"maximum":30000}},"required":["session_id"],"additionalProperties":false}`)},
+		{Name: "signal", Title: "Signal Shell Session", Description: "Send a signal to a shell session. INT and QUIT go to the foreground job; TERM, HUP and KILL go to the shell's process group.", InputSchema: schemaRaw(`{"type":"object","properties":{"session_id":{"type":"string"},"signal":{"type":"string","enum":["INT","TERM","KILL","HUP","QUIT"],"default":"INT"}},"required":["session_id"],"additionalProperties":false}`)},
+		{Name: "close_session", Title: "Close Shell Session", Annotations: &ToolAnnotations{DestructiveHint: boolPtr(true)}, InputSchema: schemaRaw(`{"type":"object","properties":{"session_id":{"type":"string"}},"required":["session_id"],"additionalProperties":false}`)},
 	}, nil
 }
 
@@ -51,6 +59,16 @@ func (p *ShellProvider) CallTool(ctx context.Context, name string, arguments map
 	switch name {
 	case "exec":
 		return p.exec(ctx, arguments)
+	case "open_session":
+		return p.openSession(ctx, arguments)
+	case "send_input":
+		return p.sendInput(ctx, arguments)
+	case "read_output":
+		return p.readOutput(ctx, arguments)
+	case "signal":
+		return p.signal(ctx, arguments)
+	case "close_session":
+		return p.closeSession(ctx, arguments)
 	default:
 		return ToolResult{}, errors.New("unknown tool")
 	}
@@ -107,7 +125,12 @@ func (p *ShellProvider) exec(ctx context.Context, arguments map[string]interface
 	}
 	cmd.Dir = cwd
 
-	if len(env) > 0 {
+	if p.sandbox != nil {
+		if err := p.sandbox.PrepareCmd(cmd, env); err != nil {
+			return ToolResult{}, err
+		}
+		cwd = cmd.Dir
+	} else if len(env) > 0 {
 		cmd.Env = append(os.Environ(), flattenEnv(env)...)
 	}
 
@@ -115,7 +138,10 @@ func (p *ShellProvider) exec(ctx context.Context, arguments map[string]interface
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err := p.run(cmd)
+	if _, ok := security.AsViolation(err); ok {
+		return ToolResult{}, err
+	}
 	exitCode := 0
 	if err != nil {
 		var ee *exec.ExitError
@@ -145,6 +171,16 @@ func (p *ShellProvider) exec(ctx context.Context, arguments map[string]interface
 	}, nil
 }
 
+func (p *ShellProvider) run(cmd *exec.Cmd) error {
+	if p.sandbox == nil {
+		return cmd.Run()
+	}
+	if err := p.sandbox.Start(cmd); err != nil {
+		return err
+	}
+	return cmd.Wait()
+}
+
 func (p *ShellProvider) resolveCwd(raw string) (string, error) {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
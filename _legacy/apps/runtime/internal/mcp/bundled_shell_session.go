@@ -0,0 +1,503 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"pryx-core/internal/bus"
+
+	"github.com/google/uuid"
+)
+
+const (
+	shellSessionBuffer      = 1 << 20
+	shellSessionsPerChat    = 8
+	shellDefaultIdleTimeout = 30 * time.Minute
+	shellMaxIdleTimeout     = 24 * time.Hour
+	shellMaxWait            = 30 * time.Second
+	shellCloseGrace         = 2 * time.Second
+)
+
+var errShellSessionNotFound = errors.New("shell session not found")
+
+// shellSession is a long-lived shell attached to a pseudo-terminal. Output is
+// kept in a bounded buffer addressed by absolute byte offsets, so readers
+// can resume from a cursor and learn how much was dropped.
+type shellSession struct {
+	id          string
+	chatSession string
+	shell       string
+	cwd         string
+	cmd         *exec.Cmd
+	term        io.ReadWriteCloser
+	idle        time.Duration
+
+	mu         sync.Mutex
+	buf        []byte
+	base       int64 // offset of buf[0]
+	readPos    int64 // where the next read without a cursor starts
+	changed    chan struct{}
+	lastActive time.Time
+	exited     bool
+	exitCode   int
+	done       chan struct{}
+	idleTimer  *time.Timer
+}
+
+// SetBus closes a chat session's shell sessions when it ends.
+func (p *ShellProvider) SetBus(b *bus.Bus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.unsubscribe != nil {
+		p.unsubscribe()
+		p.unsubscribe = nil
+	}
+	if b == nil {
+		return
+	}
+	events, cancel := b.Subscribe(bus.EventSessionEnded)
+	p.unsubscribe = cancel
+	go func() {
+		for evt := range events {
+			p.closeChatSessions(evt.SessionID)
+		}
+	}()
+}
+
+// Close terminates every shell session.
+func (p *ShellProvider) Close() error {
+	p.mu.Lock()
+	if p.unsubscribe != nil {
+		p.unsubscribe()
+		p.unsubscribe = nil
+	}
+	sessions := make([]*shellSession, 0, len(p.sessions))
+	for _, s := range p.sessions {
+		sessions = append(sessions, s)
+	}
+	p.sessions = map[string]*shellSession{}
+	p.mu.Unlock()
+
+	for _, s := range sessions {
+		s.terminate()
+	}
+	return nil
+}
+
+func (p *ShellProvider) closeChatSessions(chatSession string) {
+	p.mu.Lock()
+	var closing []*shellSession
+	for id, s := range p.sessions {
+		if s.chatSession == chatSession {
+			closing = append(closing, s)
+			delete(p.sessions, id)
+		}
+	}
+	p.mu.Unlock()
+	for _, s := range closing {
+		s.terminate()
+	}
+}
+
+func (p *ShellProvider) openSession(ctx context.Context, arguments map[string]interface{}) (ToolResult, error) {
+	chatSession := SessionIDFromContext(ctx)
+	p.mu.Lock()
+	count := 0
+	for _, s := range p.sessions {
+		if s.chatSession == chatSession {
+			count++
+		}
+	}
+	p.mu.Unlock()
+	if count >= shellSessionsPerChat {
+		return ToolResult{}, fmt.Errorf("too many shell sessions (limit %d); close one first", shellSessionsPerChat)
+	}
+
+	shell := strings.TrimSpace(argString(arguments, "shell"))
+	if shell == "" {
+		shell = defaultShell()
+	}
+	cwd, err := p.resolveCwd(argString(arguments, "cwd"))
+	if err != nil {
+		return ToolResult{}, err
+	}
+	idle := time.Duration(argInt(arguments, "idle_timeout_s")) * time.Second
+	if idle <= 0 {
+		idle = shellDefaultIdleTimeout
+	} else if idle > shellMaxIdleTimeout {
+		idle = shellMaxIdleTimeout
+	}
+
+	// Pagers and colour make terminal output hard to read back
+	env := map[string]string{"TERM": "dumb", "PAGER": "cat", "GIT_PAGER": "cat", "NO_COLOR": "1"}
+	if raw, ok := arguments["env"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			if s, ok := v.(string); ok {
+				env[k] = s
+			}
+		}
+	}
+
+	cmd := exec.Command(shell)
+	cmd.Dir = cwd
+	if p.sandbox != nil {
+		if err := p.sandbox.PrepareCmd(cmd, env); err != nil {
+			return ToolResult{}, err
+		}
+		cwd = cmd.Dir
+	} else {
+		cmd.Env = append(os.Environ(), flattenEnv(env)...)
+	}
+
+	term, release, err := attachTerminal(cmd, argInt(arguments, "cols", 120), argInt(arguments, "rows", 40))
+	if err != nil {
+		return ToolResult{}, err
+	}
+	if p.sandbox != nil {
+		err = p.sandbox.Start(cmd)
+	} else {
+		err = cmd.Start()
+	}
+	release()
+	if err != nil {
+		_ = term.Close()
+		return ToolResult{}, err
+	}
+
+	s := &shellSession{
+		id:          "sh_" + uuid.NewString()[:8],
+		chatSession: chatSession,
+		shell:       shell,
+		cwd:         cwd,
+		cmd:         cmd,
+		term:        term,
+		idle:        idle,
+		changed:     make(chan struct{}),
+		lastActive:  time.Now(),
+		done:        make(chan struct{}),
+	}
+	go s.pump()
+	s.idleTimer = time.AfterFunc(idle, func() { p.expire(s) })
+
+	p.mu.Lock()
+	p.sessions[s.id] = s
+	p.mu.Unlock()
+
+	return ToolResult{
+		Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("opened shell session %s (%s in %s)", s.id, shell, cwd)}},
+		StructuredContent: jsonRaw(map[string]interface{}{
+			"session_id":     s.id,
+			"pid":            cmd.Process.Pid,
+			"shell":          shell,
+			"cwd":            cwd,
+			"pty":            terminalSupported,
+			"idle_timeout_s": int(idle / time.Second),
+		}),
+	}, nil
+}
+
+func (p *ShellProvider) session(ctx context.Context, arguments map[string]interface{}) (*shellSession, error) {
+	id := strings.TrimSpace(argString(arguments, "session_id"))
+	p.mu.Lock()
+	s, ok := p.sessions[id]
+	p.mu.Unlock()
+	// Sessions are private to the chat session that opened them
+	if !ok || s.chatSession != SessionIDFromContext(ctx) {
+		return nil, errShellSessionNotFound
+	}
+	s.touch()
+	return s, nil
+}
+
+func (p *ShellProvider) sendInput(ctx context.Context, arguments map[string]interface{}) (ToolResult, error) {
+	s, err := p.session(ctx, arguments)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	input := argString(arguments, "input")
+	newline := true
+	if _, ok := arguments["newline"]; ok {
+		newline = argBool(arguments, "newline")
+	}
+	if newline {
+		input += "\n"
+	}
+	if s.isExited() {
+		return ToolResult{}, errors.New("shell session has exited")
+	}
+	n, err := io.WriteString(s.term, input)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	if wait := argInt(arguments, "wait_ms"); wait > 0 {
+		return s.read(arguments, time.Duration(wait)*time.Millisecond)
+	}
+	return ToolResult{
+		Content:           []ToolContent{{Type: "text", Text: fmt.Sprintf("sent %d bytes", n)}},
+		StructuredContent: jsonRaw(map[string]interface{}{"session_id": s.id, "bytes": n}),
+	}, nil
+}
+
+func (p *ShellProvider) readOutput(ctx context.Context, arguments map[string]interface{}) (ToolResult, error) {
+	s, err := p.session(ctx, arguments)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	return s.read(arguments, time.Duration(argInt(arguments, "wait_ms"))*time.Millisecond)
+}
+
+var shellSignals = map[string]syscall.Signal{
+	"INT":  syscall.SIGINT,
+	"TERM": syscall.SIGTERM,
+	"KILL": syscall.SIGKILL,
+	"HUP":  syscall.SIGHUP,
+	"QUIT": syscall.SIGQUIT,
+}
+
+// Control characters let the terminal deliver these signals to the
+// foreground job rather than the shell itself
+var shellSignalChars = map[string]string{
+	"INT":  "\x03",
+	"QUIT": "\x1c",
+}
+
+func (p *ShellProvider) signal(ctx context.Context, arguments map[string]interface{}) (ToolResult, error) {
+	s, err := p.session(ctx, arguments)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	name := strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(argString(arguments, "signal")), "SIG"))
+	if name == "" {
+		name = "INT"
+	}
+	sig, ok := shellSignals[name]
+	if !ok {
+		return ToolResult{}, fmt.Errorf("unsupported signal: %s", name)
+	}
+	if s.isExited() {
+		return ToolResult{}, errors.New("shell session has exited")
+	}
+	if ch, ok := shellSignalChars[name]; ok && terminalSupported {
+		_, err = io.WriteString(s.term, ch)
+	} else {
+		err = signalProcessGroup(s.cmd.Process, sig)
+	}
+	if err != nil {
+		return ToolResult{}, err
+	}
+	return ToolResult{Content: []ToolContent{{Type: "text", Text: "sent SIG" + name}}}, nil
+}
+
+func (p *ShellProvider) closeSession(ctx context.Context, arguments map[string]interface{}) (ToolResult, error) {
+	s, err := p.session(ctx, arguments)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	p.mu.Lock()
+	delete(p.sessions, s.id)
+	p.mu.Unlock()
+	s.terminate()
+
+	s.mu.Lock()
+	code := s.exitCode
+	s.mu.Unlock()
+	return ToolResult{
+		Content:           []ToolContent{{Type: "text", Text: "closed shell session " + s.id}},
+		StructuredContent: jsonRaw(map[string]interface{}{"session_id": s.id, "exit_code": code}),
+	}, nil
+}
+
+// expire closes s once it has been idle for its timeout
+func (p *ShellProvider) expire(s *shellSession) {
+	s.mu.Lock()
+	remaining := s.idle - time.Since(s.lastActive)
+	s.mu.Unlock()
+	if remaining > 0 {
+		s.idleTimer.Reset(remaining)
+		return
+	}
+	p.mu.Lock()
+	if p.sessions[s.id] == s {
+		delete(p.sessions, s.id)
+	}
+	p.mu.Unlock()
+	s.terminate()
+}
+
+func (s *shellSession) touch() {
+	s.mu.Lock()
+	s.lastActive = time.Now()
+	s.mu.Unlock()
+}
+
+func (s *shellSession) isExited() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.exited
+}
+
+// pump copies terminal output into the buffer until the shell exits
+func (s *shellSession) pump() {
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := s.term.Read(chunk)
+		if n > 0 {
+			s.append(chunk[:n])
+		}
+		if err != nil {
+			break
+		}
+	}
+	err := s.cmd.Wait()
+	code := 0
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			code = ee.ExitCode()
+		} else {
+			code = -1
+		}
+	}
+	s.mu.Lock()
+	s.exited = true
+	s.exitCode = code
+	s.notifyLocked()
+	s.mu.Unlock()
+	_ = s.term.Close()
+	close(s.done)
+}
+
+func (s *shellSession) append(b []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf = append(s.buf, b...)
+	if over := len(s.buf) - shellSessionBuffer; over > 0 {
+		s.buf = append(s.buf[:0:0], s.buf[over:]...)
+		s.base += int64(over)
+	}
+	s.notifyLocked()
+}
+
+func (s *shellSession) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// read returns output after the cursor, waiting up to wait for some to
+// arrive. Without a cursor it continues from the previous read.
+func (s *shellSession) read(arguments map[string]interface{}, wait time.Duration) (ToolResult, error) {
+	if wait > shellMaxWait {
+		wait = shellMaxWait
+	}
+	s.mu.Lock()
+	cursor := s.readPos
+	if _, ok := arguments["cursor"]; ok {
+		cursor = int64(argInt(arguments, "cursor"))
+	}
+	deadline := time.Now().Add(wait)
+	for cursor >= s.base+int64(len(s.buf)) && !s.exited {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		timer := time.NewTimer(remaining)
+		select {
+		case <-changed:
+		case <-timer.C:
+		}
+		timer.Stop()
+		s.mu.Lock()
+	}
+
+	end := s.base + int64(len(s.buf))
+	dropped := int64(0)
+	if cursor < s.base {
+		dropped = s.base - cursor
+		cursor = s.base
+	}
+	if cursor > end {
+		cursor = end
+	}
+	data := string(s.buf[cursor-s.base:])
+	s.readPos = end
+	exited, code := s.exited, s.exitCode
+	s.mu.Unlock()
+
+	text := cleanTerminalOutput(data)
+	if dropped > 0 {
+		text = fmt.Sprintf("... %d earlier bytes were dropped\n", dropped) + text
+	}
+	out := map[string]interface{}{
+		"session_id": s.id,
+		"cursor":     end,
+		"bytes":      len(data),
+		"running":    !exited,
+	}
+	if dropped > 0 {
+		out["dropped"] = dropped
+	}
+	if exited {
+		out["exit_code"] = code
+		text += fmt.Sprintf("\n[shell exited with code %d]", code)
+	}
+	return ToolResult{
+		Content:           []ToolContent{{Type: "text", Text: text}},
+		StructuredContent: jsonRaw(out),
+	}, nil
+}
+
+// terminate hangs up the shell, killing it if it does not exit in time
+func (s *shellSession) terminate() {
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+	}
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	_ = signalProcessGroup(s.cmd.Process, syscall.SIGHUP)
+	select {
+	case <-s.done:
+	case <-time.After(shellCloseGrace):
+		_ = signalProcessGroup(s.cmd.Process, syscall.SIGKILL)
+		_ = s.term.Close()
+		// Jobs the shell moved to other process groups can keep the
+		// terminal open; don't wait on them forever.
+		select {
+		case <-s.done:
+		case <-time.After(shellCloseGrace):
+		}
+	}
+}
+
+var terminalEscapes = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// cleanTerminalOutput strips escape sequences and carriage returns
+func cleanTerminalOutput(s string) string {
+	s = terminalEscapes.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "")
+}
+
+func defaultShell() string {
+	if runtime.GOOS == "windows" {
+		return "cmd.exe"
+	}
+	if sh := strings.TrimSpace(os.Getenv("SHELL")); sh != "" {
+		return sh
+	}
+	return "/bin/sh"
+}
@@ -0,0 +1,169 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"pryx-core/internal/bus"
+)
+
+func openTestShell(t *testing.T) (*ShellProvider, context.Context, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell sessions use a POSIX shell in this test")
+	}
+	root := t.TempDir()
+	t.Setenv("PRYX_WORKSPACE_ROOT", root)
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	p := NewShellProvider()
+	t.Cleanup(func() { _ = p.Close() })
+
+	ctx := WithSessionID(context.Background(), "chat-1")
+	res, err := p.CallTool(ctx, "open_session", map[string]interface{}{"shell": "/bin/sh"})
+	if err != nil {
+		t.Fatalf("open_session: %v", err)
+	}
+	var out struct {
+		SessionID string `json:"session_id"`
+	}
+	_ = json.Unmarshal(res.StructuredContent, &out)
+	return p, ctx, out.SessionID
+}
+
+// waitForOutput reads until want appears or the deadline passes
+func waitForOutput(t *testing.T, p *ShellProvider, ctx context.Context, id, want string) string {
+	t.Helper()
+	var seen strings.Builder
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		res, err := p.CallTool(ctx, "read_output", map[string]interface{}{"session_id": id, "wait_ms": 200})
+		if err != nil {
+			t.Fatalf("read_output: %v", err)
+		}
+		seen.WriteString(res.Content[0].Text)
+		if strings.Contains(seen.String(), want) {
+			return seen.String()
+		}
+	}
+	t.Fatalf("output never contained %q:\n%s", want, seen.String())
+	return ""
+}
+
+func TestShellSession_KeepsState(t *testing.T) {
+	p, ctx, id := openTestShell(t)
+
+	for _, input := range []string{"cd sub", "export GREETING=hello", "echo \"$GREETING from $(basename \"$PWD\")\""} {
+		if _, err := p.CallTool(ctx, "send_input", map[string]interface{}{"session_id": id, "input": input}); err != nil {
+			t.Fatalf("send_input: %v", err)
+		}
+	}
+	waitForOutput(t, p, ctx, id, "hello from sub")
+
+	// Other chat sessions cannot see it
+	other := WithSessionID(context.Background(), "chat-2")
+	if _, err := p.CallTool(other, "read_output", map[string]interface{}{"session_id": id}); err != errShellSessionNotFound {
+		t.Fatalf("expected session to be private, got %v", err)
+	}
+
+	// An explicit cursor rereads earlier output
+	res, err := p.CallTool(ctx, "read_output", map[string]interface{}{"session_id": id, "cursor": 0})
+	if err != nil || !strings.Contains(res.Content[0].Text, "hello from sub") {
+		t.Fatalf("reread from cursor 0: %v %q", err, res.Content[0].Text)
+	}
+
+	res, err = p.CallTool(ctx, "close_session", map[string]interface{}{"session_id": id})
+	if err != nil {
+		t.Fatalf("close_session: %v", err)
+	}
+	if _, err := p.CallTool(ctx, "read_output", map[string]interface{}{"session_id": id}); err != errShellSessionNotFound {
+		t.Fatalf("expected closed session to be gone, got %v", err)
+	}
+}
+
+func TestShellSession_SignalInterruptsForegroundJob(t *testing.T) {
+	if !terminalSupported {
+		t.Skip("needs a pseudo-terminal")
+	}
+	p, ctx, id := openTestShell(t)
+
+	if _, err := p.CallTool(ctx, "send_input", map[string]interface{}{"session_id": id, "input": "sleep 30; echo after-$((1+1))"}); err != nil {
+		t.Fatalf("send_input: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if _, err := p.CallTool(ctx, "signal", map[string]interface{}{"session_id": id, "signal": "INT"}); err != nil {
+		t.Fatalf("signal: %v", err)
+	}
+	if _, err := p.CallTool(ctx, "send_input", map[string]interface{}{"session_id": id, "input": "echo still-alive"}); err != nil {
+		t.Fatalf("send_input: %v", err)
+	}
+	out := waitForOutput(t, p, ctx, id, "still-alive\n")
+	if strings.Contains(out, "after-2") {
+		t.Fatalf("sleep was not interrupted:\n%s", out)
+	}
+}
+
+func TestShellSession_ClosedWhenChatSessionEnds(t *testing.T) {
+	p, ctx, id := openTestShell(t)
+	b := bus.New()
+	p.SetBus(b)
+
+	p.mu.Lock()
+	s := p.sessions[id]
+	p.mu.Unlock()
+
+	b.Publish(bus.NewEvent(bus.EventSessionEnded, "chat-1", nil))
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shell was not terminated when the chat session ended")
+	}
+	if _, err := p.CallTool(ctx, "read_output", map[string]interface{}{"session_id": id}); err != errShellSessionNotFound {
+		t.Fatalf("expected session to be removed, got %v", err)
+	}
+}
+
+func TestShellSession_IdleTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell sessions use a POSIX shell in this test")
+	}
+	t.Setenv("PRYX_WORKSPACE_ROOT", t.TempDir())
+	p := NewShellProvider()
+	defer p.Close()
+
+	ctx := WithSessionID(context.Background(), "chat-1")
+	res, err := p.CallTool(ctx, "open_session", map[string]interface{}{"shell": "/bin/sh", "idle_timeout_s": 1})
+	if err != nil {
+		t.Fatalf("open_session: %v", err)
+	}
+	var out struct {
+		SessionID string `json:"session_id"`
+	}
+	_ = json.Unmarshal(res.StructuredContent, &out)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		p.mu.Lock()
+		_, ok := p.sessions[out.SessionID]
+		p.mu.Unlock()
+		if !ok {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("idle session was not closed")
+}
+
+func TestCleanTerminalOutput(t *testing.T) {
+	got := cleanTerminalOutput("\x1b[1;32mok\x1b[0m\r\n\x1b]0;title\x07done\r")
+	if got != "ok\ndone" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,6 +17,22 @@ type Transport interface {
 	Close() error
 }
 
+// serverMessageHandler receives the requests and notifications a server
+// sends on its own initiative. Transports that can carry them implement
+// messageTransport.
+type serverMessageHandler interface {
+	handleRequest(ctx context.Context, method string, params json.RawMessage) (interface{}, *RPCError)
+	handleNotification(method string, params json.RawMessage)
+}
+
+type messageTransport interface {
+	setMessageHandler(h serverMessageHandler)
+}
+
+// supportedProtocolVersions lists the protocol versions the client can
+// speak, newest first.
+var supportedProtocolVersions = []string{"2025-11-25", "2025-06-18", "2025-03-26", "2024-11-05"}
+
 type Client struct {
 	transport       Transport
 	protocolVersion string
@@ -23,18 +40,86 @@ type Client struct {
 	initialized atomic.Bool
 	idCounter   atomic.Int64
 
+	// initMu serializes initialize, so callers that find the session
+	// expired together start one new session. sessions counts the sessions
+	// started.
+	initMu   sync.Mutex
+	sessions atomic.Int64
+
 	mu                 sync.RWMutex
 	serverCapabilities json.RawMessage
+	negotiatedVersion  string
+	onToolsChanged     func()
+	hooks              ClientHooks
+	inflight           map[string]*inflightCalls
+}
+
+// ClientHooks answer the requests a server makes of the client. A
+// capability is advertised on initialize only when its hook is set, so
+// hooks must be set before the first call.
+type ClientHooks struct {
+	// Roots lists the directories the server may work in
+	Roots func(ctx context.Context) []Root
+	// Elicit asks the user for the input a server requests
+	Elicit func(ctx context.Context, req ElicitRequest) (ElicitResult, error)
+}
+
+// inflightCalls counts the tool calls a session has in flight on a client
+type inflightCalls struct {
+	calls   int
+	surface string
 }
 
 func NewClient(transport Transport, protocolVersion string) *Client {
 	if protocolVersion == "" {
 		protocolVersion = "2025-11-25"
 	}
-	return &Client{
+	c := &Client{
 		transport:       transport,
 		protocolVersion: protocolVersion,
 	}
+	if mt, ok := transport.(messageTransport); ok {
+		mt.setMessageHandler(c)
+	}
+	return c
+}
+
+// OnToolsChanged registers fn to run when the server announces that its
+// tool list changed.
+func (c *Client) OnToolsChanged(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onToolsChanged = fn
+}
+
+// SetHooks installs the handlers for server requests.
+func (c *Client) SetHooks(h ClientHooks) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = h
+}
+
+// NotifyRootsChanged tells the server the roots list changed, if the
+// client advertised roots.
+func (c *Client) NotifyRootsChanged(ctx context.Context) error {
+	c.mu.RLock()
+	advertised := c.hooks.Roots != nil
+	c.mu.RUnlock()
+	if !advertised || !c.initialized.Load() {
+		return nil
+	}
+	return c.transport.Notify(ctx, RPCNotification{JSONRPC: "2.0", Method: "notifications/roots/list_changed"})
+}
+
+// ProtocolVersion returns the version agreed with the server on initialize,
+// or the requested one before that.
+func (c *Client) ProtocolVersion() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.negotiatedVersion != "" {
+		return c.negotiatedVersion
+	}
+	return c.protocolVersion
 }
 
 func (c *Client) Close() error {
@@ -45,15 +130,34 @@ func (c *Client) Initialize(ctx context.Context) error {
 	if c.initialized.Load() {
 		return nil
 	}
+	c.initMu.Lock()
+	defer c.initMu.Unlock()
+	return c.initializeLocked(ctx)
+}
+
+// renewSession starts a new session after the server dropped session
+// number expired. If another caller already replaced it, it does nothing.
+func (c *Client) renewSession(ctx context.Context, expired int64) error {
+	c.initMu.Lock()
+	defer c.initMu.Unlock()
+	if c.sessions.Load() != expired {
+		return nil
+	}
+	c.initialized.Store(false)
+	return c.initializeLocked(ctx)
+}
+
+func (c *Client) initializeLocked(ctx context.Context) error {
+	if c.initialized.Load() {
+		return nil
+	}
 
 	initCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	params := map[string]interface{}{
 		"protocolVersion": c.protocolVersion,
-		"capabilities": map[string]interface{}{
-			"tools": map[string]interface{}{},
-		},
+		"capabilities":    c.capabilities(),
 		"clientInfo": map[string]interface{}{
 			"name":    "pryx-core",
 			"version": "dev",
@@ -61,27 +165,50 @@ func (c *Client) Initialize(ctx context.Context) error {
 	}
 
 	var result struct {
-		Capabilities json.RawMessage `json:"capabilities"`
+		ProtocolVersion string          `json:"protocolVersion"`
+		Capabilities    json.RawMessage `json:"capabilities"`
 	}
 	if err := c.call(initCtx, "initialize", params, &result); err != nil {
 		return err
 	}
+	// The server answers with the requested version or one it prefers;
+	// older servers leave it out.
+	if result.ProtocolVersion != "" && !slices.Contains(supportedProtocolVersions, result.ProtocolVersion) {
+		return fmt.Errorf("unsupported protocol version %q", result.ProtocolVersion)
+	}
 
 	c.mu.Lock()
 	c.serverCapabilities = result.Capabilities
+	c.negotiatedVersion = result.ProtocolVersion
 	c.mu.Unlock()
 
 	if err := c.transport.Notify(initCtx, RPCNotification{
 		JSONRPC: "2.0",
-		Method:  "initialized",
+		Method:  "notifications/initialized",
 	}); err != nil {
 		return err
 	}
 
+	c.sessions.Add(1)
 	c.initialized.Store(true)
 	return nil
 }
 
+func (c *Client) capabilities() map[string]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	caps := map[string]interface{}{
+		"tools": map[string]interface{}{},
+	}
+	if c.hooks.Roots != nil {
+		caps["roots"] = map[string]interface{}{"listChanged": true}
+	}
+	if c.hooks.Elicit != nil {
+		caps["elicitation"] = map[string]interface{}{}
+	}
+	return caps
+}
+
 func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
 	if err := c.Initialize(ctx); err != nil {
 		return nil, err
@@ -113,6 +240,7 @@ func (c *Client) CallTool(ctx context.Context, name string, arguments map[string
 	if err := c.Initialize(ctx); err != nil {
 		return ToolResult{}, err
 	}
+	defer c.trackCall(ctx)()
 	params := map[string]interface{}{
 		"name":      name,
 		"arguments": arguments,
@@ -142,12 +270,21 @@ func (c *Client) call(ctx context.Context, method string, params interface{}, ou
 		Method:  method,
 		Params:  params,
 	}
+	session := c.sessions.Load()
 	resp, err := c.transport.Call(ctx, req)
+	if errors.Is(err, errSessionExpired) && method != "initialize" {
+		// The server dropped the session; start a new one and retry once
+		if err := c.renewSession(ctx, session); err != nil {
+			return err
+		}
+		req.ID = c.idCounter.Add(1)
+		resp, err = c.transport.Call(ctx, req)
+	}
 	if err != nil {
 		return err
 	}
 	if resp.Error != nil {
-		return fmt.Errorf("mcp error %d: %s", resp.Error.Code, resp.Error.Message)
+		return resp.Error.err()
 	}
 	if len(resp.Result) == 0 {
 		return errors.New("empty result")
@@ -160,3 +297,119 @@ func (c *Client) call(ctx context.Context, method string, params interface{}, ou
 	}
 	return nil
 }
+
+func (c *Client) handleRequest(ctx context.Context, method string, params json.RawMessage) (interface{}, *RPCError) {
+	c.mu.RLock()
+	hooks := c.hooks
+	c.mu.RUnlock()
+	ctx = c.callerContext(ctx)
+
+	switch {
+	case method == "ping":
+		return map[string]interface{}{}, nil
+	case method == "roots/list" && hooks.Roots != nil:
+		roots := hooks.Roots(ctx)
+		if roots == nil {
+			roots = []Root{}
+		}
+		return map[string]interface{}{"roots": roots}, nil
+	case method == "elicitation/create" && hooks.Elicit != nil:
+		var req ElicitRequest
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, &RPCError{Code: -32602, Message: "invalid params: " + err.Error()}
+		}
+		res, err := hooks.Elicit(ctx, req)
+		if err != nil {
+			return nil, &RPCError{Code: -32602, Message: err.Error()}
+		}
+		return res, nil
+	default:
+		return nil, &RPCError{Code: -32601, Message: "method not found"}
+	}
+}
+
+// trackCall records a tool call of the calling session as in flight until
+// the returned func runs.
+func (c *Client) trackCall(ctx context.Context) func() {
+	sessionID := SessionIDFromContext(ctx)
+	if sessionID == "" {
+		return func() {}
+	}
+	c.mu.Lock()
+	if c.inflight == nil {
+		c.inflight = map[string]*inflightCalls{}
+	}
+	f := c.inflight[sessionID]
+	if f == nil {
+		f = &inflightCalls{}
+		c.inflight[sessionID] = f
+	}
+	f.calls++
+	f.surface = SurfaceFromContext(ctx)
+	c.mu.Unlock()
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if f.calls--; f.calls == 0 {
+			delete(c.inflight, sessionID)
+		}
+	}
+}
+
+// callerContext attributes a server request to the session it serves.
+// Requests that arrive on a call's own response stream carry its context;
+// others, such as those over stdio, are attributed when exactly one
+// session has calls in flight.
+func (c *Client) callerContext(ctx context.Context) context.Context {
+	if SessionIDFromContext(ctx) != "" {
+		return ctx
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.inflight) != 1 {
+		return ctx
+	}
+	for sessionID, f := range c.inflight {
+		ctx = WithSessionID(ctx, sessionID)
+		if f.surface != "" && SurfaceFromContext(ctx) == "" {
+			ctx = WithSurface(ctx, f.surface)
+		}
+	}
+	return ctx
+}
+
+func (c *Client) handleNotification(method string, params json.RawMessage) {
+	_ = params
+	switch method {
+	case "notifications/tools/list_changed":
+		c.mu.RLock()
+		fn := c.onToolsChanged
+		c.mu.RUnlock()
+		if fn != nil {
+			fn()
+		}
+	}
+}
+
+// answerServerRequest builds the reply to a server request. Without a
+// handler every method is reported as not found.
+func answerServerRequest(ctx context.Context, h serverMessageHandler, msg rpcMessage) RPCResponse {
+	resp := RPCResponse{JSONRPC: "2.0", ID: msg.ID}
+	if h == nil {
+		resp.Error = &RPCError{Code: -32601, Message: "method not found"}
+		return resp
+	}
+	result, rpcErr := h.handleRequest(ctx, msg.Method, msg.Params)
+	if rpcErr != nil {
+		resp.Error = rpcErr
+		return resp
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &RPCError{Code: -32603, Message: err.Error()}
+		return resp
+	}
+	resp.Result = b
+	return resp
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"pryx-core/internal/mcp/security"
 )
 
 type ServersConfig struct {
@@ -21,11 +23,41 @@ type ServerConfig struct {
 	Headers         map[string]string `json:"headers,omitempty"`
 	ProtocolVersion string            `json:"protocol_version,omitempty"`
 	Auth            *AuthConfig       `json:"auth,omitempty"`
+	// Sandbox restricts how stdio servers and the bundled shell launch processes,
+	// which paths the bundled filesystem server may touch, and which hosts the
+	// bundled fetch server may reach. Outbound HTTP(S) is off in a sandbox
+	// unless it sets allow_outbound_http or allow_outbound_https.
+	Sandbox *security.SandboxConfig `json:"sandbox,omitempty"`
+	// Validation selects how tool arguments and structured results are
+	// checked against the server's schemas: "strict", "off", or empty for
+	// the default of rejecting bad arguments and reporting bad results.
+	Validation string `json:"validation,omitempty"`
+	// Cache opts tools of this server into result caching.
+	Cache *CacheConfig `json:"cache,omitempty"`
+	// CallTimeoutSeconds bounds each request to an http server when the
+	// caller set no deadline: 0 means two minutes, a negative value no
+	// limit.
+	CallTimeoutSeconds int `json:"call_timeout_seconds,omitempty"`
+}
+
+// CacheConfig caches the results of a server's tools. Only tools named in
+// Tools ("*" for all) that the server annotates readOnlyHint or
+// idempotentHint are cached. Calling any tool not marked read-only clears
+// the server's cached results.
+type CacheConfig struct {
+	Tools          []string       `json:"tools"`
+	TTLSeconds     int            `json:"ttl_seconds,omitempty"`
+	ToolTTLSeconds map[string]int `json:"tool_ttl_seconds,omitempty"`
 }
 
 type AuthConfig struct {
 	Type     string `json:"type"`
 	TokenRef string `json:"token_ref,omitempty"`
+	// ClientID and Scopes configure the OAuth flow used when an oauth
+	// server has no token_ref. Without a client_id the client registers
+	// itself dynamically.
+	ClientID string   `json:"client_id,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
 }
 
 func DefaultServersConfigPaths() []string {
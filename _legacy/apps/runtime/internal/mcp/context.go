@@ -0,0 +1,62 @@
+package mcp
+
+import (
+	"context"
+
+	"pryx-core/internal/channels"
+	"pryx-core/internal/policy"
+)
+
+type sessionIDKey struct{}
+
+type surfaceKey struct{}
+
+type callerKey struct{}
+
+// WithSessionID attaches the chat session a tool call belongs to.
+// Manager.CallTool sets it before dispatching, so providers can scope
+// state to the calling session.
+func WithSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionIDKey{}, sessionID)
+}
+
+// SessionIDFromContext returns the session set by WithSessionID.
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionIDKey{}).(string)
+	return id
+}
+
+// WithSurface attaches the surface (tui, mcp, telegram, ...) a tool call
+// originates from. It is copied onto the tool events Manager publishes.
+func WithSurface(ctx context.Context, surface string) context.Context {
+	return context.WithValue(ctx, surfaceKey{}, surface)
+}
+
+// SurfaceFromContext returns the surface set by WithSurface.
+func SurfaceFromContext(ctx context.Context) string {
+	s, _ := ctx.Value(surfaceKey{}).(string)
+	return s
+}
+
+// WithCaller attaches who a tool call is made for, as policies see it.
+// Callers that only set a surface get one derived from it.
+func WithCaller(ctx context.Context, caller policy.Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller of a tool call in the session. It
+// is the one set by WithCaller, else one derived from the surface.
+func CallerFromContext(ctx context.Context, sessionID string) policy.Caller {
+	caller, ok := ctx.Value(callerKey{}).(policy.Caller)
+	if !ok {
+		caller.Surface = SurfaceFromContext(ctx)
+		// Chat surfaces name the channel type, instance and chat
+		if channelType, source, chatID, ok := channels.ParseSurface(caller.Surface); ok {
+			caller = channels.Message{Type: channelType, Source: source, ChannelID: chatID}.Caller()
+		}
+	}
+	if caller.SessionID == "" {
+		caller.SessionID = sessionID
+	}
+	return caller
+}
@@ -79,9 +79,15 @@ func DefaultCuratedRegistry() *CuratedRegistry {
 				Transport:     "bundled",
 				Tools: []ToolInfo{
 					{Name: "read_file", Description: "Read contents of a file"},
+					{Name: "read_range", Description: "Read a window of lines from a file"},
 					{Name: "write_file", Description: "Write contents to a file"},
-					{Name: "list_directory", Description: "List contents of a directory"},
-					{Name: "search_files", Description: "Search for files matching a pattern"},
+					{Name: "apply_edit", Description: "Replace exact text or apply a unified diff"},
+					{Name: "list_dir", Description: "List contents of a directory"},
+					{Name: "search", Description: "Search file contents with a regular expression"},
+					{Name: "stat", Description: "Show file size, mode and modification time"},
+					{Name: "mkdir", Description: "Create a directory"},
+					{Name: "move", Description: "Move or rename a file or directory"},
+					{Name: "remove", Description: "Remove a file or directory"},
 				},
 			},
 			{
@@ -97,6 +103,11 @@ func DefaultCuratedRegistry() *CuratedRegistry {
 				Transport:     "bundled",
 				Tools: []ToolInfo{
 					{Name: "execute", Description: "Execute a shell command"},
+					{Name: "open_session", Description: "Start a persistent interactive shell"},
+					{Name: "send_input", Description: "Send input to a shell session"},
+					{Name: "read_output", Description: "Read new output from a shell session"},
+					{Name: "signal", Description: "Interrupt or stop a shell session's job"},
+					{Name: "close_session", Description: "Close a shell session"},
 				},
 				SecurityWarnings: []string{"Can execute arbitrary shell commands"},
 			},
@@ -134,6 +145,29 @@ func DefaultCuratedRegistry() *CuratedRegistry {
 					{Name: "write", Description: "Write to clipboard"},
 				},
 			},
+			{
+				ID:            "git",
+				Name:          "Git",
+				Description:   "Inspect, stage and commit changes in local repositories",
+				Author:        "pryx",
+				Version:       "1.0.0",
+				Category:      CategoryUtility,
+				Tags:          []string{"git", "vcs", "code", "local"},
+				SecurityLevel: SecurityLevelA,
+				Verified:      true,
+				Transport:     "bundled",
+				Tools: []ToolInfo{
+					{Name: "status", Description: "Show the current branch and changed files"},
+					{Name: "diff", Description: "Show unstaged, staged or revision changes"},
+					{Name: "log", Description: "List commits"},
+					{Name: "show", Description: "Show a commit or a file at a revision"},
+					{Name: "blame", Description: "Show who last changed each line"},
+					{Name: "branches", Description: "List branches"},
+					{Name: "add", Description: "Stage files"},
+					{Name: "unstage", Description: "Unstage files"},
+					{Name: "commit", Description: "Commit staged changes"},
+				},
+			},
 			{
 				ID:                   "github",
 				Name:                 "GitHub",
@@ -209,21 +243,36 @@ func DefaultCuratedRegistry() *CuratedRegistry {
 				},
 			},
 			{
-				ID:                   "fetch",
-				Name:                 "Fetch",
-				Description:          "Make HTTP requests to any URL",
-				Author:               "modelcontextprotocol",
-				Version:              "1.0.0",
-				Repository:           "https://github.com/modelcontextprotocol/servers",
-				Documentation:        "https://github.com/modelcontextprotocol/servers/tree/main/src/fetch",
-				Category:             CategoryWeb,
-				Tags:                 []string{"http", "fetch", "api", "request"},
-				SecurityLevel:        SecurityLevelB,
-				Verified:             true,
-				InstallationRequired: []string{"@modelcontextprotocol/server-fetch"},
-				Transport:            "stdio",
-				Command:              []string{"npx", "-y", "@modelcontextprotocol/server-fetch"},
-				SecurityWarnings:     []string{"Can make arbitrary HTTP requests"},
+				ID:            "data",
+				Name:          "Data",
+				Description:   "Read-only SQL queries over local SQLite databases and CSV/TSV files",
+				Author:        "pryx",
+				Version:       "1.0.0",
+				Category:      CategoryDatabase,
+				Tags:          []string{"sqlite", "csv", "sql", "data", "local"},
+				SecurityLevel: SecurityLevelA,
+				Verified:      true,
+				Transport:     "bundled",
+				Tools: []ToolInfo{
+					{Name: "list_tables", Description: "List tables and views with row counts"},
+					{Name: "describe", Description: "Show a table's columns and sample rows"},
+					{Name: "query", Description: "Run a read-only SQL query"},
+				},
+			},
+			{
+				ID:            "fetch",
+				Name:          "Fetch",
+				Description:   "Fetch web pages and APIs over HTTP(S) as Markdown, JSON or text",
+				Author:        "pryx",
+				Version:       "1.0.0",
+				Category:      CategoryWeb,
+				Tags:          []string{"http", "fetch", "api", "request"},
+				SecurityLevel: SecurityLevelB,
+				Verified:      true,
+				Transport:     "bundled",
+				SecurityWarnings: []string{
+					"Can make HTTP requests to any public host unless a sandbox host allowlist is configured",
+				},
 				Tools: []ToolInfo{
 					{Name: "fetch", Description: "Fetch content from a URL"},
 				},
@@ -0,0 +1,437 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/mcp/schema"
+
+	"github.com/google/uuid"
+)
+
+// elicitationTimeout bounds how long a server waits for the user. It stays
+// below httpCallTimeout so the answer still reaches the server.
+const elicitationTimeout = 90 * time.Second
+
+// Elicitation actions
+const (
+	ElicitAccept  = "accept"
+	ElicitDecline = "decline"
+	ElicitCancel  = "cancel"
+)
+
+// ElicitRequest is the params of an elicitation/create request
+type ElicitRequest struct {
+	Message         string          `json:"message"`
+	RequestedSchema json.RawMessage `json:"requestedSchema,omitempty"`
+}
+
+// ElicitResult is the user's answer to an elicitation
+type ElicitResult struct {
+	Action  string                 `json:"action"`
+	Content map[string]interface{} `json:"content,omitempty"`
+}
+
+// ElicitField describes one input of an elicitation form
+type ElicitField struct {
+	Name        string      `json:"name"`
+	Title       string      `json:"title,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Type        string      `json:"type"`
+	Required    bool        `json:"required,omitempty"`
+	Enum        []string    `json:"enum,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+}
+
+type pendingElicitation struct {
+	ch        chan ElicitResult
+	schema    *schema.Schema
+	fields    []ElicitField
+	sessionID string
+	surface   string
+	server    string
+	createdAt time.Time
+}
+
+// ElicitFields lists the inputs of a requested schema in declaration order.
+// Elicitation schemas are flat objects of primitive properties.
+func ElicitFields(raw json.RawMessage) ([]ElicitField, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil, nil
+	}
+	var s struct {
+		Type       string          `json:"type"`
+		Properties json.RawMessage `json:"properties"`
+		Required   []string        `json:"required"`
+	}
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("invalid requested schema: %w", err)
+	}
+	if s.Type != "" && s.Type != "object" {
+		return nil, fmt.Errorf("requested schema must be an object, got %s", s.Type)
+	}
+	if len(s.Properties) == 0 {
+		return nil, nil
+	}
+	names, err := objectKeys(s.Properties)
+	if err != nil {
+		return nil, fmt.Errorf("invalid requested schema: %w", err)
+	}
+	var props map[string]struct {
+		Type        string      `json:"type"`
+		Title       string      `json:"title"`
+		Description string      `json:"description"`
+		Enum        []string    `json:"enum"`
+		Default     interface{} `json:"default"`
+	}
+	if err := json.Unmarshal(s.Properties, &props); err != nil {
+		return nil, fmt.Errorf("invalid requested schema: %w", err)
+	}
+
+	fields := make([]ElicitField, 0, len(names))
+	for _, name := range names {
+		p := props[name]
+		switch p.Type {
+		case "string", "number", "integer", "boolean":
+		default:
+			return nil, fmt.Errorf("property %s: unsupported type %q", name, p.Type)
+		}
+		f := ElicitField{
+			Name:        name,
+			Title:       p.Title,
+			Description: p.Description,
+			Type:        p.Type,
+			Enum:        p.Enum,
+			Default:     p.Default,
+		}
+		for _, r := range s.Required {
+			if r == name {
+				f.Required = true
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// objectKeys returns the keys of a JSON object in the order they appear
+func objectKeys(raw json.RawMessage) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, errors.New("properties must be an object")
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// elicit asks the user of the calling session for the input server
+// requests and waits for the answer. Timeouts and cancelled calls are
+// reported to the server as cancel.
+func (m *Manager) elicit(ctx context.Context, server string, req ElicitRequest) (ElicitResult, error) {
+	fields, err := ElicitFields(req.RequestedSchema)
+	if err != nil {
+		return ElicitResult{}, err
+	}
+	var compiled *schema.Schema
+	if len(fields) > 0 {
+		if compiled, err = m.schemas.compile(req.RequestedSchema); err != nil {
+			return ElicitResult{}, fmt.Errorf("invalid requested schema: %w", err)
+		}
+	}
+
+	id := uuid.NewString()
+	sessionID := SessionIDFromContext(ctx)
+	pe := &pendingElicitation{
+		ch:        make(chan ElicitResult, 1),
+		schema:    compiled,
+		fields:    fields,
+		sessionID: sessionID,
+		surface:   SurfaceFromContext(ctx),
+		server:    server,
+		createdAt: time.Now(),
+	}
+	m.elicitMu.Lock()
+	if m.pendingElicitations == nil {
+		m.pendingElicitations = map[string]*pendingElicitation{}
+	}
+	m.pendingElicitations[id] = pe
+	m.elicitMu.Unlock()
+
+	if m.bus != nil {
+		m.publishCall(ctx, bus.EventElicitationRequested, sessionID, ElicitationRequested{
+			ElicitationID: id,
+			Server:        server,
+			Message:       req.Message,
+			Fields:        fields,
+			ExpiresAt:     pe.createdAt.Add(elicitationTimeout).UTC(),
+			Schema:        req.RequestedSchema,
+		})
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, elicitationTimeout)
+	defer cancel()
+	select {
+	case res := <-pe.ch:
+		return res, nil
+	case <-waitCtx.Done():
+		m.elicitMu.Lock()
+		_, ok := m.pendingElicitations[id]
+		delete(m.pendingElicitations, id)
+		m.elicitMu.Unlock()
+		if !ok {
+			// Resolved as the wait ended
+			return <-pe.ch, nil
+		}
+		reason := "timeout"
+		if ctx.Err() != nil {
+			reason = "cancelled"
+		}
+		m.publishElicitationResolved(id, pe, ElicitCancel, reason)
+		return ElicitResult{Action: ElicitCancel}, nil
+	}
+}
+
+// ResolveElicitation answers a pending elicitation. Accepted content must
+// match the requested schema; if it does not, the error says why and the
+// elicitation stays pending. It reports whether the elicitation was pending.
+func (m *Manager) ResolveElicitation(id string, res ElicitResult) (bool, error) {
+	switch res.Action {
+	case ElicitAccept:
+	case ElicitDecline, ElicitCancel:
+		res.Content = nil
+	default:
+		return false, fmt.Errorf("invalid elicitation action: %q", res.Action)
+	}
+
+	m.elicitMu.Lock()
+	pe, ok := m.pendingElicitations[id]
+	if !ok {
+		m.elicitMu.Unlock()
+		return false, nil
+	}
+	if res.Action == ElicitAccept {
+		if res.Content == nil {
+			res.Content = map[string]interface{}{}
+		}
+		if pe.schema != nil {
+			if err := pe.schema.Validate(res.Content); err != nil {
+				m.elicitMu.Unlock()
+				return true, fmt.Errorf("invalid response:\n%w", err)
+			}
+		}
+	}
+	delete(m.pendingElicitations, id)
+	m.elicitMu.Unlock()
+
+	pe.ch <- res
+	m.publishElicitationResolved(id, pe, res.Action, "")
+	return true, nil
+}
+
+// AnswerElicitationText resolves the oldest elicitation pending on surface
+// with a free-text reply, as typed in a channel. It reports whether one was
+// pending; the error is set if the reply could not be used, in which case
+// the elicitation stays pending.
+func (m *Manager) AnswerElicitationText(surface, text string) (bool, error) {
+	if surface == "" {
+		return false, nil
+	}
+	m.elicitMu.Lock()
+	id := ""
+	var pe *pendingElicitation
+	for pid, p := range m.pendingElicitations {
+		if p.surface == surface && (pe == nil || p.createdAt.Before(pe.createdAt)) {
+			id, pe = pid, p
+		}
+	}
+	m.elicitMu.Unlock()
+	if pe == nil {
+		return false, nil
+	}
+
+	res, err := ParseElicitationReply(pe.fields, text)
+	if err != nil {
+		return true, err
+	}
+	if _, err := m.ResolveElicitation(id, res); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func (m *Manager) publishElicitationResolved(id string, pe *pendingElicitation, action, reason string) {
+	if m.bus == nil {
+		return
+	}
+	evt := bus.NewEvent(bus.EventElicitationResolved, pe.sessionID, ElicitationResolved{
+		ElicitationID: id,
+		Server:        pe.server,
+		Action:        action,
+		Reason:        reason,
+	})
+	evt.Surface = pe.surface
+	m.bus.Publish(evt)
+}
+
+// ElicitationPrompt renders an elicitation as a text prompt for surfaces
+// without forms.
+func ElicitationPrompt(message string, fields []ElicitField) string {
+	var sb strings.Builder
+	sb.WriteString(message)
+	switch len(fields) {
+	case 0:
+		sb.WriteString("\n\nReply \"ok\" to continue or \"decline\" to skip.")
+	case 1:
+		sb.WriteString("\n\nReply with ")
+		sb.WriteString(describeField(fields[0]))
+		sb.WriteString(", or \"decline\" to skip.")
+	default:
+		sb.WriteString("\n\nReply with one line per field, as name: value\n")
+		for _, f := range fields {
+			sb.WriteString("- ")
+			sb.WriteString(f.Name)
+			sb.WriteString(": ")
+			sb.WriteString(describeField(f))
+			sb.WriteString("\n")
+		}
+		sb.WriteString("or \"decline\" to skip.")
+	}
+	return sb.String()
+}
+
+func describeField(f ElicitField) string {
+	desc := f.Title
+	if desc == "" {
+		desc = f.Name
+	}
+	if f.Description != "" {
+		desc += " (" + f.Description + ")"
+	}
+	switch {
+	case len(f.Enum) > 0:
+		desc += ", one of " + strings.Join(f.Enum, ", ")
+	case f.Type == "boolean":
+		desc += ", yes or no"
+	case f.Type == "integer" || f.Type == "number":
+		desc += ", a number"
+	}
+	if !f.Required {
+		desc += ", optional"
+	}
+	return desc
+}
+
+// ParseElicitationReply turns a free-text reply into an answer. "decline",
+// "no" and "skip" decline and "cancel" cancels; a single field takes the
+// whole reply, several are given as name: value lines.
+func ParseElicitationReply(fields []ElicitField, text string) (ElicitResult, error) {
+	text = strings.TrimSpace(text)
+	switch strings.ToLower(text) {
+	case "decline", "no", "skip":
+		return ElicitResult{Action: ElicitDecline}, nil
+	case "cancel":
+		return ElicitResult{Action: ElicitCancel}, nil
+	}
+
+	raw := map[string]string{}
+	if len(fields) == 1 {
+		value := text
+		if name, v, ok := strings.Cut(text, ":"); ok && fieldNamed(fields, name) != nil {
+			value = v
+		}
+		raw[fields[0].Name] = strings.TrimSpace(value)
+	} else {
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-"))
+			if line == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(line, ":")
+			if !ok {
+				name, value, ok = strings.Cut(line, "=")
+			}
+			f := fieldNamed(fields, name)
+			if !ok || f == nil {
+				return ElicitResult{}, fmt.Errorf("could not read %q; reply with name: value lines", line)
+			}
+			raw[f.Name] = strings.TrimSpace(value)
+		}
+	}
+
+	content := map[string]interface{}{}
+	for _, f := range fields {
+		s, ok := raw[f.Name]
+		if !ok || s == "" {
+			if f.Required {
+				return ElicitResult{}, fmt.Errorf("%s is required", f.Name)
+			}
+			continue
+		}
+		v, err := coerceField(f, s)
+		if err != nil {
+			return ElicitResult{}, err
+		}
+		content[f.Name] = v
+	}
+	return ElicitResult{Action: ElicitAccept, Content: content}, nil
+}
+
+func fieldNamed(fields []ElicitField, name string) *ElicitField {
+	name = strings.TrimSpace(name)
+	for i := range fields {
+		if strings.EqualFold(fields[i].Name, name) || (fields[i].Title != "" && strings.EqualFold(fields[i].Title, name)) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+func coerceField(f ElicitField, s string) (interface{}, error) {
+	switch f.Type {
+	case "integer":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a whole number", f.Name)
+		}
+		return n, nil
+	case "number":
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", f.Name)
+		}
+		return n, nil
+	case "boolean":
+		switch strings.ToLower(s) {
+		case "yes", "y", "true", "1":
+			return true, nil
+		case "no", "n", "false", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%s must be yes or no", f.Name)
+	}
+	for _, e := range f.Enum {
+		if strings.EqualFold(e, s) {
+			return e, nil
+		}
+	}
+	return s, nil
+}
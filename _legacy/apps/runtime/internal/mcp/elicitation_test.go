@@ -0,0 +1,141 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"pryx-core/internal/bus"
+)
+
+const testElicitSchema = `{
+	"type": "object",
+	"properties": {
+		"repo": {"type": "string", "title": "Repository"},
+		"visibility": {"type": "string", "enum": ["public", "private"]},
+		"stars": {"type": "integer", "minimum": 0},
+		"archived": {"type": "boolean"}
+	},
+	"required": ["repo", "visibility"]
+}`
+
+func TestElicitFields(t *testing.T) {
+	fields, err := ElicitFields(json.RawMessage(testElicitSchema))
+	if err != nil {
+		t.Fatalf("ElicitFields: %v", err)
+	}
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	if strings.Join(names, ",") != "repo,visibility,stars,archived" {
+		t.Fatalf("fields out of order: %v", names)
+	}
+	if !fields[0].Required || fields[2].Required || len(fields[1].Enum) != 2 {
+		t.Fatalf("unexpected fields: %+v", fields)
+	}
+
+	if _, err := ElicitFields(json.RawMessage(`{"type":"object","properties":{"x":{"type":"array"}}}`)); err == nil {
+		t.Fatal("expected nested types to be rejected")
+	}
+}
+
+func TestParseElicitationReply(t *testing.T) {
+	fields, _ := ElicitFields(json.RawMessage(testElicitSchema))
+
+	res, err := ParseElicitationReply(fields, "Repository: pryx\nvisibility = PRIVATE\n- stars: 12\narchived: no")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if res.Action != ElicitAccept || res.Content["repo"] != "pryx" || res.Content["visibility"] != "private" ||
+		res.Content["stars"] != int64(12) || res.Content["archived"] != false {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	for _, bad := range []string{"repo: pryx", "repo: pryx\nvisibility: public\nstars: many", "just some text"} {
+		if _, err := ParseElicitationReply(fields, bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+
+	if res, _ := ParseElicitationReply(fields, " Decline "); res.Action != ElicitDecline {
+		t.Fatalf("expected decline, got %+v", res)
+	}
+	if res, _ := ParseElicitationReply(fields[:1], "my repo: v2"); res.Content["repo"] != "my repo: v2" {
+		t.Fatalf("single field should take the whole reply: %+v", res)
+	}
+}
+
+func TestManager_Elicit(t *testing.T) {
+	b := bus.New()
+	events, cancel := b.Subscribe(bus.EventElicitationRequested, bus.EventElicitationResolved)
+	defer cancel()
+	m := newAllowAllManager(t, b)
+	defer m.Close()
+
+	req := ElicitRequest{Message: "Create the repository?", RequestedSchema: json.RawMessage(testElicitSchema)}
+	done := make(chan ElicitResult, 1)
+	go func() {
+		res, err := m.elicit(WithSessionID(context.Background(), "s1"), "github", req)
+		if err != nil {
+			t.Errorf("elicit: %v", err)
+		}
+		done <- res
+	}()
+
+	evt := <-events
+	payload := evt.Payload.(ElicitationRequested)
+	id := payload.ElicitationID
+	if evt.SessionID != "s1" || payload.Server != "github" || len(payload.Fields) != 4 {
+		t.Fatalf("unexpected request event: %+v", evt)
+	}
+
+	if _, err := m.ResolveElicitation(id, ElicitResult{Action: "maybe"}); err == nil {
+		t.Fatal("expected an invalid action to be rejected")
+	}
+	ok, err := m.ResolveElicitation(id, ElicitResult{Action: ElicitAccept, Content: map[string]interface{}{"repo": "pryx", "visibility": "secret"}})
+	if !ok || err == nil {
+		t.Fatalf("expected content outside the schema to be rejected, ok=%v err=%v", ok, err)
+	}
+	if ok, err := m.ResolveElicitation(id, ElicitResult{Action: ElicitAccept, Content: map[string]interface{}{"repo": "pryx", "visibility": "public"}}); !ok || err != nil {
+		t.Fatalf("resolve: ok=%v err=%v", ok, err)
+	}
+	if res := <-done; res.Action != ElicitAccept || res.Content["visibility"] != "public" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if evt := <-events; evt.Event != bus.EventElicitationResolved || evt.Payload.(ElicitationResolved).Action != ElicitAccept {
+		t.Fatalf("unexpected resolved event: %+v", evt)
+	}
+	if ok, _ := m.ResolveElicitation(id, ElicitResult{Action: ElicitDecline}); ok {
+		t.Fatal("elicitation resolved twice")
+	}
+}
+
+func TestManager_ElicitCancelled(t *testing.T) {
+	b := bus.New()
+	events, unsubscribe := b.Subscribe(bus.EventElicitationRequested, bus.EventElicitationResolved)
+	defer unsubscribe()
+	m := newAllowAllManager(t, b)
+	defer m.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan ElicitResult, 1)
+	go func() {
+		res, _ := m.elicit(ctx, "github", ElicitRequest{Message: "Continue?"})
+		done <- res
+	}()
+	<-events
+	cancel()
+
+	if res := <-done; res.Action != ElicitCancel {
+		t.Fatalf("expected cancel, got %+v", res)
+	}
+	evt := <-events
+	if p := evt.Payload.(ElicitationResolved); p.Action != ElicitCancel || p.Reason != "cancelled" {
+		t.Fatalf("unexpected resolved event: %+v", p)
+	}
+	if handled, _ := m.AnswerElicitationText("", "ok"); handled {
+		t.Fatal("nothing should be pending")
+	}
+}
@@ -0,0 +1,118 @@
+package mcp
+
+import (
+	"encoding/json"
+	"time"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/policy"
+)
+
+// ToolRequested is the payload of tool.request, published with the policy
+// decision before a call is approved or run.
+type ToolRequested struct {
+	CallID   string                 `json:"call_id"`
+	Tool     string                 `json:"tool"`
+	Args     map[string]interface{} `json:"args"`
+	Decision policy.Result          `json:"decision"`
+}
+
+// ToolExecuting is the payload of tool.executing.
+type ToolExecuting struct {
+	CallID string `json:"call_id"`
+	Tool   string `json:"tool"`
+}
+
+// ToolCompleted is the payload of tool.complete. Cached marks results
+// served from the result cache.
+type ToolCompleted struct {
+	CallID string     `json:"call_id"`
+	Tool   string     `json:"tool"`
+	Result ToolResult `json:"result"`
+	Cached bool       `json:"cached,omitempty"`
+}
+
+// ApprovalNeeded is the payload of approval.needed. Scopes are the ways
+// the answer may be remembered.
+type ApprovalNeeded struct {
+	ApprovalID string                 `json:"approval_id"`
+	Tool       string                 `json:"tool"`
+	Args       map[string]interface{} `json:"args"`
+	Reason     string                 `json:"reason"`
+	Scopes     []string               `json:"scopes"`
+}
+
+// ApprovalResolved is the payload of approval.resolved. Remembered marks
+// calls answered by a stored grant without a prompt, which have no
+// approval ID.
+type ApprovalResolved struct {
+	ApprovalID string     `json:"approval_id,omitempty"`
+	Tool       string     `json:"tool"`
+	Approved   bool       `json:"approved"`
+	Scope      string     `json:"scope"`
+	GrantID    string     `json:"grant_id,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Remembered bool       `json:"remembered,omitempty"`
+}
+
+// ApprovalRevoked is the payload of approval.revoked.
+type ApprovalRevoked struct {
+	GrantID  string `json:"grant_id"`
+	Tool     string `json:"tool"`
+	Scope    string `json:"scope"`
+	Approved bool   `json:"approved"`
+}
+
+// ElicitationRequested is the payload of elicitation.requested.
+type ElicitationRequested struct {
+	ElicitationID string          `json:"elicitation_id"`
+	Server        string          `json:"server"`
+	Message       string          `json:"message"`
+	Fields        []ElicitField   `json:"fields"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+	Schema        json.RawMessage `json:"schema,omitempty"`
+}
+
+// ElicitationResolved is the payload of elicitation.resolved.
+type ElicitationResolved struct {
+	ElicitationID string `json:"elicitation_id"`
+	Server        string `json:"server"`
+	Action        string `json:"action"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// SandboxViolation is the payload of sandbox.violation. Kind and Command
+// are set when the sandbox reported a structured violation.
+type SandboxViolation struct {
+	Server  string `json:"server"`
+	Tool    string `json:"tool,omitempty"`
+	Error   string `json:"error"`
+	Kind    string `json:"kind,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+// NetworkRequest is the payload of network.request, published for every
+// request a bundled tool makes. Blocked marks requests the sandbox
+// refused.
+type NetworkRequest struct {
+	Provider string `json:"provider"`
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	Host     string `json:"host"`
+	Status   int    `json:"status,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Blocked  bool   `json:"blocked,omitempty"`
+}
+
+func init() {
+	bus.RegisterPayload(bus.EventToolRequest, ToolRequested{})
+	bus.RegisterPayload(bus.EventToolExecuting, ToolExecuting{})
+	bus.RegisterPayload(bus.EventToolComplete, ToolCompleted{})
+	bus.RegisterPayload(bus.EventApprovalNeeded, ApprovalNeeded{})
+	bus.RegisterPayload(bus.EventApprovalResolved, ApprovalResolved{})
+	bus.RegisterPayload(bus.EventApprovalRevoked, ApprovalRevoked{})
+	bus.RegisterPayload(bus.EventElicitationRequested, ElicitationRequested{})
+	bus.RegisterPayload(bus.EventElicitationResolved, ElicitationResolved{})
+	bus.RegisterPayload(bus.EventSandboxViolation, SandboxViolation{})
+	bus.RegisterPayload(bus.EventNetworkRequest, NetworkRequest{})
+}
@@ -0,0 +1,291 @@
+package mcp
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlSkip are elements whose content is never useful to a model
+var htmlSkip = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Iframe: true, atom.Head: true, atom.Button: true,
+	atom.Select: true, atom.Form: true,
+}
+
+var (
+	blankLines = regexp.MustCompile(`[ \t]*\n(?:[ \t>]*\n)+`)
+	spaces     = regexp.MustCompile(`\s+`)
+)
+
+// htmlToMarkdown converts an HTML document to Markdown, keeping headings,
+// links, emphasis, lists, code and tables and dropping scripts and styling.
+// It also returns the document title.
+func htmlToMarkdown(r io.Reader, base func(string) string) (string, string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", "", err
+	}
+	c := &mdConverter{resolve: base}
+	c.title = findTitle(doc)
+	c.walk(doc)
+	out := blankLines.ReplaceAllString(c.b.String(), "\n\n")
+	return strings.TrimSpace(out) + "\n", c.title, nil
+}
+
+type mdConverter struct {
+	b       strings.Builder
+	resolve func(string) string
+	title   string
+	lists   []listState
+	pre     int
+	quote   int
+}
+
+type listState struct {
+	ordered bool
+	n       int
+}
+
+func findTitle(n *html.Node) string {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Title {
+		return strings.TrimSpace(textContent(n))
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if t := findTitle(c); t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// block starts a new paragraph-level block
+func (c *mdConverter) block() {
+	s := c.b.String()
+	if s == "" || strings.HasSuffix(s, "\n\n") {
+		return
+	}
+	if strings.HasSuffix(s, "\n") {
+		c.b.WriteString("\n")
+	} else {
+		c.b.WriteString("\n\n")
+	}
+	c.linePrefix()
+}
+
+func (c *mdConverter) newline() {
+	c.b.WriteString("\n")
+	c.linePrefix()
+}
+
+func (c *mdConverter) linePrefix() {
+	if c.quote > 0 {
+		c.b.WriteString(strings.Repeat("> ", c.quote))
+	}
+}
+
+func (c *mdConverter) text(s string) {
+	if c.pre > 0 {
+		c.b.WriteString(s)
+		return
+	}
+	s = spaces.ReplaceAllString(s, " ")
+	if cur := c.b.String(); cur == "" || strings.HasSuffix(cur, " ") || strings.HasSuffix(cur, "\n") {
+		s = strings.TrimLeft(s, " ")
+	}
+	c.b.WriteString(s)
+}
+
+func (c *mdConverter) children(n *html.Node) {
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		c.walk(ch)
+	}
+}
+
+func (c *mdConverter) inline(n *html.Node, mark string) {
+	inner := strings.TrimSpace(c.render(n))
+	if inner == "" {
+		return
+	}
+	c.text(mark + inner + mark)
+}
+
+// render converts n's children into a separate buffer
+func (c *mdConverter) render(n *html.Node) string {
+	sub := &mdConverter{resolve: c.resolve, pre: c.pre, lists: append([]listState(nil), c.lists...)}
+	sub.children(n)
+	return sub.b.String()
+}
+
+func (c *mdConverter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.text(n.Data)
+		return
+	case html.DocumentNode:
+		c.children(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	if htmlSkip[n.DataAtom] || attr(n, "hidden") != "" || attr(n, "aria-hidden") == "true" {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		c.block()
+		level := int(n.Data[1] - '0')
+		c.b.WriteString(strings.Repeat("#", level) + " " + strings.Join(strings.Fields(c.render(n)), " "))
+		c.block()
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer, atom.Aside, atom.Nav, atom.Figure, atom.Dl:
+		c.block()
+		c.children(n)
+		c.block()
+	case atom.Br:
+		c.newline()
+	case atom.Hr:
+		c.block()
+		c.b.WriteString("---")
+		c.block()
+	case atom.Strong, atom.B:
+		c.inline(n, "**")
+	case atom.Em, atom.I:
+		c.inline(n, "*")
+	case atom.Code:
+		if c.pre > 0 {
+			c.children(n)
+			return
+		}
+		c.text("`" + strings.TrimSpace(textContent(n)) + "`")
+	case atom.Pre:
+		c.block()
+		c.b.WriteString("```\n")
+		c.pre++
+		c.children(n)
+		c.pre--
+		if !strings.HasSuffix(c.b.String(), "\n") {
+			c.b.WriteString("\n")
+		}
+		c.b.WriteString("```")
+		c.block()
+	case atom.A:
+		label := strings.Join(strings.Fields(c.render(n)), " ")
+		href := strings.TrimSpace(attr(n, "href"))
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			c.text(label)
+			return
+		}
+		if c.resolve != nil {
+			href = c.resolve(href)
+		}
+		if label == "" {
+			label = href
+		}
+		c.text("[" + label + "](" + href + ")")
+	case atom.Img:
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			c.text("[image: " + alt + "]")
+		}
+	case atom.Ul, atom.Ol:
+		c.block()
+		c.lists = append(c.lists, listState{ordered: n.DataAtom == atom.Ol})
+		c.children(n)
+		c.lists = c.lists[:len(c.lists)-1]
+		c.block()
+	case atom.Li:
+		if !strings.HasSuffix(c.b.String(), "\n") && c.b.Len() > 0 {
+			c.newline()
+		}
+		marker := "- "
+		if depth := len(c.lists); depth > 0 {
+			c.b.WriteString(strings.Repeat("  ", depth-1))
+			if l := &c.lists[depth-1]; l.ordered {
+				l.n++
+				marker = strconv.Itoa(l.n) + ". "
+			}
+		}
+		c.b.WriteString(marker + strings.TrimSpace(c.render(n)))
+		c.newline()
+	case atom.Blockquote:
+		c.block()
+		c.quote++
+		c.linePrefix()
+		c.children(n)
+		c.quote--
+		c.block()
+	case atom.Table:
+		c.block()
+		c.table(n)
+		c.block()
+	case atom.Dt:
+		c.newline()
+		c.inline(n, "**")
+	case atom.Dd:
+		c.newline()
+		c.text(": " + strings.TrimSpace(c.render(n)))
+	default:
+		c.children(n)
+	}
+}
+
+// table renders rows as pipe-separated cells, with a separator after the
+// first row.
+func (c *mdConverter) table(n *html.Node) {
+	var rows [][]string
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			if ch.Type != html.ElementNode {
+				continue
+			}
+			if ch.DataAtom != atom.Tr {
+				collect(ch)
+				continue
+			}
+			var row []string
+			for cell := ch.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+					text := strings.Join(strings.Fields(c.render(cell)), " ")
+					row = append(row, strings.ReplaceAll(text, "|", `\|`))
+				}
+			}
+			if len(row) > 0 {
+				rows = append(rows, row)
+			}
+		}
+	}
+	collect(n)
+
+	for i, row := range rows {
+		c.b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			c.b.WriteString("|" + strings.Repeat(" --- |", len(row)) + "\n")
+		}
+	}
+}
@@ -1,6 +1,15 @@
 package mcp
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+
+	"pryx-core/internal/mcp/security"
+)
+
+// codeSandboxViolation marks a bundled tool refused by its sandbox; the
+// error data holds the security.ViolationError.
+const codeSandboxViolation = -32001
 
 type RPCError struct {
 	Code    int             `json:"code"`
@@ -8,6 +17,28 @@ type RPCError struct {
 	Data    json.RawMessage `json:"data,omitempty"`
 }
 
+// toolError converts a provider error for the wire, keeping sandbox
+// violations typed
+func toolError(err error) *RPCError {
+	if v, ok := security.AsViolation(err); ok {
+		if data, merr := json.Marshal(v); merr == nil {
+			return &RPCError{Code: codeSandboxViolation, Message: err.Error(), Data: data}
+		}
+	}
+	return &RPCError{Code: -32000, Message: err.Error()}
+}
+
+// err converts an error response back into a Go error
+func (e *RPCError) err() error {
+	if e.Code == codeSandboxViolation {
+		var v security.ViolationError
+		if json.Unmarshal(e.Data, &v) == nil && v.Kind != "" {
+			return &v
+		}
+	}
+	return fmt.Errorf("mcp error %d: %s", e.Code, e.Message)
+}
+
 type RPCRequest struct {
 	JSONRPC string      `json:"jsonrpc"`
 	ID      interface{} `json:"id,omitempty"`
@@ -38,3 +69,22 @@ func idKey(raw json.RawMessage) string {
 	}
 	return "n:" + string(raw)
 }
+
+// rpcMessage is any message a server may send: a response, or a request or
+// notification of its own.
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+func (m rpcMessage) isRequest() bool {
+	return m.Method != "" && len(m.ID) > 0 && string(m.ID) != "null"
+}
+
+func (m rpcMessage) response() RPCResponse {
+	return RPCResponse{JSONRPC: m.JSONRPC, ID: m.ID, Result: m.Result, Error: m.Error}
+}
@@ -0,0 +1,491 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"pryx-core/internal/bus"
+)
+
+// ServerStatus is the connection state of a configured MCP server.
+type ServerStatus string
+
+const (
+	ServerStatusConnecting ServerStatus = "connecting"
+	ServerStatusReady      ServerStatus = "ready"
+	ServerStatusFailed     ServerStatus = "failed"
+)
+
+const (
+	retryBaseDelay    = 1 * time.Second
+	retryMaxDelay     = 5 * time.Minute
+	healthCheckPeriod = 30 * time.Second
+)
+
+// configPollInterval is how often WatchConfig checks servers.json for changes.
+var configPollInterval = 2 * time.Second
+
+// ServerState is a point-in-time snapshot of a managed server.
+type ServerState struct {
+	Name        string       `json:"name"`
+	Transport   string       `json:"transport"`
+	Status      ServerStatus `json:"status"`
+	Error       string       `json:"error,omitempty"`
+	Attempts    int          `json:"attempts"`
+	ConnectedAt *time.Time   `json:"connected_at,omitempty"`
+	NextRetryAt *time.Time   `json:"next_retry_at,omitempty"`
+}
+
+// managedServer owns the connect/retry loop of a single server. Its state
+// and client fields are guarded by Manager.mu.
+type managedServer struct {
+	name   string
+	config ServerConfig
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	state  ServerState
+	client *Client
+}
+
+// ServerStates returns the state of every configured server, sorted by name.
+func (m *Manager) ServerStates() []ServerState {
+	m.mu.RLock()
+	out := make([]ServerState, 0, len(m.servers)+len(m.natives))
+	for _, ms := range m.servers {
+		out = append(out, ms.state)
+	}
+	for name := range m.natives {
+		out = append(out, nativeState(name))
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ServerState returns the state of a single server.
+func (m *Manager) ServerState(name string) (ServerState, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, ok := m.natives[name]; ok {
+		return nativeState(name), true
+	}
+	ms, ok := m.servers[name]
+	if !ok {
+		return ServerState{}, false
+	}
+	return ms.state, true
+}
+
+func nativeState(name string) ServerState {
+	return ServerState{Name: name, Transport: "native", Status: ServerStatusReady, Attempts: 1}
+}
+
+// ApplyConfig reconciles running servers with cfg: new servers are started,
+// changed ones restarted and removed ones stopped. Unchanged servers are left
+// alone. It waits for the first connection attempt of every started server
+// and returns the joined errors of those that failed; failed servers keep
+// retrying in the background.
+func (m *Manager) ApplyConfig(ctx context.Context, cfg *ServersConfig) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+
+	m.mu.RLock()
+	var stale []*managedServer
+	for name, ms := range m.servers {
+		next, ok := cfg.Servers[name]
+		if !ok || !reflect.DeepEqual(ms.config, next) {
+			stale = append(stale, ms)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, ms := range stale {
+		m.stopServer(ms)
+	}
+
+	var errs []error
+	names := make([]string, 0, len(cfg.Servers))
+	m.mu.RLock()
+	for name := range cfg.Servers {
+		if !ValidServerName(name) {
+			errs = append(errs, fmt.Errorf("%s: server names may not be empty or contain \":\", \"/\" or %q", name, aggregateSeparator))
+			continue
+		}
+		if _, native := m.natives[name]; native {
+			errs = append(errs, fmt.Errorf("%s: name is reserved for a built-in server", name))
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(cfg.Servers[name].Validation)) {
+		case ValidationDefault, ValidationStrict, ValidationOff:
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown validation mode %q", name, cfg.Servers[name].Validation))
+			continue
+		}
+		if _, running := m.servers[name]; !running {
+			names = append(names, name)
+		}
+	}
+	m.mu.RUnlock()
+	sort.Strings(names)
+
+	firsts := make(map[string]chan error, len(names))
+	for _, name := range names {
+		firsts[name] = m.startServer(name, cfg.Servers[name])
+	}
+
+	for _, name := range names {
+		select {
+		case err := <-firsts[name]:
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RestartServer tears down a server and connects it again with its current
+// configuration.
+func (m *Manager) RestartServer(ctx context.Context, name string) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+
+	m.mu.RLock()
+	ms, ok := m.servers[name]
+	_, native := m.natives[name]
+	m.mu.RUnlock()
+	if native {
+		return fmt.Errorf("built-in mcp server cannot be restarted: %s", name)
+	}
+	if !ok {
+		return fmt.Errorf("unknown mcp server: %s", name)
+	}
+
+	m.stopServer(ms)
+	first := m.startServer(name, ms.config)
+
+	select {
+	case err := <-first:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WatchConfig polls the servers config files and applies changes until ctx
+// is cancelled. A config that fails to parse leaves the running servers as
+// they are.
+func (m *Manager) WatchConfig(ctx context.Context) {
+	m.lifecycleMu.Lock()
+	last := m.configFP
+	m.lifecycleMu.Unlock()
+	if last == nil {
+		last = configFingerprint(DefaultServersConfigPaths())
+	}
+
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := configFingerprint(DefaultServersConfigPaths())
+		if bytes.Equal(current, last) {
+			continue
+		}
+		last = current
+
+		cfg, path, err := loadServersConfigWithDefaults()
+		if err != nil {
+			m.publish(bus.EventErrorOccurred, map[string]interface{}{
+				"kind":  "mcp.config_invalid",
+				"error": err.Error(),
+				"path":  path,
+			})
+			continue
+		}
+
+		applyCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		err = m.ApplyConfig(applyCtx, cfg)
+		cancel()
+
+		payload := map[string]interface{}{
+			"kind": "mcp.config_reloaded",
+			"path": path,
+		}
+		if err != nil {
+			payload["error"] = err.Error()
+		}
+		m.publish(bus.EventTraceEvent, payload)
+	}
+}
+
+// Close stops every managed server.
+func (m *Manager) Close() error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+
+	m.mu.RLock()
+	servers := make([]*managedServer, 0, len(m.servers))
+	for _, ms := range m.servers {
+		servers = append(servers, ms)
+	}
+	m.mu.RUnlock()
+
+	for _, ms := range servers {
+		m.stopServer(ms)
+	}
+	m.cancel()
+	return nil
+}
+
+func (m *Manager) startServer(name string, sc ServerConfig) chan error {
+	ctx, cancel := context.WithCancel(m.ctx)
+	ms := &managedServer{
+		name:   name,
+		config: sc,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		state: ServerState{
+			Name:      name,
+			Transport: strings.ToLower(strings.TrimSpace(sc.Transport)),
+			Status:    ServerStatusConnecting,
+		},
+	}
+
+	m.mu.Lock()
+	m.servers[name] = ms
+	m.mu.Unlock()
+
+	first := make(chan error, 1)
+	go m.runServer(ctx, ms, first)
+	return first
+}
+
+func (m *Manager) stopServer(ms *managedServer) {
+	ms.cancel()
+	<-ms.done
+
+	m.mu.Lock()
+	if m.servers[ms.name] == ms {
+		delete(m.servers, ms.name)
+	}
+	m.mu.Unlock()
+
+	m.cacheMu.Lock()
+	delete(m.cache, ms.name)
+	m.cacheMu.Unlock()
+	m.results.invalidate(ms.name)
+
+	m.publish(bus.EventTraceEvent, map[string]interface{}{
+		"kind":   "mcp.server_stopped",
+		"server": ms.name,
+	})
+}
+
+// runServer connects ms, retrying with exponential backoff, and reconnects
+// when a health check fails. The first attempt's outcome is sent on first.
+func (m *Manager) runServer(ctx context.Context, ms *managedServer, first chan<- error) {
+	defer close(ms.done)
+	defer m.detachClient(ms)
+
+	delay := retryBaseDelay
+	reported := false
+	report := func(err error) {
+		if !reported {
+			reported = true
+			first <- err
+		}
+	}
+	defer func() { report(ctx.Err()) }()
+
+	for {
+		m.setState(ms, func(s *ServerState) {
+			s.Status = ServerStatusConnecting
+			s.Attempts++
+			s.NextRetryAt = nil
+		})
+
+		client, err := m.connect(ctx, ms)
+		if err == nil {
+			delay = retryBaseDelay
+			report(nil)
+			err = m.superviseClient(ctx, ms, client)
+			if ctx.Err() != nil {
+				return
+			}
+		} else {
+			report(err)
+			if ctx.Err() != nil {
+				return
+			}
+		}
+
+		next := time.Now().UTC().Add(delay)
+		m.setState(ms, func(s *ServerState) {
+			s.Status = ServerStatusFailed
+			s.Error = err.Error()
+			s.NextRetryAt = &next
+		})
+		m.publish(bus.EventErrorOccurred, map[string]interface{}{
+			"kind":     "mcp.server_failed",
+			"server":   ms.name,
+			"error":    err.Error(),
+			"retry_in": delay.String(),
+		})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+	}
+}
+
+func (m *Manager) connect(ctx context.Context, ms *managedServer) (*Client, error) {
+	client, err := m.buildClient(ms.name, ms.config)
+	if err != nil {
+		return nil, err
+	}
+	client.OnToolsChanged(func() { m.forgetTools(ms.name) })
+	client.SetHooks(m.clientHooks(ms.name))
+	// Results from a previous connection or config may no longer hold
+	m.results.invalidate(ms.name)
+	if err := client.Initialize(ctx); err != nil {
+		m.reportViolation("", ms.name, "", err)
+		_ = client.Close()
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	m.mu.Lock()
+	ms.client = client
+	ms.state.Status = ServerStatusReady
+	ms.state.Error = ""
+	ms.state.ConnectedAt = &now
+	m.clients[ms.name] = client
+	m.mu.Unlock()
+
+	m.publish(bus.EventTraceEvent, map[string]interface{}{
+		"kind":   "mcp.server_ready",
+		"server": ms.name,
+	})
+	return client, nil
+}
+
+// superviseClient pings a ready client until ctx is cancelled or a ping
+// fails, in which case the client is detached and the error returned.
+func (m *Manager) superviseClient(ctx context.Context, ms *managedServer, client *Client) error {
+	ticker := time.NewTicker(healthCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := client.Ping(pingCtx)
+		cancel()
+		if err != nil && ctx.Err() == nil && !isMethodNotFound(err) {
+			m.detachClient(ms)
+			return fmt.Errorf("health check failed: %w", err)
+		}
+	}
+}
+
+func (m *Manager) detachClient(ms *managedServer) {
+	m.mu.Lock()
+	client := ms.client
+	ms.client = nil
+	if client != nil && m.clients[ms.name] == client {
+		delete(m.clients, ms.name)
+	}
+	m.mu.Unlock()
+
+	if client != nil {
+		_ = client.Close()
+	}
+}
+
+func (m *Manager) setState(ms *managedServer, fn func(*ServerState)) {
+	m.mu.Lock()
+	fn(&ms.state)
+	m.mu.Unlock()
+}
+
+func (m *Manager) publish(eventType bus.EventType, payload map[string]interface{}) {
+	if m.bus == nil {
+		return
+	}
+	m.bus.Publish(bus.NewEvent(eventType, "", payload))
+}
+
+// isMethodNotFound reports whether err is a JSON-RPC "method not found"
+// error; servers without ping support are still considered healthy.
+func isMethodNotFound(err error) bool {
+	return strings.Contains(err.Error(), "mcp error -32601")
+}
+
+// loadServersConfigWithDefaults loads the first existing servers config and
+// falls back to the bundled tier-1 servers when none exists.
+func loadServersConfigWithDefaults() (*ServersConfig, string, error) {
+	cfg, path, err := LoadServersConfigFromFirstExisting(DefaultServersConfigPaths())
+	if err != nil {
+		return nil, path, err
+	}
+
+	if path == "" && len(cfg.Servers) == 0 {
+		cfg.Servers = map[string]ServerConfig{
+			"filesystem": {Transport: "bundled"},
+			"shell":      {Transport: "bundled"},
+			"browser":    {Transport: "bundled"},
+			"clipboard":  {Transport: "bundled"},
+			"git":        {Transport: "bundled"},
+			"fetch":      {Transport: "bundled"},
+			"data":       {Transport: "bundled"},
+		}
+	}
+	return cfg, path, nil
+}
+
+// configFingerprint hashes the path and contents of the first existing
+// config file, so that switching files or editing one both register.
+func configFingerprint(paths []string) []byte {
+	h := sha256.New()
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+		h.Write(data)
+		break
+	}
+	return h.Sum(nil)
+}
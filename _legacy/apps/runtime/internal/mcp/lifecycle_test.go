@@ -0,0 +1,159 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pryx-core/internal/bus"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ApplyConfig_IndependentServers(t *testing.T) {
+	t.Setenv("PRYX_WORKSPACE_ROOT", t.TempDir())
+
+	m := NewManager(bus.New(), nil, nil)
+	defer m.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := m.ApplyConfig(ctx, &ServersConfig{Servers: map[string]ServerConfig{
+		"filesystem": {Transport: "bundled"},
+		"broken":     {Transport: "bundled"},
+	}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broken")
+
+	fs, ok := m.ServerState("filesystem")
+	require.True(t, ok)
+	assert.Equal(t, ServerStatusReady, fs.Status)
+	assert.NotNil(t, fs.ConnectedAt)
+
+	broken, ok := m.ServerState("broken")
+	require.True(t, ok)
+	assert.Equal(t, ServerStatusFailed, broken.Status)
+	assert.NotEmpty(t, broken.Error)
+	assert.NotNil(t, broken.NextRetryAt)
+
+	tools, err := m.ListToolsFlat(ctx, true)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tools)
+
+	_, err = m.CallTool(ctx, "s1", "broken:anything", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed")
+}
+
+func TestManager_ApplyConfig_Diff(t *testing.T) {
+	t.Setenv("PRYX_WORKSPACE_ROOT", t.TempDir())
+
+	m := NewManager(bus.New(), nil, nil)
+	defer m.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, m.ApplyConfig(ctx, &ServersConfig{Servers: map[string]ServerConfig{
+		"filesystem": {Transport: "bundled"},
+		"shell":      {Transport: "bundled"},
+	}}))
+
+	m.mu.RLock()
+	fsClient := m.clients["filesystem"]
+	shellClient := m.clients["shell"]
+	m.mu.RUnlock()
+
+	require.NoError(t, m.ApplyConfig(ctx, &ServersConfig{Servers: map[string]ServerConfig{
+		"filesystem": {Transport: "bundled"},
+		"shell":      {Transport: "bundled", ProtocolVersion: "2025-06-18"},
+		"clipboard":  {Transport: "bundled"},
+	}}))
+
+	m.mu.RLock()
+	assert.Same(t, fsClient, m.clients["filesystem"], "unchanged server should keep its client")
+	assert.NotSame(t, shellClient, m.clients["shell"], "changed server should be restarted")
+	assert.NotNil(t, m.clients["clipboard"])
+	m.mu.RUnlock()
+
+	require.NoError(t, m.ApplyConfig(ctx, &ServersConfig{Servers: map[string]ServerConfig{
+		"filesystem": {Transport: "bundled"},
+	}}))
+
+	states := m.ServerStates()
+	require.Len(t, states, 1)
+	assert.Equal(t, "filesystem", states[0].Name)
+
+	m.mu.RLock()
+	_, shellPresent := m.clients["shell"]
+	m.mu.RUnlock()
+	assert.False(t, shellPresent)
+}
+
+func TestManager_RestartServer(t *testing.T) {
+	t.Setenv("PRYX_WORKSPACE_ROOT", t.TempDir())
+
+	m := NewManager(bus.New(), nil, nil)
+	defer m.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, m.ApplyConfig(ctx, &ServersConfig{Servers: map[string]ServerConfig{
+		"filesystem": {Transport: "bundled"},
+	}}))
+
+	m.mu.RLock()
+	before := m.clients["filesystem"]
+	m.mu.RUnlock()
+
+	require.NoError(t, m.RestartServer(ctx, "filesystem"))
+
+	m.mu.RLock()
+	after := m.clients["filesystem"]
+	m.mu.RUnlock()
+	assert.NotNil(t, after)
+	assert.NotSame(t, before, after)
+
+	assert.Error(t, m.RestartServer(ctx, "missing"))
+}
+
+func TestManager_WatchConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PRYX_WORKSPACE_ROOT", t.TempDir())
+
+	oldWD, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWD) }()
+	require.NoError(t, os.Chdir(t.TempDir()))
+
+	oldInterval := configPollInterval
+	configPollInterval = 20 * time.Millisecond
+	defer func() { configPollInterval = oldInterval }()
+
+	path := filepath.Join(home, ".pryx", "mcp", "servers.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(`{"servers":{"filesystem":{"transport":"bundled"}}}`), 0o600))
+
+	m := NewManager(bus.New(), nil, nil)
+	defer m.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = m.LoadAndConnect(ctx)
+	require.NoError(t, err)
+	go m.WatchConfig(ctx)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"servers":{"shell":{"transport":"bundled"}}}`), 0o600))
+
+	assert.Eventually(t, func() bool {
+		states := m.ServerStates()
+		return len(states) == 1 && states[0].Name == "shell" && states[0].Status == ServerStatusReady
+	}, 3*time.Second, 20*time.Millisecond)
+}
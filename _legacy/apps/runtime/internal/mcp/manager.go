@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"net/http"
 	"os"
 	"strings"
 	"sync"
@@ -12,7 +14,10 @@ import (
 	"pryx-core/internal/bus"
 	"pryx-core/internal/hostrpc"
 	"pryx-core/internal/keychain"
+	"pryx-core/internal/mcp/security"
 	"pryx-core/internal/policy"
+
+	"github.com/google/uuid"
 )
 
 type Manager struct {
@@ -22,12 +27,32 @@ type Manager struct {
 
 	mu      sync.RWMutex
 	clients map[string]*Client
+	servers map[string]*managedServer
+	natives map[string]*Client
+
+	// lifecycleMu serialises config reconciliation and restarts; ctx bounds
+	// every background connect loop and is cancelled by Close.
+	lifecycleMu sync.Mutex
+	configFP    []byte
+	ctx         context.Context
+	cancel      context.CancelFunc
 
 	cacheMu sync.RWMutex
 	cache   map[string]cachedTools
 
 	approvalMu       sync.Mutex
 	pendingApprovals map[string]pendingApproval
+	grants           GrantStore
+	noApprover       bool
+
+	schemas schemaCache
+	results resultCache
+
+	rootsMu      sync.RWMutex
+	sessionRoots map[string][]string
+
+	elicitMu            sync.Mutex
+	pendingElicitations map[string]*pendingElicitation
 }
 
 type cachedTools struct {
@@ -41,97 +66,79 @@ type pendingApproval struct {
 	tool      string
 	reason    string
 	args      map[string]interface{}
+	surface   string
+	// grantSurface is the caller's policy surface remembered grants are
+	// keyed on
+	grantSurface string
 }
 
 func NewManager(b *bus.Bus, p *policy.Engine, kc *keychain.Keychain) *Manager {
 	if p == nil {
 		p = policy.NewEngine(nil)
 	}
-	return &Manager{
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
 		bus:              b,
 		policy:           p,
 		keychain:         kc,
 		clients:          map[string]*Client{},
+		servers:          map[string]*managedServer{},
+		natives:          map[string]*Client{},
+		ctx:              ctx,
+		cancel:           cancel,
 		cache:            map[string]cachedTools{},
 		pendingApprovals: map[string]pendingApproval{},
 	}
+	p.SetProviders(m)
+	return m
 }
 
 func (m *Manager) ResolveApproval(approvalID string, approved bool) bool {
-	m.approvalMu.Lock()
-	pa, ok := m.pendingApprovals[approvalID]
-	if ok {
-		delete(m.pendingApprovals, approvalID)
-	}
-	m.approvalMu.Unlock()
-
-	if !ok {
-		return false
-	}
-
-	select {
-	case pa.ch <- approved:
-	default:
-	}
-
-	if m.bus != nil {
-		m.bus.Publish(bus.NewEvent(bus.EventApprovalResolved, pa.sessionID, map[string]interface{}{
-			"approval_id": approvalID,
-			"tool":        pa.tool,
-			"approved":    approved,
-		}))
-	}
-
-	return true
+	ok, _ := m.ResolveApprovalWith(approvalID, ApprovalResolution{Approved: approved})
+	return ok
 }
 
+// LoadAndConnect loads the servers config and reconciles the managed servers
+// with it. Each server connects independently; the returned error joins the
+// first-attempt failures, which keep retrying in the background.
 func (m *Manager) LoadAndConnect(ctx context.Context) (string, error) {
-	cfg, path, err := LoadServersConfigFromFirstExisting(DefaultServersConfigPaths())
+	fp := configFingerprint(DefaultServersConfigPaths())
+	m.lifecycleMu.Lock()
+	m.configFP = fp
+	m.lifecycleMu.Unlock()
+
+	cfg, path, err := loadServersConfigWithDefaults()
 	if err != nil {
 		return path, err
 	}
+	return path, m.ApplyConfig(ctx, cfg)
+}
 
-	if path == "" && len(cfg.Servers) == 0 {
-		cfg.Servers = map[string]ServerConfig{
-			"filesystem": {Transport: "bundled"},
-			"shell":      {Transport: "bundled"},
-			"browser":    {Transport: "bundled"},
-			"clipboard":  {Transport: "bundled"},
-		}
+// RegisterProvider serves an in-process provider as the MCP server name.
+// Registered providers are always ready, go through the same policy and
+// events as configured servers, and are not touched by servers.json reloads.
+func (m *Manager) RegisterProvider(name string, provider ToolProvider) error {
+	name = strings.TrimSpace(name)
+	if !ValidServerName(name) {
+		return fmt.Errorf("invalid server name: %q", name)
 	}
 
-	clients := map[string]*Client{}
-	for name, sc := range cfg.Servers {
-		client, err := m.buildClient(name, sc)
-		if err != nil {
-			return path, fmt.Errorf("%s: %w", name, err)
-		}
-		clients[name] = client
+	client := NewClient(NewBundledTransport(provider), "")
+	if err := client.Initialize(context.Background()); err != nil {
+		return err
 	}
 
-	var wg sync.WaitGroup
-	errs := make(chan error, len(clients))
-	for name, c := range clients {
-		wg.Add(1)
-		go func(name string, c *Client) {
-			defer wg.Done()
-			if err := c.Initialize(ctx); err != nil {
-				errs <- fmt.Errorf("%s: %w", name, err)
-			}
-		}(name, c)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.servers[name]; ok {
+		return fmt.Errorf("mcp server already configured: %s", name)
 	}
-	wg.Wait()
-	close(errs)
-
-	for err := range errs {
-		return path, err
+	if _, ok := m.natives[name]; ok {
+		return fmt.Errorf("mcp server already registered: %s", name)
 	}
-
-	m.mu.Lock()
-	m.clients = clients
-	m.mu.Unlock()
-
-	return path, nil
+	m.natives[name] = client
+	m.clients[name] = client
+	return nil
 }
 
 func (m *Manager) ListTools(ctx context.Context, refresh bool) (map[string][]Tool, error) {
@@ -168,6 +175,7 @@ func (m *Manager) ListToolsFlat(ctx context.Context, refresh bool) ([]Tool, erro
 				Description:  t.Description,
 				InputSchema:  t.InputSchema,
 				OutputSchema: t.OutputSchema,
+				Annotations:  t.Annotations,
 			})
 		}
 	}
@@ -184,22 +192,48 @@ func (m *Manager) CallTool(ctx context.Context, sessionID string, toolName strin
 	client := m.clients[server]
 	m.mu.RUnlock()
 	if client == nil {
+		if st, ok := m.ServerState(server); ok {
+			return ToolResult{}, fmt.Errorf("mcp server %s is %s", server, st.Status)
+		}
 		return ToolResult{}, fmt.Errorf("unknown mcp server: %s", server)
 	}
 
+	ctx = WithSessionID(ctx, sessionID)
 	fullName := fmt.Sprintf("mcp.%s.%s", server, name)
-	decision := m.policy.Evaluate(fullName, args)
+	callID := uuid.New().String()
+
+	mode := m.validationMode(server)
+	cacheCfg := m.cacheConfig(server)
+	var tool *Tool
+	if mode != ValidationOff || cacheCfg != nil {
+		tool = m.lookupTool(ctx, server, client, name)
+	}
+	if mode != ValidationOff {
+		if err := m.validateArgs(tool, args); err != nil {
+			m.publishValidationError(ctx, sessionID, fullName, "mcp.invalid_arguments", err)
+			return ToolResult{}, err
+		}
+	}
+
+	decision := m.policy.Decide(fullName, args, CallerFromContext(ctx, sessionID))
 	if m.bus != nil {
-		m.bus.Publish(bus.NewEvent(bus.EventToolRequest, sessionID, map[string]interface{}{
-			"tool":     fullName,
-			"args":     args,
-			"decision": decision,
-		}))
+		m.publishCall(ctx, bus.EventToolRequest, sessionID, ToolRequested{
+			CallID:   callID,
+			Tool:     fullName,
+			Args:     args,
+			Decision: decision,
+		})
 	}
 
 	switch decision.Decision {
 	case policy.DecisionAllow:
 	case policy.DecisionAsk:
+		if grant := m.rememberedDecision(ctx, sessionID, fullName, args); grant != nil {
+			if !grant.Approved {
+				return ToolResult{}, errors.New("denied by remembered decision")
+			}
+			break
+		}
 		if strings.TrimSpace(os.Getenv("PRYX_HOST_RPC")) == "1" {
 			client := hostrpc.NewDefaultClient()
 			approved, err := client.RequestPermission(hostrpc.PermissionRequest{
@@ -214,26 +248,32 @@ func (m *Manager) CallTool(ctx context.Context, sessionID string, toolName strin
 			}
 			return ToolResult{}, errors.New("denied by user")
 		}
+		if m.approvalsDisabled() {
+			return ToolResult{}, errors.New("denied by policy: approval required but no approver is available")
+		}
 		approvalID := fmt.Sprintf("%s-%d", sessionID, time.Now().UnixNano())
 		ch := make(chan bool, 1)
 
 		m.approvalMu.Lock()
 		m.pendingApprovals[approvalID] = pendingApproval{
-			ch:        ch,
-			sessionID: sessionID,
-			tool:      fullName,
-			reason:    decision.Reason,
-			args:      args,
+			ch:           ch,
+			sessionID:    sessionID,
+			tool:         fullName,
+			reason:       decision.Reason,
+			args:         args,
+			surface:      SurfaceFromContext(ctx),
+			grantSurface: CallerFromContext(ctx, sessionID).Surface,
 		}
 		m.approvalMu.Unlock()
 
 		if m.bus != nil {
-			m.bus.Publish(bus.NewEvent(bus.EventApprovalNeeded, sessionID, map[string]interface{}{
-				"approval_id": approvalID,
-				"tool":        fullName,
-				"args":        args,
-				"reason":      decision.Reason,
-			}))
+			m.publishCall(ctx, bus.EventApprovalNeeded, sessionID, ApprovalNeeded{
+				ApprovalID: approvalID,
+				Tool:       fullName,
+				Args:       args,
+				Reason:     decision.Reason,
+				Scopes:     m.approvalScopes(),
+			})
 		}
 
 		waitCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
@@ -254,31 +294,82 @@ func (m *Manager) CallTool(ctx context.Context, sessionID string, toolName strin
 	default:
 		return ToolResult{}, errors.New("unknown policy decision")
 	}
+	if err := m.policy.Charge(decision); err != nil {
+		return ToolResult{}, fmt.Errorf("denied by policy: %w", err)
+	}
+
+	// Policy and approval apply to cached results as to live calls
+	ttl := resultTTL(cacheCfg, tool)
+	cacheKey, cacheable := "", false
+	if ttl > 0 {
+		cacheKey, cacheable = resultCacheKey(sessionID, server, name, args)
+	}
+	if cacheable {
+		if res, ok := m.results.get(cacheKey, server); ok {
+			if m.bus != nil {
+				m.publishCall(ctx, bus.EventToolComplete, sessionID, ToolCompleted{
+					CallID: callID,
+					Tool:   fullName,
+					Result: res,
+					Cached: true,
+				})
+			}
+			return TruncateToolResultFor(res, OutputMeta{SessionID: sessionID, Tool: fullName, CallID: callID}), nil
+		}
+	}
 
 	if m.bus != nil {
-		m.bus.Publish(bus.NewEvent(bus.EventToolExecuting, sessionID, map[string]interface{}{
-			"tool": fullName,
-		}))
+		m.publishCall(ctx, bus.EventToolExecuting, sessionID, ToolExecuting{
+			CallID: callID,
+			Tool:   fullName,
+		})
 	}
 
+	mutating := cacheCfg != nil && mutatesServer(tool)
+	if mutating {
+		m.results.invalidate(server)
+	}
+	gen := m.results.generation(server)
 	res, err := client.CallTool(ctx, name, args)
+	if mutating {
+		// Again, in case a concurrent read cached what the call changed
+		m.results.invalidate(server)
+	}
 	if err != nil {
+		m.reportViolation(sessionID, server, fullName, err)
 		if m.bus != nil {
-			m.bus.Publish(bus.NewEvent(bus.EventErrorOccurred, sessionID, map[string]interface{}{
-				"tool":  fullName,
-				"error": err.Error(),
-			}))
+			m.publishCall(ctx, bus.EventErrorOccurred, sessionID, map[string]interface{}{
+				"call_id": callID,
+				"tool":    fullName,
+				"error":   err.Error(),
+			})
 		}
 		return ToolResult{}, err
 	}
 
+	if mode != ValidationOff {
+		if err := m.validateResult(tool, res, mode == ValidationStrict); err != nil {
+			m.publishValidationError(ctx, sessionID, fullName, "mcp.invalid_result", err)
+			if mode == ValidationStrict {
+				return ToolResult{}, err
+			}
+			cacheable = false
+		}
+	}
+
+	// Errors may be transient, so only successful results are cached
+	if cacheable && !res.IsError {
+		m.results.put(cacheKey, server, gen, res, ttl)
+	}
+
 	if m.bus != nil {
-		m.bus.Publish(bus.NewEvent(bus.EventToolComplete, sessionID, map[string]interface{}{
-			"tool":   fullName,
-			"result": res,
-		}))
+		m.publishCall(ctx, bus.EventToolComplete, sessionID, ToolCompleted{
+			CallID: callID,
+			Tool:   fullName,
+			Result: res,
+		})
 	}
-	return TruncateToolResult(res), nil
+	return TruncateToolResultFor(res, OutputMeta{SessionID: sessionID, Tool: fullName, CallID: callID}), nil
 }
 
 func (m *Manager) buildClient(name string, sc ServerConfig) (*Client, error) {
@@ -289,40 +380,104 @@ func (m *Manager) buildClient(name string, sc ServerConfig) (*Client, error) {
 		if err != nil {
 			return nil, err
 		}
+		if sc.Sandbox != nil {
+			sp, ok := provider.(sandboxedProvider)
+			if !ok {
+				return nil, fmt.Errorf("bundled server %s does not support sandbox", name)
+			}
+			sp.SetSandbox(security.NewSandbox(*sc.Sandbox))
+			if bundledKind(name) == "fetch" && !sc.Sandbox.AllowOutboundHTTP && !sc.Sandbox.AllowOutboundHTTPS {
+				log.Printf("mcp: sandbox for %s allows neither allow_outbound_http nor allow_outbound_https; every fetch will be refused", name)
+			}
+		}
+		if ap, ok := provider.(auditedProvider); ok && m.bus != nil {
+			ap.SetBus(m.bus)
+		}
 		tr := NewBundledTransport(provider)
 		return NewClient(tr, proto), nil
 	case "stdio":
 		tr := NewStdioTransport(sc.Command, sc.Cwd, sc.Env)
+		if sc.Sandbox != nil {
+			tr.SetSandbox(security.NewSandbox(*sc.Sandbox))
+		}
 		return NewClient(tr, proto), nil
 	case "http":
 		headers := map[string]string{}
 		for k, v := range sc.Headers {
 			headers[k] = v
 		}
-		if sc.Auth != nil {
+		if sc.Auth != nil && !usesOAuthFlow(sc.Auth) {
 			if err := m.applyAuth(headers, *sc.Auth); err != nil {
 				return nil, err
 			}
 		}
 		tr := NewHTTPTransport(sc.URL, headers)
+		if sc.CallTimeoutSeconds != 0 {
+			tr.SetCallTimeout(time.Duration(sc.CallTimeoutSeconds) * time.Second)
+		}
+		if usesOAuthFlow(sc.Auth) {
+			tr.SetRoundTripper(m.oauthTransport(name))
+		}
 		return NewClient(tr, proto), nil
 	case "sse":
 		headers := map[string]string{}
 		for k, v := range sc.Headers {
 			headers[k] = v
 		}
-		if sc.Auth != nil {
+		if sc.Auth != nil && !usesOAuthFlow(sc.Auth) {
 			if err := m.applyAuth(headers, *sc.Auth); err != nil {
 				return nil, err
 			}
 		}
 		tr := NewSSETransport(sc.URL, headers)
+		if usesOAuthFlow(sc.Auth) {
+			tr.SetRoundTripper(m.oauthTransport(name))
+		}
 		return NewClient(tr, proto), nil
 	default:
 		return nil, fmt.Errorf("unsupported transport: %s", sc.Transport)
 	}
 }
 
+// publishCall publishes a tool-call event tagged with the caller's surface.
+func (m *Manager) publishCall(ctx context.Context, eventType bus.EventType, sessionID string, payload interface{}) {
+	evt := bus.NewEvent(eventType, sessionID, payload)
+	evt.Surface = SurfaceFromContext(ctx)
+	m.bus.Publish(evt)
+}
+
+// sandboxedProvider is implemented by bundled providers that launch processes
+// or make network requests.
+type sandboxedProvider interface {
+	SetSandbox(sb *security.Sandbox)
+}
+
+// auditedProvider is implemented by bundled providers that use the event bus,
+// to publish their own events or follow session lifecycle.
+type auditedProvider interface {
+	SetBus(b *bus.Bus)
+}
+
+// reportViolation publishes a sandbox violation event when err carries one.
+// Bundled providers' violations come back through their transport typed.
+func (m *Manager) reportViolation(sessionID, server, tool string, err error) {
+	if m.bus == nil || err == nil {
+		return
+	}
+	v, ok := security.AsViolation(err)
+	if !ok {
+		return
+	}
+	payload := SandboxViolation{
+		Server:  server,
+		Tool:    tool,
+		Error:   err.Error(),
+		Kind:    v.Kind,
+		Command: v.Command,
+	}
+	m.bus.Publish(bus.NewEvent(bus.EventSandboxViolation, sessionID, payload))
+}
+
 func (m *Manager) applyAuth(headers map[string]string, ac AuthConfig) error {
 	if strings.ToLower(strings.TrimSpace(ac.Type)) != "oauth" {
 		return nil
@@ -352,6 +507,12 @@ func (m *Manager) applyAuth(headers map[string]string, ac AuthConfig) error {
 	return nil
 }
 
+// oauthTransport authorizes requests to server name with the credentials
+// stored by `pryx-core mcp auth <name> login`.
+func (m *Manager) oauthTransport(name string) http.RoundTripper {
+	return &oauthRoundTripper{source: &oauthTokenSource{kc: m.keychain, name: name}}
+}
+
 func (m *Manager) listToolsCached(ctx context.Context, name string, c *Client, refresh bool) ([]Tool, error) {
 	if !refresh {
 		m.cacheMu.RLock()
@@ -374,6 +535,19 @@ func (m *Manager) listToolsCached(ctx context.Context, name string, c *Client, r
 	return tools, nil
 }
 
+// forgetTools drops the cached tool list of server name, so the next
+// listing asks the server again.
+func (m *Manager) forgetTools(name string) {
+	m.cacheMu.Lock()
+	delete(m.cache, name)
+	m.cacheMu.Unlock()
+
+	m.publish(bus.EventTraceEvent, map[string]interface{}{
+		"kind":   "mcp.tools_changed",
+		"server": name,
+	})
+}
+
 func splitToolName(full string) (string, string) {
 	full = strings.TrimSpace(full)
 	if full == "" {
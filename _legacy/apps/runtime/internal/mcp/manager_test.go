@@ -2,11 +2,13 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"pryx-core/internal/bus"
 	"pryx-core/internal/keychain"
+	"pryx-core/internal/mcp/security"
 	"pryx-core/internal/policy"
 
 	"github.com/stretchr/testify/assert"
@@ -97,14 +99,44 @@ func TestManager_ResolveApproval_BusEvent(t *testing.T) {
 	case evt := <-events:
 		assert.Equal(t, bus.EventApprovalResolved, evt.Event)
 		assert.Equal(t, "session-2", evt.SessionID)
-		payload := evt.Payload.(map[string]interface{})
-		assert.Equal(t, approvalID, payload["approval_id"])
-		assert.False(t, payload["approved"].(bool))
+		payload := evt.Payload.(ApprovalResolved)
+		assert.Equal(t, approvalID, payload.ApprovalID)
+		assert.False(t, payload.Approved)
 	case <-time.After(100 * time.Millisecond):
 		t.Fatal("Expected event to be published")
 	}
 }
 
+func TestManager_ReportViolation(t *testing.T) {
+	b := bus.New()
+	events, cancel := b.Subscribe(bus.EventSandboxViolation)
+	defer cancel()
+
+	mgr := NewManager(b, nil, nil)
+	mgr.reportViolation("session-3", "shell", "mcp.shell.exec", errors.New("exit status 1"))
+	mgr.reportViolation("session-3", "shell", "mcp.shell.exec", &security.ViolationError{
+		Kind:    security.ViolationShell,
+		Command: "sh",
+		Detail:  "shell execution is not allowed: sh",
+	})
+
+	select {
+	case evt := <-events:
+		assert.Equal(t, "session-3", evt.SessionID)
+		payload := evt.Payload.(SandboxViolation)
+		assert.Equal(t, security.ViolationShell, payload.Kind)
+		assert.Equal(t, "mcp.shell.exec", payload.Tool)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Expected violation event to be published")
+	}
+
+	select {
+	case evt := <-events:
+		t.Fatalf("Unexpected extra event: %+v", evt)
+	default:
+	}
+}
+
 func TestSplitToolName(t *testing.T) {
 	tests := []struct {
 		name         string
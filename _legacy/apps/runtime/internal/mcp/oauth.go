@@ -0,0 +1,524 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"pryx-core/internal/auth"
+	"pryx-core/internal/keychain"
+)
+
+// oauthRefreshSkew refreshes access tokens slightly before they expire
+const oauthRefreshSkew = time.Minute
+
+// ProtectedResourceMetadata is the RFC 9728 document an MCP server publishes
+// to name its authorization servers.
+type ProtectedResourceMetadata struct {
+	Resource             string   `json:"resource"`
+	AuthorizationServers []string `json:"authorization_servers"`
+	ScopesSupported      []string `json:"scopes_supported,omitempty"`
+}
+
+// AuthorizationServerMetadata is the RFC 8414 authorization server document
+type AuthorizationServerMetadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	RegistrationEndpoint          string   `json:"registration_endpoint,omitempty"`
+	ScopesSupported               []string `json:"scopes_supported,omitempty"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+// OAuthDiscovery is the result of MCP authorization discovery
+type OAuthDiscovery struct {
+	Resource string
+	Scopes   []string
+	Server   AuthorizationServerMetadata
+}
+
+// OAuthCredentials are the client registration and tokens stored in the
+// keychain for a remote MCP server.
+type OAuthCredentials struct {
+	ClientID      string    `json:"client_id"`
+	ClientSecret  string    `json:"client_secret,omitempty"`
+	TokenEndpoint string    `json:"token_endpoint"`
+	Resource      string    `json:"resource,omitempty"`
+	AccessToken   string    `json:"access_token"`
+	RefreshToken  string    `json:"refresh_token,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at,omitempty"`
+}
+
+// OAuthKeychainKey is the keychain entry holding credentials for server name
+func OAuthKeychainKey(name string) string {
+	return "mcp_oauth_" + name
+}
+
+// LoadOAuthCredentials reads the stored credentials for server name
+func LoadOAuthCredentials(kc *keychain.Keychain, name string) (*OAuthCredentials, error) {
+	if kc == nil {
+		return nil, errors.New("keychain not available")
+	}
+	raw, err := kc.Get(OAuthKeychainKey(name))
+	if err != nil || strings.TrimSpace(raw) == "" {
+		return nil, fmt.Errorf("mcp server %s is not authorized; run 'pryx-core mcp auth %s login'", name, name)
+	}
+	creds := &OAuthCredentials{}
+	if err := json.Unmarshal([]byte(raw), creds); err != nil {
+		return nil, fmt.Errorf("invalid stored credentials for %s: %w", name, err)
+	}
+	return creds, nil
+}
+
+// SaveOAuthCredentials stores credentials for server name
+func SaveOAuthCredentials(kc *keychain.Keychain, name string, creds *OAuthCredentials) error {
+	if kc == nil {
+		return errors.New("keychain not available")
+	}
+	b, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	return kc.Set(OAuthKeychainKey(name), string(b))
+}
+
+// usesOAuthFlow reports whether auth is managed by the OAuth flow rather
+// than a static token reference.
+func usesOAuthFlow(ac *AuthConfig) bool {
+	return ac != nil && strings.EqualFold(strings.TrimSpace(ac.Type), "oauth") && strings.TrimSpace(ac.TokenRef) == ""
+}
+
+// DiscoverOAuth locates the authorization server for an MCP server URL. It
+// follows the resource_metadata hint of a 401 challenge, then the RFC 9728
+// well-known locations, and finally falls back to treating the server's
+// origin as the authorization server as earlier protocol revisions did.
+// The authorization server must publish RFC 8414 metadata for the issuer
+// it was looked up as and advertise PKCE S256; endpoints are never guessed.
+func DiscoverOAuth(ctx context.Context, client *http.Client, serverURL string) (*OAuthDiscovery, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	target, err := url.Parse(serverURL)
+	if err != nil || target.Host == "" {
+		return nil, fmt.Errorf("invalid server url: %s", serverURL)
+	}
+	origin := target.Scheme + "://" + target.Host
+	path := strings.TrimRight(target.Path, "/")
+
+	disc := &OAuthDiscovery{Resource: canonicalResource(target)}
+
+	metadataURL, scope := probeChallenge(ctx, client, serverURL)
+	if scope != "" {
+		disc.Scopes = strings.Fields(scope)
+	}
+
+	candidates := []string{}
+	if metadataURL != "" {
+		candidates = append(candidates, metadataURL)
+	}
+	if path != "" {
+		candidates = append(candidates, origin+"/.well-known/oauth-protected-resource"+path)
+	}
+	candidates = append(candidates, origin+"/.well-known/oauth-protected-resource")
+
+	var prm *ProtectedResourceMetadata
+	for _, u := range candidates {
+		var doc ProtectedResourceMetadata
+		if err := getJSON(ctx, client, u, &doc); err == nil && len(doc.AuthorizationServers) > 0 {
+			prm = &doc
+			break
+		}
+	}
+
+	issuer := origin
+	if prm != nil {
+		issuer = strings.TrimRight(prm.AuthorizationServers[0], "/")
+		if prm.Resource != "" {
+			// RFC 9728 §3.3: metadata naming another resource must be
+			// rejected, or tokens could be minted for the wrong audience.
+			if !resourceCovers(prm.Resource, target) {
+				return nil, fmt.Errorf("protected resource metadata is for %s, not %s", prm.Resource, disc.Resource)
+			}
+			disc.Resource = prm.Resource
+		}
+		if len(disc.Scopes) == 0 {
+			disc.Scopes = prm.ScopesSupported
+		}
+	}
+
+	asm, err := fetchAuthServerMetadata(ctx, client, issuer)
+	if err != nil {
+		return nil, err
+	}
+	// A server that does not list its PKCE methods may not support PKCE
+	// at all, and the code would then be usable without the verifier.
+	if !containsString(asm.CodeChallengeMethodsSupported, "S256") {
+		return nil, errors.New("authorization server does not advertise PKCE S256")
+	}
+	disc.Server = *asm
+	return disc, nil
+}
+
+// AuthorizeServer runs discovery, dynamic client registration (unless a
+// client_id is configured) and the browser PKCE flow for server name, then
+// stores the credentials in the keychain.
+func AuthorizeServer(ctx context.Context, kc *keychain.Keychain, name string, sc ServerConfig) (*OAuthCredentials, error) {
+	if strings.TrimSpace(sc.URL) == "" {
+		return nil, errors.New("oauth requires a remote server url")
+	}
+	client := &http.Client{Timeout: 15 * time.Second}
+
+	disc, err := DiscoverOAuth(ctx, client, sc.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	cb, err := auth.StartCallbackServer()
+	if err != nil {
+		return nil, err
+	}
+	defer cb.Close()
+
+	creds := &OAuthCredentials{
+		TokenEndpoint: disc.Server.TokenEndpoint,
+		Resource:      disc.Resource,
+	}
+	scopes := disc.Scopes
+	if sc.Auth != nil {
+		creds.ClientID = strings.TrimSpace(sc.Auth.ClientID)
+		if len(sc.Auth.Scopes) > 0 {
+			scopes = sc.Auth.Scopes
+		}
+	}
+	if creds.ClientID == "" {
+		if disc.Server.RegistrationEndpoint == "" {
+			return nil, errors.New("authorization server does not support dynamic client registration; set auth.client_id")
+		}
+		creds.ClientID, creds.ClientSecret, err = registerClient(ctx, client, disc.Server.RegistrationEndpoint, cb.RedirectURI())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	config := auth.ProviderConfig{
+		Name:         name,
+		ClientID:     creds.ClientID,
+		ClientSecret: creds.ClientSecret,
+		AuthURL:      disc.Server.AuthorizationEndpoint,
+		TokenURL:     disc.Server.TokenEndpoint,
+		Scopes:       scopes,
+		PKCEEnabled:  true,
+	}
+	tokens, err := auth.NewProviderOAuth(kc).AuthorizeCode(ctx, config, cb, resourceParam(creds.Resource))
+	if err != nil {
+		return nil, err
+	}
+	creds.setTokens(tokens)
+
+	if err := SaveOAuthCredentials(kc, name, creds); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+func (c *OAuthCredentials) setTokens(tokens *auth.TokenResponse) {
+	c.AccessToken = tokens.AccessToken
+	if tokens.RefreshToken != "" {
+		c.RefreshToken = tokens.RefreshToken
+	}
+	c.ExpiresAt = time.Time{}
+	if tokens.ExpiresIn > 0 {
+		c.ExpiresAt = time.Now().Add(time.Duration(tokens.ExpiresIn) * time.Second).UTC()
+	}
+}
+
+// oauthTokenSource hands out the stored access token for a server and
+// refreshes it when it expires or the server rejects it.
+type oauthTokenSource struct {
+	kc   *keychain.Keychain
+	name string
+
+	mu    sync.Mutex
+	creds *OAuthCredentials
+}
+
+func (s *oauthTokenSource) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.creds == nil {
+		creds, err := LoadOAuthCredentials(s.kc, s.name)
+		if err != nil {
+			return "", err
+		}
+		s.creds = creds
+	}
+	if !s.creds.ExpiresAt.IsZero() && time.Until(s.creds.ExpiresAt) < oauthRefreshSkew && s.creds.RefreshToken != "" {
+		if err := s.refreshLocked(ctx); err != nil {
+			return "", err
+		}
+	}
+	return s.creds.AccessToken, nil
+}
+
+// refresh renews the token unless another request already replaced rejected
+func (s *oauthTokenSource) refresh(ctx context.Context, rejected string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.creds == nil {
+		creds, err := LoadOAuthCredentials(s.kc, s.name)
+		if err != nil {
+			return "", err
+		}
+		s.creds = creds
+	}
+	if s.creds.AccessToken != rejected {
+		return s.creds.AccessToken, nil
+	}
+	if s.creds.RefreshToken == "" {
+		return "", fmt.Errorf("mcp server %s rejected the access token; run 'pryx-core mcp auth %s login'", s.name, s.name)
+	}
+	if err := s.refreshLocked(ctx); err != nil {
+		return "", err
+	}
+	return s.creds.AccessToken, nil
+}
+
+func (s *oauthTokenSource) refreshLocked(ctx context.Context) error {
+	config := auth.ProviderConfig{
+		Name:         s.name,
+		ClientID:     s.creds.ClientID,
+		ClientSecret: s.creds.ClientSecret,
+		TokenURL:     s.creds.TokenEndpoint,
+	}
+	tokens, err := auth.NewProviderOAuth(s.kc).RefreshWithConfig(ctx, config, s.creds.RefreshToken, resourceParam(s.creds.Resource))
+	if err != nil {
+		return fmt.Errorf("mcp server %s: %w", s.name, err)
+	}
+	s.creds.setTokens(tokens)
+	return SaveOAuthCredentials(s.kc, s.name, s.creds)
+}
+
+// oauthRoundTripper authorizes requests with an oauthTokenSource and retries
+// once with a refreshed token when the server answers 401.
+type oauthRoundTripper struct {
+	base   http.RoundTripper
+	source *oauthTokenSource
+}
+
+func (rt *oauthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := rt.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	token, err := rt.source.token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := base.RoundTrip(withBearer(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	fresh, err := rt.source.refresh(req.Context(), token)
+	if err != nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	retry := withBearer(req, fresh)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return base.RoundTrip(retry)
+}
+
+func withBearer(req *http.Request, token string) *http.Request {
+	out := req.Clone(req.Context())
+	out.Header.Set("Authorization", "Bearer "+token)
+	return out
+}
+
+// registerClient performs RFC 7591 dynamic client registration as a public
+// client using PKCE.
+func registerClient(ctx context.Context, client *http.Client, endpoint, redirectURI string) (string, string, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"client_name":                "pryx",
+		"redirect_uris":              []string{redirectURI},
+		"grant_types":                []string{"authorization_code", "refresh_token"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("client registration failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return "", "", fmt.Errorf("client registration failed: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+
+	var out struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", "", fmt.Errorf("client registration failed: %w", err)
+	}
+	if out.ClientID == "" {
+		return "", "", errors.New("client registration returned no client_id")
+	}
+	return out.ClientID, out.ClientSecret, nil
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// probeChallenge sends an unauthenticated request and returns the
+// resource_metadata and scope parameters of the Bearer challenge, if any.
+func probeChallenge(ctx context.Context, client *http.Client, serverURL string) (string, string) {
+	body := []byte(`{"jsonrpc":"2.0","id":0,"method":"ping"}`)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, serverURL, bytes.NewReader(body))
+	if err != nil {
+		return "", ""
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", ""
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		return "", ""
+	}
+
+	var metadata, scope string
+	for _, h := range resp.Header.Values("WWW-Authenticate") {
+		for _, m := range challengeParam.FindAllStringSubmatch(h, -1) {
+			switch strings.ToLower(m[1]) {
+			case "resource_metadata":
+				metadata = m[2]
+			case "scope":
+				scope = m[2]
+			}
+		}
+	}
+	return metadata, scope
+}
+
+func fetchAuthServerMetadata(ctx context.Context, client *http.Client, issuer string) (*AuthorizationServerMetadata, error) {
+	u, err := url.Parse(issuer)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid authorization server: %s", issuer)
+	}
+	origin := u.Scheme + "://" + u.Host
+	path := strings.TrimRight(u.Path, "/")
+
+	var candidates []string
+	if path != "" {
+		candidates = []string{
+			origin + "/.well-known/oauth-authorization-server" + path,
+			origin + "/.well-known/openid-configuration" + path,
+			origin + path + "/.well-known/openid-configuration",
+		}
+	} else {
+		candidates = []string{
+			origin + "/.well-known/oauth-authorization-server",
+			origin + "/.well-known/openid-configuration",
+		}
+	}
+
+	for _, c := range candidates {
+		var doc AuthorizationServerMetadata
+		if err := getJSON(ctx, client, c, &doc); err == nil && doc.AuthorizationEndpoint != "" && doc.TokenEndpoint != "" {
+			// RFC 8414 §3.3: the metadata must name the issuer it was
+			// fetched for, or another server could supply the endpoints.
+			if strings.TrimRight(doc.Issuer, "/") != strings.TrimRight(issuer, "/") {
+				return nil, fmt.Errorf("authorization server metadata is for issuer %q, not %q", doc.Issuer, issuer)
+			}
+			return &doc, nil
+		}
+	}
+	return nil, fmt.Errorf("no authorization server metadata found for %s", issuer)
+}
+
+func getJSON(ctx context.Context, client *http.Client, u string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: HTTP %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// canonicalResource returns the RFC 8707 resource indicator for a server URL
+func canonicalResource(u *url.URL) string {
+	c := *u
+	c.Scheme = strings.ToLower(c.Scheme)
+	c.Host = strings.ToLower(c.Host)
+	c.RawQuery = ""
+	c.Fragment = ""
+	c.Path = strings.TrimRight(c.Path, "/")
+	c.RawPath = ""
+	return c.String()
+}
+
+// resourceCovers reports whether resource identifies target: the same
+// origin and either the same path or a parent of it.
+func resourceCovers(resource string, target *url.URL) bool {
+	u, err := url.Parse(resource)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	have, want := canonicalResource(u), canonicalResource(target)
+	return have == want || strings.HasPrefix(want, have+"/")
+}
+
+func resourceParam(resource string) url.Values {
+	if resource == "" {
+		return nil
+	}
+	return url.Values{"resource": {resource}}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
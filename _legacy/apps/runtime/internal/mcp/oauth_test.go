@@ -0,0 +1,302 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"pryx-core/internal/auth"
+	"pryx-core/internal/keychain"
+	"pryx-core/internal/policy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuthServer is an MCP server protected by its own OAuth 2.1
+// authorization server, with discovery, registration and PKCE.
+type fakeAuthServer struct {
+	*httptest.Server
+	t    *testing.T
+	mock *MockServer
+
+	mu         sync.Mutex
+	clients    map[string]string // client_id -> redirect_uri
+	challenges map[string]string // code -> code_challenge
+	valid      map[string]bool   // access tokens
+	refreshes  int
+	resources  []string
+
+	// prmResource overrides the resource named in the metadata
+	prmResource string
+	// asMetadata, when set, adjusts the authorization server metadata
+	asMetadata func(*AuthorizationServerMetadata)
+}
+
+func newFakeAuthServer(t *testing.T) *fakeAuthServer {
+	f := &fakeAuthServer{
+		t:          t,
+		mock:       NewMockServer(),
+		clients:    map[string]string{},
+		challenges: map[string]string{},
+		valid:      map[string]bool{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", f.handleMCP)
+	mux.HandleFunc("/.well-known/oauth-protected-resource/mcp", func(w http.ResponseWriter, r *http.Request) {
+		resource := f.URL + "/mcp"
+		if f.prmResource != "" {
+			resource = f.prmResource
+		}
+		writeJSON(w, ProtectedResourceMetadata{Resource: resource, AuthorizationServers: []string{f.URL + "/as"}})
+	})
+	mux.HandleFunc("/.well-known/oauth-authorization-server/as", func(w http.ResponseWriter, r *http.Request) {
+		md := AuthorizationServerMetadata{
+			Issuer:                        f.URL + "/as",
+			AuthorizationEndpoint:         f.URL + "/as/authorize",
+			TokenEndpoint:                 f.URL + "/as/token",
+			RegistrationEndpoint:          f.URL + "/as/register",
+			CodeChallengeMethodsSupported: []string{"S256"},
+		}
+		if f.asMetadata != nil {
+			f.asMetadata(&md)
+		}
+		writeJSON(w, md)
+	})
+	mux.HandleFunc("/as/register", f.handleRegister)
+	mux.HandleFunc("/as/authorize", f.handleAuthorize)
+	mux.HandleFunc("/as/token", f.handleToken)
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeAuthServer) handleMCP(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	f.mu.Lock()
+	ok := len(token) > 7 && f.valid[token[7:]]
+	f.mu.Unlock()
+	if !ok {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer resource_metadata="%s/.well-known/oauth-protected-resource/mcp"`, f.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var req RPCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if req.ID == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	writeJSON(w, f.mock.HandleRequest(r.Context(), req))
+}
+
+func (f *fakeAuthServer) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		RedirectURIs []string `json:"redirect_uris"`
+	}
+	require.NoError(f.t, json.NewDecoder(r.Body).Decode(&body))
+	require.Len(f.t, body.RedirectURIs, 1)
+
+	f.mu.Lock()
+	id := fmt.Sprintf("client-%d", len(f.clients)+1)
+	f.clients[id] = body.RedirectURIs[0]
+	f.mu.Unlock()
+
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, map[string]string{"client_id": id})
+}
+
+func (f *fakeAuthServer) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f.mu.Lock()
+	redirect, ok := f.clients[q.Get("client_id")]
+	f.mu.Unlock()
+	if !ok || redirect != q.Get("redirect_uri") || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "bad authorization request", http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	code := fmt.Sprintf("code-%d", len(f.challenges)+1)
+	f.challenges[code] = q.Get("code_challenge")
+	f.resources = append(f.resources, q.Get("resource"))
+	f.mu.Unlock()
+
+	http.Redirect(w, r, redirect+"?"+url.Values{"code": {code}, "state": {q.Get("state")}}.Encode(), http.StatusFound)
+}
+
+func (f *fakeAuthServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	require.NoError(f.t, r.ParseForm())
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.resources = append(f.resources, r.PostForm.Get("resource"))
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		challenge := f.challenges[r.PostForm.Get("code")]
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if challenge == "" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		delete(f.challenges, r.PostForm.Get("code"))
+		f.valid["access-1"] = true
+		writeJSON(w, map[string]interface{}{"access_token": "access-1", "refresh_token": "refresh-1", "expires_in": 3600, "token_type": "Bearer"})
+	case "refresh_token":
+		if r.PostForm.Get("refresh_token") != "refresh-1" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		f.refreshes++
+		f.valid["access-2"] = true
+		writeJSON(w, map[string]interface{}{"access_token": "access-2", "expires_in": 3600, "token_type": "Bearer"})
+	default:
+		http.Error(w, `{"error":"unsupported_grant_type"}`, http.StatusBadRequest)
+	}
+}
+
+func (f *fakeAuthServer) revoke(token string) {
+	f.mu.Lock()
+	delete(f.valid, token)
+	f.mu.Unlock()
+}
+
+func TestDiscoverOAuth(t *testing.T) {
+	f := newFakeAuthServer(t)
+
+	disc, err := DiscoverOAuth(context.Background(), nil, f.URL+"/mcp")
+	require.NoError(t, err)
+	assert.Equal(t, f.URL+"/mcp", disc.Resource)
+	assert.Equal(t, f.URL+"/as/token", disc.Server.TokenEndpoint)
+	assert.Equal(t, f.URL+"/as/register", disc.Server.RegistrationEndpoint)
+}
+
+func TestDiscoverOAuth_ResourceMismatch(t *testing.T) {
+	f := newFakeAuthServer(t)
+
+	f.prmResource = "https://other.example.com/mcp"
+	_, err := DiscoverOAuth(context.Background(), nil, f.URL+"/mcp")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "protected resource metadata is for")
+
+	f.prmResource = f.URL + "/mcp-admin"
+	_, err = DiscoverOAuth(context.Background(), nil, f.URL+"/mcp")
+	require.Error(t, err)
+
+	f.prmResource = f.URL
+	disc, err := DiscoverOAuth(context.Background(), nil, f.URL+"/mcp")
+	require.NoError(t, err)
+	assert.Equal(t, f.URL, disc.Resource)
+}
+
+func TestDiscoverOAuth_AuthServerMetadata(t *testing.T) {
+	f := newFakeAuthServer(t)
+
+	f.asMetadata = func(md *AuthorizationServerMetadata) { md.Issuer = "https://evil.example.com/as" }
+	_, err := DiscoverOAuth(context.Background(), nil, f.URL+"/mcp")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is for issuer")
+
+	f.asMetadata = func(md *AuthorizationServerMetadata) { md.CodeChallengeMethodsSupported = []string{"plain"} }
+	_, err = DiscoverOAuth(context.Background(), nil, f.URL+"/mcp")
+	require.Error(t, err)
+
+	f.asMetadata = func(md *AuthorizationServerMetadata) { md.CodeChallengeMethodsSupported = nil }
+	_, err = DiscoverOAuth(context.Background(), nil, f.URL+"/mcp")
+	require.Error(t, err, "a server that does not list S256 must be refused")
+
+	// Without any metadata the flow must not guess endpoints at the origin
+	bare := httptest.NewServer(http.NotFoundHandler())
+	defer bare.Close()
+	_, err = DiscoverOAuth(context.Background(), nil, bare.URL+"/mcp")
+	require.Error(t, err)
+}
+
+func TestAuthorizeServer_FlowAndRefresh(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PRYX_KEYCHAIN_FILE", filepath.Join(t.TempDir(), "keychain.json"))
+	kc := keychain.New("pryx")
+	f := newFakeAuthServer(t)
+
+	// Stand in for the user's browser: follow the redirect to the callback.
+	oldOpen := auth.OpenBrowser
+	auth.OpenBrowser = func(u string) error {
+		go func() {
+			resp, err := http.Get(u)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		return nil
+	}
+	defer func() { auth.OpenBrowser = oldOpen }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sc := ServerConfig{Transport: "http", URL: f.URL + "/mcp", Auth: &AuthConfig{Type: "oauth"}}
+	creds, err := AuthorizeServer(ctx, kc, "remote", sc)
+	require.NoError(t, err)
+	assert.Equal(t, "client-1", creds.ClientID)
+	assert.Equal(t, "access-1", creds.AccessToken)
+
+	stored, err := LoadOAuthCredentials(kc, "remote")
+	require.NoError(t, err)
+	assert.Equal(t, "refresh-1", stored.RefreshToken)
+
+	// The server rejects the stored token; the client refreshes and retries.
+	f.revoke("access-1")
+
+	m := NewManager(nil, policy.NewEngine(&policy.Policy{Default: policy.DecisionAllow}), kc)
+	defer m.Close()
+	require.NoError(t, m.ApplyConfig(ctx, &ServersConfig{Servers: map[string]ServerConfig{"remote": sc}}))
+
+	tools, err := m.ListToolsFlat(ctx, true)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tools)
+	assert.Equal(t, 1, f.refreshes)
+
+	stored, err = LoadOAuthCredentials(kc, "remote")
+	require.NoError(t, err)
+	assert.Equal(t, "access-2", stored.AccessToken)
+	assert.Equal(t, "refresh-1", stored.RefreshToken)
+
+	for _, r := range f.resources {
+		assert.Equal(t, f.URL+"/mcp", r)
+	}
+}
+
+func TestManager_OAuthNotAuthorized(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PRYX_KEYCHAIN_FILE", filepath.Join(t.TempDir(), "keychain.json"))
+	f := newFakeAuthServer(t)
+
+	m := NewManager(nil, nil, keychain.New("pryx"))
+	defer m.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := m.ApplyConfig(ctx, &ServersConfig{Servers: map[string]ServerConfig{
+		"remote": {Transport: "http", URL: f.URL + "/mcp", Auth: &AuthConfig{Type: "oauth"}},
+	}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mcp auth remote login")
+}
@@ -0,0 +1,200 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheTTL       = time.Minute
+	resultCacheMaxEntries = 1000
+)
+
+// CacheStats counts result cache activity for one server
+type CacheStats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Invalidations int64 `json:"invalidations"`
+	Entries       int   `json:"entries"`
+}
+
+// resultCache holds tool results keyed by session, server, tool and
+// arguments. The zero value is ready to use.
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResult
+	stats   map[string]*CacheStats
+	// generations counts the invalidations of each server, so a result
+	// read before one is not stored after it
+	generations map[string]uint64
+}
+
+type cachedResult struct {
+	server  string
+	result  ToolResult
+	expires time.Time
+}
+
+// resultCacheKey hashes the arguments so equal maps share a key; JSON
+// encoding sorts map keys.
+func resultCacheKey(sessionID, server, tool string, args map[string]interface{}) (string, bool) {
+	b, err := json.Marshal(args)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(b)
+	return strings.Join([]string{sessionID, server, tool, hex.EncodeToString(sum[:])}, "\x00"), true
+}
+
+func (c *resultCache) get(key, server string) (ToolResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.statsFor(server)
+	e, ok := c.entries[key]
+	if ok && time.Now().Before(e.expires) {
+		st.Hits++
+		return e.result, true
+	}
+	if ok {
+		delete(c.entries, key)
+	}
+	st.Misses++
+	return ToolResult{}, false
+}
+
+// generation returns the invalidation count of server, to pass to put
+// once the call it is taken before returns.
+func (c *resultCache) generation(server string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generations[server]
+}
+
+// put stores res unless server was invalidated since generation gen.
+func (c *resultCache) put(key, server string, gen uint64, res ToolResult, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generations[server] != gen {
+		return
+	}
+	if c.entries == nil {
+		c.entries = map[string]cachedResult{}
+	}
+	now := time.Now()
+	if len(c.entries) >= resultCacheMaxEntries {
+		// Drop expired entries, then the one closest to expiring
+		oldest := ""
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+				continue
+			}
+			if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		if len(c.entries) >= resultCacheMaxEntries {
+			delete(c.entries, oldest)
+		}
+	}
+	c.entries[key] = cachedResult{server: server, result: res, expires: now.Add(ttl)}
+}
+
+// invalidate drops every cached result of server
+func (c *resultCache) invalidate(server string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generations == nil {
+		c.generations = map[string]uint64{}
+	}
+	c.generations[server]++
+	dropped := false
+	for k, e := range c.entries {
+		if e.server == server {
+			delete(c.entries, k)
+			dropped = true
+		}
+	}
+	if dropped {
+		c.statsFor(server).Invalidations++
+	}
+}
+
+func (c *resultCache) snapshot() map[string]CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]CacheStats, len(c.stats))
+	for server, st := range c.stats {
+		out[server] = *st
+	}
+	now := time.Now()
+	for _, e := range c.entries {
+		if now.Before(e.expires) {
+			st := out[e.server]
+			st.Entries++
+			out[e.server] = st
+		}
+	}
+	return out
+}
+
+func (c *resultCache) statsFor(server string) *CacheStats {
+	if c.stats == nil {
+		c.stats = map[string]*CacheStats{}
+	}
+	st, ok := c.stats[server]
+	if !ok {
+		st = &CacheStats{}
+		c.stats[server] = st
+	}
+	return st
+}
+
+// cacheConfig returns the result cache settings of server, or nil when
+// caching is off.
+func (m *Manager) cacheConfig(server string) *CacheConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if ms, ok := m.servers[server]; ok {
+		return ms.config.Cache
+	}
+	return nil
+}
+
+// resultTTL returns how long results of tool may be cached: zero unless the
+// tool is listed in the server's cache config and the server declares it
+// read-only or idempotent.
+func resultTTL(cfg *CacheConfig, tool *Tool) time.Duration {
+	if cfg == nil || tool == nil || tool.Annotations == nil {
+		return 0
+	}
+	if !tool.Annotations.ReadOnlyHint && !tool.Annotations.IdempotentHint {
+		return 0
+	}
+	if !slices.Contains(cfg.Tools, "*") && !slices.Contains(cfg.Tools, tool.Name) {
+		return 0
+	}
+	if secs, ok := cfg.ToolTTLSeconds[tool.Name]; ok && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if cfg.TTLSeconds > 0 {
+		return time.Duration(cfg.TTLSeconds) * time.Second
+	}
+	return defaultCacheTTL
+}
+
+// mutatesServer reports whether a call to tool may change what the
+// server's other tools return. Undeclared tools are assumed to.
+func mutatesServer(tool *Tool) bool {
+	return tool == nil || tool.Annotations == nil || !tool.Annotations.ReadOnlyHint
+}
+
+// CacheStats returns result cache activity per server.
+func (m *Manager) CacheStats() map[string]CacheStats {
+	return m.results.snapshot()
+}
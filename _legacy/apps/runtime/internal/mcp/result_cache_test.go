@@ -0,0 +1,189 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"pryx-core/internal/bus"
+)
+
+// countingProvider counts calls per tool and stamps each result with the
+// time, so a cached result is recognisable.
+type countingProvider struct {
+	calls map[string]int
+}
+
+func (p *countingProvider) ServerInfo() map[string]interface{} {
+	return map[string]interface{}{"name": "counting", "version": "test"}
+}
+
+func (p *countingProvider) ListTools(ctx context.Context) ([]Tool, error) {
+	return []Tool{
+		{Name: "lookup", Annotations: &ToolAnnotations{ReadOnlyHint: true}},
+		{Name: "search", Annotations: &ToolAnnotations{IdempotentHint: true, ReadOnlyHint: true}},
+		{Name: "bump"},
+		{Name: "broken", Annotations: &ToolAnnotations{ReadOnlyHint: true}},
+		{Name: "typed", Annotations: &ToolAnnotations{ReadOnlyHint: true}, OutputSchema: json.RawMessage(`{"type":"object","required":["count"]}`)},
+	}, nil
+}
+
+func (p *countingProvider) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (ToolResult, error) {
+	p.calls[name]++
+	switch name {
+	case "broken":
+		return ToolResult{Content: []ToolContent{{Type: "text", Text: "backend unavailable"}}, IsError: true}, nil
+	case "typed":
+		return ToolResult{Content: []ToolContent{{Type: "text", Text: "{}"}}, StructuredContent: json.RawMessage(`{}`)}, nil
+	}
+	return ToolResult{Content: []ToolContent{{Type: "text", Text: name + " " + time.Now().String()}}}, nil
+}
+
+func newCachingManager(t *testing.T, b *bus.Bus, cfg *CacheConfig) (*Manager, *countingProvider) {
+	t.Helper()
+	m := newAllowAllManager(t, b)
+	t.Cleanup(func() { m.Close() })
+	p := &countingProvider{calls: map[string]int{}}
+	client := NewClient(NewBundledTransport(p), "")
+	m.mu.Lock()
+	done := make(chan struct{})
+	close(done)
+	m.servers["docs"] = &managedServer{name: "docs", config: ServerConfig{Transport: "bundled", Cache: cfg}, cancel: func() {}, done: done}
+	m.clients["docs"] = client
+	m.mu.Unlock()
+	return m, p
+}
+
+func TestManager_ResultCache(t *testing.T) {
+	b := bus.New()
+	events, cancel := b.Subscribe(bus.EventToolComplete)
+	defer cancel()
+	m, p := newCachingManager(t, b, &CacheConfig{Tools: []string{"lookup", "bump"}})
+	ctx := context.Background()
+
+	call := func(session, tool string, args map[string]interface{}) string {
+		t.Helper()
+		res, err := m.CallTool(ctx, session, "docs:"+tool, args)
+		if err != nil {
+			t.Fatalf("%s: %v", tool, err)
+		}
+		return res.Content[0].Text
+	}
+
+	first := call("s1", "lookup", map[string]interface{}{"q": "go", "n": 1})
+	if again := call("s1", "lookup", map[string]interface{}{"n": 1, "q": "go"}); again != first || p.calls["lookup"] != 1 {
+		t.Fatalf("expected a cache hit, calls=%d", p.calls["lookup"])
+	}
+	<-events
+	evt := <-events
+	if !evt.Payload.(ToolCompleted).Cached {
+		t.Fatalf("cache hit not marked in event: %v", evt.Payload)
+	}
+
+	call("s1", "lookup", map[string]interface{}{"q": "rust", "n": 1})
+	call("s2", "lookup", map[string]interface{}{"q": "go", "n": 1})
+	if p.calls["lookup"] != 3 {
+		t.Fatalf("different args or sessions should miss, calls=%d", p.calls["lookup"])
+	}
+
+	// search is read-only but not listed; bump is listed but not read-only
+	// or idempotent, so neither is cached and bump clears the server
+	call("s1", "search", nil)
+	call("s1", "search", nil)
+	call("s1", "bump", nil)
+	call("s1", "bump", nil)
+	if p.calls["search"] != 2 || p.calls["bump"] != 2 {
+		t.Fatalf("unexpected calls: %v", p.calls)
+	}
+	call("s1", "lookup", map[string]interface{}{"q": "go", "n": 1})
+	if p.calls["lookup"] != 4 {
+		t.Fatal("mutating tool did not invalidate the cache")
+	}
+
+	st := m.CacheStats()["docs"]
+	if st.Hits != 1 || st.Misses != 4 || st.Invalidations != 1 || st.Entries != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestManager_ResultCacheTTL(t *testing.T) {
+	m, p := newCachingManager(t, nil, &CacheConfig{Tools: []string{"*"}, ToolTTLSeconds: map[string]int{"search": 60}})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.CallTool(ctx, "s1", "docs:search", nil); err != nil {
+			t.Fatalf("search: %v", err)
+		}
+	}
+	if p.calls["search"] != 1 {
+		t.Fatalf("expected a cache hit, calls=%d", p.calls["search"])
+	}
+
+	m.results.mu.Lock()
+	for k, e := range m.results.entries {
+		e.expires = time.Now().Add(-time.Second)
+		m.results.entries[k] = e
+	}
+	m.results.mu.Unlock()
+	if _, err := m.CallTool(ctx, "s1", "docs:search", nil); err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if p.calls["search"] != 2 {
+		t.Fatal("expired entry was served")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.CallTool(ctx, "s1", "docs:broken", nil); err == nil {
+			t.Fatal("expected the error result to fail the call")
+		}
+	}
+	if p.calls["broken"] != 2 {
+		t.Fatal("error result was cached")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.CallTool(ctx, "s1", "docs:typed", nil); err != nil {
+			t.Fatalf("typed: %v", err)
+		}
+	}
+	if p.calls["typed"] != 2 {
+		t.Fatal("result that failed output validation was cached")
+	}
+}
+
+func TestResultCache_StalePut(t *testing.T) {
+	var c resultCache
+	gen := c.generation("docs")
+	c.invalidate("docs")
+	c.put("k", "docs", gen, ToolResult{}, time.Minute)
+	if _, ok := c.get("k", "docs"); ok {
+		t.Fatal("result read before an invalidation was cached")
+	}
+	c.put("k", "docs", c.generation("docs"), ToolResult{}, time.Minute)
+	if _, ok := c.get("k", "docs"); !ok {
+		t.Fatal("current result was not cached")
+	}
+}
+
+func TestResultTTL(t *testing.T) {
+	ro := &Tool{Name: "a", Annotations: &ToolAnnotations{ReadOnlyHint: true}}
+	cases := []struct {
+		cfg  *CacheConfig
+		tool *Tool
+		want time.Duration
+	}{
+		{nil, ro, 0},
+		{&CacheConfig{Tools: []string{"a"}}, nil, 0},
+		{&CacheConfig{Tools: []string{"a"}}, &Tool{Name: "a"}, 0},
+		{&CacheConfig{Tools: []string{"b"}}, ro, 0},
+		{&CacheConfig{Tools: []string{"a"}}, ro, defaultCacheTTL},
+		{&CacheConfig{Tools: []string{"*"}, TTLSeconds: 5}, ro, 5 * time.Second},
+		{&CacheConfig{Tools: []string{"*"}, TTLSeconds: 5, ToolTTLSeconds: map[string]int{"a": 30}}, ro, 30 * time.Second},
+	}
+	for i, tc := range cases {
+		if got := resultTTL(tc.cfg, tc.tool); got != tc.want {
+			t.Errorf("case %d: got %v, want %v", i, got, tc.want)
+		}
+	}
+}
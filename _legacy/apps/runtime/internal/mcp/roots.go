@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"pryx-core/internal/policy"
+)
+
+// Root is a directory a server may work in, as listed by roots/list.
+type Root struct {
+	URI  string `json:"uri"`
+	Name string `json:"name,omitempty"`
+}
+
+// fileRoot returns the root for an absolute directory.
+func fileRoot(dir string) Root {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}
+	return Root{URI: u.String(), Name: filepath.Base(dir)}
+}
+
+// SetSessionRoots sets the workspace directories of a session, replacing
+// the workspace root for servers serving its calls. Nil dirs restores the
+// default. Servers are told when the roots change.
+func (m *Manager) SetSessionRoots(sessionID string, dirs []string) error {
+	var clean []string
+	for _, d := range dirs {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		abs, err := filepath.Abs(d)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(clean, abs) {
+			clean = append(clean, abs)
+		}
+	}
+
+	m.rootsMu.Lock()
+	prev, had := m.sessionRoots[sessionID]
+	if len(clean) == 0 {
+		delete(m.sessionRoots, sessionID)
+	} else {
+		if m.sessionRoots == nil {
+			m.sessionRoots = map[string][]string{}
+		}
+		m.sessionRoots[sessionID] = clean
+	}
+	m.rootsMu.Unlock()
+
+	if (had || len(clean) > 0) && !slices.Equal(prev, clean) {
+		m.notifyRootsChanged()
+	}
+	return nil
+}
+
+// SessionRoots returns the workspace directories servers see for sessionID.
+func (m *Manager) SessionRoots(sessionID string) []string {
+	m.rootsMu.RLock()
+	dirs := slices.Clone(m.sessionRoots[sessionID])
+	m.rootsMu.RUnlock()
+	if len(dirs) > 0 {
+		return dirs
+	}
+	if root := policy.WorkspaceRoot(); root != "" {
+		return []string{root}
+	}
+	return nil
+}
+
+// rootsFor answers roots/list for the session the request serves.
+func (m *Manager) rootsFor(ctx context.Context) []Root {
+	dirs := m.SessionRoots(SessionIDFromContext(ctx))
+	roots := make([]Root, 0, len(dirs))
+	for _, d := range dirs {
+		roots = append(roots, fileRoot(d))
+	}
+	return roots
+}
+
+// notifyRootsChanged tells every connected server to list roots again.
+func (m *Manager) notifyRootsChanged() {
+	m.mu.RLock()
+	clients := make([]*Client, 0, len(m.clients))
+	for _, c := range m.clients {
+		clients = append(clients, c)
+	}
+	m.mu.RUnlock()
+
+	for _, c := range clients {
+		go func(c *Client) {
+			ctx, cancel := context.WithTimeout(m.ctx, 5*time.Second)
+			defer cancel()
+			_ = c.NotifyRootsChanged(ctx)
+		}(c)
+	}
+}
+
+// clientHooks answers server requests on behalf of the named server.
+func (m *Manager) clientHooks(server string) ClientHooks {
+	return ClientHooks{
+		Roots: m.rootsFor,
+		Elicit: func(ctx context.Context, req ElicitRequest) (ElicitResult, error) {
+			return m.elicit(ctx, server, req)
+		},
+	}
+}
@@ -0,0 +1,552 @@
+// Package schema validates JSON values against the subset of JSON Schema
+// (draft 2020-12) that MCP tool input and output schemas use in practice.
+//
+// Supported keywords: type, enum, const, properties, required,
+// additionalProperties, patternProperties, propertyNames, minProperties,
+// maxProperties, items, prefixItems, minItems, maxItems, uniqueItems,
+// minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, multipleOf, allOf, anyOf, oneOf, not, $ref (local
+// references into $defs/definitions) and $defs. Other keywords, including
+// format, are accepted and ignored. Patterns use Go's RE2 syntax; ECMA-262
+// features RE2 lacks, such as lookaround and backreferences, are not checked
+// (see SkippedPatterns).
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxRefDepth bounds $ref expansion so recursive schemas cannot loop forever
+const maxRefDepth = 64
+
+// Schema is a compiled JSON Schema
+type Schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+	skipped  []string
+}
+
+// FieldError describes one validation failure
+type FieldError struct {
+	// Path locates the offending value, e.g. "options.paths[2]"; empty for
+	// the top-level value.
+	Path    string
+	Message string
+}
+
+func (e FieldError) String() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError lists every failure found in a value
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		lines = append(lines, "- "+fe.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Compile parses a schema document. An empty document yields a schema that
+// accepts everything.
+func Compile(raw json.RawMessage) (*Schema, error) {
+	s := &Schema{root: true, patterns: map[string]*regexp.Regexp{}}
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(raw, &s.root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	s.compilePatterns(s.root)
+	return s, nil
+}
+
+// SkippedPatterns lists the patterns RE2 could not compile. Values are not
+// checked against them.
+func (s *Schema) SkippedPatterns() []string {
+	return s.skipped
+}
+
+// Validate checks v, which may be any Go value that marshals to JSON. It
+// returns a *ValidationError describing every failure.
+func (s *Schema) Validate(v interface{}) error {
+	doc, err := normalize(v)
+	if err != nil {
+		return err
+	}
+	vc := &validator{schema: s}
+	vc.validate(s.root, doc, "", 0)
+	if len(vc.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: vc.errs}
+}
+
+// normalize converts v into the generic form produced by encoding/json so
+// integers, structs and typed maps validate like their JSON encoding.
+func normalize(v interface{}) (interface{}, error) {
+	switch v.(type) {
+	case nil, bool, string, float64, map[string]interface{}, []interface{}:
+		if !containsTyped(v) {
+			return v, nil
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func containsTyped(v interface{}) bool {
+	switch t := v.(type) {
+	case nil, bool, string, float64:
+		return false
+	case map[string]interface{}:
+		for _, e := range t {
+			if containsTyped(e) {
+				return true
+			}
+		}
+		return false
+	case []interface{}:
+		for _, e := range t {
+			if containsTyped(e) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// dataKeywords hold instance values rather than subschemas
+var dataKeywords = map[string]bool{"const": true, "default": true, "enum": true, "examples": true}
+
+func (s *Schema) compilePatterns(node interface{}) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if p, ok := n["pattern"].(string); ok {
+			s.addPattern(p)
+		}
+		if pp, ok := n["patternProperties"].(map[string]interface{}); ok {
+			for p := range pp {
+				s.addPattern(p)
+			}
+		}
+		for k, child := range n {
+			if !dataKeywords[k] {
+				s.compilePatterns(child)
+			}
+		}
+	case []interface{}:
+		for _, child := range n {
+			s.compilePatterns(child)
+		}
+	}
+}
+
+func (s *Schema) addPattern(p string) {
+	if _, ok := s.patterns[p]; ok {
+		return
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		s.skipped = append(s.skipped, p)
+	}
+	// A nil entry marks a skipped pattern, which every value matches
+	s.patterns[p] = re
+}
+
+// resolveRef follows a local JSON pointer such as "#/$defs/item"
+func (s *Schema) resolveRef(ref string) (interface{}, bool) {
+	if ref == "#" {
+		return s.root, true
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+	node := s.root
+	for _, tok := range strings.Split(ref[2:], "/") {
+		tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[tok]
+			if !ok {
+				return nil, false
+			}
+			node = child
+		case []interface{}:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, false
+			}
+			node = n[i]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+type validator struct {
+	schema *Schema
+	errs   []FieldError
+}
+
+func (vc *validator) fail(path, format string, args ...interface{}) {
+	vc.errs = append(vc.errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// matches reports whether v satisfies node without recording errors
+func (vc *validator) matches(node, v interface{}, depth int) bool {
+	sub := &validator{schema: vc.schema}
+	sub.validate(node, v, "", depth)
+	return len(sub.errs) == 0
+}
+
+func (vc *validator) validate(node, v interface{}, path string, depth int) {
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			vc.fail(path, "no value is allowed here")
+		}
+		return
+	case map[string]interface{}:
+		vc.validateObjectSchema(n, v, path, depth)
+	}
+}
+
+func (vc *validator) validateObjectSchema(n map[string]interface{}, v interface{}, path string, depth int) {
+	if ref, ok := n["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			vc.fail(path, "schema reference nesting too deep")
+			return
+		}
+		target, ok := vc.schema.resolveRef(ref)
+		if !ok {
+			vc.fail(path, "unresolvable schema reference %s", ref)
+			return
+		}
+		vc.validate(target, v, path, depth+1)
+	}
+
+	if t, ok := n["type"]; ok && !typeMatches(t, v) {
+		vc.fail(path, "expected %s, got %s", describeType(t), jsonType(v))
+		return
+	}
+
+	if c, ok := n["const"]; ok && !reflect.DeepEqual(c, v) {
+		vc.fail(path, "must be %s", compact(c))
+	}
+	if enum, ok := n["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			opts := make([]string, 0, len(enum))
+			for _, e := range enum {
+				opts = append(opts, compact(e))
+			}
+			vc.fail(path, "must be one of %s", strings.Join(opts, ", "))
+		}
+	}
+
+	switch val := v.(type) {
+	case string:
+		vc.validateString(n, val, path)
+	case float64:
+		vc.validateNumber(n, val, path)
+	case map[string]interface{}:
+		vc.validateObject(n, val, path, depth)
+	case []interface{}:
+		vc.validateArray(n, val, path, depth)
+	}
+
+	if all, ok := n["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			vc.validate(sub, v, path, depth+1)
+		}
+	}
+	if anyOf, ok := n["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if vc.matches(sub, v, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			vc.fail(path, "does not match any of the allowed schemas")
+		}
+	}
+	if oneOf, ok := n["oneOf"].([]interface{}); ok {
+		count := 0
+		for _, sub := range oneOf {
+			if vc.matches(sub, v, depth+1) {
+				count++
+			}
+		}
+		if count != 1 {
+			vc.fail(path, "must match exactly one of the allowed schemas (matched %d)", count)
+		}
+	}
+	if not, ok := n["not"]; ok && vc.matches(not, v, depth+1) {
+		vc.fail(path, "matches a schema it must not match")
+	}
+}
+
+func (vc *validator) validateString(n map[string]interface{}, s, path string) {
+	length := utf8.RuneCountInString(s)
+	if min, ok := number(n["minLength"]); ok && float64(length) < min {
+		vc.fail(path, "must be at least %s characters long", fmtNum(min))
+	}
+	if max, ok := number(n["maxLength"]); ok && float64(length) > max {
+		vc.fail(path, "must be at most %s characters long", fmtNum(max))
+	}
+	if p, ok := n["pattern"].(string); ok {
+		if re := vc.schema.patterns[p]; re != nil && !re.MatchString(s) {
+			vc.fail(path, "must match pattern %s", p)
+		}
+	}
+}
+
+func (vc *validator) validateNumber(n map[string]interface{}, f float64, path string) {
+	if min, ok := number(n["minimum"]); ok && f < min {
+		vc.fail(path, "must be >= %s", fmtNum(min))
+	}
+	if max, ok := number(n["maximum"]); ok && f > max {
+		vc.fail(path, "must be <= %s", fmtNum(max))
+	}
+	if min, ok := number(n["exclusiveMinimum"]); ok && f <= min {
+		vc.fail(path, "must be > %s", fmtNum(min))
+	}
+	if max, ok := number(n["exclusiveMaximum"]); ok && f >= max {
+		vc.fail(path, "must be < %s", fmtNum(max))
+	}
+	if m, ok := number(n["multipleOf"]); ok && m > 0 {
+		q := f / m
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			vc.fail(path, "must be a multiple of %s", fmtNum(m))
+		}
+	}
+}
+
+func (vc *validator) validateObject(n map[string]interface{}, obj map[string]interface{}, path string, depth int) {
+	if req, ok := n["required"].([]interface{}); ok {
+		for _, r := range req {
+			name, _ := r.(string)
+			if _, present := obj[name]; !present {
+				vc.fail(path, "missing required property %q", name)
+			}
+		}
+	}
+	if min, ok := number(n["minProperties"]); ok && float64(len(obj)) < min {
+		vc.fail(path, "must have at least %s properties", fmtNum(min))
+	}
+	if max, ok := number(n["maxProperties"]); ok && float64(len(obj)) > max {
+		vc.fail(path, "must have at most %s properties", fmtNum(max))
+	}
+
+	props, _ := n["properties"].(map[string]interface{})
+	patternProps, _ := n["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := n["additionalProperties"]
+	names, hasNames := n["propertyNames"]
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		child := joinPath(path, k)
+		if hasNames && !vc.matches(names, k, depth+1) {
+			vc.fail(child, "property name is not allowed")
+		}
+
+		matched := false
+		if sub, ok := props[k]; ok {
+			matched = true
+			vc.validate(sub, obj[k], child, depth+1)
+		}
+		for p, sub := range patternProps {
+			re := vc.schema.patterns[p]
+			if re == nil {
+				// Skipped pattern: it may match, so additionalProperties
+				// must not reject the key
+				matched = true
+				continue
+			}
+			if re.MatchString(k) {
+				matched = true
+				vc.validate(sub, obj[k], child, depth+1)
+			}
+		}
+		if matched || !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok {
+			if !allowed {
+				vc.fail(path, "unexpected property %q", k)
+			}
+			continue
+		}
+		vc.validate(additional, obj[k], child, depth+1)
+	}
+}
+
+func (vc *validator) validateArray(n map[string]interface{}, arr []interface{}, path string, depth int) {
+	if min, ok := number(n["minItems"]); ok && float64(len(arr)) < min {
+		vc.fail(path, "must have at least %s items", fmtNum(min))
+	}
+	if max, ok := number(n["maxItems"]); ok && float64(len(arr)) > max {
+		vc.fail(path, "must have at most %s items", fmtNum(max))
+	}
+	if unique, _ := n["uniqueItems"].(bool); unique {
+		for i := 0; i < len(arr); i++ {
+			for j := i + 1; j < len(arr); j++ {
+				if reflect.DeepEqual(arr[i], arr[j]) {
+					vc.fail(path, "items %d and %d are duplicates", i, j)
+				}
+			}
+		}
+	}
+
+	prefix, _ := n["prefixItems"].([]interface{})
+	for i, item := range arr {
+		child := fmt.Sprintf("%s[%d]", path, i)
+		if i < len(prefix) {
+			vc.validate(prefix[i], item, child, depth+1)
+			continue
+		}
+		if items, ok := n["items"]; ok {
+			if allowed, isBool := items.(bool); isBool && !allowed {
+				vc.fail(path, "must have at most %d items", len(prefix))
+				break
+			}
+			vc.validate(items, item, child, depth+1)
+		}
+	}
+}
+
+func typeMatches(t, v interface{}) bool {
+	switch tt := t.(type) {
+	case string:
+		return isType(tt, v)
+	case []interface{}:
+		for _, e := range tt {
+			if name, ok := e.(string); ok && isType(name, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isType(name string, v interface{}) bool {
+	switch name {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	}
+	return false
+}
+
+func describeType(t interface{}) string {
+	if list, ok := t.([]interface{}); ok {
+		parts := make([]string, 0, len(list))
+		for _, e := range list {
+			parts = append(parts, fmt.Sprint(e))
+		}
+		return strings.Join(parts, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func jsonType(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func number(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func fmtNum(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func compact(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
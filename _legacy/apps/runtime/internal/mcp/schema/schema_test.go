@@ -0,0 +1,149 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	const doc = `{
+		"type": "object",
+		"properties": {
+			"path": {"type": "string", "minLength": 1},
+			"mode": {"enum": ["read", "write"]},
+			"limit": {"type": "integer", "minimum": 1, "maximum": 100},
+			"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
+			"options": {"$ref": "#/$defs/options"}
+		},
+		"required": ["path"],
+		"additionalProperties": false,
+		"$defs": {
+			"options": {
+				"type": "object",
+				"properties": {"timeout": {"type": "integer"}},
+				"additionalProperties": {"type": "boolean"}
+			}
+		}
+	}`
+	s, err := Compile(json.RawMessage(doc))
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+	}{
+		{"valid", map[string]interface{}{"path": "a", "mode": "read", "limit": 5, "tags": []string{"x", "y"}}, nil},
+		{"missing required", map[string]interface{}{}, []string{`missing required property "path"`}},
+		{"wrong type", map[string]interface{}{"path": 3}, []string{"path: expected string, got integer"}},
+		{"enum", map[string]interface{}{"path": "a", "mode": "exec"}, []string{`mode: must be one of "read", "write"`}},
+		{"integer", map[string]interface{}{"path": "a", "limit": 1.5}, []string{"limit: expected integer, got number"}},
+		{"range", map[string]interface{}{"path": "a", "limit": 0}, []string{"limit: must be >= 1"}},
+		{"additional", map[string]interface{}{"path": "a", "extra": true}, []string{`unexpected property "extra"`}},
+		{"duplicates", map[string]interface{}{"path": "a", "tags": []string{"x", "x"}}, []string{"tags: items 0 and 1 are duplicates"}},
+		{"ref", map[string]interface{}{"path": "a", "options": map[string]interface{}{"timeout": "5s", "verbose": "yes"}}, []string{
+			"options.timeout: expected integer, got string",
+			"options.verbose: expected boolean, got string",
+		}},
+		{"array index", map[string]interface{}{"path": "a", "tags": []interface{}{"x", 2}}, []string{"tags[1]: expected string, got integer"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Validate(tt.value)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected ValidationError, got %v", err)
+			}
+			if len(verr.Errors) != len(tt.want) {
+				t.Fatalf("expected %d errors, got:\n%v", len(tt.want), err)
+			}
+			for i, want := range tt.want {
+				if got := verr.Errors[i].String(); got != want {
+					t.Errorf("error %d = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_Composition(t *testing.T) {
+	s, err := Compile(json.RawMessage(`{
+		"oneOf": [
+			{"type": "string", "pattern": "^[a-z]+$"},
+			{"type": "array", "prefixItems": [{"type": "number"}, {"type": "number"}], "items": false}
+		],
+		"not": {"const": "forbidden"}
+	}`))
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+
+	for _, ok := range []interface{}{"abc", []interface{}{1, 2.5}} {
+		if err := s.Validate(ok); err != nil {
+			t.Errorf("Validate(%v) = %v", ok, err)
+		}
+	}
+	for _, bad := range []interface{}{"ABC", []interface{}{1, 2, 3}, "forbidden", true} {
+		if err := s.Validate(bad); err == nil {
+			t.Errorf("Validate(%v) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestUnsupportedPatterns(t *testing.T) {
+	s, err := Compile(json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"password": {"type": "string", "pattern": "^(?=.*\\d).{8,}$"},
+			"slug": {"type": "string", "pattern": "^[a-z]+$", "default": {"pattern": "("}, "examples": [{"pattern": "(\\w)\\1"}]}
+		},
+		"patternProperties": {"^(?!x-)": {"type": "string"}},
+		"additionalProperties": false
+	}`))
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	skipped := s.SkippedPatterns()
+	sort.Strings(skipped)
+	if want := []string{`^(?!x-)`, `^(?=.*\d).{8,}$`}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped = %q, want %q", skipped, want)
+	}
+	if err := s.Validate(map[string]interface{}{"password": "x", "slug": "abc", "other": "y"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := s.Validate(map[string]interface{}{"slug": "ABC"}); err == nil {
+		t.Error("expected supported pattern to be checked")
+	}
+}
+
+func TestCompile(t *testing.T) {
+	if _, err := Compile(json.RawMessage(`{`)); err == nil {
+		t.Error("expected malformed schema to fail")
+	}
+
+	s, err := Compile(nil)
+	if err != nil {
+		t.Fatalf("compile empty: %v", err)
+	}
+	if err := s.Validate(map[string]interface{}{"any": "thing"}); err != nil {
+		t.Errorf("empty schema rejected value: %v", err)
+	}
+
+	s, _ = Compile(json.RawMessage(`{"$ref": "#/$defs/missing"}`))
+	if err := s.Validate(1); err == nil || !strings.Contains(err.Error(), "unresolvable") {
+		t.Errorf("expected unresolvable reference error, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package security
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// RefusePrivateAddress rejects connections to internal addresses after DNS
+// resolution, so hostnames cannot be used to reach them.
+func RefusePrivateAddress(host string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		ipStr, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if ip := net.ParseIP(ipStr); ip == nil || IsPrivateIP(ip) {
+			return &ViolationError{Kind: ViolationNetwork, Detail: fmt.Sprintf("%s resolves to private address %s", host, ipStr)}
+		}
+		return nil
+	}
+}
+
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPrivateIP reports whether ip is loopback, private, link-local,
+// shared (CGNAT), multicast or unspecified.
+func IsPrivateIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 0 {
+		return true
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		cgnatRange.Contains(ip)
+}
@@ -0,0 +1,19 @@
+package security
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsPrivateIP(t *testing.T) {
+	for _, s := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0", "::1", "fd00::1", "fe80::1", "::ffff:127.0.0.1"} {
+		if !IsPrivateIP(net.ParseIP(s)) {
+			t.Errorf("%s should be private", s)
+		}
+	}
+	for _, s := range []string{"93.184.216.34", "8.8.8.8", "2606:4700::1111"} {
+		if IsPrivateIP(net.ParseIP(s)) {
+			t.Errorf("%s should be public", s)
+		}
+	}
+}
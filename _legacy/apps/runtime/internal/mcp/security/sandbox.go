@@ -3,7 +3,9 @@ package security
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -16,7 +18,10 @@ type SandboxConfig struct {
 	MaxFileSize  int64    `json:"max_file_size,omitempty"` // bytes
 	AllowTempDir bool     `json:"allow_temp_dir,omitempty"`
 
-	// Network restrictions
+	// Network restrictions. Outbound HTTP and HTTPS are each off unless
+	// enabled here, so a sandbox for a server that fetches must set
+	// allow_outbound_https (and allow_outbound_http for plain http). Hosts
+	// are exact names or "*.example.com" for a domain and its subdomains.
 	AllowedHosts       []string `json:"allowed_hosts,omitempty"`
 	BlockedHosts       []string `json:"blocked_hosts,omitempty"`
 	AllowOutboundHTTP  bool     `json:"allow_outbound_http,omitempty"`
@@ -36,6 +41,18 @@ type SandboxConfig struct {
 	AllowShell      bool     `json:"allow_shell,omitempty"`
 	AllowedCommands []string `json:"allowed_commands,omitempty"`
 	BlockSudo       bool     `json:"block_sudo,omitempty"`
+
+	// Isolation (Linux only): run in fresh user/mount/pid/ipc namespaces,
+	// plus a network namespace when outbound traffic is not allowed
+	Namespaces bool `json:"namespaces,omitempty"`
+
+	// Landlock (Linux only, where the kernel supports it): confine the
+	// command's filesystem access to allowed_dirs (read-only when read_only
+	// is set), the temp dir when allowed, and read access to the system
+	// directories. Landlock can only grant access, so blocked_dirs beneath
+	// an allowed dir are not enforced for the process itself. Without
+	// allowed_dirs, or on kernels without landlock, this is a no-op.
+	Landlock bool `json:"landlock,omitempty"`
 }
 
 // DefaultSandboxConfig returns a secure default sandbox configuration
@@ -130,6 +147,12 @@ func (s *Sandbox) ValidateTool(name, description string) error {
 	return nil
 }
 
+// AllowedDirs returns the directories file access is confined to; empty
+// means unrestricted.
+func (s *Sandbox) AllowedDirs() []string {
+	return append([]string(nil), s.config.AllowedDirs...)
+}
+
 // ValidateFilePath checks if a file path is allowed
 func (s *Sandbox) ValidateFilePath(path string, write bool) error {
 	if write && s.config.ReadOnly {
@@ -140,7 +163,7 @@ func (s *Sandbox) ValidateFilePath(path string, write bool) error {
 	if len(s.config.AllowedDirs) > 0 {
 		allowed := false
 		for _, dir := range s.config.AllowedDirs {
-			if isSubpath(path, dir) {
+			if underDir(path, dir) {
 				allowed = true
 				break
 			}
@@ -152,7 +175,7 @@ func (s *Sandbox) ValidateFilePath(path string, write bool) error {
 
 	// Check blocked directories
 	for _, dir := range s.config.BlockedDirs {
-		if isSubpath(path, dir) {
+		if underDir(path, dir) {
 			return fmt.Errorf("access denied (blocked directory): %s", path)
 		}
 	}
@@ -163,11 +186,11 @@ func (s *Sandbox) ValidateFilePath(path string, write bool) error {
 // ValidateHost checks if a host is allowed for network connections
 func (s *Sandbox) ValidateHost(host string, https bool) error {
 	if !s.config.AllowOutboundHTTP && !https {
-		return fmt.Errorf("outbound HTTP connections not allowed: %s", host)
+		return fmt.Errorf("outbound HTTP connections not allowed (allow_outbound_http is off): %s", host)
 	}
 
 	if !s.config.AllowOutboundHTTPS && https {
-		return fmt.Errorf("outbound HTTPS connections not allowed: %s", host)
+		return fmt.Errorf("outbound HTTPS connections not allowed (allow_outbound_https is off): %s", host)
 	}
 
 	// Check blocked hosts
@@ -194,6 +217,17 @@ func (s *Sandbox) ValidateHost(host string, https bool) error {
 	return nil
 }
 
+// HostAllowlisted reports whether host is explicitly listed in
+// AllowedHosts. Such hosts may resolve to private addresses.
+func (s *Sandbox) HostAllowlisted(host string) bool {
+	for _, allowedHost := range s.config.AllowedHosts {
+		if matchesHost(host, allowedHost) {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateEnvironment filters environment variables
 func (s *Sandbox) ValidateEnvironment(env map[string]string) map[string]string {
 	if !s.config.SanitizeEnv {
@@ -365,19 +399,35 @@ func GetSandboxConfig(ctx context.Context) (SandboxConfig, bool) {
 	return config, ok
 }
 
+// underDir reports whether path is within dir as configured or with its
+// symlinks resolved, so resolved paths match the directories they lie in
+func underDir(path, dir string) bool {
+	if isSubpath(path, dir) {
+		return true
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	return err == nil && resolved != dir && isSubpath(path, resolved)
+}
+
 func isSubpath(path, parent string) bool {
 	// Simple subpath check - in production, use filepath.IsLocal or proper path validation
 	return len(path) >= len(parent) && path[:len(parent)] == parent
 }
 
+// matchesHost reports whether host is pattern, or for "*.example.com" is
+// example.com or one of its subdomains. Case and a trailing dot are ignored.
 func matchesHost(host, pattern string) bool {
-	// Simple host matching - exact or wildcard
-	if pattern == host {
-		return true
+	host = normalizeHost(host)
+	pattern = normalizeHost(pattern)
+	if host == "" || pattern == "" {
+		return false
 	}
-	if len(pattern) > 2 && pattern[0] == '*' && pattern[1] == '.' {
-		suffix := pattern[2:]
-		return len(host) > len(suffix) && host[len(host)-len(suffix):] == suffix
+	if base, ok := strings.CutPrefix(pattern, "*."); ok {
+		return host == base || strings.HasSuffix(host, "."+base)
 	}
-	return false
+	return host == pattern
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
 }
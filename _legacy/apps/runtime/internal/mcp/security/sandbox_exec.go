@@ -0,0 +1,307 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Violation kinds reported by ViolationError
+const (
+	ViolationCommand = "command"
+	ViolationShell   = "shell"
+	ViolationSudo    = "sudo"
+	ViolationCwd     = "cwd"
+	ViolationLimits  = "limits"
+	ViolationNetwork = "network"
+	ViolationPath    = "path"
+)
+
+// ViolationError reports a process launch, request or file access refused by
+// the sandbox
+type ViolationError struct {
+	Kind    string `json:"kind"`
+	Command string `json:"command,omitempty"`
+	Detail  string `json:"detail"`
+}
+
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("sandbox violation (%s): %s", e.Kind, e.Detail)
+}
+
+// AsViolation unwraps a ViolationError from err, if any
+func AsViolation(err error) (*ViolationError, bool) {
+	var v *ViolationError
+	if errors.As(err, &v) {
+		return v, true
+	}
+	return nil, false
+}
+
+var (
+	shellCommands = map[string]bool{
+		"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true, "fish": true,
+		"cmd": true, "cmd.exe": true, "powershell": true, "powershell.exe": true, "pwsh": true,
+	}
+	privilegeCommands = map[string]bool{
+		"sudo": true, "doas": true, "su": true, "pkexec": true,
+	}
+)
+
+// Config returns the sandbox configuration
+func (s *Sandbox) Config() SandboxConfig {
+	return s.config
+}
+
+// CheckCommand validates a command line against the execution restrictions.
+//
+// With an allowlist, a command is allowed when it runs the same file as an
+// entry: bare names are looked up on PATH, so allowing "ls" does not allow
+// "./ls". A shell only passes when it runs a -c script that is a single
+// simple command, itself allowed; interactive shells and script files
+// cannot be checked and are refused.
+func (s *Sandbox) CheckCommand(argv []string) error {
+	if len(argv) == 0 {
+		return errors.New("missing command")
+	}
+	base := commandBase(argv[0])
+
+	if s.config.BlockSudo && privilegeCommands[base] {
+		return &ViolationError{Kind: ViolationSudo, Command: argv[0], Detail: "privilege escalation is blocked: " + base}
+	}
+	if !s.config.AllowShell && shellCommands[base] {
+		return &ViolationError{Kind: ViolationShell, Command: argv[0], Detail: "shell execution is not allowed: " + base}
+	}
+	if len(s.config.AllowedCommands) == 0 {
+		return nil
+	}
+	if !s.commandAllowed(argv[0]) {
+		return &ViolationError{Kind: ViolationCommand, Command: argv[0], Detail: "command not in allowlist: " + argv[0]}
+	}
+	if !shellCommands[base] {
+		return nil
+	}
+
+	script, ok := shellScript(argv)
+	if !ok {
+		return &ViolationError{Kind: ViolationShell, Command: argv[0], Detail: "only shell -c commands can be checked against the allowlist"}
+	}
+	name, ok := simpleCommand(script)
+	if !ok {
+		return &ViolationError{Kind: ViolationShell, Command: argv[0], Detail: "shell command is not a single simple command: " + script}
+	}
+	if shellCommands[commandBase(name)] || !s.commandAllowed(name) {
+		return &ViolationError{Kind: ViolationCommand, Command: name, Detail: "command not in allowlist: " + name}
+	}
+	if s.config.BlockSudo && privilegeCommands[commandBase(name)] {
+		return &ViolationError{Kind: ViolationSudo, Command: name, Detail: "privilege escalation is blocked: " + name}
+	}
+	return nil
+}
+
+// commandAllowed reports whether name runs the same file as an allowlist
+// entry. A bare name also matches itself, as both are looked up on the
+// same PATH when the command starts.
+func (s *Sandbox) commandAllowed(name string) bool {
+	bare := !strings.ContainsAny(name, `/\`)
+	target, resolved := resolveCommand(name)
+	for _, allowed := range s.config.AllowedCommands {
+		allowed = strings.TrimSpace(allowed)
+		if bare && allowed == name {
+			return true
+		}
+		if !resolved {
+			continue
+		}
+		if path, ok := resolveCommand(allowed); ok && path == target {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveCommand returns the absolute, symlink-free path of the file a
+// command runs, looking bare names up on PATH
+func resolveCommand(name string) (string, bool) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", false
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return "", false
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path, true
+}
+
+// shellScript returns the script a shell runs with -c (or /C for cmd.exe)
+func shellScript(argv []string) (string, bool) {
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+		if strings.EqualFold(arg, "/c") || (strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "c")) {
+			if i+1 < len(argv) {
+				return argv[i+1], true
+			}
+			return "", false
+		}
+		if !strings.HasPrefix(arg, "-") {
+			// A script file
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// simpleCommand returns the program a shell script runs, provided the
+// script is one command with plain arguments: no separators, pipes,
+// redirections, substitutions or variable assignments.
+func simpleCommand(script string) (string, bool) {
+	if strings.ContainsAny(script, ";&|`$<>()\n\\") {
+		return "", false
+	}
+	fields := strings.Fields(script)
+	if len(fields) == 0 {
+		return "", false
+	}
+	name := fields[0]
+	if strings.ContainsAny(name, `"'=*?[{~`) {
+		return "", false
+	}
+	return name, true
+}
+
+// WorkingDir resolves dir and confines it to the allowed directories.
+// An empty dir defaults to the first allowed directory.
+func (s *Sandbox) WorkingDir(dir string) (string, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		if len(s.config.AllowedDirs) == 0 {
+			return "", nil
+		}
+		dir = s.config.AllowedDirs[0]
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+
+	if len(s.config.AllowedDirs) > 0 {
+		allowed := false
+		for _, root := range s.config.AllowedDirs {
+			if withinDir(abs, root) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "", &ViolationError{Kind: ViolationCwd, Detail: "working directory not in allowed directories: " + abs}
+		}
+	}
+	for _, root := range s.config.BlockedDirs {
+		if withinDir(abs, root) {
+			return "", &ViolationError{Kind: ViolationCwd, Detail: "working directory is blocked: " + abs}
+		}
+	}
+	return abs, nil
+}
+
+// Environ builds a child environment from base plus extra, scrubbed by the
+// environment restrictions. Entries are returned in KEY=VALUE form.
+func (s *Sandbox) Environ(base []string, extra map[string]string) []string {
+	env := make(map[string]string, len(base)+len(extra))
+	for _, kv := range base {
+		if k, v, ok := strings.Cut(kv, "="); ok && k != "" {
+			env[k] = v
+		}
+	}
+	for k, v := range extra {
+		if strings.TrimSpace(k) != "" {
+			env[k] = v
+		}
+	}
+
+	env = s.ValidateEnvironment(env)
+	if s.config.AllowTempDir {
+		if _, ok := env["TMPDIR"]; !ok && runtime.GOOS != "windows" {
+			env["TMPDIR"] = os.TempDir()
+		}
+	}
+
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, k+"="+env[k])
+	}
+	return out
+}
+
+// PrepareCmd applies command, working-directory, environment and platform
+// restrictions to cmd before it is started. extra holds caller-supplied
+// environment variables layered on top of the parent environment.
+func (s *Sandbox) PrepareCmd(cmd *exec.Cmd, extra map[string]string) error {
+	if err := s.CheckCommand(cmd.Args); err != nil {
+		return err
+	}
+
+	dir, err := s.WorkingDir(cmd.Dir)
+	if err != nil {
+		return err
+	}
+	cmd.Dir = dir
+
+	cmd.Env = s.Environ(os.Environ(), extra)
+	if err := applyLandlock(cmd, s.config); err != nil {
+		return &ViolationError{Kind: ViolationLimits, Command: cmd.Path, Detail: err.Error()}
+	}
+	if err := applyResourceLimits(cmd, s.config); err != nil {
+		return &ViolationError{Kind: ViolationLimits, Command: cmd.Path, Detail: err.Error()}
+	}
+	return applyPlatformAttrs(cmd, s.config)
+}
+
+// Start starts a prepared command. The starting goroutine keeps its OS
+// thread meanwhile: the parent-death signal is tied to the thread that
+// forks, which must not be one that exits with a locked goroutine.
+func (s *Sandbox) Start(cmd *exec.Cmd) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	return cmd.Start()
+}
+
+func commandBase(command string) string {
+	return strings.ToLower(filepath.Base(strings.TrimSpace(command)))
+}
+
+func withinDir(path, root string) bool {
+	root = strings.TrimSpace(root)
+	if root == "" {
+		return false
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
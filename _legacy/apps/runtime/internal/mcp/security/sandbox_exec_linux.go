@@ -0,0 +1,286 @@
+//go:build linux
+
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// rlimitEnv carries the limits for the exec trampoline; see
+// applyResourceLimits.
+const rlimitEnv = "PRYX_SANDBOX_RLIMITS"
+
+// landlockEnv carries the landlock rules for the exec trampoline; see
+// applyLandlock.
+const landlockEnv = "PRYX_SANDBOX_LANDLOCK"
+
+// trampolineArg0 marks a command already routed through the trampoline
+const trampolineArg0 = "pryx-sandbox"
+
+// systemDirs are readable under landlock so commands can load their
+// binaries, libraries and configuration
+var systemDirs = []string{
+	"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/etc", "/opt",
+	"/nix", "/proc", "/sys", "/run",
+}
+
+// landlockRules lists the directories the sandboxed command may read and
+// the ones it may also write
+type landlockRules struct {
+	Read  []string `json:"read,omitempty"`
+	Write []string `json:"write,omitempty"`
+}
+
+// limitNames maps the names used in rlimitEnv to resources
+var limitNames = map[string]int{
+	"cpu":   unix.RLIMIT_CPU,
+	"as":    unix.RLIMIT_AS,
+	"nproc": unix.RLIMIT_NPROC,
+}
+
+func init() {
+	// Running as the exec trampoline: set the limits and the landlock
+	// rules, then become the sandboxed command, before the program does
+	// anything else
+	spec, hasLimits := os.LookupEnv(rlimitEnv)
+	rules, hasRules := os.LookupEnv(landlockEnv)
+	if !hasLimits && !hasRules {
+		return
+	}
+	os.Unsetenv(rlimitEnv)
+	os.Unsetenv(landlockEnv)
+	if err := execWithLimits(spec, rules, os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		os.Exit(126)
+	}
+}
+
+func execWithLimits(spec, rules string, argv []string) error {
+	if len(argv) < 2 {
+		return fmt.Errorf("missing command")
+	}
+	if err := setLimits(spec); err != nil {
+		return err
+	}
+	if rules != "" {
+		var r landlockRules
+		if err := json.Unmarshal([]byte(rules), &r); err != nil {
+			return fmt.Errorf("invalid landlock rules: %w", err)
+		}
+		if err := restrictSelf(r); err != nil {
+			return fmt.Errorf("landlock: %w", err)
+		}
+	}
+	// argv is the command's path followed by its own argv
+	return syscall.Exec(argv[0], argv[1:], os.Environ())
+}
+
+func setLimits(spec string) error {
+	if spec == "" {
+		return nil
+	}
+	for _, item := range strings.Split(spec, ",") {
+		name, value, _ := strings.Cut(item, "=")
+		resource, ok := limitNames[name]
+		if !ok {
+			return fmt.Errorf("unknown limit %q", name)
+		}
+		limit, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid limit %q", item)
+		}
+		if err := unix.Setrlimit(resource, &unix.Rlimit{Cur: limit, Max: limit}); err != nil {
+			return fmt.Errorf("set %s limit: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// landlockABI returns the kernel's landlock ABI version, or 0 when landlock
+// is unsupported or disabled
+func landlockABI() int {
+	v, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0
+	}
+	return int(v)
+}
+
+// landlockAccess returns the filesystem rights handled by a ruleset for
+// the given ABI and the subset granted on read-only directories
+func landlockAccess(abi int) (handled, read uint64) {
+	handled = unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR | unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR | unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG | unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO | unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+	if abi >= 2 {
+		handled |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		handled |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	read = unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_DIR
+	return handled, read
+}
+
+// restrictSelf confines this process, and the command it execs, to the
+// rules. Directories that do not exist are skipped.
+func restrictSelf(r landlockRules) error {
+	abi := landlockABI()
+	if abi == 0 {
+		return nil
+	}
+	handled, read := landlockAccess(abi)
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET,
+		uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("create ruleset: %w", errno)
+	}
+	ruleset := int(fd)
+	defer unix.Close(ruleset)
+
+	add := func(dir string, access uint64) error {
+		pfd, err := unix.Open(dir, unix.O_PATH|unix.O_CLOEXEC|unix.O_DIRECTORY, 0)
+		if err != nil {
+			return nil
+		}
+		defer unix.Close(pfd)
+		rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(pfd)}
+		_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset),
+			unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0)
+		if errno != 0 {
+			return fmt.Errorf("add rule for %s: %w", dir, errno)
+		}
+		return nil
+	}
+	for _, dir := range r.Read {
+		if err := add(dir, read); err != nil {
+			return err
+		}
+	}
+	for _, dir := range r.Write {
+		if err := add(dir, handled); err != nil {
+			return err
+		}
+	}
+	// Device files such as /dev/null and the terminal stay writable
+	if err := add("/dev", read|unix.LANDLOCK_ACCESS_FS_WRITE_FILE); err != nil {
+		return err
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("set no_new_privs: %w", err)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, uintptr(ruleset), 0, 0); errno != 0 {
+		return fmt.Errorf("restrict self: %w", errno)
+	}
+	return nil
+}
+
+func applyPlatformAttrs(cmd *exec.Cmd, config SandboxConfig) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	attr := cmd.SysProcAttr
+	attr.Pdeathsig = syscall.SIGKILL
+
+	if config.Namespaces {
+		attr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS
+		if !config.AllowOutboundHTTP && !config.AllowOutboundHTTPS && len(config.AllowedHosts) == 0 {
+			attr.Cloneflags |= syscall.CLONE_NEWNET
+		}
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	}
+	return nil
+}
+
+// applyResourceLimits makes cmd start through this executable, which sets
+// the limits on itself and then execs the command, so they hold from its
+// first instruction.
+func applyResourceLimits(cmd *exec.Cmd, config SandboxConfig) error {
+	var limits []string
+	if config.MaxCPUTime > 0 {
+		secs := uint64((config.MaxCPUTime + 999_999_999) / 1_000_000_000)
+		limits = append(limits, "cpu="+strconv.FormatUint(secs, 10))
+	}
+	if config.MaxMemory > 0 {
+		limits = append(limits, "as="+strconv.FormatInt(config.MaxMemory, 10))
+	}
+	// RLIMIT_NPROC counts every process of the real uid, so it is only
+	// meaningful once the child runs in its own user namespace.
+	if config.MaxProcesses > 0 && config.Namespaces {
+		limits = append(limits, "nproc="+strconv.Itoa(config.MaxProcesses))
+	}
+	if len(limits) == 0 || cmd.Err != nil {
+		return nil
+	}
+	return viaTrampoline(cmd, rlimitEnv+"="+strings.Join(limits, ","))
+}
+
+// applyLandlock makes cmd start through this executable, which confines
+// itself with landlock and then execs the command. It does nothing without
+// allowed dirs, which landlock needs as its grants, or where the kernel
+// lacks landlock.
+func applyLandlock(cmd *exec.Cmd, config SandboxConfig) error {
+	if !config.Landlock || len(config.AllowedDirs) == 0 || cmd.Err != nil || landlockABI() == 0 {
+		return nil
+	}
+	rules := landlockRules{Read: append([]string(nil), systemDirs...)}
+	if cmd.Path != "" {
+		rules.Read = append(rules.Read, filepath.Dir(cmd.Path))
+	}
+	for _, dir := range config.AllowedDirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if config.ReadOnly {
+			rules.Read = append(rules.Read, abs)
+		} else {
+			rules.Write = append(rules.Write, abs)
+		}
+	}
+	if config.AllowTempDir {
+		rules.Write = append(rules.Write, os.TempDir())
+	}
+	spec, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	return viaTrampoline(cmd, landlockEnv+"="+string(spec))
+}
+
+// viaTrampoline routes cmd through this executable, once, and passes it env
+func viaTrampoline(cmd *exec.Cmd, env string) error {
+	if len(cmd.Args) == 0 || cmd.Args[0] != trampolineArg0 {
+		self, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("locate executable for the sandbox: %w", err)
+		}
+		args := append([]string{trampolineArg0, cmd.Path}, cmd.Args...)
+		cmd.Path = self
+		cmd.Args = args
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, env)
+	return nil
+}
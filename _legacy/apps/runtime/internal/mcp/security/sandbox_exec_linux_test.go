@@ -0,0 +1,63 @@
+//go:build linux
+
+package security
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSandboxLimitsAppliedBeforeExec(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	sandbox := NewSandbox(SandboxConfig{AllowShell: true, MaxCPUTime: 7 * time.Second})
+
+	cmd := exec.Command("sh", "-c", "ulimit -t")
+	if err := sandbox.PrepareCmd(cmd, nil); err != nil {
+		t.Fatalf("PrepareCmd: %v", err)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "7" {
+		t.Errorf("expected cpu limit 7 in child, got %q", got)
+	}
+}
+
+func TestSandboxLandlockConfinesFilesystem(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	if landlockABI() == 0 {
+		t.Skip("landlock not available")
+	}
+	allowed, outside := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sandbox := NewSandbox(SandboxConfig{AllowShell: true, AllowedDirs: []string{allowed}, Landlock: true})
+
+	run := func(script string) error {
+		cmd := exec.Command("sh", "-c", script)
+		cmd.Dir = allowed
+		if err := sandbox.PrepareCmd(cmd, nil); err != nil {
+			t.Fatalf("PrepareCmd: %v", err)
+		}
+		return cmd.Run()
+	}
+	if err := run("echo ok > " + filepath.Join(allowed, "out") + " && cat out >/dev/null"); err != nil {
+		t.Fatalf("expected the allowed dir to be writable: %v", err)
+	}
+	if err := run("cat " + filepath.Join(outside, "secret")); err == nil {
+		t.Error("expected a file outside the allowed dirs to be unreadable")
+	}
+	if err := run("echo x > " + filepath.Join(outside, "new")); err == nil {
+		t.Error("expected a dir outside the allowed dirs to be unwritable")
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package security
+
+import "os/exec"
+
+// Process isolation, landlock and resource limits are only enforced on
+// Linux; other platforms still get command, working-directory and
+// environment checks.
+func applyPlatformAttrs(cmd *exec.Cmd, config SandboxConfig) error {
+	return nil
+}
+
+func applyLandlock(cmd *exec.Cmd, config SandboxConfig) error {
+	return nil
+}
+
+func applyResourceLimits(cmd *exec.Cmd, config SandboxConfig) error {
+	return nil
+}
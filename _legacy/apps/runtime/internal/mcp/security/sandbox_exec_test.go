@@ -0,0 +1,131 @@
+package security
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSandboxCheckCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SandboxConfig
+		argv   []string
+		kind   string
+	}{
+		{"plain command allowed", SandboxConfig{}, []string{"node", "server.js"}, ""},
+		{"sudo blocked", SandboxConfig{BlockSudo: true}, []string{"/usr/bin/sudo", "ls"}, ViolationSudo},
+		{"sudo allowed when not blocked", SandboxConfig{AllowShell: true}, []string{"sudo", "ls"}, ""},
+		{"shell blocked", SandboxConfig{}, []string{"sh", "-c", "ls"}, ViolationShell},
+		{"shell allowed", SandboxConfig{AllowShell: true}, []string{"bash", "-lc", "ls"}, ""},
+		{"allowlist bare name", SandboxConfig{AllowedCommands: []string{"ls"}}, []string{"ls", "-la"}, ""},
+		{"allowlist miss", SandboxConfig{AllowedCommands: []string{"npx"}}, []string{"python3"}, ViolationCommand},
+		{"allowlist path is not a basename", SandboxConfig{AllowedCommands: []string{"/usr/bin/python3"}}, []string{"/tmp/python3"}, ViolationCommand},
+		{"allowlist relative path", SandboxConfig{AllowedCommands: []string{"ls"}}, []string{"./ls"}, ViolationCommand},
+		{"allowlist shell script checked", SandboxConfig{AllowShell: true, AllowedCommands: []string{"sh", "ls"}}, []string{"sh", "-lc", "ls -la"}, ""},
+		{"allowlist shell script not allowed", SandboxConfig{AllowShell: true, AllowedCommands: []string{"sh", "ls"}}, []string{"sh", "-c", "rm -rf /"}, ViolationCommand},
+		{"allowlist shell compound", SandboxConfig{AllowShell: true, AllowedCommands: []string{"sh", "ls"}}, []string{"sh", "-c", "ls; rm -rf /"}, ViolationShell},
+		{"allowlist interactive shell", SandboxConfig{AllowShell: true, AllowedCommands: []string{"sh"}}, []string{"sh"}, ViolationShell},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewSandbox(tt.config).CheckCommand(tt.argv)
+			if tt.kind == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			v, ok := AsViolation(err)
+			if !ok {
+				t.Fatalf("expected violation, got %v", err)
+			}
+			if v.Kind != tt.kind {
+				t.Errorf("expected kind %s, got %s", tt.kind, v.Kind)
+			}
+		})
+	}
+}
+
+func TestSandboxWorkingDir(t *testing.T) {
+	root := t.TempDir()
+	inside := filepath.Join(root, "project")
+	if err := os.Mkdir(inside, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sibling := root + "-other"
+
+	sandbox := NewSandbox(SandboxConfig{
+		AllowedDirs: []string{root},
+		BlockedDirs: []string{filepath.Join(root, "secret")},
+	})
+
+	dir, err := sandbox.WorkingDir("")
+	if err != nil {
+		t.Fatalf("default dir: %v", err)
+	}
+	if resolved, _ := filepath.EvalSymlinks(root); dir != resolved {
+		t.Errorf("expected default %s, got %s", resolved, dir)
+	}
+
+	if _, err := sandbox.WorkingDir(inside); err != nil {
+		t.Errorf("inside dir rejected: %v", err)
+	}
+	if _, err := sandbox.WorkingDir(sibling); err == nil {
+		t.Error("expected sibling prefix dir to be rejected")
+	}
+	if _, err := sandbox.WorkingDir(filepath.Join(root, "secret", "x")); err == nil {
+		t.Error("expected blocked dir to be rejected")
+	}
+
+	link := filepath.Join(root, "escape")
+	if err := os.Symlink(os.TempDir(), link); err == nil {
+		if _, err := sandbox.WorkingDir(link); err == nil {
+			t.Error("expected symlink escape to be rejected")
+		}
+	}
+}
+
+func TestSandboxEnviron(t *testing.T) {
+	sandbox := NewSandbox(SandboxConfig{
+		SanitizeEnv:    true,
+		AllowedEnvVars: []string{"LANG", "API_KEY"},
+		BlockedEnvVars: []string{"AWS_SECRET_ACCESS_KEY"},
+	})
+
+	env := sandbox.Environ(
+		[]string{"LANG=C", "HOME=/root", "AWS_SECRET_ACCESS_KEY=x"},
+		map[string]string{"API_KEY": "k"},
+	)
+
+	joined := strings.Join(env, " ")
+	if !strings.Contains(joined, "LANG=C") || !strings.Contains(joined, "API_KEY=k") {
+		t.Errorf("expected allowed vars, got %v", env)
+	}
+	if strings.Contains(joined, "HOME=") || strings.Contains(joined, "AWS_SECRET") {
+		t.Errorf("expected scrubbed vars, got %v", env)
+	}
+}
+
+func TestSandboxPrepareCmd(t *testing.T) {
+	root := t.TempDir()
+	sandbox := NewSandbox(SandboxConfig{
+		AllowedDirs:    []string{root},
+		SanitizeEnv:    true,
+		AllowedEnvVars: []string{"FOO"},
+	})
+
+	cmd := exec.Command("echo", "hi")
+	if err := sandbox.PrepareCmd(cmd, map[string]string{"FOO": "bar"}); err != nil {
+		t.Fatalf("PrepareCmd: %v", err)
+	}
+	if resolved, _ := filepath.EvalSymlinks(root); cmd.Dir != resolved {
+		t.Errorf("expected dir %s, got %s", resolved, cmd.Dir)
+	}
+	if len(cmd.Env) != 1 || cmd.Env[0] != "FOO=bar" {
+		t.Errorf("unexpected env: %v", cmd.Env)
+	}
+}
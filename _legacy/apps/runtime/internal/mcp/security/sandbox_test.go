@@ -345,9 +345,13 @@ func TestMatchesHost(t *testing.T) {
 	}{
 		{"example.com", "example.com", true},
 		{"api.example.com", "*.example.com", true},
-		{"example.com", "*.example.com", false},
+		{"example.com", "*.example.com", true},
 		{"deep.sub.example.com", "*.example.com", true},
 		{"other.com", "example.com", false},
+		{"evilexample.com", "*.example.com", false},
+		{"api.evilexample.com", "*.example.com", false},
+		{"API.Example.COM.", "*.example.com", true},
+		{"example.com.", "Example.com", true},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,275 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionHeader carries the Streamable HTTP session assigned on initialize
+const sessionHeader = "Mcp-Session-Id"
+
+const maxHTTPRequestBody = 4 * 1024 * 1024
+
+// DefaultSessionTTL is how long an idle Streamable HTTP session is kept
+const DefaultSessionTTL = 30 * time.Minute
+
+// DefaultMaxSessions caps the live Streamable HTTP sessions
+const DefaultMaxSessions = 1000
+
+// sessionPruneInterval is how often Run drops expired sessions
+const sessionPruneInterval = time.Minute
+
+// HTTPServeOptions controls who may use a StreamableHTTPHandler
+type HTTPServeOptions struct {
+	// Token, when set, must be sent as "Authorization: Bearer <token>".
+	Token string
+	// AllowedOrigins lists browser origins accepted in addition to
+	// loopback ones (e.g. "https://app.example.com").
+	AllowedOrigins []string
+	// SessionTTL expires sessions idle for longer; zero means DefaultSessionTTL.
+	SessionTTL time.Duration
+	// MaxSessions refuses new sessions while this many are live; zero
+	// means DefaultMaxSessions.
+	MaxSessions int
+}
+
+// StreamableHTTPHandler serves a ToolProvider over the MCP Streamable HTTP
+// transport. Every POST carries one JSON-RPC message and requests are
+// answered with a single application/json response; the server never
+// initiates messages, so GET streams are not offered.
+//
+// Requests carrying an Origin header are rejected unless the origin is
+// loopback or explicitly allowed, which guards against DNS rebinding.
+// Idle sessions expire after SessionTTL; Run drops them periodically.
+type StreamableHTTPHandler struct {
+	provider ToolProvider
+	opts     HTTPServeOptions
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+type httpSession struct {
+	transport *BundledTransport
+	lastUsed  time.Time
+}
+
+// NewStreamableHTTPHandler creates a handler serving provider
+func NewStreamableHTTPHandler(provider ToolProvider, opts HTTPServeOptions) *StreamableHTTPHandler {
+	if opts.SessionTTL <= 0 {
+		opts.SessionTTL = DefaultSessionTTL
+	}
+	if opts.MaxSessions <= 0 {
+		opts.MaxSessions = DefaultMaxSessions
+	}
+	return &StreamableHTTPHandler{
+		provider: provider,
+		opts:     opts,
+		sessions: map[string]*httpSession{},
+	}
+}
+
+func (h *StreamableHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.originAllowed(r.Header.Get("Origin")) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodDelete:
+		id := r.Header.Get(sessionHeader)
+		h.mu.Lock()
+		_, ok := h.sessions[id]
+		delete(h.sessions, id)
+		h.mu.Unlock()
+		if !ok {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StreamableHTTPHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPRequestBody))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var envelope struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id,omitempty"`
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params,omitempty"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		writeHTTPResponse(w, RPCResponse{JSONRPC: "2.0", Error: &RPCError{Code: -32700, Message: "parse error"}})
+		return
+	}
+
+	// Responses from the client (to requests we never send) are accepted
+	// and dropped.
+	if strings.TrimSpace(envelope.Method) == "" {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	var session *BundledTransport
+	id := r.Header.Get(sessionHeader)
+	if envelope.Method == "initialize" {
+		id, err = newSessionID()
+		if err != nil {
+			http.Error(w, "failed to create session", http.StatusInternalServerError)
+			return
+		}
+		session = NewBundledTransport(h.provider)
+		h.mu.Lock()
+		h.pruneLocked(time.Now())
+		if len(h.sessions) >= h.opts.MaxSessions {
+			h.mu.Unlock()
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, "too many sessions", http.StatusServiceUnavailable)
+			return
+		}
+		h.sessions[id] = &httpSession{transport: session, lastUsed: time.Now()}
+		h.mu.Unlock()
+		w.Header().Set(sessionHeader, id)
+	} else {
+		if strings.TrimSpace(id) == "" {
+			http.Error(w, "missing "+sessionHeader, http.StatusBadRequest)
+			return
+		}
+		session = h.session(id)
+		if session == nil {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+	}
+
+	var params interface{}
+	if len(envelope.Params) > 0 {
+		params = envelope.Params
+	}
+
+	if len(envelope.ID) == 0 {
+		_ = session.Notify(r.Context(), RPCNotification{JSONRPC: "2.0", Method: envelope.Method, Params: params})
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	ctx := WithSessionID(r.Context(), "mcp-"+id)
+	resp, err := session.Call(ctx, RPCRequest{JSONRPC: "2.0", ID: envelope.ID, Method: envelope.Method, Params: params})
+	if err != nil {
+		resp = RPCResponse{JSONRPC: "2.0", ID: envelope.ID, Error: &RPCError{Code: -32000, Message: err.Error()}}
+	}
+	writeHTTPResponse(w, resp)
+}
+
+// session returns the live session id and marks it used
+func (h *StreamableHTTPHandler) session(id string) *BundledTransport {
+	now := time.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.sessions[id]
+	if s == nil {
+		return nil
+	}
+	if now.Sub(s.lastUsed) > h.opts.SessionTTL {
+		delete(h.sessions, id)
+		return nil
+	}
+	s.lastUsed = now
+	return s.transport
+}
+
+// Run drops expired sessions every sessionPruneInterval until ctx is done
+func (h *StreamableHTTPHandler) Run(ctx context.Context) {
+	ticker := time.NewTicker(sessionPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			h.mu.Lock()
+			h.pruneLocked(now)
+			h.mu.Unlock()
+		}
+	}
+}
+
+func (h *StreamableHTTPHandler) pruneLocked(now time.Time) {
+	for id, s := range h.sessions {
+		if now.Sub(s.lastUsed) > h.opts.SessionTTL {
+			delete(h.sessions, id)
+		}
+	}
+}
+
+func (h *StreamableHTTPHandler) authorized(r *http.Request) bool {
+	if h.opts.Token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(h.opts.Token)) == 1
+}
+
+func (h *StreamableHTTPHandler) originAllowed(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range h.opts.AllowedOrigins {
+		if strings.EqualFold(strings.TrimRight(allowed, "/"), origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return IsLoopbackHost(u.Hostname())
+}
+
+// IsLoopbackHost reports whether host names the local machine only
+func IsLoopbackHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeHTTPResponse(w http.ResponseWriter, resp RPCResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
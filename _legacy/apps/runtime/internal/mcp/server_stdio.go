@@ -56,7 +56,7 @@ func ServeStdio(ctx context.Context, provider ToolProvider) error {
 		}
 
 		if len(envelope.ID) == 0 {
-			if envelope.Method == "initialized" {
+			if isInitializedNotification(envelope.Method) {
 				initialized = true
 			}
 			continue
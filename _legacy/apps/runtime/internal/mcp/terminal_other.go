@@ -0,0 +1,44 @@
+//go:build !linux && !darwin
+
+package mcp
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Pseudo-terminals are only allocated on Linux and macOS; elsewhere sessions
+// run over pipes, so programs that need a terminal may behave differently.
+const terminalSupported = false
+
+func attachTerminal(cmd *exec.Cmd, cols, rows int) (io.ReadWriteCloser, func(), error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	r, w := io.Pipe()
+	cmd.Stdout, cmd.Stderr = w, w
+	return &pipeTerminal{PipeReader: r, w: w, stdin: stdin}, func() {}, nil
+}
+
+type pipeTerminal struct {
+	*io.PipeReader
+	w     *io.PipeWriter
+	stdin io.WriteCloser
+}
+
+func (t *pipeTerminal) Write(b []byte) (int, error) { return t.stdin.Write(b) }
+
+func (t *pipeTerminal) Close() error {
+	_ = t.stdin.Close()
+	return t.w.Close()
+}
+
+func signalProcessGroup(p *os.Process, sig syscall.Signal) error {
+	if sig == syscall.SIGKILL {
+		return p.Kill()
+	}
+	return p.Signal(sig)
+}
@@ -0,0 +1,47 @@
+//go:build linux || darwin
+
+package mcp
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const terminalSupported = true
+
+// attachTerminal connects cmd to a new pseudo-terminal as its controlling
+// terminal and session leader. The returned closer releases the child's end
+// and must be called once the process has started.
+func attachTerminal(cmd *exec.Cmd, cols, rows int) (io.ReadWriteCloser, func(), error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, nil, err
+	}
+	ws := &unix.Winsize{Col: uint16(cols), Row: uint16(rows)}
+	if err := unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, ws); err != nil {
+		_ = master.Close()
+		_ = slave.Close()
+		return nil, nil, err
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+	return master, func() { _ = slave.Close() }, nil
+}
+
+// signalProcessGroup delivers sig to the session's shell and the jobs in its
+// process group.
+func signalProcessGroup(p *os.Process, sig syscall.Signal) error {
+	if err := syscall.Kill(-p.Pid, sig); err != nil {
+		return p.Signal(sig)
+	}
+	return nil
+}
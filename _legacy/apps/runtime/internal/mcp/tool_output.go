@@ -0,0 +1,169 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultReadLimit is the number of lines ReadOutput returns when no limit
+// is given.
+const DefaultReadLimit = 200
+
+// ReadOptions selects a window of a truncated output. With Grep set, only
+// matching lines are returned and Offset/Limit page through the matches.
+type ReadOptions struct {
+	Offset int    `json:"offset,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+	Grep   string `json:"grep,omitempty"`
+}
+
+// OutputLine is one line of a truncated output; Number is 1-based.
+type OutputLine struct {
+	Number int    `json:"number"`
+	Text   string `json:"text"`
+}
+
+// OutputPage is a window of a truncated output
+type OutputPage struct {
+	Output  OutputMeta   `json:"output"`
+	Offset  int          `json:"offset"`
+	Total   int          `json:"total"`
+	Content []OutputLine `json:"content"`
+	// NextOffset is the offset of the following page, or zero when the
+	// page reaches the end.
+	NextOffset int `json:"next_offset,omitempty"`
+}
+
+// Text renders the page with line numbers, the way it is shown to models.
+func (p *OutputPage) Text() string {
+	var b strings.Builder
+	for _, l := range p.Content {
+		fmt.Fprintf(&b, "%6d\t%s\n", l.Number, l.Text)
+	}
+	if p.NextOffset > 0 {
+		fmt.Fprintf(&b, "... %d more lines, continue with offset %d\n", p.Total-p.NextOffset, p.NextOffset)
+	}
+	return b.String()
+}
+
+// Meta returns the metadata of a truncated output.
+func (t *Truncator) Meta(id string) (OutputMeta, error) {
+	if !outputIDPattern.MatchString(id) {
+		return OutputMeta{}, ErrOutputNotFound
+	}
+	info, err := os.Stat(t.outputPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return OutputMeta{}, ErrOutputNotFound
+		}
+		return OutputMeta{}, err
+	}
+	if time.Since(info.ModTime()) > RetentionPeriod {
+		return OutputMeta{}, ErrOutputNotFound
+	}
+
+	meta := OutputMeta{ID: id, Bytes: int(info.Size()), CreatedAt: info.ModTime().UTC()}
+	if data, err := os.ReadFile(t.metaPath(id)); err == nil {
+		_ = json.Unmarshal(data, &meta)
+	}
+	return meta, nil
+}
+
+// ReadOutput returns a window of the output saved under id.
+func (t *Truncator) ReadOutput(id string, opts ReadOptions) (*OutputPage, error) {
+	meta, err := t.Meta(id)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Offset < 0 {
+		return nil, errors.New("offset must not be negative")
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultReadLimit
+	}
+	if limit > t.maxLines {
+		limit = t.maxLines
+	}
+	var re *regexp.Regexp
+	if opts.Grep != "" {
+		if re, err = regexp.Compile(opts.Grep); err != nil {
+			return nil, fmt.Errorf("invalid grep pattern: %w", err)
+		}
+	}
+
+	data, err := os.ReadFile(t.outputPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrOutputNotFound
+		}
+		return nil, err
+	}
+
+	page := &OutputPage{Output: meta, Offset: opts.Offset, Content: []OutputLine{}}
+	var size int
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if re != nil && !re.MatchString(line) {
+			continue
+		}
+		idx := page.Total
+		page.Total++
+		if idx < opts.Offset || page.NextOffset > 0 {
+			continue
+		}
+		// Stop at the line or byte budget so a page never needs
+		// truncating itself.
+		if len(page.Content) == limit || (len(page.Content) > 0 && size+len(line) > t.maxBytes) {
+			page.NextOffset = idx
+			continue
+		}
+		page.Content = append(page.Content, OutputLine{Number: n, Text: line})
+		size += len(line) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// ListOutputs returns the retained outputs of a session, newest first. An
+// empty sessionID lists every output.
+func (t *Truncator) ListOutputs(sessionID string) ([]OutputMeta, error) {
+	entries, err := os.ReadDir(t.outputDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []OutputMeta{}, nil
+		}
+		return nil, err
+	}
+
+	out := []OutputMeta{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".txt" {
+			continue
+		}
+		meta, err := t.Meta(strings.TrimSuffix(name, ".txt"))
+		if err != nil {
+			continue
+		}
+		if sessionID != "" && meta.SessionID != sessionID {
+			continue
+		}
+		out = append(out, meta)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 )
@@ -20,13 +21,18 @@ func NewBundledTransport(provider ToolProvider) *BundledTransport {
 	return &BundledTransport{provider: provider}
 }
 
+// Close releases the provider's resources, such as running shell sessions,
+// when it implements io.Closer.
 func (t *BundledTransport) Close() error {
+	if c, ok := t.provider.(io.Closer); ok {
+		return c.Close()
+	}
 	return nil
 }
 
 func (t *BundledTransport) Notify(ctx context.Context, notif RPCNotification) error {
 	_ = ctx
-	if strings.TrimSpace(notif.Method) == "initialized" {
+	if isInitializedNotification(notif.Method) {
 		t.mu.Lock()
 		t.initialized = true
 		t.mu.Unlock()
@@ -85,7 +91,7 @@ func (t *BundledTransport) Call(ctx context.Context, req RPCRequest) (RPCRespons
 
 		res, err := t.provider.CallTool(ctx, params.Name, params.Arguments)
 		if err != nil {
-			return RPCResponse{JSONRPC: "2.0", ID: mustMarshalID(req.ID), Error: &RPCError{Code: -32000, Message: err.Error()}}, nil
+			return RPCResponse{JSONRPC: "2.0", ID: mustMarshalID(req.ID), Error: toolError(err)}, nil
 		}
 		b, _ := json.Marshal(res)
 		return RPCResponse{JSONRPC: "2.0", ID: mustMarshalID(req.ID), Result: b}, nil
@@ -95,6 +101,16 @@ func (t *BundledTransport) Call(ctx context.Context, req RPCRequest) (RPCRespons
 	}
 }
 
+// isInitializedNotification accepts both the spec's notifications/initialized
+// and the bare form older pryx clients send.
+func isInitializedNotification(method string) bool {
+	switch strings.TrimSpace(method) {
+	case "notifications/initialized", "initialized":
+		return true
+	}
+	return false
+}
+
 func mustMarshalID(v interface{}) json.RawMessage {
 	if v == nil {
 		return nil
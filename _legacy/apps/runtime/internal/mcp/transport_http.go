@@ -6,160 +6,545 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+const (
+	headerSessionID       = "Mcp-Session-Id"
+	headerProtocolVersion = "MCP-Protocol-Version"
+	headerLastEventID     = "Last-Event-ID"
+
+	httpCallTimeout    = 2 * time.Minute // default for servers without call_timeout_seconds
+	httpResumeAttempts = 5
+	httpDefaultRetry   = time.Second
+)
+
+// errSessionExpired is returned when the server answers 404 for a session
+// it no longer knows. The client starts a new session and retries.
+var errSessionExpired = errors.New("mcp session expired")
+
+// HTTPTransport speaks the Streamable HTTP transport: every message is
+// POSTed to one endpoint, which answers with JSON or an SSE stream, and a
+// GET stream carries messages the server sends on its own. Servers that
+// reject the initialize POST are assumed to speak the older HTTP+SSE
+// transport and are handed to an SSETransport.
 type HTTPTransport struct {
-	url     string
-	headers map[string]string
-	client  *http.Client
+	url         string
+	headers     map[string]string
+	client      *http.Client
+	callTimeout time.Duration
+
+	mu         sync.Mutex
+	sessionID  string
+	protocol   string
+	handler    serverMessageHandler
+	legacy     *SSETransport
+	stopStream context.CancelFunc
+	closed     bool
 }
 
 func NewHTTPTransport(url string, headers map[string]string) *HTTPTransport {
 	return &HTTPTransport{
 		url:     url,
 		headers: headers,
-		client:  &http.Client{Timeout: 15 * time.Second},
+		// Responses may stream for as long as a tool runs, so requests are
+		// bounded by their context rather than a client timeout
+		client:      &http.Client{},
+		callTimeout: httpCallTimeout,
 	}
 }
 
+// SetCallTimeout bounds calls made without a deadline of their own. Zero
+// or less leaves them unbounded.
+func (t *HTTPTransport) SetCallTimeout(d time.Duration) {
+	t.callTimeout = d
+}
+
+// SetRoundTripper replaces the HTTP transport, e.g. to authorize requests
+func (t *HTTPTransport) SetRoundTripper(rt http.RoundTripper) {
+	t.client.Transport = rt
+}
+
+func (t *HTTPTransport) setMessageHandler(h serverMessageHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handler = h
+	if t.legacy != nil {
+		t.legacy.setMessageHandler(h)
+	}
+}
+
+// SessionID returns the session the server assigned on initialize, if any.
+func (t *HTTPTransport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+// Close stops the GET stream and ends the session on the server.
 func (t *HTTPTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	stop, session, legacy := t.stopStream, t.sessionID, t.legacy
+	t.stopStream = nil
+	t.mu.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+	if legacy != nil {
+		return legacy.Close()
+	}
+	if session == "" {
+		return nil
+	}
+	// Servers that don't allow clients to end sessions answer 405; either
+	// way there is nothing more to do.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := t.newRequest(ctx, http.MethodDelete, nil)
+	if err != nil {
+		return nil
+	}
+	if resp, err := t.client.Do(req); err == nil {
+		drainClose(resp.Body)
+	}
 	return nil
 }
 
 func (t *HTTPTransport) Call(ctx context.Context, req RPCRequest) (RPCResponse, error) {
+	if legacy := t.legacyTransport(); legacy != nil {
+		return legacy.Call(ctx, req)
+	}
+	if _, ok := ctx.Deadline(); !ok && t.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.callTimeout)
+		defer cancel()
+	}
+
+	initialize := req.Method == "initialize"
+	if initialize {
+		// A new session never carries the old one's ID
+		t.resetSession()
+	}
+
 	body, err := json.Marshal(req)
 	if err != nil {
 		return RPCResponse{}, err
 	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	httpReq, err := t.newRequest(ctx, http.MethodPost, body)
 	if err != nil {
 		return RPCResponse{}, err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json, text/event-stream")
-	for k, v := range t.headers {
-		if strings.TrimSpace(k) == "" {
-			continue
-		}
-		httpReq.Header.Set(k, v)
-	}
-
 	resp, err := t.client.Do(httpReq)
 	if err != nil {
 		return RPCResponse{}, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		b, _ := io.ReadAll(resp.Body)
-		return RPCResponse{}, errors.New(strings.TrimSpace(string(b)))
+	switch {
+	case resp.StatusCode == http.StatusNotFound && httpReq.Header.Get(headerSessionID) != "":
+		t.expireSession(httpReq.Header.Get(headerSessionID))
+		return RPCResponse{}, errSessionExpired
+	case initialize && isLegacyStatus(resp.StatusCode):
+		drainClose(resp.Body)
+		return t.fallBackToSSE(ctx, req)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return RPCResponse{}, httpStatusError(resp)
 	}
 
-	ct := strings.ToLower(resp.Header.Get("Content-Type"))
-	if strings.HasPrefix(ct, "text/event-stream") {
-		return readSSEForResponse(resp.Body, req.ID)
+	var idRaw json.RawMessage
+	if req.ID != nil {
+		idRaw, _ = json.Marshal(req.ID)
+	}
+	var out RPCResponse
+	if isEventStream(resp) {
+		out, err = t.readResponseStream(ctx, resp.Body, idKey(idRaw))
+	} else {
+		var b []byte
+		if b, err = io.ReadAll(resp.Body); err == nil {
+			err = json.Unmarshal(b, &out)
+		}
 	}
-
-	b, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return RPCResponse{}, err
 	}
-	out := RPCResponse{}
-	if err := json.Unmarshal(b, &out); err != nil {
-		return RPCResponse{}, err
+
+	if initialize && out.Error == nil {
+		var result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(out.Result, &result)
+		t.mu.Lock()
+		t.sessionID = strings.TrimSpace(resp.Header.Get(headerSessionID))
+		t.protocol = result.ProtocolVersion
+		t.mu.Unlock()
 	}
 	return out, nil
 }
 
 func (t *HTTPTransport) Notify(ctx context.Context, notif RPCNotification) error {
+	if legacy := t.legacyTransport(); legacy != nil {
+		return legacy.Notify(ctx, notif)
+	}
 	body, err := json.Marshal(notif)
 	if err != nil {
 		return err
 	}
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	httpReq, err := t.newRequest(ctx, http.MethodPost, body)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Do(httpReq)
 	if err != nil {
 		return err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json, text/event-stream")
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && httpReq.Header.Get(headerSessionID) != "" {
+		t.expireSession(httpReq.Header.Get(headerSessionID))
+		return errSessionExpired
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return httpStatusError(resp)
+	}
+	if isInitializedNotification(notif.Method) {
+		t.startStream()
+	}
+	return nil
+}
+
+func (t *HTTPTransport) newRequest(ctx context.Context, method string, body []byte) (*http.Request, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, t.url, r)
+	if err != nil {
+		return nil, err
+	}
+	switch method {
+	case http.MethodPost:
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+	case http.MethodGet:
+		req.Header.Set("Accept", "text/event-stream")
+	}
 	for k, v := range t.headers {
 		if strings.TrimSpace(k) == "" {
 			continue
 		}
-		httpReq.Header.Set(k, v)
+		req.Header.Set(k, v)
 	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set(headerSessionID, t.sessionID)
+	}
+	if t.protocol != "" {
+		req.Header.Set(headerProtocolVersion, t.protocol)
+	}
+	t.mu.Unlock()
+	return req, nil
+}
 
-	resp, err := t.client.Do(httpReq)
+// get opens an SSE stream, resuming after lastEventID when set
+func (t *HTTPTransport) get(ctx context.Context, lastEventID string) (*http.Response, error) {
+	req, err := t.newRequest(ctx, http.MethodGet, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
+	if lastEventID != "" {
+		req.Header.Set(headerLastEventID, lastEventID)
+	}
+	return t.client.Do(req)
+}
 
-	if resp.StatusCode == http.StatusAccepted {
-		return nil
+func (t *HTTPTransport) legacyTransport() *SSETransport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.legacy
+}
+
+func (t *HTTPTransport) resetSession() {
+	t.mu.Lock()
+	stop := t.stopStream
+	t.sessionID, t.protocol, t.stopStream = "", "", nil
+	t.mu.Unlock()
+	if stop != nil {
+		stop()
 	}
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		b, _ := io.ReadAll(resp.Body)
-		return errors.New(strings.TrimSpace(string(b)))
+}
+
+// expireSession forgets session id after the server answered 404 for it.
+// A request that was sent on an older session leaves a newer one alone.
+func (t *HTTPTransport) expireSession(id string) {
+	t.mu.Lock()
+	current := t.sessionID == id
+	t.mu.Unlock()
+	if current {
+		t.resetSession()
 	}
-	return nil
 }
 
-func readSSEForResponse(r io.Reader, reqID interface{}) (RPCResponse, error) {
-	var idRaw json.RawMessage
-	if reqID != nil {
-		idRaw, _ = json.Marshal(reqID)
+// fallBackToSSE retries initialize over the HTTP+SSE transport of protocol
+// version 2024-11-05, where the URL is the SSE stream and the server names
+// the POST endpoint in its first event.
+func (t *HTTPTransport) fallBackToSSE(ctx context.Context, req RPCRequest) (RPCResponse, error) {
+	legacy := NewSSETransport(t.url, t.headers)
+	legacy.SetEndpoints("", "")
+	if t.client.Transport != nil {
+		legacy.SetRoundTripper(t.client.Transport)
+	}
+	t.mu.Lock()
+	legacy.setMessageHandler(t.handler)
+	t.mu.Unlock()
+
+	resp, err := legacy.Call(ctx, req)
+	if err != nil {
+		_ = legacy.Close()
+		return RPCResponse{}, fmt.Errorf("server rejected streamable http and legacy sse failed: %w", err)
 	}
-	targetKey := idKey(idRaw)
+	t.mu.Lock()
+	t.legacy = legacy
+	t.mu.Unlock()
+	return resp, nil
+}
 
-	scanner := bufio.NewScanner(r)
-	buf := make([]byte, 0, 1024*1024)
-	scanner.Buffer(buf, 1024*1024)
+// streamCursor tracks what is needed to resume an SSE stream
+type streamCursor struct {
+	lastID string
+	retry  time.Duration
+}
 
-	var dataLines []string
-	flush := func() (*RPCResponse, bool) {
-		if len(dataLines) == 0 {
-			return nil, false
+// readResponseStream reads an SSE response until the reply to want
+// arrives. If the stream drops first, it is resumed with a GET carrying the
+// last event ID, as the server replays what followed.
+func (t *HTTPTransport) readResponseStream(ctx context.Context, body io.ReadCloser, want string) (RPCResponse, error) {
+	cur := streamCursor{retry: httpDefaultRetry}
+	attempts := 0
+	for {
+		resp, ok, err := t.consume(ctx, body, want, &cur)
+		body.Close()
+		if ok {
+			return resp, nil
 		}
-		payload := strings.Join(dataLines, "\n")
-		dataLines = nil
-		payload = strings.TrimSpace(payload)
-		if payload == "" {
-			return nil, false
+		for {
+			if ctx.Err() != nil {
+				return RPCResponse{}, ctx.Err()
+			}
+			if cur.lastID == "" || attempts >= httpResumeAttempts {
+				if err != nil && !errors.Is(err, io.EOF) {
+					return RPCResponse{}, err
+				}
+				return RPCResponse{}, errors.New("no response in sse stream")
+			}
+			attempts++
+			if !sleepContext(ctx, cur.retry) {
+				return RPCResponse{}, ctx.Err()
+			}
+			r, gerr := t.get(ctx, cur.lastID)
+			if gerr != nil {
+				err = gerr
+				continue
+			}
+			if r.StatusCode != http.StatusOK || !isEventStream(r) {
+				err := httpStatusError(r)
+				r.Body.Close()
+				return RPCResponse{}, fmt.Errorf("resume stream: %w", err)
+			}
+			body = r.Body
+			break
 		}
-		resp := RPCResponse{}
-		if json.Unmarshal([]byte(payload), &resp) != nil {
-			return nil, false
+	}
+}
+
+func (t *HTTPTransport) startStream() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed || t.stopStream != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.stopStream = cancel
+	go t.runStream(ctx)
+}
+
+// runStream listens on the GET stream for server-initiated messages,
+// reconnecting with the last event ID when it drops. A server that offers
+// no stream answers 405, which ends it.
+func (t *HTTPTransport) runStream(ctx context.Context) {
+	cur := streamCursor{retry: httpDefaultRetry}
+	failures := 0
+	for ctx.Err() == nil {
+		resp, err := t.get(ctx, cur.lastID)
+		switch {
+		case err != nil:
+			failures++
+			if failures > httpResumeAttempts {
+				return
+			}
+		case resp.StatusCode != http.StatusOK || !isEventStream(resp):
+			drainClose(resp.Body)
+			return
+		default:
+			failures = 0
+			_, _, _ = t.consume(ctx, resp.Body, "", &cur)
+			resp.Body.Close()
 		}
-		if targetKey == "" || idKey(resp.ID) == targetKey {
-			return &resp, true
+		if !sleepContext(ctx, cur.retry) {
+			return
 		}
-		return nil, false
 	}
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		line = strings.TrimRight(line, "\r")
-		if line == "" {
-			if resp, ok := flush(); ok {
-				return *resp, nil
-			}
+// consume reads JSON-RPC messages from an SSE stream until it ends or the
+// response to want arrives. Requests and notifications from the server go
+// to the message handler on the way.
+func (t *HTTPTransport) consume(ctx context.Context, body io.Reader, want string, cur *streamCursor) (RPCResponse, bool, error) {
+	r := bufio.NewReaderSize(body, 64*1024)
+	for {
+		ev, err := readSSEEvent(r)
+		if err != nil {
+			return RPCResponse{}, false, err
+		}
+		if ev.hasID {
+			cur.lastID = ev.id
+		}
+		if ev.retry > 0 {
+			cur.retry = ev.retry
+		}
+		if strings.TrimSpace(ev.data) == "" {
+			continue
+		}
+		var msg rpcMessage
+		if json.Unmarshal([]byte(ev.data), &msg) != nil {
+			continue
+		}
+		if msg.Method != "" {
+			t.dispatch(ctx, msg)
 			continue
 		}
-		if strings.HasPrefix(line, "data:") {
-			dataLines = append(dataLines, strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		if want != "" && idKey(msg.ID) == want {
+			return msg.response(), true, nil
 		}
 	}
-	if resp, ok := flush(); ok {
-		return *resp, nil
+}
+
+// dispatch hands a server message to the handler. Requests are answered
+// with a POST of their own, off the reading goroutine, since the server may
+// wait on the answer before the stream continues.
+func (t *HTTPTransport) dispatch(ctx context.Context, msg rpcMessage) {
+	t.mu.Lock()
+	h := t.handler
+	t.mu.Unlock()
+
+	if !msg.isRequest() {
+		if h != nil {
+			h.handleNotification(msg.Method, msg.Params)
+		}
+		return
 	}
-	if err := scanner.Err(); err != nil {
-		return RPCResponse{}, err
+	go func() {
+		replyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), httpCallTimeout)
+		defer cancel()
+		body, err := json.Marshal(answerServerRequest(replyCtx, h, msg))
+		if err != nil {
+			return
+		}
+		req, err := t.newRequest(replyCtx, http.MethodPost, body)
+		if err != nil {
+			return
+		}
+		if resp, err := t.client.Do(req); err == nil {
+			drainClose(resp.Body)
+		}
+	}()
+}
+
+type sseEvent struct {
+	id    string
+	hasID bool
+	name  string
+	data  string
+	retry time.Duration
+}
+
+// readSSEEvent reads the next event from r. An event cut off by the end of
+// the stream is still returned; the following call reports the error.
+func readSSEEvent(r *bufio.Reader) (sseEvent, error) {
+	var ev sseEvent
+	var data []string
+	seen := false
+	for {
+		line, err := r.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			seen = true
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "data":
+				data = append(data, value)
+			case "id":
+				ev.id, ev.hasID = value, true
+			case "event":
+				ev.name = value
+			case "retry":
+				if ms, err := strconv.Atoi(value); err == nil && ms > 0 {
+					ev.retry = time.Duration(ms) * time.Millisecond
+				}
+			}
+		}
+		if (line == "" || err != nil) && seen {
+			ev.data = strings.Join(data, "\n")
+			return ev, nil
+		}
+		if err != nil {
+			return sseEvent{}, err
+		}
+	}
+}
+
+func isEventStream(resp *http.Response) bool {
+	return strings.HasPrefix(strings.ToLower(resp.Header.Get("Content-Type")), "text/event-stream")
+}
+
+// isLegacyStatus reports whether an initialize POST was refused in the way
+// HTTP+SSE servers refuse it
+func isLegacyStatus(code int) bool {
+	return code == http.StatusBadRequest || code == http.StatusNotFound || code == http.StatusMethodNotAllowed
+}
+
+func httpStatusError(resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if msg := strings.TrimSpace(string(b)); msg != "" {
+		return errors.New(msg)
+	}
+	return errors.New(resp.Status)
+}
+
+func drainClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 64*1024))
+	_ = body.Close()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
 	}
-	return RPCResponse{}, errors.New("no response in sse stream")
 }
@@ -3,9 +3,14 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestHTTPTransport_Call_JSON(t *testing.T) {
@@ -94,3 +99,308 @@ func mustJSON(v interface{}) json.RawMessage {
 	b, _ := json.Marshal(v)
 	return b
 }
+
+// streamableServer is a minimal Streamable HTTP server for the session,
+// stream and resumption tests.
+type streamableServer struct {
+	t        *testing.T
+	mu       sync.Mutex
+	sessions int
+	session  string
+	expire   bool
+	deleted  []string
+	replies  []RPCResponse
+	// serverStream offers a GET stream with a notification and a ping
+	serverStream bool
+	// callStream answers tools/call with a stream that drops after one
+	// event; the response is replayed on resume.
+	callStream bool
+	callID     json.RawMessage
+}
+
+func (s *streamableServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sid := r.Header.Get(headerSessionID)
+
+	switch r.Method {
+	case http.MethodDelete:
+		s.deleted = append(s.deleted, sid)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodGet:
+		if sid != s.session {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		if r.Header.Get(headerLastEventID) == "call-1" {
+			writeEvent(w, "call-2", RPCResponse{JSONRPC: "2.0", ID: s.callID, Result: mustJSON(map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": "resumed"}}})})
+			return
+		}
+		if !s.serverStream {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		writeEvent(w, "g-1", RPCNotification{JSONRPC: "2.0", Method: "notifications/tools/list_changed"})
+		writeEvent(w, "g-2", RPCRequest{JSONRPC: "2.0", ID: "srv-1", Method: "ping"})
+		w.(http.Flusher).Flush()
+		s.mu.Unlock()
+		<-r.Context().Done()
+		s.mu.Lock()
+		return
+	}
+
+	var msg rpcMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if msg.Method == "" {
+		s.replies = append(s.replies, msg.response())
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if msg.Method == "initialize" {
+		if sid != "" {
+			s.t.Errorf("initialize carried session %q", sid)
+		}
+		s.sessions++
+		s.session = fmt.Sprintf("session-%d", s.sessions)
+		w.Header().Set(headerSessionID, s.session)
+		_ = json.NewEncoder(w).Encode(RPCResponse{JSONRPC: "2.0", ID: msg.ID, Result: mustJSON(map[string]interface{}{"protocolVersion": "2025-06-18", "capabilities": map[string]interface{}{}})})
+		return
+	}
+	if s.expire {
+		s.expire = false
+		s.session = ""
+	}
+	if sid != s.session {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if v := r.Header.Get(headerProtocolVersion); v != "2025-06-18" {
+		s.t.Errorf("%s sent protocol version %q", msg.Method, v)
+	}
+	if !msg.isRequest() {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if msg.Method == "tools/call" && s.callStream {
+		s.callID = msg.ID
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "retry: 10\n\n")
+		writeEvent(w, "call-1", RPCNotification{JSONRPC: "2.0", Method: "notifications/progress"})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(RPCResponse{JSONRPC: "2.0", ID: msg.ID, Result: mustJSON(map[string]interface{}{"tools": []map[string]interface{}{{"name": "t1"}}})})
+}
+
+func writeEvent(w io.Writer, id string, v interface{}) {
+	b, _ := json.Marshal(v)
+	fmt.Fprintf(w, "id: %s\ndata: %s\n\n", id, b)
+}
+
+func TestHTTPTransport_SessionAndServerStream(t *testing.T) {
+	s := &streamableServer{t: t, serverStream: true}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	tr := NewHTTPTransport(srv.URL, nil)
+	c := NewClient(tr, "")
+	changed := make(chan struct{}, 1)
+	c.OnToolsChanged(func() { changed <- struct{}{} })
+
+	ctx := context.Background()
+	if _, err := c.ListTools(ctx); err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	if tr.SessionID() != "session-1" || c.ProtocolVersion() != "2025-06-18" {
+		t.Fatalf("session %q, version %q", tr.SessionID(), c.ProtocolVersion())
+	}
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("list_changed notification not delivered")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		replies := append([]RPCResponse(nil), s.replies...)
+		s.mu.Unlock()
+		if len(replies) > 0 {
+			if idKey(replies[0].ID) != `s:srv-1` || replies[0].Error != nil {
+				t.Fatalf("unexpected ping reply: %+v", replies[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("server ping was not answered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.deleted) != 1 || s.deleted[0] != "session-1" {
+		t.Fatalf("session not deleted: %v", s.deleted)
+	}
+}
+
+func TestHTTPTransport_SessionExpiry(t *testing.T) {
+	s := &streamableServer{t: t}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	tr := NewHTTPTransport(srv.URL, nil)
+	c := NewClient(tr, "")
+	defer c.Close()
+
+	ctx := context.Background()
+	if _, err := c.ListTools(ctx); err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	s.mu.Lock()
+	s.expire = true
+	s.mu.Unlock()
+
+	if _, err := c.ListTools(ctx); err != nil {
+		t.Fatalf("ListTools after expiry: %v", err)
+	}
+	if tr.SessionID() != "session-2" {
+		t.Fatalf("expected a new session, got %q", tr.SessionID())
+	}
+}
+
+func TestHTTPTransport_ConcurrentSessionExpiry(t *testing.T) {
+	s := &streamableServer{t: t}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	tr := NewHTTPTransport(srv.URL, nil)
+	c := NewClient(tr, "")
+	defer c.Close()
+
+	ctx := context.Background()
+	if _, err := c.ListTools(ctx); err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	s.mu.Lock()
+	s.expire = true
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.ListTools(ctx); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("ListTools after expiry: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions != 2 || tr.SessionID() != "session-2" {
+		t.Fatalf("expected one new session, got %d sessions, current %q", s.sessions, tr.SessionID())
+	}
+}
+
+func TestHTTPTransport_CallTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	tr := NewHTTPTransport(srv.URL, nil)
+	tr.SetCallTimeout(50 * time.Millisecond)
+	_, err := tr.Call(context.Background(), RPCRequest{JSONRPC: "2.0", ID: 1, Method: "ping"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the call to time out, got %v", err)
+	}
+}
+
+func TestHTTPTransport_ResumesDroppedStream(t *testing.T) {
+	s := &streamableServer{t: t, callStream: true}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	c := NewClient(NewHTTPTransport(srv.URL, nil), "")
+	defer c.Close()
+	ctx := context.Background()
+	res, err := c.CallTool(ctx, "t1", nil)
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if len(res.Content) != 1 || res.Content[0].Text != "resumed" {
+		t.Fatalf("unexpected result: %#v", res)
+	}
+}
+
+func TestHTTPTransport_FallsBackToLegacySSE(t *testing.T) {
+	events := make(chan []byte, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case r.Method == http.MethodGet && r.URL.Path == "/":
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: endpoint\ndata: /messages?sessionId=abc\n\n")
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case b := <-events:
+					fmt.Fprintf(w, "event: message\ndata: %s\n\n", b)
+					w.(http.Flusher).Flush()
+				case <-r.Context().Done():
+					return
+				}
+			}
+		case r.Method == http.MethodPost && r.URL.Path == "/messages":
+			if r.URL.Query().Get("sessionId") != "abc" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			var msg rpcMessage
+			_ = json.NewDecoder(r.Body).Decode(&msg)
+			w.WriteHeader(http.StatusAccepted)
+			if !msg.isRequest() {
+				return
+			}
+			result := map[string]interface{}{"protocolVersion": "2024-11-05", "capabilities": map[string]interface{}{}}
+			if msg.Method == "tools/list" {
+				result = map[string]interface{}{"tools": []map[string]interface{}{{"name": "legacy"}}}
+			}
+			b, _ := json.Marshal(RPCResponse{JSONRPC: "2.0", ID: msg.ID, Result: mustJSON(result)})
+			events <- b
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(NewHTTPTransport(srv.URL+"/", nil), "")
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "legacy" || c.ProtocolVersion() != "2024-11-05" {
+		t.Fatalf("unexpected tools %#v, version %q", tools, c.ProtocolVersion())
+	}
+}
@@ -9,9 +9,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
-	"time"
 )
 
 type SSETransport struct {
@@ -21,11 +21,14 @@ type SSETransport struct {
 	sseEndpoint  string
 	postEndpoint string
 
-	mu          sync.RWMutex
-	conn        *sseConnection
-	pending     map[string]chan RPCResponse
-	closed      bool
-	lastEventID string
+	mu            sync.RWMutex
+	conn          *sseConnection
+	pending       map[string]chan RPCResponse
+	closed        bool
+	lastEventID   string
+	postURL       string
+	endpointReady chan struct{}
+	handler       serverMessageHandler
 }
 
 type sseConnection struct {
@@ -37,20 +40,59 @@ type sseConnection struct {
 
 func NewSSETransport(endpointURL string, headers map[string]string) *SSETransport {
 	return &SSETransport{
-		baseURL:      strings.TrimRight(endpointURL, "/"),
-		headers:      headers,
-		client:       &http.Client{Timeout: 30 * time.Second},
-		pending:      make(map[string]chan RPCResponse),
-		sseEndpoint:  "/sse",
-		postEndpoint: "/message",
+		baseURL: strings.TrimRight(endpointURL, "/"),
+		headers: headers,
+		// The event stream stays open for the life of the connection, so
+		// requests are bounded by their context instead of a client timeout
+		client:        &http.Client{},
+		pending:       make(map[string]chan RPCResponse),
+		endpointReady: make(chan struct{}),
+		sseEndpoint:   "/sse",
+		postEndpoint:  "/message",
 	}
 }
 
+// SetRoundTripper replaces the HTTP transport, e.g. to authorize requests
+func (t *SSETransport) SetRoundTripper(rt http.RoundTripper) {
+	t.client.Transport = rt
+}
+
+// SetEndpoints sets the paths of the event stream and the POST endpoint
+// relative to the base URL. An empty postPath waits for the server to name
+// the endpoint in an "endpoint" event, as HTTP+SSE servers do.
 func (t *SSETransport) SetEndpoints(ssePath, postPath string) {
 	t.sseEndpoint = ssePath
 	t.postEndpoint = postPath
 }
 
+func (t *SSETransport) setMessageHandler(h serverMessageHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handler = h
+}
+
+// postTarget returns the URL messages are POSTed to. The endpoint event,
+// when the server sends one, wins over the configured path.
+func (t *SSETransport) postTarget(ctx context.Context) (string, error) {
+	t.mu.RLock()
+	target, path := t.postURL, t.postEndpoint
+	t.mu.RUnlock()
+	if target != "" {
+		return target, nil
+	}
+	if path != "" {
+		return t.baseURL + path, nil
+	}
+	select {
+	case <-t.endpointReady:
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+		return t.postURL, nil
+	case <-ctx.Done():
+		return "", fmt.Errorf("waiting for endpoint event: %w", ctx.Err())
+	}
+}
+
 func (t *SSETransport) Connect(ctx context.Context) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -59,9 +101,13 @@ func (t *SSETransport) Connect(ctx context.Context) error {
 		return nil
 	}
 
+	// The stream outlives the call that opened it, so only the connection
+	// context, cancelled on Close, may end it
+	connCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
 	url := t.baseURL + t.sseEndpoint
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(connCtx, http.MethodGet, url, nil)
 	if err != nil {
+		cancel()
 		return fmt.Errorf("create SSE request: %w", err)
 	}
 
@@ -73,18 +119,26 @@ func (t *SSETransport) Connect(ctx context.Context) error {
 		}
 	}
 
+	stop := context.AfterFunc(ctx, cancel)
 	resp, err := t.client.Do(req)
+	if !stop() {
+		err = ctx.Err()
+	}
 	if err != nil {
+		cancel()
+		if resp != nil {
+			resp.Body.Close()
+		}
 		return fmt.Errorf("connect SSE: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
+		cancel()
 		return fmt.Errorf("SSE connection failed: %d - %s", resp.StatusCode, string(body))
 	}
 
-	connCtx, cancel := context.WithCancel(context.Background())
 	t.conn = &sseConnection{
 		resp:   resp,
 		reader: bufio.NewReader(resp.Body),
@@ -148,7 +202,13 @@ func (t *SSETransport) Call(ctx context.Context, req RPCRequest) (RPCResponse, e
 	t.pending[key] = ch
 	t.mu.Unlock()
 
-	url := t.baseURL + t.postEndpoint
+	url, err := t.postTarget(ctx)
+	if err != nil {
+		t.mu.Lock()
+		delete(t.pending, key)
+		t.mu.Unlock()
+		return RPCResponse{}, err
+	}
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		t.mu.Lock()
@@ -204,7 +264,10 @@ func (t *SSETransport) Notify(ctx context.Context, notif RPCNotification) error
 		return fmt.Errorf("marshal notification: %w", err)
 	}
 
-	url := t.baseURL + t.postEndpoint
+	url, err := t.postTarget(ctx)
+	if err != nil {
+		return err
+	}
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("create POST request: %w", err)
@@ -228,6 +291,7 @@ func (t *SSETransport) Notify(ctx context.Context, notif RPCNotification) error
 
 func (t *SSETransport) readLoop() {
 	var currentData []string
+	var currentEvent string
 
 	for {
 		t.mu.RLock()
@@ -247,14 +311,20 @@ func (t *SSETransport) readLoop() {
 		line = strings.TrimRight(line, "\r\n")
 
 		if line == "" {
-			t.dispatchEvent(currentData)
-			currentData = nil
+			if currentEvent == "endpoint" {
+				t.setEndpoint(strings.Join(currentData, ""))
+			} else {
+				t.dispatchEvent(currentData)
+			}
+			currentData, currentEvent = nil, ""
 			continue
 		}
 
 		if strings.HasPrefix(line, "data:") {
 			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
 			currentData = append(currentData, data)
+		} else if strings.HasPrefix(line, "event:") {
+			currentEvent = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
 		} else if strings.HasPrefix(line, "id:") {
 			t.mu.Lock()
 			t.lastEventID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
@@ -274,10 +344,15 @@ func (t *SSETransport) dispatchEvent(dataLines []string) {
 		return
 	}
 
-	var resp RPCResponse
-	if err := json.Unmarshal([]byte(payload), &resp); err != nil {
+	var msg rpcMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
 		return
 	}
+	if msg.Method != "" {
+		t.handleServerMessage(msg)
+		return
+	}
+	resp := msg.response()
 
 	key := idKey(resp.ID)
 	if key == "" {
@@ -297,6 +372,70 @@ func (t *SSETransport) dispatchEvent(dataLines []string) {
 	}
 }
 
+// setEndpoint records the POST endpoint named by an endpoint event,
+// resolved against the stream URL.
+func (t *SSETransport) setEndpoint(raw string) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return
+	}
+	base, err := url.Parse(t.baseURL + t.sseEndpoint)
+	if err != nil {
+		return
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	first := t.postURL == ""
+	t.postURL = base.ResolveReference(ref).String()
+	if first {
+		close(t.endpointReady)
+	}
+}
+
+// handleServerMessage passes a server notification to the handler and
+// POSTs the answer to a server request.
+func (t *SSETransport) handleServerMessage(msg rpcMessage) {
+	t.mu.RLock()
+	h := t.handler
+	t.mu.RUnlock()
+
+	if !msg.isRequest() {
+		if h != nil {
+			h.handleNotification(msg.Method, msg.Params)
+		}
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), httpCallTimeout)
+		defer cancel()
+		body, err := json.Marshal(answerServerRequest(ctx, h, msg))
+		if err != nil {
+			return
+		}
+		target, err := t.postTarget(ctx)
+		if err != nil {
+			return
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range t.headers {
+			if strings.TrimSpace(k) != "" {
+				req.Header.Set(k, v)
+			}
+		}
+		if resp, err := t.client.Do(req); err == nil {
+			drainClose(resp.Body)
+		}
+	}()
+}
+
 func (t *SSETransport) handleError(err error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -9,12 +9,15 @@ import (
 	"io"
 	"os/exec"
 	"sync"
+
+	"pryx-core/internal/mcp/security"
 )
 
 type StdioTransport struct {
 	command []string
 	cwd     string
 	env     map[string]string
+	sandbox *security.Sandbox
 
 	startOnce sync.Once
 	startErr  error
@@ -28,6 +31,10 @@ type StdioTransport struct {
 	mu      sync.Mutex
 	pending map[string]chan RPCResponse
 	closed  bool
+	handler serverMessageHandler
+
+	// writeMu keeps lines written from concurrent calls and replies whole
+	writeMu sync.Mutex
 }
 
 func NewStdioTransport(command []string, cwd string, env map[string]string) *StdioTransport {
@@ -39,6 +46,17 @@ func NewStdioTransport(command []string, cwd string, env map[string]string) *Std
 	}
 }
 
+func (t *StdioTransport) setMessageHandler(h serverMessageHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handler = h
+}
+
+// SetSandbox enforces sb on the server process. It must be called before Start.
+func (t *StdioTransport) SetSandbox(sb *security.Sandbox) {
+	t.sandbox = sb
+}
+
 func (t *StdioTransport) Start(ctx context.Context) error {
 	t.startOnce.Do(func() {
 		if len(t.command) == 0 {
@@ -53,7 +71,13 @@ func (t *StdioTransport) Start(ctx context.Context) error {
 		if t.cwd != "" {
 			t.cmd.Dir = t.cwd
 		}
-		if len(t.env) > 0 {
+		if t.sandbox != nil {
+			if err := t.sandbox.PrepareCmd(t.cmd, t.env); err != nil {
+				cancel()
+				t.startErr = err
+				return
+			}
+		} else if len(t.env) > 0 {
 			var out []string
 			for k, v := range t.env {
 				out = append(out, fmt.Sprintf("%s=%s", k, v))
@@ -72,7 +96,11 @@ func (t *StdioTransport) Start(ctx context.Context) error {
 			return
 		}
 
-		if err := t.cmd.Start(); err != nil {
+		start := t.cmd.Start
+		if t.sandbox != nil {
+			start = func() error { return t.sandbox.Start(t.cmd) }
+		}
+		if err := start(); err != nil {
 			t.startErr = err
 			return
 		}
@@ -146,7 +174,7 @@ func (t *StdioTransport) Call(ctx context.Context, req RPCRequest) (RPCResponse,
 	t.pending[key] = ch
 	t.mu.Unlock()
 
-	if _, err := t.stdin.Write(append(b, '\n')); err != nil {
+	if err := t.writeLine(b); err != nil {
 		t.mu.Lock()
 		delete(t.pending, key)
 		t.mu.Unlock()
@@ -175,7 +203,13 @@ func (t *StdioTransport) Notify(ctx context.Context, notif RPCNotification) erro
 	if err != nil {
 		return err
 	}
-	_, err = t.stdin.Write(append(b, '\n'))
+	return t.writeLine(b)
+}
+
+func (t *StdioTransport) writeLine(b []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err := t.stdin.Write(append(b, '\n'))
 	return err
 }
 
@@ -186,10 +220,15 @@ func (t *StdioTransport) readLoop() {
 
 	for scanner.Scan() {
 		line := scanner.Bytes()
-		resp := RPCResponse{}
-		if err := json.Unmarshal(line, &resp); err != nil {
+		var msg rpcMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			continue
+		}
+		if msg.Method != "" {
+			t.handleServerMessage(msg)
 			continue
 		}
+		resp := msg.response()
 		key := idKey(resp.ID)
 		if key == "" {
 			continue
@@ -215,6 +254,31 @@ func (t *StdioTransport) readLoop() {
 	}
 }
 
+// handleServerMessage dispatches a request or notification from the
+// server. Requests are answered in the background so that a slow answer,
+// such as one waiting on the user, does not hold up responses.
+func (t *StdioTransport) handleServerMessage(msg rpcMessage) {
+	t.mu.Lock()
+	h := t.handler
+	t.mu.Unlock()
+
+	if !msg.isRequest() {
+		if h != nil {
+			h.handleNotification(msg.Method, msg.Params)
+		}
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), httpCallTimeout)
+		defer cancel()
+		b, err := json.Marshal(answerServerRequest(ctx, h, msg))
+		if err != nil {
+			return
+		}
+		_ = t.writeLine(b)
+	}()
+}
+
 func (t *StdioTransport) failAll(err error) {
 	t.mu.Lock()
 	if t.closed {
@@ -6,8 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/mcp/security"
 )
 
 func TestStdioTransport_ClientFlow(t *testing.T) {
@@ -73,3 +77,162 @@ func TestMCPHelperProcess(t *testing.T) {
 	}
 	os.Exit(0)
 }
+
+func TestStdioTransport_Sandbox(t *testing.T) {
+	cmd := []string{os.Args[0], "-test.run=TestMCPHelperProcess", "--"}
+	tr := NewStdioTransport(cmd, "", map[string]string{"GO_WANT_MCP_HELPER_PROCESS": "1"})
+	tr.SetSandbox(security.NewSandbox(security.SandboxConfig{
+		AllowedDirs:    []string{t.TempDir()},
+		SanitizeEnv:    true,
+		AllowedEnvVars: []string{"GO_WANT_MCP_HELPER_PROCESS"},
+		MaxCPUTime:     10 * time.Second,
+		MaxMemory:      4 << 30,
+		BlockSudo:      true,
+	}))
+	c := NewClient(tr, "2025-11-25")
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	if len(tools) != 1 {
+		t.Fatalf("unexpected tools: %#v", tools)
+	}
+}
+
+func TestStdioTransport_SandboxRejectsCommand(t *testing.T) {
+	tr := NewStdioTransport([]string{"sudo", "mcp-server"}, "", nil)
+	tr.SetSandbox(security.NewSandbox(security.SandboxConfig{BlockSudo: true}))
+	c := NewClient(tr, "2025-11-25")
+	defer c.Close()
+
+	err := c.Initialize(context.Background())
+	if err == nil {
+		t.Fatal("expected sandbox violation")
+	}
+	v, ok := security.AsViolation(err)
+	if !ok || v.Kind != security.ViolationSudo {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStdioTransport_ServerRequests(t *testing.T) {
+	b := bus.New()
+	events, unsubscribe := b.Subscribe(bus.EventElicitationRequested)
+	defer unsubscribe()
+	m := newAllowAllManager(t, b)
+	defer m.Close()
+
+	dir := t.TempDir()
+	if err := m.SetSessionRoots("s1", []string{dir}); err != nil {
+		t.Fatalf("SetSessionRoots: %v", err)
+	}
+
+	cmd := []string{os.Args[0], "-test.run=TestMCPRequestingHelperProcess", "--"}
+	tr := NewStdioTransport(cmd, "", map[string]string{"GO_WANT_MCP_REQUESTING_HELPER": "1"})
+	c := NewClient(tr, "2025-11-25")
+	c.SetHooks(m.clientHooks("helper"))
+	defer c.Close()
+
+	go func() {
+		evt := <-events
+		if evt.SessionID != "s1" || evt.Surface != "channel:tg:42" {
+			t.Errorf("elicitation not attributed to the caller: %q %q", evt.SessionID, evt.Surface)
+		}
+		if _, err := m.AnswerElicitationText("channel:tg:42", "name: ada\nage: 36"); err != nil {
+			t.Errorf("answer: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = WithSurface(WithSessionID(ctx, "s1"), "channel:tg:42")
+	res, err := c.CallTool(ctx, "ask", nil)
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+
+	var got struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+		Roots        []Root                 `json:"roots"`
+		Elicit       ElicitResult           `json:"elicit"`
+	}
+	if err := json.Unmarshal([]byte(res.Content[0].Text), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Capabilities["roots"] == nil || got.Capabilities["elicitation"] == nil {
+		t.Fatalf("capabilities not advertised: %v", got.Capabilities)
+	}
+	if len(got.Roots) != 1 || got.Roots[0].URI != "file://"+filepath.ToSlash(dir) {
+		t.Fatalf("unexpected roots: %+v", got.Roots)
+	}
+	if got.Elicit.Action != ElicitAccept || got.Elicit.Content["name"] != "ada" || got.Elicit.Content["age"] != float64(36) {
+		t.Fatalf("unexpected elicitation result: %+v", got.Elicit)
+	}
+}
+
+// TestMCPRequestingHelperProcess asks the client for its roots and for
+// user input while serving a tool call, and returns what it got.
+func TestMCPRequestingHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_MCP_REQUESTING_HELPER") != "1" {
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	reply := func(id json.RawMessage, result interface{}) {
+		b, _ := json.Marshal(RPCResponse{JSONRPC: "2.0", ID: id, Result: mustJSON(result)})
+		fmt.Fprintln(os.Stdout, string(b))
+	}
+	ask := func(id, method string, params interface{}) json.RawMessage {
+		b, _ := json.Marshal(RPCRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+		fmt.Fprintln(os.Stdout, string(b))
+		for scanner.Scan() {
+			var msg rpcMessage
+			if json.Unmarshal(scanner.Bytes(), &msg) == nil && msg.Method == "" && idKey(msg.ID) == "s:"+id {
+				return msg.Result
+			}
+		}
+		return nil
+	}
+
+	var caps json.RawMessage
+	for scanner.Scan() {
+		var msg rpcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || !msg.isRequest() {
+			continue
+		}
+		switch msg.Method {
+		case "initialize":
+			var p struct {
+				Capabilities json.RawMessage `json:"capabilities"`
+			}
+			_ = json.Unmarshal(msg.Params, &p)
+			caps = p.Capabilities
+			reply(msg.ID, map[string]interface{}{"capabilities": map[string]interface{}{"tools": map[string]interface{}{}}})
+		case "tools/call":
+			roots := ask("r1", "roots/list", nil)
+			elicit := ask("r2", "elicitation/create", map[string]interface{}{
+				"message": "Who are you?",
+				"requestedSchema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"name": map[string]interface{}{"type": "string"},
+						"age":  map[string]interface{}{"type": "integer"},
+					},
+					"required": []string{"name"},
+				},
+			})
+			var r struct {
+				Roots json.RawMessage `json:"roots"`
+			}
+			_ = json.Unmarshal(roots, &r)
+			out, _ := json.Marshal(map[string]json.RawMessage{"capabilities": caps, "roots": r.Roots, "elicit": elicit})
+			reply(msg.ID, map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": string(out)}}})
+		}
+	}
+	os.Exit(0)
+}
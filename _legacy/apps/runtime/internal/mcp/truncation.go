@@ -1,9 +1,12 @@
 package mcp
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -21,12 +24,33 @@ var (
 	globalTruncator *Truncator
 )
 
+// ErrOutputNotFound is returned when a truncated output does not exist or
+// has expired.
+var ErrOutputNotFound = errors.New("tool output not found")
+
+// outputIDPattern matches the IDs of spilled outputs and keeps lookups
+// inside the output directory.
+var outputIDPattern = regexp.MustCompile(`^tool_[0-9]+_[0-9]+$`)
+
 type TruncationResult struct {
 	Content    string `json:"content"`
 	Truncated  bool   `json:"truncated"`
+	OutputID   string `json:"output_id,omitempty"`
 	OutputPath string `json:"output_path,omitempty"`
 }
 
+// OutputMeta links a spilled output to the tool call that produced it. It
+// is stored next to the output as <id>.json.
+type OutputMeta struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id,omitempty"`
+	Tool      string    `json:"tool,omitempty"`
+	CallID    string    `json:"call_id,omitempty"`
+	Lines     int       `json:"lines"`
+	Bytes     int       `json:"bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Truncator struct {
 	outputDir string
 	maxLines  int
@@ -43,6 +67,12 @@ func NewTruncator(dataDir string) *Truncator {
 }
 
 func (t *Truncator) Process(content string) (*TruncationResult, error) {
+	return t.ProcessFor(content, OutputMeta{})
+}
+
+// ProcessFor is Process with the session, tool and call recorded against
+// the spilled output so it can be read back later.
+func (t *Truncator) ProcessFor(content string, meta OutputMeta) (*TruncationResult, error) {
 	lines := strings.Split(content, "\n")
 	totalBytes := len(content)
 
@@ -57,29 +87,47 @@ func (t *Truncator) Process(content string) (*TruncationResult, error) {
 		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	filename := fmt.Sprintf("tool_%d_%d.txt", time.Now().UnixNano(), os.Getpid())
-	filepath := filepath.Join(t.outputDir, filename)
+	id := fmt.Sprintf("tool_%d_%d", time.Now().UnixNano(), os.Getpid())
+	path := t.outputPath(id)
 
-	if err := os.WriteFile(filepath, []byte(content), 0644); err != nil {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
 		return nil, fmt.Errorf("failed to write output file: %w", err)
 	}
 
+	meta.ID = id
+	meta.Lines = len(lines)
+	meta.Bytes = totalBytes
+	meta.CreatedAt = time.Now().UTC()
+	if data, err := json.Marshal(meta); err == nil {
+		_ = os.WriteFile(t.metaPath(id), data, 0644)
+	}
+
 	truncatedContent := t.truncateContent(lines, totalBytes)
 
 	hint := fmt.Sprintf(
-		"\n\n... output truncated (full content saved to: %s)\n"+
-			"Use Grep to search the full content or Read with offset/limit to view specific sections.",
-		filepath,
+		"\n\n... output truncated (output id: %s, full content saved to: %s)\n"+
+			"Use Grep to search the full content or Read with offset/limit to view specific sections, "+
+			"or call the read_tool_output tool with this output id.",
+		id, path,
 	)
 	truncatedContent += hint
 
 	return &TruncationResult{
 		Content:    truncatedContent,
 		Truncated:  true,
-		OutputPath: filepath,
+		OutputID:   id,
+		OutputPath: path,
 	}, nil
 }
 
+func (t *Truncator) outputPath(id string) string {
+	return filepath.Join(t.outputDir, id+".txt")
+}
+
+func (t *Truncator) metaPath(id string) string {
+	return filepath.Join(t.outputDir, id+".json")
+}
+
 func (t *Truncator) truncateContent(lines []string, totalBytes int) string {
 	var out []string
 	var bytes int
@@ -106,6 +154,12 @@ func (t *Truncator) truncateContent(lines []string, totalBytes int) string {
 }
 
 func (t *Truncator) ProcessToolResult(result ToolResult) ToolResult {
+	return t.ProcessToolResultFor(result, OutputMeta{})
+}
+
+// ProcessToolResultFor truncates result, recording meta against every
+// output it spills.
+func (t *Truncator) ProcessToolResultFor(result ToolResult, meta OutputMeta) ToolResult {
 	if result.IsError || len(result.Content) == 0 {
 		return result
 	}
@@ -113,7 +167,7 @@ func (t *Truncator) ProcessToolResult(result ToolResult) ToolResult {
 	var newContent []ToolContent
 	for _, content := range result.Content {
 		if content.Type == "text" && content.Text != "" {
-			truncated, err := t.Process(content.Text)
+			truncated, err := t.ProcessFor(content.Text, meta)
 			if err != nil {
 				newContent = append(newContent, content)
 				continue
@@ -189,11 +243,17 @@ func GetTruncator() *Truncator {
 }
 
 func TruncateToolResult(result ToolResult) ToolResult {
+	return TruncateToolResultFor(result, OutputMeta{})
+}
+
+// TruncateToolResultFor truncates result with the global truncator and
+// links any spilled output to meta.
+func TruncateToolResultFor(result ToolResult, meta OutputMeta) ToolResult {
 	truncator := GetTruncator()
 	if truncator == nil {
 		return result
 	}
-	return truncator.ProcessToolResult(result)
+	return truncator.ProcessToolResultFor(result, meta)
 }
 
 func Cleanup() error {
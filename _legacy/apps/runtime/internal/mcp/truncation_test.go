@@ -1,6 +1,7 @@
 package mcp
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -300,3 +301,51 @@ func TestCleanup_NoInit(t *testing.T) {
 		t.Errorf("Cleanup() with no init should return nil, got %v", err)
 	}
 }
+
+func TestTruncator_ReadOutput(t *testing.T) {
+	truncator := NewTruncator(t.TempDir())
+	truncator.maxLines = 5
+
+	var lines []string
+	for i := 1; i <= 12; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	result, err := truncator.ProcessFor(strings.Join(lines, "\n"), OutputMeta{SessionID: "s1", Tool: "mcp.fs.read", CallID: "c1"})
+	if err != nil {
+		t.Fatalf("ProcessFor() error = %v", err)
+	}
+	if result.OutputID == "" || !strings.Contains(result.Content, result.OutputID) {
+		t.Fatalf("expected output id in hint, got %q", result.Content)
+	}
+
+	page, err := truncator.ReadOutput(result.OutputID, ReadOptions{Offset: 4, Limit: 3})
+	if err != nil {
+		t.Fatalf("ReadOutput() error = %v", err)
+	}
+	if page.Total != 12 || len(page.Content) != 3 || page.Content[0].Number != 5 || page.NextOffset != 7 {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	if page.Output.SessionID != "s1" || page.Output.CallID != "c1" || page.Output.Lines != 12 {
+		t.Errorf("unexpected meta: %+v", page.Output)
+	}
+
+	page, err = truncator.ReadOutput(result.OutputID, ReadOptions{Grep: `line 1\d?$`})
+	if err != nil {
+		t.Fatalf("ReadOutput(grep) error = %v", err)
+	}
+	if page.Total != 4 || page.NextOffset != 0 || page.Content[1].Text != "line 10" {
+		t.Fatalf("unexpected grep page: %+v", page)
+	}
+
+	if _, err := truncator.ReadOutput("../secret", ReadOptions{}); err != ErrOutputNotFound {
+		t.Errorf("expected ErrOutputNotFound for invalid id, got %v", err)
+	}
+
+	outputs, err := truncator.ListOutputs("s1")
+	if err != nil || len(outputs) != 1 || outputs[0].ID != result.OutputID {
+		t.Fatalf("ListOutputs(s1) = %+v, %v", outputs, err)
+	}
+	if outputs, _ := truncator.ListOutputs("s2"); len(outputs) != 0 {
+		t.Errorf("expected no outputs for s2, got %d", len(outputs))
+	}
+}
@@ -0,0 +1,134 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/mcp/schema"
+)
+
+// Validation modes for ServerConfig.Validation
+const (
+	// ValidationDefault rejects invalid arguments and reports, but passes
+	// through, results that do not match the output schema.
+	ValidationDefault = ""
+	// ValidationStrict also rejects results that do not match the output
+	// schema, including missing structured content.
+	ValidationStrict = "strict"
+	// ValidationOff skips schema validation entirely.
+	ValidationOff = "off"
+)
+
+// schemaCache holds compiled schemas keyed by their raw JSON
+type schemaCache struct {
+	mu      sync.Mutex
+	entries map[string]*schema.Schema
+}
+
+func (c *schemaCache) compile(raw json.RawMessage) (*schema.Schema, error) {
+	key := string(raw)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.entries[key]; ok {
+		return s, nil
+	}
+	s, err := schema.Compile(raw)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range s.SkippedPatterns() {
+		log.Printf("mcp: schema pattern %q is not supported by RE2 and is not checked", p)
+	}
+	if c.entries == nil {
+		c.entries = map[string]*schema.Schema{}
+	}
+	c.entries[key] = s
+	return s, nil
+}
+
+// validationMode returns the configured mode for server; built-in servers
+// use the default.
+func (m *Manager) validationMode(server string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if ms, ok := m.servers[server]; ok {
+		return strings.ToLower(strings.TrimSpace(ms.config.Validation))
+	}
+	return ValidationDefault
+}
+
+// lookupTool finds the declaration of tool on server, using the tool cache.
+// A nil result means the server did not list the tool.
+func (m *Manager) lookupTool(ctx context.Context, server string, client *Client, name string) *Tool {
+	tools, err := m.listToolsCached(ctx, server, client, false)
+	if err != nil {
+		return nil
+	}
+	for i := range tools {
+		if tools[i].Name == name {
+			return &tools[i]
+		}
+	}
+	return nil
+}
+
+// validateArgs checks args against the tool's input schema. Tools that are
+// not listed or have no schema are not checked.
+func (m *Manager) validateArgs(tool *Tool, args map[string]interface{}) error {
+	if tool == nil || len(tool.InputSchema) == 0 {
+		return nil
+	}
+	s, err := m.schemas.compile(tool.InputSchema)
+	if err != nil {
+		return fmt.Errorf("tool %s has an invalid input schema: %w", tool.Name, err)
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	if err := s.Validate(args); err != nil {
+		return fmt.Errorf("invalid arguments for tool %s:\n%w", tool.Name, err)
+	}
+	return nil
+}
+
+// validateResult checks structured content against the tool's output
+// schema. Error results are not checked.
+func (m *Manager) validateResult(tool *Tool, res ToolResult, strict bool) error {
+	if tool == nil || len(tool.OutputSchema) == 0 || res.IsError {
+		return nil
+	}
+	if len(res.StructuredContent) == 0 {
+		if strict {
+			return fmt.Errorf("tool %s declares an output schema but returned no structured content", tool.Name)
+		}
+		return nil
+	}
+	s, err := m.schemas.compile(tool.OutputSchema)
+	if err != nil {
+		return fmt.Errorf("tool %s has an invalid output schema: %w", tool.Name, err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(res.StructuredContent, &v); err != nil {
+		return fmt.Errorf("tool %s returned malformed structured content: %w", tool.Name, err)
+	}
+	if err := s.Validate(v); err != nil {
+		return fmt.Errorf("tool %s returned structured content that does not match its output schema:\n%w", tool.Name, err)
+	}
+	return nil
+}
+
+func (m *Manager) publishValidationError(ctx context.Context, sessionID, fullName, kind string, err error) {
+	if m.bus == nil {
+		return
+	}
+	m.publishCall(ctx, bus.EventErrorOccurred, sessionID, map[string]interface{}{
+		"kind":  kind,
+		"tool":  fullName,
+		"error": err.Error(),
+	})
+}
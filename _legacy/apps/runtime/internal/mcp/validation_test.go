@@ -0,0 +1,120 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"pryx-core/internal/bus"
+)
+
+// typedProvider declares input and output schemas and returns whatever
+// structured content it is given.
+type typedProvider struct {
+	calls      int
+	structured json.RawMessage
+}
+
+func (p *typedProvider) ServerInfo() map[string]interface{} {
+	return map[string]interface{}{"name": "typed", "version": "test"}
+}
+
+func (p *typedProvider) ListTools(ctx context.Context) ([]Tool, error) {
+	return []Tool{{
+		Name:         "add",
+		InputSchema:  schemaRaw(`{"type":"object","properties":{"a":{"type":"integer"},"b":{"type":"integer"}},"required":["a","b"],"additionalProperties":false}`),
+		OutputSchema: schemaRaw(`{"type":"object","properties":{"sum":{"type":"integer"}},"required":["sum"]}`),
+	}}, nil
+}
+
+func (p *typedProvider) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (ToolResult, error) {
+	p.calls++
+	return ToolResult{Content: []ToolContent{{Type: "text", Text: "ok"}}, StructuredContent: p.structured}, nil
+}
+
+func TestManager_ValidatesArguments(t *testing.T) {
+	b := bus.New()
+	events, cancel := b.Subscribe(bus.EventErrorOccurred, bus.EventToolRequest)
+	defer cancel()
+
+	m := newAllowAllManager(t, b)
+	defer m.Close()
+	p := &typedProvider{structured: json.RawMessage(`{"sum":3}`)}
+	if err := m.RegisterProvider("typed", p); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	_, err := m.CallTool(context.Background(), "s1", "typed:add", map[string]interface{}{"a": 1, "b": "2", "c": 3})
+	if err == nil {
+		t.Fatal("expected invalid arguments to be rejected")
+	}
+	for _, want := range []string{"invalid arguments for tool add", "b: expected integer, got string", `unexpected property "c"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	if p.calls != 0 {
+		t.Fatal("tool ran despite invalid arguments")
+	}
+
+	// Validation happens before policy evaluation, so no tool.request is
+	// published for the rejected call.
+	evt := <-events
+	if evt.Event != bus.EventErrorOccurred || evt.Payload.(map[string]interface{})["kind"] != "mcp.invalid_arguments" {
+		t.Fatalf("unexpected event: %+v", evt)
+	}
+
+	if _, err := m.CallTool(context.Background(), "s1", "typed:add", map[string]interface{}{"a": 1, "b": 2}); err != nil {
+		t.Fatalf("valid call failed: %v", err)
+	}
+}
+
+func TestManager_ValidatesResults(t *testing.T) {
+	b := bus.New()
+	events, cancel := b.Subscribe(bus.EventErrorOccurred)
+	defer cancel()
+
+	m := newAllowAllManager(t, b)
+	defer m.Close()
+	p := &typedProvider{structured: json.RawMessage(`{"sum":"three"}`)}
+	if err := m.RegisterProvider("typed", p); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// The default mode reports the mismatch but returns the result.
+	res, err := m.CallTool(context.Background(), "s1", "typed:add", map[string]interface{}{"a": 1, "b": 2})
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if string(res.StructuredContent) != `{"sum":"three"}` {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	evt := <-events
+	if evt.Payload.(map[string]interface{})["kind"] != "mcp.invalid_result" {
+		t.Fatalf("unexpected event: %+v", evt)
+	}
+
+	tool := &Tool{Name: "add", OutputSchema: schemaRaw(`{"type":"object"}`)}
+	if err := m.validateResult(tool, ToolResult{}, true); err == nil {
+		t.Error("expected strict mode to require structured content")
+	}
+	if err := m.validateResult(tool, ToolResult{}, false); err != nil {
+		t.Errorf("default mode rejected missing structured content: %v", err)
+	}
+	if err := m.validateResult(tool, ToolResult{IsError: true, StructuredContent: json.RawMessage(`1`)}, true); err != nil {
+		t.Errorf("error results should not be validated: %v", err)
+	}
+}
+
+func TestManager_RejectsUnknownValidationMode(t *testing.T) {
+	m := newAllowAllManager(t, nil)
+	defer m.Close()
+
+	err := m.ApplyConfig(context.Background(), &ServersConfig{Servers: map[string]ServerConfig{
+		"fs": {Transport: "bundled", Command: []string{"clipboard"}, Validation: "loose"},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "unknown validation mode") {
+		t.Fatalf("expected unknown validation mode error, got %v", err)
+	}
+}
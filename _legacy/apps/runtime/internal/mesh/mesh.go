@@ -55,6 +55,8 @@ type Manager struct {
 	// Channels
 	sendCh chan WebSocketMessage
 	stopCh chan struct{}
+	// reconnectCh is signalled after each successful connect
+	reconnectCh chan struct{}
 }
 
 // NewManager creates a new mesh manager
@@ -65,12 +67,13 @@ func NewManager(cfg *config.Config, b *bus.Bus, s *store.Store, kc *keychain.Key
 	}
 
 	return &Manager{
-		cfg:      cfg,
-		bus:      b,
-		store:    s,
-		keychain: kc,
-		sendCh:   make(chan WebSocketMessage, bufferSize),
-		stopCh:   make(chan struct{}),
+		cfg:         cfg,
+		bus:         b,
+		store:       s,
+		keychain:    kc,
+		sendCh:      make(chan WebSocketMessage, bufferSize),
+		stopCh:      make(chan struct{}),
+		reconnectCh: make(chan struct{}, 1),
 	}
 }
 
@@ -168,6 +171,11 @@ func (m *Manager) connect(ctx context.Context) error {
 	// Send initial presence
 	m.sendPresence()
 
+	select {
+	case m.reconnectCh <- struct{}{}:
+	default:
+	}
+
 	return nil
 }
 
@@ -191,16 +199,42 @@ func (m *Manager) isConnected() bool {
 	return m.connected && m.wsConn != nil
 }
 
-// listenForBroadcasts watches for local events to send to mesh
+// listenForBroadcasts watches for local events to send to mesh. Events
+// that cannot be sent while the coordinator is unreachable are replayed
+// from the bus journal once it is back, when the bus keeps one.
 func (m *Manager) listenForBroadcasts(ctx context.Context) {
-	events, closer := m.bus.Subscribe(
+	topics := []bus.EventType{
 		bus.EventSessionMessage,
 		bus.EventSessionTyping,
 		bus.EventToolRequest,
 		bus.EventToolComplete,
 		bus.EventApprovalNeeded,
-	)
-	defer closer()
+	}
+
+	// sent is the version of the last event queued for the coordinator;
+	// once one is missed, later ones wait for the replay to keep order
+	sent := m.bus.Version()
+	missed := false
+
+	// A journaled subscription makes up for events the bus drops while
+	// this loop is slow; without a journal, spill rather than drop them
+	events, closer, err := m.bus.SubscribeFrom(sent, topics...)
+	if err != nil {
+		events, closer = m.bus.SubscribeDelivery(bus.Filter{Topics: topics}, bus.Delivery{Mode: bus.DeliverSpill})
+	}
+	defer func() { closer() }()
+
+	resume := func() bool {
+		replay, replayCloser, err := m.bus.SubscribeFrom(sent, topics...)
+		missed = false
+		if err != nil {
+			log.Printf("Mesh: Cannot replay missed events: %v", err)
+			return false
+		}
+		closer()
+		events, closer = replay, replayCloser
+		return true
+	}
 
 	for {
 		select {
@@ -208,24 +242,46 @@ func (m *Manager) listenForBroadcasts(ctx context.Context) {
 			return
 		case <-m.stopCh:
 			return
+		case <-m.reconnectCh:
+			if missed {
+				resume()
+			}
 		case evt, ok := <-events:
 			if !ok {
 				return
 			}
-			m.broadcastEvent(evt)
+			if evt.Event == bus.EventReplayGap {
+				gap, _ := evt.Payload.(bus.ReplayGap)
+				log.Printf("Mesh: Events %d to %d cannot be replayed: %s", gap.From, gap.To, gap.Reason)
+				continue
+			}
+			if missed {
+				if !m.isConnected() || len(m.sendCh) >= cap(m.sendCh)/2 {
+					continue
+				}
+				if resume() {
+					continue
+				}
+			}
+			if m.broadcastEvent(evt) {
+				sent = evt.Version
+			} else {
+				missed = true
+			}
 		}
 	}
 }
 
-// broadcastEvent sends an event to the mesh
-func (m *Manager) broadcastEvent(evt bus.Event) {
+// broadcastEvent queues an event for the mesh. It returns false if the
+// event could not be queued.
+func (m *Manager) broadcastEvent(evt bus.Event) bool {
 	if !m.isConnected() {
-		return // Queue for later or drop
+		return false
 	}
 
 	payload, err := json.Marshal(evt)
 	if err != nil {
-		return
+		return true // Sending again would not help
 	}
 
 	msg := WebSocketMessage{
@@ -238,9 +294,10 @@ func (m *Manager) broadcastEvent(evt bus.Event) {
 
 	select {
 	case m.sendCh <- msg:
+		return true
 	default:
-		// Channel full, drop message
-		log.Println("Mesh: Send queue full, dropping message")
+		log.Println("Mesh: Send queue full, deferring message")
+		return false
 	}
 }
 
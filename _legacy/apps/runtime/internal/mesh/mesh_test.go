@@ -2,10 +2,13 @@ package mesh
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"testing"
 	"time"
 
+	"nhooyr.io/websocket"
+
 	"pryx-core/internal/bus"
 	"pryx-core/internal/config"
 	"pryx-core/internal/keychain"
@@ -450,3 +453,46 @@ func TestManager_handlePresence(t *testing.T) {
 	// Should not panic
 	manager.handlePresence(msg)
 }
+
+func TestManager_listenForBroadcastsLosesNothing(t *testing.T) {
+	st, err := store.New(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	eventBus := bus.New()
+	if err := eventBus.SetJournal(st); err != nil {
+		t.Fatal(err)
+	}
+	defer eventBus.Close()
+
+	const total = 5000
+	manager := NewManager(&config.Config{WebSocketBufferSize: total}, eventBus, st, keychain.New("pryx"))
+	manager.wsConn = &websocket.Conn{}
+	manager.connected = true
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go manager.listenForBroadcasts(ctx)
+	time.Sleep(20 * time.Millisecond)
+
+	// Far more than a subscriber buffer, published without pause
+	for i := 0; i < total; i++ {
+		eventBus.Publish(bus.NewEvent(bus.EventSessionMessage, "s1", map[string]interface{}{"n": i}))
+	}
+	last := 0
+	for i := 0; i < total; i++ {
+		select {
+		case msg := <-manager.sendCh:
+			var evt bus.Event
+			if err := json.Unmarshal(msg.Payload, &evt); err != nil {
+				t.Fatal(err)
+			}
+			if last != 0 && evt.Version != last+1 {
+				t.Fatalf("expected version %d after %d, got %d", last+1, last, evt.Version)
+			}
+			last = evt.Version
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d events broadcast", i, total)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Trust is how far the person behind a call is trusted
+type Trust string
+
+const (
+	// TrustOwner is the local user at the TUI or CLI.
+	TrustOwner Trust = "owner"
+	// TrustTrusted is an agent acting for the owner: the scheduler, a
+	// subagent or a local MCP client.
+	TrustTrusted Trust = "trusted"
+	// TrustUntrusted is anyone else, such as a sender on a messaging
+	// channel.
+	TrustUntrusted Trust = "untrusted"
+)
+
+// Surfaces tool calls come from. Calls from a messaging channel have the
+// channel type as surface, such as telegram or discord.
+const (
+	SurfaceTUI       = "tui"
+	SurfaceCLI       = "cli"
+	SurfaceMCP       = "mcp"
+	SurfaceScheduler = "scheduler"
+	SurfaceSubagent  = "subagent"
+)
+
+// Caller identifies who a tool call is made for. Policies match on it
+// with a rule's From; it comes from the runtime, never from tool
+// arguments.
+type Caller struct {
+	SessionID string `json:"session_id,omitempty" yaml:"session_id,omitempty"`
+	Surface   string `json:"surface,omitempty" yaml:"surface,omitempty"`
+	// Source is the channel instance a message came in on, such as
+	// telegram-main, and ChannelID the chat within it.
+	Source    string `json:"source,omitempty" yaml:"source,omitempty"`
+	ChannelID string `json:"channel_id,omitempty" yaml:"channel_id,omitempty"`
+	// Sender is the user on the channel; empty for the local user.
+	Sender string `json:"sender,omitempty" yaml:"sender,omitempty"`
+	// Trust defaults from the surface when empty; see TrustFor.
+	Trust Trust `json:"trust,omitempty" yaml:"trust,omitempty"`
+}
+
+// TrustFor returns the trust calls from a surface get unless the runtime
+// knows better: the owner for local surfaces, trusted for agents working
+// for the owner, untrusted for everything else. A call without a surface
+// came from a path that did not say who it is for, so it is untrusted.
+func TrustFor(surface string) Trust {
+	switch surface {
+	case SurfaceTUI, SurfaceCLI:
+		return TrustOwner
+	case SurfaceMCP, SurfaceScheduler, SurfaceSubagent:
+		return TrustTrusted
+	default:
+		return TrustUntrusted
+	}
+}
+
+// trust returns the caller's trust, defaulting from the surface
+func (c Caller) trust() Trust {
+	if c.Trust != "" {
+		return c.Trust
+	}
+	return TrustFor(c.Surface)
+}
+
+// subject names the counter a limit uses for the caller
+func (c Caller) subject(per string) string {
+	switch per {
+	case LimitPerSession:
+		return c.SessionID
+	case LimitPerUser:
+		if c.Sender == "" {
+			return "local"
+		}
+		// Sender IDs are only unique within their channel
+		return c.where() + ":" + c.Sender
+	case LimitPerChannel:
+		if c.ChannelID != "" {
+			return c.where() + ":" + c.ChannelID
+		}
+		return c.where()
+	default:
+		return ""
+	}
+}
+
+// where names the channel instance, or the surface for local calls
+func (c Caller) where() string {
+	if c.Source != "" {
+		return c.Source
+	}
+	return c.Surface
+}
+
+// CallerMatch restricts a rule to some callers. Each field lists accepted
+// values, any of which may be a glob such as "telegram-*"; an empty field
+// accepts anything, and all set fields must accept the caller.
+type CallerMatch struct {
+	Surface []string `json:"surface,omitempty" yaml:"surface,omitempty"`
+	Source  []string `json:"source,omitempty" yaml:"source,omitempty"`
+	Channel []string `json:"channel,omitempty" yaml:"channel,omitempty"` // chat IDs
+	Sender  []string `json:"sender,omitempty" yaml:"sender,omitempty"`
+	Session []string `json:"session,omitempty" yaml:"session,omitempty"`
+	Trust   []Trust  `json:"trust,omitempty" yaml:"trust,omitempty"`
+}
+
+// why returns why the caller is not accepted, or "" if it is
+func (m *CallerMatch) why(c Caller) string {
+	trust := make([]string, len(m.Trust))
+	for i, t := range m.Trust {
+		trust[i] = string(t)
+	}
+	fields := []struct {
+		name   string
+		value  string
+		accept []string
+	}{
+		{"surface", c.Surface, m.Surface},
+		{"source", c.Source, m.Source},
+		{"channel", c.ChannelID, m.Channel},
+		{"sender", c.Sender, m.Sender},
+		{"session", c.SessionID, m.Session},
+		{"trust", string(c.trust()), trust},
+	}
+	for _, f := range fields {
+		if len(f.accept) > 0 && !matchAny(f.accept, f.value) {
+			return fmt.Sprintf("%s %q is not one of %v", f.name, f.value, f.accept)
+		}
+	}
+	return ""
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *CallerMatch) validate() []error {
+	var errs []error
+	lists := map[string][]string{
+		"surface": m.Surface, "source": m.Source, "channel": m.Channel,
+		"sender": m.Sender, "session": m.Session,
+	}
+	empty := len(m.Trust) == 0
+	for _, name := range []string{"surface", "source", "channel", "sender", "session"} {
+		empty = empty && len(lists[name]) == 0
+		for _, p := range lists[name] {
+			if strings.TrimSpace(p) == "" {
+				errs = append(errs, fmt.Errorf("%s: empty value", name))
+			} else if _, err := path.Match(p, ""); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid pattern %q", name, p))
+			}
+		}
+	}
+	for _, t := range m.Trust {
+		switch t {
+		case TrustOwner, TrustTrusted, TrustUntrusted:
+		default:
+			errs = append(errs, fmt.Errorf("trust: unknown level %q", t))
+		}
+	}
+	if empty {
+		errs = append(errs, errors.New("no fields set"))
+	}
+	return errs
+}
@@ -0,0 +1,530 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Condition is a typed test on tool arguments. Exactly one field is set:
+// All, Any and Not compose other conditions, the rest test one argument.
+// A missing or unparseable argument never satisfies a test, so Not of a
+// test holds for it.
+type Condition struct {
+	All []Condition `json:"all,omitempty" yaml:"all,omitempty"`
+	Any []Condition `json:"any,omitempty" yaml:"any,omitempty"`
+	Not *Condition  `json:"not,omitempty" yaml:"not,omitempty"`
+
+	PathWithin *PathCondition    `json:"path_within,omitempty" yaml:"path_within,omitempty"`
+	HostIn     *HostCondition    `json:"host_in,omitempty" yaml:"host_in,omitempty"`
+	CommandIn  *CommandCondition `json:"command_in,omitempty" yaml:"command_in,omitempty"`
+	Range      *RangeCondition   `json:"range,omitempty" yaml:"range,omitempty"`
+	Match      *ArgMatcher       `json:"match,omitempty" yaml:"match,omitempty"`
+}
+
+// PathCondition holds when the path in Arg lies within one of Roots.
+// Relative paths are taken from the workspace root, and both sides have
+// symlinks resolved so a link cannot lead out of a root. A root may be
+// "${workspace}" or start with "~/".
+type PathCondition struct {
+	Arg   string   `json:"arg" yaml:"arg"`
+	Roots []string `json:"roots" yaml:"roots"`
+}
+
+// HostCondition holds when the URL in Arg has one of Hosts. A host may be
+// exact, "*.example.com" for any subdomain, a CIDR range or "*".
+type HostCondition struct {
+	Arg   string   `json:"arg" yaml:"arg"`
+	Hosts []string `json:"hosts" yaml:"hosts"`
+}
+
+// CommandCondition holds when every command in the command line in Arg is
+// one of Commands. A bare program name matches a bare entry; a program
+// given as a path matches an absolute entry, or a bare entry as found on
+// PATH, once its directory's symlinks are resolved. Command substitution,
+// output redirection, environment assignments, an env argument and
+// running a shell fail the test, since each can change what runs. An argv
+// array is accepted as well as a command line.
+type CommandCondition struct {
+	Arg      string   `json:"arg" yaml:"arg"`
+	Commands []string `json:"commands" yaml:"commands"`
+}
+
+// RangeCondition holds when the number in Arg is within [Min, Max]; either
+// bound may be left out.
+type RangeCondition struct {
+	Arg string   `json:"arg" yaml:"arg"`
+	Min *float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	Max *float64 `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// Holds reports whether args satisfy the condition.
+func (c *Condition) Holds(args map[string]interface{}) bool {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	switch {
+	case c.All != nil:
+		for i := range c.All {
+			if !c.All[i].Holds(args) {
+				return false
+			}
+		}
+		return true
+	case c.Any != nil:
+		for i := range c.Any {
+			if c.Any[i].Holds(args) {
+				return true
+			}
+		}
+		return false
+	case c.Not != nil:
+		return !c.Not.Holds(args)
+	case c.PathWithin != nil:
+		return eachString(args[c.PathWithin.Arg], c.PathWithin.match)
+	case c.HostIn != nil:
+		return eachString(args[c.HostIn.Arg], c.HostIn.match)
+	case c.CommandIn != nil:
+		return c.CommandIn.match(args)
+	case c.Range != nil:
+		return c.Range.match(args[c.Range.Arg])
+	case c.Match != nil:
+		return matchArg(*c.Match, args)
+	default:
+		return false
+	}
+}
+
+// eachString applies fn to a string argument, or to every element of a
+// list of strings. Anything else fails.
+func eachString(v interface{}, fn func(string) bool) bool {
+	switch v := v.(type) {
+	case string:
+		return fn(v)
+	case []interface{}:
+		if len(v) == 0 {
+			return false
+		}
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok || !fn(s) {
+				return false
+			}
+		}
+		return true
+	case []string:
+		if len(v) == 0 {
+			return false
+		}
+		for _, s := range v {
+			if !fn(s) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *PathCondition) match(p string) bool {
+	if strings.TrimSpace(p) == "" {
+		return false
+	}
+	workspace := WorkspaceRoot()
+	target := resolvePath(ExpandHome(p), workspace)
+	for _, root := range c.Roots {
+		if pathWithin(resolvePath(expandRoot(root, workspace), workspace), target) {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkspaceRoot returns the absolute directory relative paths are resolved
+// from: PRYX_WORKSPACE_ROOT, or the working directory when it is unset. The
+// bundled MCP servers use the same root, so rules see paths as they do.
+func WorkspaceRoot() string {
+	root := strings.TrimSpace(os.Getenv("PRYX_WORKSPACE_ROOT"))
+	if root == "" {
+		if cwd, err := os.Getwd(); err == nil {
+			root = cwd
+		}
+	}
+	if root != "" {
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+	}
+	return root
+}
+
+// ExpandHome replaces a leading "~" in p with the home directory, as the
+// bundled filesystem server does with its path arguments.
+func ExpandHome(p string) string {
+	if strings.HasPrefix(p, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = filepath.Join(home, strings.TrimPrefix(p, "~"))
+		}
+	}
+	return p
+}
+
+func expandRoot(root, workspace string) string {
+	return ExpandHome(strings.ReplaceAll(root, "${workspace}", workspace))
+}
+
+// resolvePath makes p absolute against base and resolves the symlinks of
+// its longest existing prefix. The prefix is resolved before any ".." is
+// applied, as the OS would, so "link/.." means the link target's parent.
+func resolvePath(p, base string) string {
+	if !filepath.IsAbs(p) {
+		p = base + string(filepath.Separator) + p
+	}
+	sep := string(filepath.Separator)
+	parts := strings.Split(p, sep)
+	for i := len(parts); i > 0; i-- {
+		prefix := strings.Join(parts[:i], sep)
+		if prefix == "" {
+			prefix = sep
+		}
+		if resolved, err := filepath.EvalSymlinks(prefix); err == nil {
+			return filepath.Join(append([]string{resolved}, parts[i:]...)...)
+		}
+	}
+	return filepath.Clean(p)
+}
+
+func pathWithin(root, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+func (c *HostCondition) match(raw string) bool {
+	host := urlHost(raw)
+	if host == "" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	for _, pattern := range c.Hosts {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case pattern == "*":
+			return true
+		case strings.HasPrefix(pattern, "*."):
+			if strings.HasSuffix(host, pattern[1:]) {
+				return true
+			}
+		case strings.Contains(pattern, "/"):
+			if _, network, err := net.ParseCIDR(pattern); err == nil && ip != nil && network.Contains(ip) {
+				return true
+			}
+		case host == strings.TrimSuffix(pattern, "."):
+			return true
+		}
+	}
+	return false
+}
+
+// urlHost returns the lowercased host of a URL, or of a bare host name
+func urlHost(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
+
+// shells run a script the condition cannot see
+var shells = map[string]bool{
+	"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true, "mksh": true,
+	"ash": true, "csh": true, "tcsh": true, "fish": true, "busybox": true,
+}
+
+func (c *CommandCondition) match(args map[string]interface{}) bool {
+	// The shell tool sets env for the command, so PATH or LD_PRELOAD could
+	// run something else under an allowed name
+	if env, ok := args["env"]; ok && env != nil {
+		if m, isMap := env.(map[string]interface{}); !isMap || len(m) > 0 {
+			return false
+		}
+	}
+	var programs []string
+	switch v := args[c.Arg].(type) {
+	case string, nil:
+		line, _ := v.(string)
+		if strings.TrimSpace(line) == "" {
+			// The shell tool also takes the program as the first of args
+			if argv, ok := args["args"].([]interface{}); ok && len(argv) > 0 {
+				if s, ok := argv[0].(string); ok {
+					programs = []string{s}
+				}
+			}
+			break
+		}
+		if _, hasArgv := args["args"].([]interface{}); hasArgv && !strings.ContainsAny(line, " \t") {
+			programs = []string{line}
+			break
+		}
+		cmds, err := splitCommandLine(line)
+		if err != nil {
+			return false
+		}
+		for _, argv := range cmds {
+			programs = append(programs, argv[0])
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			if s, ok := v[0].(string); ok {
+				programs = []string{s}
+			}
+		}
+	}
+	if len(programs) == 0 {
+		return false
+	}
+	dir := WorkspaceRoot()
+	if cwd, ok := args["cwd"].(string); ok && strings.TrimSpace(cwd) != "" {
+		dir = resolvePath(ExpandHome(cwd), dir)
+	}
+	for _, prog := range programs {
+		if shells[filepath.Base(prog)] || !commandAllowed(c.Commands, prog, dir) {
+			return false
+		}
+	}
+	return true
+}
+
+// commandAllowed reports whether prog, run from dir, is one of allowed. A
+// path must lead to the same file as an absolute entry or a bare entry
+// looked up on PATH, so "./ls" or "/tmp/x/ls" does not pass for "ls".
+func commandAllowed(allowed []string, prog, dir string) bool {
+	if !strings.Contains(prog, "/") {
+		return slices.Contains(allowed, prog)
+	}
+	target := programPath(prog, dir)
+	for _, a := range allowed {
+		if !strings.Contains(a, "/") {
+			found, err := exec.LookPath(a)
+			if err != nil {
+				continue
+			}
+			a = found
+		}
+		if filepath.IsAbs(a) && programPath(a, dir) == target {
+			return true
+		}
+	}
+	return false
+}
+
+// programPath makes prog absolute against dir and resolves the symlinks of
+// its directory. The file name is kept, since multi-call binaries such as
+// coreutils act on the name they are run as.
+func programPath(prog, dir string) string {
+	if !filepath.IsAbs(prog) {
+		prog = filepath.Join(dir, prog)
+	}
+	return filepath.Join(resolvePath(filepath.Dir(prog), dir), filepath.Base(prog))
+}
+
+// splitCommandLine splits a POSIX shell command line into the argv of each
+// command it runs, separated by ;, &, | and newlines. It fails on command
+// substitution, output redirection, variable assignments and unterminated
+// quotes, whose effect cannot be judged from the program names.
+func splitCommandLine(line string) ([][]string, error) {
+	var (
+		cmds    [][]string
+		argv    []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	endWord := func() {
+		if inWord {
+			argv = append(argv, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	assigns := false
+	endCmd := func() {
+		endWord()
+		// VAR=value assignments set the environment of what follows, such
+		// as PATH
+		if len(argv) > 0 && isAssignment(argv[0]) {
+			assigns = true
+		}
+		if len(argv) > 0 {
+			cmds = append(cmds, argv)
+		}
+		argv = nil
+	}
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			inWord, escaped = true, false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '`' || (r == '$' && i+1 < len(runes) && runes[i+1] == '('):
+			return nil, errors.New("command substitution")
+		case r == '>':
+			return nil, errors.New("output redirection")
+		case r == '\\':
+			escaped = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ';' || r == '&' || r == '|' || r == '\n' || r == '(' || r == ')':
+			endCmd()
+		case r == ' ' || r == '\t':
+			endWord()
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote")
+	}
+	endCmd()
+	if assigns {
+		return nil, errors.New("environment assignment")
+	}
+	if len(cmds) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return cmds, nil
+}
+
+func isAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *RangeCondition) match(v interface{}) bool {
+	var n float64
+	switch v := v.(type) {
+	case float64:
+		n = v
+	case float32:
+		n = float64(v)
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return false
+		}
+		n = f
+	default:
+		return false
+	}
+	return (c.Min == nil || n >= *c.Min) && (c.Max == nil || n <= *c.Max)
+}
+
+func (c *Condition) validate() []error {
+	set := 0
+	for _, isSet := range []bool{c.All != nil, c.Any != nil, c.Not != nil, c.PathWithin != nil, c.HostIn != nil, c.CommandIn != nil, c.Range != nil, c.Match != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return []error{fmt.Errorf("exactly one of all, any, not, path_within, host_in, command_in, range or match must be set, found %d", set)}
+	}
+
+	var errs []error
+	nested := func(at string, sub *Condition) {
+		for _, err := range sub.validate() {
+			errs = append(errs, fmt.Errorf("%s: %w", at, err))
+		}
+	}
+	switch {
+	case c.All != nil || c.Any != nil:
+		list, name := c.All, "all"
+		if c.Any != nil {
+			list, name = c.Any, "any"
+		}
+		if len(list) == 0 {
+			errs = append(errs, fmt.Errorf("%s needs at least one condition", name))
+		}
+		for i := range list {
+			nested(fmt.Sprintf("%s[%d]", name, i), &list[i])
+		}
+	case c.Not != nil:
+		nested("not", c.Not)
+	case c.PathWithin != nil:
+		if c.PathWithin.Arg == "" || len(c.PathWithin.Roots) == 0 {
+			errs = append(errs, errors.New("path_within needs arg and roots"))
+		}
+	case c.HostIn != nil:
+		if c.HostIn.Arg == "" || len(c.HostIn.Hosts) == 0 {
+			errs = append(errs, errors.New("host_in needs arg and hosts"))
+		}
+		for _, h := range c.HostIn.Hosts {
+			if strings.Contains(h, "/") {
+				if _, _, err := net.ParseCIDR(h); err != nil {
+					errs = append(errs, fmt.Errorf("host_in: %w", err))
+				}
+			}
+		}
+	case c.CommandIn != nil:
+		if c.CommandIn.Arg == "" || len(c.CommandIn.Commands) == 0 {
+			errs = append(errs, errors.New("command_in needs arg and commands"))
+		}
+	case c.Range != nil:
+		switch {
+		case c.Range.Arg == "" || (c.Range.Min == nil && c.Range.Max == nil):
+			errs = append(errs, errors.New("range needs arg and min or max"))
+		case c.Range.Min != nil && c.Range.Max != nil && *c.Range.Min > *c.Range.Max:
+			errs = append(errs, errors.New("range min is above max"))
+		}
+	case c.Match != nil:
+		if err := c.Match.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("match: %w", err))
+		}
+	}
+	return errs
+}
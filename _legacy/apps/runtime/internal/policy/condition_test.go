@@ -0,0 +1,244 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func float(f float64) *float64 { return &f }
+
+func TestCondition_PathWithin(t *testing.T) {
+	ws := t.TempDir()
+	outside := t.TempDir()
+	t.Setenv("PRYX_WORKSPACE_ROOT", ws)
+	if err := os.MkdirAll(filepath.Join(ws, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(ws, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	cond := &Condition{PathWithin: &PathCondition{Arg: "path", Roots: []string{"${workspace}"}}}
+	cases := []struct {
+		name string
+		path interface{}
+		want bool
+	}{
+		{"relative", "src/main.go", true},
+		{"absolute", filepath.Join(ws, "src", "main.go"), true},
+		{"new file", "src/new/dir/file.go", true},
+		{"root itself", ws, true},
+		{"dot dot", "../etc/passwd", false},
+		{"outside", outside, false},
+		{"symlink out", "escape/secret", false},
+		{"through symlink and back", "escape/../f", false},
+		{"list all inside", []interface{}{"a.go", "src/b.go"}, true},
+		{"list one outside", []interface{}{"a.go", "/etc/passwd"}, false},
+		{"empty list", []interface{}{}, false},
+		{"missing", nil, false},
+		{"not a string", 42.0, false},
+		{"home", "~/x", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := map[string]interface{}{}
+			if tc.path != nil {
+				args["path"] = tc.path
+			}
+			if got := cond.Holds(args); got != tc.want {
+				t.Errorf("path %v: got %v, want %v", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCondition_PathWithinHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PRYX_WORKSPACE_ROOT", t.TempDir())
+
+	cond := &Condition{PathWithin: &PathCondition{Arg: "path", Roots: []string{"~/notes"}}}
+	for path, want := range map[string]bool{
+		"~/notes/a.md":                    true,
+		"~/secrets":                       false,
+		"notes/a.md":                      false,
+		filepath.Join(home, "notes", "b"): true,
+	} {
+		if got := cond.Holds(map[string]interface{}{"path": path}); got != want {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestCondition_HostIn(t *testing.T) {
+	cond := &Condition{HostIn: &HostCondition{Arg: "url", Hosts: []string{"*.internal", "example.com", "10.0.0.0/8"}}}
+	cases := []struct {
+		url  string
+		want bool
+	}{
+		{"https://api.internal/v1", true},
+		{"https://a.b.internal:8443/x", true},
+		{"https://internal/", false},
+		{"https://evilinternal/", false},
+		{"https://EXAMPLE.com./path", true},
+		{"https://sub.example.com/", false},
+		{"https://example.com@evil.com/", false},
+		{"http://10.1.2.3/", true},
+		{"http://11.1.2.3/", false},
+		{"example.com/path", true},
+		{"://bad", false},
+		{"", false},
+	}
+	for _, tc := range cases {
+		if got := cond.Holds(map[string]interface{}{"url": tc.url}); got != tc.want {
+			t.Errorf("%q: got %v, want %v", tc.url, got, tc.want)
+		}
+	}
+}
+
+func TestCondition_CommandIn(t *testing.T) {
+	cond := &Condition{CommandIn: &CommandCondition{Arg: "command", Commands: []string{"ls", "git", "grep", "/usr/bin/cat", "sh"}}}
+	cases := []struct {
+		name string
+		args map[string]interface{}
+		want bool
+	}{
+		{"single", map[string]interface{}{"command": "ls -la"}, true},
+		{"by path", map[string]interface{}{"command": "/bin/ls"}, true},
+		{"absolute only", map[string]interface{}{"command": "cat x"}, false},
+		{"absolute match", map[string]interface{}{"command": "/usr/bin/cat x"}, true},
+		{"pipeline", map[string]interface{}{"command": "git log | grep fix"}, true},
+		{"chained other", map[string]interface{}{"command": "ls && rm -rf /"}, false},
+		{"semicolon", map[string]interface{}{"command": "ls; curl evil"}, false},
+		{"quoted separator", map[string]interface{}{"command": `grep "a; rm" file`}, true},
+		{"env assignment", map[string]interface{}{"command": "GIT_PAGER=cat git log"}, false},
+		{"path assignment", map[string]interface{}{"command": "PATH=/tmp/evil; ls"}, false},
+		{"env argument", map[string]interface{}{"command": "ls", "env": map[string]interface{}{"PATH": "/tmp/evil"}}, false},
+		{"empty env argument", map[string]interface{}{"command": "ls", "env": map[string]interface{}{}}, true},
+		{"preload", map[string]interface{}{"command": "ls", "args": []interface{}{"-l"}, "env": map[string]interface{}{"LD_PRELOAD": "/tmp/x.so"}}, false},
+		{"same name elsewhere", map[string]interface{}{"command": "/tmp/evil/ls"}, false},
+		{"relative name", map[string]interface{}{"command": "./ls"}, false},
+		{"relative in cwd", map[string]interface{}{"command": "./ls", "cwd": "/tmp"}, false},
+		{"substitution", map[string]interface{}{"command": "ls $(rm -rf /)"}, false},
+		{"backticks", map[string]interface{}{"command": "ls `id`"}, false},
+		{"redirect", map[string]interface{}{"command": "ls > /etc/passwd"}, false},
+		{"subshell", map[string]interface{}{"command": "(rm x)"}, false},
+		{"unterminated", map[string]interface{}{"command": `ls "x`}, false},
+		{"argv form", map[string]interface{}{"command": "git", "args": []interface{}{"status"}}, true},
+		{"argv only", map[string]interface{}{"args": []interface{}{"rm", "-rf"}}, false},
+		{"argv only allowed", map[string]interface{}{"args": []interface{}{"ls"}}, true},
+		{"shell", map[string]interface{}{"command": "sh", "args": []interface{}{"-c", "rm -rf /"}}, false},
+		{"missing", map[string]interface{}{}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := cond.Holds(tc.args); got != tc.want {
+				t.Errorf("%v: got %v, want %v", tc.args, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCondition_RangeAndComposition(t *testing.T) {
+	small := Condition{Range: &RangeCondition{Arg: "timeout_ms", Max: float(5000)}}
+	cases := []struct {
+		name string
+		cond Condition
+		args map[string]interface{}
+		want bool
+	}{
+		{"in range", small, map[string]interface{}{"timeout_ms": 100.0}, true},
+		{"at max", small, map[string]interface{}{"timeout_ms": 5000}, true},
+		{"over", small, map[string]interface{}{"timeout_ms": 5001.0}, false},
+		{"string number", small, map[string]interface{}{"timeout_ms": "42"}, true},
+		{"missing", small, map[string]interface{}{}, false},
+		{"min", Condition{Range: &RangeCondition{Arg: "n", Min: float(1)}}, map[string]interface{}{"n": 0.5}, false},
+		{"not missing", Condition{Not: &small}, map[string]interface{}{}, true},
+		{"all", Condition{All: []Condition{small, {Match: &ArgMatcher{Key: "cwd", Operator: "exists"}}}}, map[string]interface{}{"timeout_ms": 1.0}, false},
+		{"any", Condition{Any: []Condition{small, {Match: &ArgMatcher{Key: "cwd", Operator: "exists"}}}}, map[string]interface{}{"cwd": "/"}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.cond.Holds(tc.args); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEngine_ConditionRules(t *testing.T) {
+	ws := t.TempDir()
+	t.Setenv("PRYX_WORKSPACE_ROOT", ws)
+	p, err := Parse([]byte(`
+default: ask
+rules:
+  - id: workspace-writes
+    tool: mcp.filesystem.write_file
+    decision: allow
+    when:
+      path_within: {arg: path, roots: ["${workspace}"]}
+  - id: internal-fetch
+    tool: mcp.fetch.fetch
+    decision: allow
+    when:
+      host_in: {arg: url, hosts: ["*.internal"]}
+  - id: safe-shell
+    tool: mcp.shell.exec
+    decision: allow
+    when:
+      all:
+        - command_in: {arg: command, commands: [ls, git]}
+        - not: {range: {arg: timeout_ms, min: 60000}}
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	e := NewEngine(p)
+
+	cases := []struct {
+		tool string
+		args map[string]interface{}
+		want Decision
+		rule string
+	}{
+		{"mcp.filesystem.write_file", map[string]interface{}{"path": "notes.md"}, DecisionAllow, "workspace-writes"},
+		{"mcp.filesystem.write_file", map[string]interface{}{"path": "/etc/hosts"}, DecisionAsk, ""},
+		{"mcp.fetch.fetch", map[string]interface{}{"url": "https://wiki.internal/page"}, DecisionAllow, "internal-fetch"},
+		{"mcp.fetch.fetch", map[string]interface{}{"url": "https://example.com"}, DecisionAsk, ""},
+		{"mcp.shell.exec", map[string]interface{}{"command": "git status"}, DecisionAllow, "safe-shell"},
+		{"mcp.shell.exec", map[string]interface{}{"command": "git status", "timeout_ms": 120000.0}, DecisionAsk, ""},
+		{"mcp.shell.exec", map[string]interface{}{"command": "git status | sh"}, DecisionAsk, ""},
+	}
+	for _, tc := range cases {
+		if got := e.Evaluate(tc.tool, tc.args, Caller{}).Decision; got != tc.want {
+			t.Errorf("%s %v: got %s, want %s", tc.tool, tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestCondition_Validate(t *testing.T) {
+	cases := []struct {
+		name string
+		cond Condition
+		ok   bool
+	}{
+		{"empty", Condition{}, false},
+		{"two set", Condition{Not: &Condition{}, Range: &RangeCondition{Arg: "n", Max: float(1)}}, false},
+		{"empty all", Condition{All: []Condition{}}, false},
+		{"nested invalid", Condition{Any: []Condition{{HostIn: &HostCondition{Arg: "url"}}}}, false},
+		{"bad cidr", Condition{HostIn: &HostCondition{Arg: "url", Hosts: []string{"10.0.0.0/99"}}}, false},
+		{"inverted range", Condition{Range: &RangeCondition{Arg: "n", Min: float(2), Max: float(1)}}, false},
+		{"bad match", Condition{Match: &ArgMatcher{Key: "k", Operator: "like"}}, false},
+		{"valid", Condition{All: []Condition{
+			{PathWithin: &PathCondition{Arg: "path", Roots: []string{"~/src"}}},
+			{Not: &Condition{CommandIn: &CommandCondition{Arg: "command", Commands: []string{"rm"}}}},
+		}}, true},
+	}
+	for _, tc := range cases {
+		errs := tc.cond.validate()
+		if (len(errs) == 0) != tc.ok {
+			t.Errorf("%s: errors %v", tc.name, errs)
+		}
+	}
+}
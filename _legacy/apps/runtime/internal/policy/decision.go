@@ -13,6 +13,9 @@ const (
 type Result struct {
 	Decision Decision `json:"decision"`
 	Reason   string   `json:"reason,omitempty"`
+
+	// charge is set by Decide when the call counts against rate limits
+	charge *charge
 }
 
 func Allow(reason string) Result {
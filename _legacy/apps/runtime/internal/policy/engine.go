@@ -5,12 +5,27 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Engine evaluates tool calls against the active policy
 type Engine struct {
 	mu     sync.RWMutex
 	policy *Policy
+
+	// limitMu serialises counting so concurrent calls cannot both take
+	// the last slot of a limit
+	limitMu  sync.Mutex
+	counters Counters
+	now      func() time.Time
+
+	providers Providers
+}
+
+// Providers reports which implementation serves a tool, such as
+// "bundled:git"; "" when it is not known.
+type Providers interface {
+	ToolProvider(toolName string) string
 }
 
 func NewEngine(p *Policy) *Engine {
@@ -18,32 +33,87 @@ func NewEngine(p *Policy) *Engine {
 		p = NewDefaultPolicy()
 	}
 	return &Engine{
-		policy: p,
+		policy:   p,
+		counters: newMemCounters(),
+		now:      time.Now,
+	}
+}
+
+// SetPolicy replaces the active policy. Evaluations in progress finish
+// against the previous one.
+func (e *Engine) SetPolicy(p *Policy) {
+	if p == nil {
+		p = NewDefaultPolicy()
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.policy = p
+}
+
+// SetProviders sets how rules with a provider learn which implementation
+// serves a tool. Without it such rules never match.
+func (e *Engine) SetProviders(p Providers) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.providers = p
+}
+
+func (e *Engine) providerOf(toolName string) string {
+	e.mu.RLock()
+	p := e.providers
+	e.mu.RUnlock()
+	if p == nil {
+		return ""
 	}
+	return p.ToolProvider(toolName)
 }
 
-// Evaluate checks if a tool call is allowed
-func (e *Engine) Evaluate(toolName string, args map[string]interface{}) Result {
+// Policy returns the active policy. It must not be modified.
+func (e *Engine) Policy() *Policy {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
+	return e.policy
+}
 
-	// 1. Check Rules
-	for _, rule := range e.policy.Rules {
-		if matchTool(rule.Tool, toolName) {
-			// Check scope matching if specified
-			if rule.Scope != "" && !matchScope(rule.Scope, args) {
-				continue
-			}
-			// Check argument matching if specified
-			if len(rule.Args) > 0 && !matchArgs(rule.Args, args) {
-				continue
-			}
-			return Result{Decision: rule.Decision, Reason: rule.Description}
+// Evaluate checks if a tool call made for caller is allowed. It decides
+// like Decide, limits included, but the result cannot be charged, so it
+// suits previews; calls that run go through Decide and Charge.
+func (e *Engine) Evaluate(toolName string, args map[string]interface{}, caller Caller) Result {
+	res := e.Decide(toolName, args, caller)
+	res.charge = nil
+	return res
+}
+
+// mismatch returns why the rule does not apply to the call of a tool served
+// by provider, or "" if it does
+func (r *Rule) mismatch(toolName, provider string, args map[string]interface{}, caller Caller) string {
+	if !matchTool(r.Tool, toolName) {
+		return fmt.Sprintf("tool %q does not match %q", toolName, r.Tool)
+	}
+	if r.Provider != "" && r.Provider != provider {
+		if provider == "" {
+			provider = "unknown"
 		}
+		return fmt.Sprintf("provider %s is not %s", provider, r.Provider)
 	}
-
-	// 2. Fallback to Default
-	return Result{Decision: e.policy.Default, Reason: "Default policy"}
+	// Check the caller if the rule is limited to some
+	if r.From != nil {
+		if why := r.From.why(caller); why != "" {
+			return "from: " + why
+		}
+	}
+	// Check argument matching if specified
+	for _, m := range r.Args {
+		if !matchArg(m, args) {
+			return "args: " + describeMatcher(m, args)
+		}
+	}
+	if r.When != nil {
+		if why := r.When.why(args); why != "" {
+			return "when: " + why
+		}
+	}
+	return ""
 }
 
 func matchTool(pattern, toolName string) bool {
@@ -62,36 +132,6 @@ func matchTool(pattern, toolName string) bool {
 	return matched
 }
 
-// matchScope checks if the current execution context matches the required scope
-func matchScope(scope ScopeType, args map[string]interface{}) bool {
-	// Extract current scope from args (context should provide this)
-	currentScope := ""
-	if args != nil {
-		if s, ok := args["_scope"].(string); ok {
-			currentScope = s
-		}
-	}
-
-	// If no scope specified in context, default to global
-	if currentScope == "" {
-		currentScope = string(ScopeGlobal)
-	}
-
-	switch scope {
-	case ScopeGlobal:
-		// Global scope: only operations explicitly marked as global
-		return currentScope == string(ScopeGlobal)
-	case ScopeWorkspace:
-		// Workspace scope: allows operations in workspace context only
-		return currentScope == string(ScopeWorkspace)
-	case ScopeNetwork:
-		// Network scope: allows operations in network context only
-		return currentScope == string(ScopeNetwork)
-	default:
-		return false
-	}
-}
-
 // matchArgs checks if the provided arguments match the required pattern
 func matchArgs(matchers []ArgMatcher, args map[string]interface{}) bool {
 	if args == nil {
@@ -0,0 +1,170 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RuleTrace records how one rule fared against a call
+type RuleTrace struct {
+	Index    int      `json:"index"`
+	ID       string   `json:"id,omitempty"`
+	Layer    string   `json:"layer,omitempty"`
+	Tool     string   `json:"tool"`
+	Decision Decision `json:"decision"`
+	Matched  bool     `json:"matched"`
+	// Reason is why the rule did not apply; empty when it matched.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Explanation is the outcome of a call with the rules that led to it.
+// Rules lists every rule tried, in order; evaluation stops at the first
+// match, so rules after it are not listed.
+type Explanation struct {
+	Tool     string      `json:"tool"`
+	Provider string      `json:"provider,omitempty"`
+	Caller   Caller      `json:"caller"`
+	Decision Decision    `json:"decision"`
+	Reason   string      `json:"reason,omitempty"`
+	RuleID   string      `json:"rule_id,omitempty"`
+	Layer    string      `json:"layer,omitempty"`
+	Default  bool        `json:"default"`
+	Rules    []RuleTrace `json:"rules"`
+	// Limits reports the rate limits of the deciding rule. When one is
+	// reached, Decision is its exceed decision.
+	Limits []LimitTrace `json:"limits,omitempty"`
+}
+
+// Result returns the decision as Decide would.
+func (x Explanation) Result() Result {
+	return Result{Decision: x.Decision, Reason: x.Reason}
+}
+
+// Explain evaluates a call like Decide, without counting it, and reports
+// which rule decided it and why each earlier rule did not.
+func (e *Engine) Explain(toolName string, args map[string]interface{}, caller Caller) Explanation {
+	return e.explain(toolName, e.providerOf(toolName), args, caller)
+}
+
+func (e *Engine) explain(toolName, provider string, args map[string]interface{}, caller Caller) Explanation {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if caller.Trust == "" {
+		caller.Trust = caller.trust()
+	}
+	x := Explanation{Tool: toolName, Provider: provider, Caller: caller, Rules: []RuleTrace{}}
+	for i := range e.policy.Rules {
+		rule := &e.policy.Rules[i]
+		trace := RuleTrace{
+			Index:    i,
+			ID:       rule.ID,
+			Layer:    rule.Layer,
+			Tool:     rule.Tool,
+			Decision: rule.Decision,
+			Reason:   rule.mismatch(toolName, provider, args, caller),
+		}
+		trace.Matched = trace.Reason == ""
+		x.Rules = append(x.Rules, trace)
+		if trace.Matched {
+			x.Decision = rule.Decision
+			x.Reason = rule.Description
+			x.RuleID = rule.ID
+			x.Layer = rule.Layer
+			if len(rule.Limits) > 0 && rule.Decision != DecisionDeny {
+				e.limitMu.Lock()
+				traces, over := e.checkLimits(toolName, e.windows(rule, caller))
+				e.limitMu.Unlock()
+				x.Limits = traces
+				if over != nil {
+					x.Decision, x.Reason = over.Decision, over.Reason
+				}
+			}
+			return x
+		}
+	}
+	x.Decision = e.policy.Default
+	x.Reason = "Default policy"
+	x.Default = true
+	return x
+}
+
+// why returns why args do not satisfy the condition, or "" if they do
+func (c *Condition) why(args map[string]interface{}) string {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	switch {
+	case c.All != nil:
+		for i := range c.All {
+			if why := c.All[i].why(args); why != "" {
+				return fmt.Sprintf("all[%d]: %s", i, why)
+			}
+		}
+		return ""
+	case c.Any != nil:
+		if c.Holds(args) {
+			return ""
+		}
+		whys := make([]string, len(c.Any))
+		for i := range c.Any {
+			whys[i] = c.Any[i].why(args)
+		}
+		return "none of any: " + strings.Join(whys, "; ")
+	case c.Not != nil:
+		if c.Not.Holds(args) {
+			return "not: condition holds"
+		}
+		return ""
+	}
+
+	if c.Holds(args) {
+		return ""
+	}
+	switch {
+	case c.PathWithin != nil:
+		return fmt.Sprintf("path_within: %s=%s is not within %v",
+			c.PathWithin.Arg, describeArg(args, c.PathWithin.Arg), c.PathWithin.Roots)
+	case c.HostIn != nil:
+		return fmt.Sprintf("host_in: %s=%s is not on a host in %v",
+			c.HostIn.Arg, describeArg(args, c.HostIn.Arg), c.HostIn.Hosts)
+	case c.CommandIn != nil:
+		return fmt.Sprintf("command_in: %s=%s runs a command not in %v",
+			c.CommandIn.Arg, describeArg(args, c.CommandIn.Arg), c.CommandIn.Commands)
+	case c.Range != nil:
+		return fmt.Sprintf("range: %s=%s is outside [%s, %s]",
+			c.Range.Arg, describeArg(args, c.Range.Arg), bound(c.Range.Min), bound(c.Range.Max))
+	case c.Match != nil:
+		return "match: " + describeMatcher(*c.Match, args)
+	default:
+		return "empty condition"
+	}
+}
+
+// describeMatcher explains a failed argument matcher
+func describeMatcher(m ArgMatcher, args map[string]interface{}) string {
+	switch m.Operator {
+	case "exists", "not_exists":
+		return fmt.Sprintf("%s %s does not hold", m.Key, m.Operator)
+	default:
+		return fmt.Sprintf("%s=%s does not satisfy %s %q", m.Key, describeArg(args, m.Key), m.Operator, m.Value)
+	}
+}
+
+func describeArg(args map[string]interface{}, key string) string {
+	v, ok := args[key]
+	if !ok {
+		return "(missing)"
+	}
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func bound(f *float64) string {
+	if f == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%g", *f)
+}
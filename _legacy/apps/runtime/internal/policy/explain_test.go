@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEngine_Explain(t *testing.T) {
+	dir := t.TempDir()
+	user, project := filepath.Join(dir, "user.yaml"), filepath.Join(dir, "project.yaml")
+	writePolicy(t, user, `
+rules:
+  - id: short-shell
+    tool: mcp.shell.exec
+    decision: allow
+    when:
+      range: {arg: timeout_ms, max: 5000}
+  - id: no-shell
+    tool: "mcp.shell.*"
+    decision: deny
+`)
+	writePolicy(t, project, `
+rules:
+  - id: read-config
+    tool: mcp.fs.read
+    decision: ask
+    args:
+      - {key: path, operator: eq, value: config.yaml}
+`)
+	e := NewEngine(nil)
+	if err := NewLoader(e, user, project).Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	x := e.Explain("mcp.shell.exec", map[string]interface{}{"timeout_ms": 60000.0}, Caller{})
+	if x.Decision != DecisionDeny || x.RuleID != "no-shell" || x.Layer != LayerUser || x.Default {
+		t.Fatalf("unexpected explanation: %+v", x)
+	}
+	if len(x.Rules) != 2 {
+		t.Fatalf("expected 2 rules considered, got %+v", x.Rules)
+	}
+	if r := x.Rules[0]; r.Matched || !strings.Contains(r.Reason, "range: timeout_ms=60000 is outside [-, 5000]") {
+		t.Errorf("rule 0: %+v", r)
+	}
+	if r := x.Rules[1]; !r.Matched || r.Reason != "" {
+		t.Errorf("rule 1: %+v", r)
+	}
+	if got := e.Evaluate("mcp.shell.exec", map[string]interface{}{"timeout_ms": 60000.0}, Caller{}); got != x.Result() {
+		t.Errorf("Evaluate %+v disagrees with Explain %+v", got, x.Result())
+	}
+
+	x = e.Explain("mcp.fs.read", map[string]interface{}{"path": "secrets.env"}, Caller{})
+	if !x.Default || x.Decision != DecisionAsk || x.RuleID != "" {
+		t.Fatalf("expected the default, got %+v", x)
+	}
+	if len(x.Rules) != 4 || !strings.Contains(x.Rules[0].Reason, "does not match") {
+		t.Errorf("unexpected traces: %+v", x.Rules)
+	}
+	if r := x.Rules[2]; r.ID != "read-config" || r.Layer != LayerProject || r.Matched || !strings.Contains(r.Reason, `path="secrets.env" does not satisfy eq "config.yaml"`) {
+		t.Errorf("rule 2: %+v", r)
+	}
+	if x.Rules[3].Layer != LayerBuiltin {
+		t.Errorf("expected the built-in rule last, got %+v", x.Rules[3])
+	}
+}
+
+func TestSuite_Run(t *testing.T) {
+	dir := t.TempDir()
+	writePolicy(t, filepath.Join(dir, "policy.yaml"), `
+rules:
+  - id: no-shell
+    tool: "mcp.shell.*"
+    decision: deny
+`)
+	suitePath := filepath.Join(dir, "suite.yaml")
+	writePolicy(t, suitePath, `
+policies: [policy.yaml]
+cases:
+  - name: shell is denied
+    tool: mcp.shell.exec
+    args: {command: ls}
+    expect: deny
+    rule: no-shell
+  - name: git reads are allowed
+    tool: mcp.git.status
+    provider: bundled:git
+    expect: allow
+  - name: wrong expectation
+    tool: mcp.fs.write
+    expect: allow
+  - name: wrong rule
+    tool: mcp.other
+    expect: ask
+    rule: no-shell
+  - name: default
+    tool: mcp.other
+    expect: ask
+    rule: default
+`)
+	suite, err := LoadSuite(suitePath)
+	if err != nil {
+		t.Fatalf("LoadSuite: %v", err)
+	}
+	e, err := suite.Engine()
+	if err != nil || e == nil {
+		t.Fatalf("Engine: %v", err)
+	}
+	results := suite.Run(e)
+	want := []bool{true, true, false, false, true}
+	for i, r := range results {
+		if r.Passed != want[i] {
+			t.Errorf("%s: passed=%v (%s)", r.Case.Name, r.Passed, r.Failure)
+		}
+	}
+	if f := results[2].Failure; f != "expected allow, got ask" {
+		t.Errorf("unexpected failure: %q", f)
+	}
+	if f := results[3].Failure; f != `expected rule "no-shell", got the default` {
+		t.Errorf("unexpected failure: %q", f)
+	}
+}
+
+func TestLoadSuite_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]string{
+		"empty":         "cases: []\n",
+		"unknown field": "cases:\n  - tool: x\n    expect: allow\n    decision: allow\n",
+		"bad expect":    "cases:\n  - tool: x\n    expect: maybe\n",
+		"missing tool":  "cases:\n  - expect: allow\n",
+	}
+	for name, content := range cases {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "-")+".yaml")
+		writePolicy(t, path, content)
+		if _, err := LoadSuite(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limit scopes: whose calls share a counter
+const (
+	LimitPerSession = "session"
+	LimitPerUser    = "user"
+	LimitPerChannel = "channel"
+	LimitPerGlobal  = "global"
+)
+
+// Limit caps how often a rule lets calls through. Counts are kept per
+// window, aligned to UTC, so a "day" quota resets at midnight UTC. Calls
+// over the cap get the Exceed decision instead of the rule's.
+type Limit struct {
+	Per    string   `json:"per" yaml:"per"`                           // session, user, channel or global
+	Max    int      `json:"max" yaml:"max"`                           // calls allowed per window
+	Window string   `json:"window" yaml:"window"`                     // minute, hour, day or a duration such as 10m
+	Exceed Decision `json:"exceed,omitempty" yaml:"exceed,omitempty"` // deny (default) or ask
+}
+
+// Counters persists call counts for rate limits
+type Counters interface {
+	CountPolicyCalls(key string) (int, error)
+	// TakePolicyCall counts one call under the key of every quota, unless
+	// one of them has already reached its max, as a single atomic step. It
+	// returns the index of the first full quota, or -1 once the call is
+	// counted.
+	TakePolicyCall(quotas []Quota) (int, error)
+}
+
+// Quota is one counter a call is charged to
+type Quota struct {
+	Key     string
+	Max     int
+	Expires time.Time
+}
+
+// memCounters keeps counts in memory, for engines without a store
+type memCounters struct {
+	mu     sync.Mutex
+	counts map[string]memCount
+}
+
+type memCount struct {
+	n       int
+	expires time.Time
+}
+
+func newMemCounters() *memCounters {
+	return &memCounters{counts: map[string]memCount{}}
+}
+
+func (m *memCounters) CountPolicyCalls(key string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.counts[key]
+	if !ok || !time.Now().Before(c.expires) {
+		return 0, nil
+	}
+	return c.n, nil
+}
+
+func (m *memCounters) TakePolicyCall(quotas []Quota) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for k, c := range m.counts {
+		if !now.Before(c.expires) {
+			delete(m.counts, k)
+		}
+	}
+	for i, q := range quotas {
+		if m.counts[q.Key].n >= q.Max {
+			return i, nil
+		}
+	}
+	for _, q := range quotas {
+		c := m.counts[q.Key]
+		m.counts[q.Key] = memCount{n: c.n + 1, expires: q.Expires}
+	}
+	return -1, nil
+}
+
+// SetCounters sets where Decide keeps call counts. Without it counts are
+// kept in memory and reset on restart.
+func (e *Engine) SetCounters(c Counters) {
+	e.limitMu.Lock()
+	defer e.limitMu.Unlock()
+	e.counters = c
+}
+
+// Decide evaluates a call made for caller against the rules, and applies
+// the limits of the rule that matched: a call over one gets its exceed
+// decision. Nothing is counted until the call is approved and passed to
+// Charge, so asks the user declines leave the quota alone.
+func (e *Engine) Decide(toolName string, args map[string]interface{}, caller Caller) Result {
+	provider := e.providerOf(toolName)
+	e.mu.RLock()
+	var rule *Rule
+	for i := range e.policy.Rules {
+		if e.policy.Rules[i].mismatch(toolName, provider, args, caller) == "" {
+			rule = &e.policy.Rules[i]
+			break
+		}
+	}
+	def := e.policy.Default
+	e.mu.RUnlock()
+
+	if rule == nil {
+		return Result{Decision: def, Reason: "Default policy"}
+	}
+	res := Result{Decision: rule.Decision, Reason: rule.Description}
+	if len(rule.Limits) == 0 || rule.Decision == DecisionDeny {
+		return res
+	}
+
+	e.limitMu.Lock()
+	defer e.limitMu.Unlock()
+	windows := e.windows(rule, caller)
+	if _, over := e.checkLimits(toolName, windows); over != nil {
+		return *over
+	}
+	res.charge = &charge{tool: toolName, windows: windows}
+	return res
+}
+
+// charge is what Charge counts for a call Decide let through
+type charge struct {
+	tool    string
+	windows []limitWindow
+}
+
+// Charge counts a call Decide let through against the limits of its rule,
+// once the call is approved to run. The limits are checked again as the
+// call is counted, so it fails without counting when other calls used them
+// up after Decide. Results without limits are not counted.
+func (e *Engine) Charge(res Result) error {
+	if res.charge == nil {
+		return nil
+	}
+	quotas := make([]Quota, len(res.charge.windows))
+	for i, w := range res.charge.windows {
+		quotas[i] = Quota{Key: w.key, Max: w.limit.Max, Expires: w.expires}
+	}
+
+	e.limitMu.Lock()
+	defer e.limitMu.Unlock()
+	full, err := e.counters.TakePolicyCall(quotas)
+	if err != nil {
+		return fmt.Errorf("rate limit check failed: %v", err)
+	}
+	if full >= 0 {
+		return errors.New(res.charge.windows[full].limit.describe(res.charge.tool))
+	}
+	return nil
+}
+
+// limitWindow is the counter one limit of a rule uses for a call
+type limitWindow struct {
+	limit   Limit
+	key     string
+	expires time.Time
+}
+
+// windows returns the counters the limits of rule use for caller now
+func (e *Engine) windows(rule *Rule, caller Caller) []limitWindow {
+	now := e.now()
+	out := make([]limitWindow, len(rule.Limits))
+	for i, l := range rule.Limits {
+		window, _ := parseWindow(l.Window)
+		start := now.Truncate(window)
+		out[i] = limitWindow{
+			limit:   l,
+			key:     fmt.Sprintf("%s|%s|%s|%d|%d", ruleKey(rule), l.Per, caller.subject(l.Per), window/time.Second, start.Unix()),
+			expires: start.Add(window),
+		}
+	}
+	return out
+}
+
+// LimitTrace reports how much of a limit has been used in the current
+// window.
+type LimitTrace struct {
+	Per     string `json:"per"`
+	Max     int    `json:"max"`
+	Window  string `json:"window"`
+	Used    int    `json:"used"`
+	Reached bool   `json:"reached"`
+	Error   string `json:"error,omitempty"`
+}
+
+// checkLimits reads the counters of windows without adding to them. It
+// returns the outcome of the first limit that is reached or cannot be
+// read, or nil if the call is within all of them. The caller holds limitMu.
+func (e *Engine) checkLimits(toolName string, windows []limitWindow) ([]LimitTrace, *Result) {
+	traces := make([]LimitTrace, len(windows))
+	var over *Result
+	for i, w := range windows {
+		traces[i] = LimitTrace{Per: w.limit.Per, Max: w.limit.Max, Window: w.limit.Window}
+		n, err := e.counters.CountPolicyCalls(w.key)
+		switch {
+		case err != nil:
+			traces[i].Error = err.Error()
+			if over == nil {
+				over = &Result{Decision: w.limit.exceed(), Reason: fmt.Sprintf("rate limit check failed: %v", err)}
+			}
+		case n >= w.limit.Max:
+			traces[i].Used, traces[i].Reached = n, true
+			if over == nil {
+				over = &Result{Decision: w.limit.exceed(), Reason: w.limit.describe(toolName)}
+			}
+		default:
+			traces[i].Used = n
+		}
+	}
+	return traces, over
+}
+
+// ruleKey identifies a rule's counters across reloads
+func ruleKey(r *Rule) string {
+	if r.ID != "" {
+		return r.ID
+	}
+	return r.Layer + ":" + r.Tool
+}
+
+func (l Limit) exceed() Decision {
+	if l.Exceed == "" {
+		return DecisionDeny
+	}
+	return l.Exceed
+}
+
+func (l Limit) describe(toolName string) string {
+	per := "in total"
+	if l.Per != LimitPerGlobal {
+		per = "per " + l.Per
+	}
+	return fmt.Sprintf("Rate limit reached: %s is limited to %d calls per %s %s", toolName, l.Max, l.Window, per)
+}
+
+// parseWindow reads a limit window
+func parseWindow(s string) (time.Duration, error) {
+	switch s {
+	case "minute":
+		return time.Minute, nil
+	case "hour":
+		return time.Hour, nil
+	case "day":
+		return 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("window must be minute, hour, day or a duration: %q", s)
+	}
+	if d < time.Second {
+		return 0, fmt.Errorf("window must be at least 1s: %q", s)
+	}
+	return d, nil
+}
+
+func (l Limit) validate() []error {
+	var errs []error
+	switch l.Per {
+	case LimitPerSession, LimitPerUser, LimitPerChannel, LimitPerGlobal:
+	default:
+		errs = append(errs, fmt.Errorf("per must be session, user, channel or global: %q", l.Per))
+	}
+	if l.Max < 1 {
+		errs = append(errs, errors.New("max must be at least 1"))
+	}
+	if _, err := parseWindow(l.Window); err != nil {
+		errs = append(errs, err)
+	}
+	switch l.Exceed {
+	case "", DecisionDeny, DecisionAsk:
+	default:
+		errs = append(errs, fmt.Errorf("exceed must be deny or ask: %q", l.Exceed))
+	}
+	return errs
+}
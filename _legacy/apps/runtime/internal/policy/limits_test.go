@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEngine_DecideLimits(t *testing.T) {
+	p, err := Parse([]byte(`
+rules:
+  - id: shell
+    tool: mcp.shell.exec
+    decision: allow
+    limits:
+      - {per: session, max: 2, window: minute}
+      - {per: channel, max: 3, window: day, exceed: ask}
+  - id: fetch
+    tool: "mcp.fetch.*"
+    decision: allow
+    limits:
+      - {per: user, max: 1, window: hour}
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	e := NewEngine(p)
+	now := time.Now()
+	e.now = func() time.Time { return now }
+
+	tui := Caller{SessionID: "s1", Surface: "tui"}
+	// Calls let through go ahead and are charged
+	run := func(tool string, c Caller) Result {
+		t.Helper()
+		res := e.Decide(tool, nil, c)
+		if res.Decision == DecisionAllow {
+			if err := e.Charge(res); err != nil {
+				t.Fatalf("Charge: %v", err)
+			}
+		}
+		return res
+	}
+	decide := func(c Caller) Result { return run("mcp.shell.exec", c) }
+
+	// Evaluate previews the decision without counting the call
+	if res := e.Evaluate("mcp.shell.exec", nil, tui); res.Decision != DecisionAllow || res.charge != nil {
+		t.Fatalf("expected an uncharged allow, got %+v", res)
+	}
+	for i := 0; i < 2; i++ {
+		if got := decide(tui).Decision; got != DecisionAllow {
+			t.Fatalf("call %d: got %s", i, got)
+		}
+	}
+	res := decide(tui)
+	if res.Decision != DecisionDeny || !strings.Contains(res.Reason, "2 calls per minute per session") {
+		t.Fatalf("expected the session limit, got %+v", res)
+	}
+	if got := e.Evaluate("mcp.shell.exec", nil, tui).Decision; got != DecisionDeny {
+		t.Fatalf("expected Evaluate to apply the used-up limit, got %s", got)
+	}
+	x := e.Explain("mcp.shell.exec", nil, tui)
+	if x.Result() != res || len(x.Limits) != 2 || !x.Limits[0].Reached || x.Limits[0].Used != 2 || x.Limits[1].Reached || x.Limits[1].Used != 2 {
+		t.Fatalf("Explain disagrees with Decide: %+v", x)
+	}
+
+	// Another session on the same surface hits the daily channel quota,
+	// which asks instead of denying
+	other := Caller{SessionID: "s2", Surface: "tui"}
+	if got := decide(other).Decision; got != DecisionAllow {
+		t.Fatalf("expected allow, got %s", got)
+	}
+	if res := decide(other); res.Decision != DecisionAsk || !strings.Contains(res.Reason, "per channel") {
+		t.Fatalf("expected the channel quota, got %+v", res)
+	}
+
+	// A new minute resets the session limit but not the daily quota
+	now = now.Add(time.Minute)
+	if got := decide(tui).Decision; got != DecisionAsk {
+		t.Fatalf("expected the daily quota to hold, got %s", got)
+	}
+	if got := decide(Caller{SessionID: "s1", Surface: "telegram", Source: "telegram-main", ChannelID: "1"}).Decision; got != DecisionAllow {
+		t.Fatalf("expected another channel to have its own quota, got %s", got)
+	}
+
+	// Users are told apart by channel and sender
+	fetch := func(c Caller) Decision { return run("mcp.fetch.get", c).Decision }
+	alice := Caller{Surface: "telegram", Source: "telegram-main", ChannelID: "1", Sender: "alice"}
+	if fetch(alice) != DecisionAllow || fetch(alice) != DecisionDeny {
+		t.Fatal("expected alice to get one call per hour")
+	}
+	if fetch(Caller{Surface: "telegram", Source: "telegram-main", ChannelID: "2", Sender: "alice"}) != DecisionDeny {
+		t.Error("expected alice's quota to span her chats")
+	}
+	if fetch(Caller{Surface: "slack", Source: "slack-work", ChannelID: "1", Sender: "alice"}) != DecisionAllow {
+		t.Error("expected a different channel's alice to have her own quota")
+	}
+
+	// Evaluate never counts
+	if got := e.Evaluate("mcp.fetch.get", nil, Caller{}).Decision; got != DecisionAllow {
+		t.Errorf("Evaluate: got %s", got)
+	}
+}
+
+func TestEngine_DecideCounters(t *testing.T) {
+	p, err := Parse([]byte(`
+rules:
+  - id: quota
+    tool: "*"
+    decision: ask
+    limits: [{per: global, max: 1, window: day}]
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	counters := newMemCounters()
+	e := NewEngine(p)
+	e.SetCounters(counters)
+
+	// Asks the user declines are never charged
+	for i := 0; i < 3; i++ {
+		if res := e.Decide("a", nil, Caller{}); res.Decision != DecisionAsk {
+			t.Fatalf("declined asks used the quota: %+v", res)
+		}
+	}
+
+	// Of two calls decided together, only the first to be approved runs
+	first, second := e.Decide("a", nil, Caller{}), e.Decide("a", nil, Caller{})
+	if err := e.Charge(first); err != nil {
+		t.Fatalf("Charge: %v", err)
+	}
+	if err := e.Charge(second); err == nil || !strings.Contains(err.Error(), "Rate limit reached") {
+		t.Fatalf("expected the second call to find the quota used, got %v", err)
+	}
+
+	// A second engine sharing the counters, as after a restart, sees the
+	// quota used
+	e2 := NewEngine(p)
+	e2.SetCounters(counters)
+	if res := e2.Decide("b", nil, Caller{}); res.Decision != DecisionDeny {
+		t.Errorf("expected the shared quota to be used up, got %+v", res)
+	}
+}
+
+func TestLimit_Validate(t *testing.T) {
+	cases := []struct {
+		name string
+		yaml string
+	}{
+		{"unknown per", "limits: [{per: team, max: 1, window: day}]"},
+		{"zero max", "limits: [{per: session, max: 0, window: day}]"},
+		{"bad window", "limits: [{per: session, max: 1, window: fortnight}]"},
+		{"tiny window", "limits: [{per: session, max: 1, window: 10ms}]"},
+		{"bad exceed", "limits: [{per: session, max: 1, window: day, exceed: allow}]"},
+	}
+	for _, tc := range cases {
+		doc := "rules:\n  - tool: x\n    decision: allow\n    " + tc.yaml + "\n"
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+	if _, err := Parse([]byte("rules:\n  - tool: x\n    decision: deny\n    limits: [{per: session, max: 1, window: day}]\n")); err == nil {
+		t.Error("expected limits on a deny rule to be rejected")
+	}
+}
@@ -0,0 +1,378 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Layer IDs, from highest to lowest precedence. The project policy comes
+// with whatever repository the runtime is started in, so it ranks below the
+// user's and can only tighten: see restrictProject.
+const (
+	LayerUser    = "user"
+	LayerProject = "project"
+	LayerBuiltin = "builtin"
+)
+
+// PollInterval is how often Watch checks the policy files for changes.
+var PollInterval = 2 * time.Second
+
+// Layer is one source of rules in the effective policy
+type Layer struct {
+	ID   string `json:"id"`
+	Path string `json:"path,omitempty"`
+	// Exists is false when the layer's file is absent; the layer then
+	// contributes nothing.
+	Exists bool `json:"exists"`
+	// Error is why the file was last rejected. Policy is then the last
+	// version that was accepted.
+	Error  string  `json:"error,omitempty"`
+	Policy *Policy `json:"policy,omitempty"`
+	// Ignored lists what the layer sets but may not, such as allow rules
+	// in the project policy.
+	Ignored []string `json:"ignored,omitempty"`
+}
+
+// DefaultPaths returns the user policy file and the project policy file of
+// the working directory.
+func DefaultPaths() (user, project string) {
+	if home, err := os.UserHomeDir(); err == nil {
+		user = filepath.Join(home, ".pryx", "policy.yaml")
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		project = filepath.Join(cwd, ".pryx", "policy.yaml")
+	}
+	return user, project
+}
+
+// Parse decodes and validates a policy document. JSON is accepted as YAML.
+func Parse(data []byte) (*Policy, error) {
+	p := &Policy{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Merge layers policies given from highest to lowest precedence. Rules are
+// tried in that order, so the first matching rule of the most specific
+// layer decides; the default comes from the most specific layer that sets
+// one.
+func Merge(policies ...*Policy) *Policy {
+	out := &Policy{}
+	for _, p := range policies {
+		if p == nil {
+			continue
+		}
+		if out.Version == "" {
+			out.Version = p.Version
+		}
+		if out.Default == "" {
+			out.Default = p.Default
+		}
+		out.Rules = append(out.Rules, p.Rules...)
+	}
+	if out.Default == "" {
+		out.Default = DecisionAsk
+	}
+	return out
+}
+
+// Loader keeps an engine in sync with the project and user policy files,
+// layered over the built-in default policy.
+type Loader struct {
+	engine  *Engine
+	paths   map[string]string
+	builtin *Policy
+
+	mu     sync.Mutex
+	layers map[string]Layer
+	fp     []byte
+}
+
+// NewLoader loads into e from userPath and projectPath; either may be empty
+// to skip that layer.
+func NewLoader(e *Engine, userPath, projectPath string) *Loader {
+	return &Loader{
+		engine:  e,
+		paths:   map[string]string{LayerProject: projectPath, LayerUser: userPath},
+		builtin: NewDefaultPolicy(),
+		layers:  map[string]Layer{},
+	}
+}
+
+// NewDefaultLoader loads into e from DefaultPaths.
+func NewDefaultLoader(e *Engine) *Loader {
+	user, project := DefaultPaths()
+	return NewLoader(e, user, project)
+}
+
+// Load reads the policy files and swaps the merged policy into the engine.
+// If any file is invalid the engine keeps its current policy, and the error
+// names the offending files.
+func (l *Loader) Load() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.fp = l.fingerprint()
+	var errs []error
+	for _, id := range []string{LayerUser, LayerProject} {
+		path := l.paths[id]
+		layer := Layer{ID: id, Path: path}
+		if path != "" {
+			data, err := os.ReadFile(path)
+			switch {
+			case errors.Is(err, os.ErrNotExist):
+			case err != nil:
+				layer.Exists = true
+				layer.Error = err.Error()
+			default:
+				layer.Exists = true
+				if p, err := Parse(data); err != nil {
+					layer.Error = err.Error()
+				} else {
+					layer.Policy = p
+				}
+			}
+		}
+		if layer.Error != "" {
+			layer.Policy = l.layers[id].Policy
+			errs = append(errs, fmt.Errorf("%s policy %s: %s", id, path, layer.Error))
+		}
+		l.layers[id] = layer
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	user := l.layers[LayerUser].Policy
+	base := Merge(user, l.builtin).Default
+	project := l.layers[LayerProject]
+	var restricted *Policy
+	restricted, project.Ignored = restrictProject(project.Policy, base)
+	l.layers[LayerProject] = project
+
+	l.engine.SetPolicy(Merge(
+		withLayer(user, LayerUser),
+		withLayer(restricted, LayerProject),
+		withLayer(l.builtin, LayerBuiltin),
+	))
+	return nil
+}
+
+// restrictProject returns the part of a project policy that applies: its
+// deny and ask rules, and its default only when stricter than base, the
+// default the user and built-in layers give. The rest is described in the
+// returned list.
+func restrictProject(p *Policy, base Decision) (*Policy, []string) {
+	if p == nil {
+		return nil, nil
+	}
+	out := *p
+	out.Rules = nil
+	var ignored []string
+	for i, rule := range p.Rules {
+		if rule.Decision == DecisionAllow {
+			name := rule.ID
+			if name == "" {
+				name = fmt.Sprintf("#%d (%s)", i+1, rule.Tool)
+			}
+			ignored = append(ignored, fmt.Sprintf("rule %s: a project policy cannot allow", name))
+			continue
+		}
+		out.Rules = append(out.Rules, rule)
+	}
+	if out.Default != "" && strictness(out.Default) <= strictness(base) {
+		if out.Default != base {
+			ignored = append(ignored, fmt.Sprintf("default %s: less strict than %s", out.Default, base))
+		}
+		out.Default = ""
+	}
+	return &out, ignored
+}
+
+// strictness orders decisions from allow to deny
+func strictness(d Decision) int {
+	switch d {
+	case DecisionDeny:
+		return 2
+	case DecisionAsk:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// withLayer copies p with its rules marked as coming from layer
+func withLayer(p *Policy, layer string) *Policy {
+	if p == nil {
+		return nil
+	}
+	out := *p
+	out.Rules = make([]Rule, len(p.Rules))
+	for i, rule := range p.Rules {
+		rule.Layer = layer
+		out.Rules[i] = rule
+	}
+	return &out
+}
+
+// Layers returns the policy layers from highest to lowest precedence.
+func (l *Loader) Layers() []Layer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := []Layer{l.layerLocked(LayerUser), l.layerLocked(LayerProject)}
+	return append(out, Layer{ID: LayerBuiltin, Exists: true, Policy: l.builtin})
+}
+
+// Layer returns a single layer by ID.
+func (l *Loader) Layer(id string) (Layer, bool) {
+	for _, layer := range l.Layers() {
+		if layer.ID == id {
+			return layer, true
+		}
+	}
+	return Layer{}, false
+}
+
+func (l *Loader) layerLocked(id string) Layer {
+	if layer, ok := l.layers[id]; ok {
+		return layer
+	}
+	return Layer{ID: id, Path: l.paths[id]}
+}
+
+// Engine returns the engine the loader keeps in sync.
+func (l *Loader) Engine() *Engine {
+	return l.engine
+}
+
+// Effective returns the policy the engine evaluates.
+func (l *Loader) Effective() *Policy {
+	return l.engine.Policy()
+}
+
+// Write validates data and saves it as the file of the project or user
+// layer, then reloads.
+func (l *Loader) Write(id string, data []byte) error {
+	path, ok := l.paths[id]
+	if !ok {
+		return fmt.Errorf("policy layer %q cannot be written", id)
+	}
+	if path == "" {
+		return fmt.Errorf("policy layer %q has no file", id)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	if id == LayerProject {
+		l.mu.Lock()
+		base := Merge(l.layers[LayerUser].Policy, l.builtin).Default
+		l.mu.Unlock()
+		if _, ignored := restrictProject(p, base); len(ignored) > 0 {
+			return fmt.Errorf("project policy can only deny or ask: %s", strings.Join(ignored, "; "))
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return l.Load()
+}
+
+// Watch polls the policy files and reloads when they change, until ctx is
+// cancelled. onReload, if set, is called after every reload with its
+// result.
+func (l *Loader) Watch(ctx context.Context, onReload func(error)) {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		l.mu.Lock()
+		changed := !bytes.Equal(l.fingerprint(), l.fp)
+		l.mu.Unlock()
+		if !changed {
+			continue
+		}
+		err := l.Load()
+		if onReload != nil {
+			onReload(err)
+		}
+	}
+}
+
+// fingerprint hashes the policy files, including which are absent
+func (l *Loader) fingerprint() []byte {
+	h := sha256.New()
+	for _, id := range []string{LayerUser, LayerProject} {
+		h.Write([]byte(id))
+		h.Write([]byte{0})
+		if data, err := os.ReadFile(l.paths[id]); err == nil {
+			h.Write([]byte{1})
+			h.Write(data)
+		}
+		h.Write([]byte{0})
+	}
+	return h.Sum(nil)
+}
+
+// LayerSummary describes a layer without listing its rules
+type LayerSummary struct {
+	ID      string   `json:"id"`
+	Path    string   `json:"path,omitempty"`
+	Exists  bool     `json:"exists"`
+	Error   string   `json:"error,omitempty"`
+	Rules   int      `json:"rules"`
+	Default Decision `json:"default,omitempty"`
+	Ignored []string `json:"ignored,omitempty"`
+}
+
+// Summary describes the layer without listing its rules.
+func (l Layer) Summary() LayerSummary {
+	s := LayerSummary{ID: l.ID, Path: l.Path, Exists: l.Exists, Error: l.Error, Ignored: l.Ignored}
+	if l.Policy != nil {
+		s.Rules = len(l.Policy.Rules)
+		s.Default = l.Policy.Default
+	}
+	return s
+}
+
+// Summaries describes every layer, from highest to lowest precedence.
+func (l *Loader) Summaries() []LayerSummary {
+	layers := l.Layers()
+	out := make([]LayerSummary, 0, len(layers))
+	for _, layer := range layers {
+		out = append(out, layer.Summary())
+	}
+	return out
+}
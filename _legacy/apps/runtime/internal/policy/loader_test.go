@@ -0,0 +1,183 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writePolicy(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoader_Layering(t *testing.T) {
+	dir := t.TempDir()
+	user, project := filepath.Join(dir, "user.yaml"), filepath.Join(dir, "project", "policy.yaml")
+	writePolicy(t, user, `
+default: deny
+rules:
+  - id: no-shell
+    tool: "mcp.shell.*"
+    decision: deny
+  - tool: "mcp.fs.read"
+    decision: allow
+`)
+	writePolicy(t, project, `
+default: allow
+rules:
+  - tool: "mcp.shell.exec"
+    decision: ask
+  - tool: "mcp.git.status"
+    decision: deny
+  - id: everything
+    tool: "*"
+    decision: allow
+`)
+
+	e := NewEngine(nil)
+	e.SetProviders(bundledGit)
+	l := NewLoader(e, user, project)
+	if err := l.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	cases := map[string]Decision{
+		"mcp.shell.exec": DecisionDeny,  // user rule over project
+		"mcp.shell.kill": DecisionDeny,  // user rule
+		"mcp.fs.read":    DecisionAllow, // user rule
+		"mcp.git.status": DecisionDeny,  // project tightens a built-in rule
+		"mcp.git.log":    DecisionAllow, // built-in rule
+		"other.tool":     DecisionDeny,  // user default; project allows are ignored
+	}
+	for tool, want := range cases {
+		if got := e.Evaluate(tool, nil, Caller{}).Decision; got != want {
+			t.Errorf("%s: got %s, want %s", tool, got, want)
+		}
+	}
+
+	layers := l.Summaries()
+	if len(layers) != 3 || layers[0].ID != LayerUser || layers[0].Rules != 2 || layers[2].ID != LayerBuiltin {
+		t.Fatalf("unexpected layers: %+v", layers)
+	}
+	if ignored := layers[1].Ignored; len(ignored) != 2 || !strings.Contains(ignored[0], "rule everything") || !strings.Contains(ignored[1], "default allow") {
+		t.Fatalf("unexpected ignored: %v", ignored)
+	}
+}
+
+func TestLoader_ProjectCannotLoosen(t *testing.T) {
+	dir := t.TempDir()
+	project := filepath.Join(dir, "project", "policy.yaml")
+	writePolicy(t, project, `
+default: allow
+rules:
+  - tool: "*"
+    decision: allow
+`)
+	e := NewEngine(nil)
+	l := NewLoader(e, filepath.Join(dir, "user.yaml"), project)
+	if err := l.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := e.Evaluate("mcp.shell.exec", nil, Caller{}).Decision; got != DecisionAsk {
+		t.Fatalf("project policy loosened the default: got %s", got)
+	}
+
+	writePolicy(t, project, "default: deny\n")
+	if err := l.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := e.Evaluate("mcp.shell.exec", nil, Caller{}).Decision; got != DecisionDeny {
+		t.Fatalf("stricter project default not applied: got %s", got)
+	}
+
+	if err := l.Write(LayerProject, []byte("rules:\n  - tool: any.tool\n    decision: allow\n")); err == nil {
+		t.Fatal("expected a project allow rule to be refused")
+	}
+}
+
+func TestLoader_InvalidFileKeepsPolicy(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "policy.yaml")
+	writePolicy(t, user, "rules:\n  - tool: safe.*\n    decision: allow\n")
+
+	e := NewEngine(nil)
+	l := NewLoader(e, user, "")
+	if err := l.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	writePolicy(t, user, "rules:\n  - tool: \"(\"\n    decision: sometimes\n    colour: red\n")
+	err := l.Load()
+	if err == nil || !strings.Contains(err.Error(), "colour") {
+		t.Fatalf("expected unknown field to be rejected, got %v", err)
+	}
+	writePolicy(t, user, "rules:\n  - tool: \"(\"\n    decision: sometimes\n")
+	err = l.Load()
+	if err == nil || !strings.Contains(err.Error(), "invalid pattern") || !strings.Contains(err.Error(), "unknown decision") {
+		t.Fatalf("expected both rule errors, got %v", err)
+	}
+	if got := e.Evaluate("safe.read", nil, Caller{}).Decision; got != DecisionAllow {
+		t.Fatalf("previous policy should stay in force, got %s", got)
+	}
+	if layer, _ := l.Layer(LayerUser); layer.Error == "" || layer.Policy == nil || len(layer.Policy.Rules) != 1 {
+		t.Fatalf("layer should report the error and keep the accepted policy: %+v", layer)
+	}
+}
+
+func TestLoader_WatchAndWrite(t *testing.T) {
+	prev := PollInterval
+	PollInterval = 10 * time.Millisecond
+	defer func() { PollInterval = prev }()
+
+	dir := t.TempDir()
+	user := filepath.Join(dir, "user", "policy.yaml")
+	project := filepath.Join(dir, "project", "policy.yaml")
+	e := NewEngine(nil)
+	l := NewLoader(e, user, project)
+	if err := l.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan error, 4)
+	go l.Watch(ctx, func(err error) { reloaded <- err })
+
+	writePolicy(t, user, "default: allow\n")
+	select {
+	case err := <-reloaded:
+		if err != nil {
+			t.Fatalf("reload: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("change was not picked up")
+	}
+	if got := e.Evaluate("any.tool", nil, Caller{}).Decision; got != DecisionAllow {
+		t.Fatalf("got %s after reload", got)
+	}
+
+	if err := l.Write(LayerProject, []byte("rules:\n  - tool: any.tool\n    decision: nope\n")); err == nil {
+		t.Fatal("expected invalid policy to be refused")
+	}
+	if _, err := os.Stat(project); !os.IsNotExist(err) {
+		t.Fatal("invalid policy was written")
+	}
+	if err := l.Write(LayerProject, []byte(`{"rules": [{"tool": "any.tool", "decision": "deny"}]}`)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := e.Evaluate("any.tool", nil, Caller{}).Decision; got != DecisionDeny {
+		t.Fatalf("got %s after write", got)
+	}
+	if err := l.Write(LayerBuiltin, []byte("{}")); err == nil {
+		t.Fatal("built-in layer should not be writable")
+	}
+}
@@ -1,36 +1,36 @@
 package policy
 
-// ScopeType definitions
-type ScopeType string
-
-const (
-	ScopeGlobal    ScopeType = "global"
-	ScopeWorkspace ScopeType = "workspace"
-	ScopeNetwork   ScopeType = "network"
-)
-
 // ArgMatcher defines how to match arguments
 type ArgMatcher struct {
-	Key      string `json:"key"`             // Argument key to match
-	Operator string `json:"operator"`        // Operator: eq, neq, exists, not_exists, contains, regex
-	Value    string `json:"value,omitempty"` // Value for comparison (optional for exists/not_exists)
+	Key      string `json:"key" yaml:"key"`                         // Argument key to match
+	Operator string `json:"operator" yaml:"operator"`               // Operator: eq, neq, exists, not_exists, contains, regex
+	Value    string `json:"value,omitempty" yaml:"value,omitempty"` // Value for comparison (optional for exists/not_exists)
 }
 
 // Rule defines a single policy rule
 type Rule struct {
-	ID          string       `json:"id"`
-	Description string       `json:"description"`
-	Tool        string       `json:"tool"`           // exact match, prefix wildcard (foo*), "*" or regex
-	Scope       ScopeType    `json:"scope"`          // scope requirement
-	Args        []ArgMatcher `json:"args,omitempty"` // argument matchers
-	Decision    Decision     `json:"decision"`
+	ID          string `json:"id" yaml:"id"`
+	Description string `json:"description" yaml:"description"`
+	Tool        string `json:"tool" yaml:"tool"` // exact match, prefix wildcard (foo*), "*" or regex
+	// Provider limits the rule to tools served by one implementation,
+	// such as "bundled:git" for pryx's bundled git server, whatever the
+	// server is named.
+	Provider string       `json:"provider,omitempty" yaml:"provider,omitempty"`
+	From     *CallerMatch `json:"from,omitempty" yaml:"from,omitempty"` // callers the rule applies to
+	Args     []ArgMatcher `json:"args,omitempty" yaml:"args,omitempty"` // argument matchers
+	When     *Condition   `json:"when,omitempty" yaml:"when,omitempty"` // typed argument conditions
+	Decision Decision     `json:"decision" yaml:"decision"`
+	Limits   []Limit      `json:"limits,omitempty" yaml:"limits,omitempty"` // rate limits and quotas
+	// Layer is the policy layer the rule was loaded from, set when layers
+	// are merged.
+	Layer string `json:"layer,omitempty" yaml:"-"`
 }
 
 // Policy is a collection of rules
 type Policy struct {
-	Version string   `json:"version"`
-	Rules   []Rule   `json:"rules"`
-	Default Decision `json:"default"`
+	Version string   `json:"version" yaml:"version"`
+	Rules   []Rule   `json:"rules" yaml:"rules"`
+	Default Decision `json:"default" yaml:"default"`
 }
 
 func NewDefaultPolicy() *Policy {
@@ -38,7 +38,15 @@ func NewDefaultPolicy() *Policy {
 	// For MVP, "Ask" is a good balance.
 	return &Policy{
 		Version: "1.0",
-		Rules:   []Rule{},
+		Rules: []Rule{
+			{
+				ID:          "bundled-git-read-only",
+				Description: "Read-only git operations",
+				Tool:        `^mcp\.git\.(status|diff|log|show|blame|branches)$`,
+				Provider:    "bundled:git",
+				Decision:    DecisionAllow,
+			},
+		},
 		Default: DecisionAsk,
 	}
 }
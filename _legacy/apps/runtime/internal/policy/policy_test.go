@@ -1,17 +1,48 @@
 package policy
 
 import (
+	"strings"
 	"testing"
 )
 
+// providerFunc serves tool providers from a function
+type providerFunc func(string) string
+
+func (f providerFunc) ToolProvider(tool string) string { return f(tool) }
+
+// bundledGit reports tools of the server named git as the bundled one
+var bundledGit = providerFunc(func(tool string) string {
+	if strings.HasPrefix(tool, "mcp.git.") {
+		return "bundled:git"
+	}
+	return ""
+})
+
 func TestDefaultPolicy(t *testing.T) {
 	engine := NewEngine(nil)
+	engine.SetProviders(bundledGit)
 
 	// Test default behavior (should be Ask)
-	res := engine.Evaluate("some.tool", nil)
+	res := engine.Evaluate("some.tool", nil, Caller{})
 	if res.Decision != DecisionAsk {
 		t.Errorf("Expected default decision Ask, got %v", res.Decision)
 	}
+
+	// Read-only bundled git tools are allowed, mutating ones ask
+	if res := engine.Evaluate("mcp.git.diff", nil, Caller{}); res.Decision != DecisionAllow {
+		t.Errorf("Expected Allow for mcp.git.diff, got %v", res.Decision)
+	}
+	for _, tool := range []string{"mcp.git.commit", "mcp.git.add", "mcp.git.statusx"} {
+		if res := engine.Evaluate(tool, nil, Caller{}); res.Decision != DecisionAsk {
+			t.Errorf("Expected Ask for %s, got %v", tool, res.Decision)
+		}
+	}
+
+	// A server that is only named git gets no free reads
+	engine.SetProviders(providerFunc(func(string) string { return "" }))
+	if res := engine.Evaluate("mcp.git.status", nil, Caller{}); res.Decision != DecisionAsk {
+		t.Errorf("Expected Ask for a non-bundled git server, got %v", res.Decision)
+	}
 }
 
 func TestExplicitAllow(t *testing.T) {
@@ -23,12 +54,12 @@ func TestExplicitAllow(t *testing.T) {
 	})
 	engine := NewEngine(p)
 
-	res := engine.Evaluate("safe.read_file", nil)
+	res := engine.Evaluate("safe.read_file", nil, Caller{})
 	if res.Decision != DecisionAllow {
 		t.Errorf("Expected Allow for safe.read_file, got %v", res.Decision)
 	}
 
-	res = engine.Evaluate("dangerous.exec", nil)
+	res = engine.Evaluate("dangerous.exec", nil, Caller{})
 	if res.Decision != DecisionAsk {
 		t.Errorf("Expected Ask for dangerous.exec, got %v", res.Decision)
 	}
@@ -42,38 +73,79 @@ func TestExactMatch(t *testing.T) {
 	})
 	engine := NewEngine(p)
 
-	res := engine.Evaluate("exact.match", nil)
+	res := engine.Evaluate("exact.match", nil, Caller{})
 	if res.Decision != DecisionDeny {
 		t.Errorf("Expected Deny, got %v", res.Decision)
 	}
 }
 
-func TestScopeMatching(t *testing.T) {
+func TestCallerMatching(t *testing.T) {
 	p := NewDefaultPolicy()
 	p.Rules = append(p.Rules, Rule{
 		Tool:        "workspace.*",
-		Scope:       ScopeWorkspace,
+		From:        &CallerMatch{Surface: []string{"tui", "cli"}},
 		Decision:    DecisionAllow,
-		Description: "Allow workspace tools",
+		Description: "Allow workspace tools locally",
 	})
 	engine := NewEngine(p)
 
-	// Test with workspace scope
-	res := engine.Evaluate("workspace.read", map[string]interface{}{"_scope": "workspace"})
+	// Test from the TUI
+	res := engine.Evaluate("workspace.read", nil, Caller{Surface: SurfaceTUI})
 	if res.Decision != DecisionAllow {
-		t.Errorf("Expected Allow for workspace scope, got %v", res.Decision)
+		t.Errorf("Expected Allow from the TUI, got %v", res.Decision)
 	}
 
-	// Test with global scope (should fall back to default)
-	res = engine.Evaluate("workspace.read", map[string]interface{}{"_scope": "global"})
+	// Test from a channel (should fall back to default)
+	res = engine.Evaluate("workspace.read", nil, Caller{Surface: "telegram"})
 	if res.Decision != DecisionAsk {
-		t.Errorf("Expected Ask for global scope with workspace rule, got %v", res.Decision)
+		t.Errorf("Expected Ask from telegram, got %v", res.Decision)
 	}
 
-	// Test with no scope (defaults to global)
-	res = engine.Evaluate("workspace.read", nil)
+	// Arguments cannot claim a surface
+	res = engine.Evaluate("workspace.read", map[string]interface{}{"_scope": "tui", "surface": "tui"}, Caller{Surface: "telegram"})
 	if res.Decision != DecisionAsk {
-		t.Errorf("Expected Ask for no scope with workspace rule, got %v", res.Decision)
+		t.Errorf("Expected Ask for spoofed arguments, got %v", res.Decision)
+	}
+}
+
+func TestTrustMatching(t *testing.T) {
+	p, err := Parse([]byte(`
+default: ask
+rules:
+  - id: strangers-no-shell
+    tool: "mcp.shell.*"
+    decision: deny
+    from: {trust: [untrusted]}
+  - id: known-sender
+    tool: "mcp.web.*"
+    decision: allow
+    from: {source: ["telegram-*"], sender: ["42"]}
+  - tool: "mcp.shell.*"
+    decision: ask
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	engine := NewEngine(p)
+
+	stranger := Caller{Surface: "telegram", Source: "telegram-main", ChannelID: "9", Sender: "7"}
+	cases := []struct {
+		tool   string
+		caller Caller
+		want   Decision
+	}{
+		{"mcp.shell.exec", stranger, DecisionDeny},
+		{"mcp.shell.exec", Caller{Surface: SurfaceTUI}, DecisionAsk},
+		{"mcp.shell.exec", Caller{}, DecisionDeny},
+		{"mcp.shell.exec", Caller{Surface: SurfaceScheduler}, DecisionAsk},
+		{"mcp.shell.exec", Caller{Surface: "telegram", Sender: "42", Trust: TrustTrusted}, DecisionAsk},
+		{"mcp.web.fetch", Caller{Surface: "telegram", Source: "telegram-main", Sender: "42"}, DecisionAllow},
+		{"mcp.web.fetch", stranger, DecisionAsk},
+	}
+	for _, tc := range cases {
+		if got := engine.Evaluate(tc.tool, nil, tc.caller).Decision; got != tc.want {
+			t.Errorf("%s %+v: got %s, want %s", tc.tool, tc.caller, got, tc.want)
+		}
 	}
 }
 
@@ -88,13 +160,13 @@ func TestArgMatching(t *testing.T) {
 	engine := NewEngine(p)
 
 	// Test matching arg
-	res := engine.Evaluate("file.read", map[string]interface{}{"path": "/safe/path"})
+	res := engine.Evaluate("file.read", map[string]interface{}{"path": "/safe/path"}, Caller{})
 	if res.Decision != DecisionAllow {
 		t.Errorf("Expected Allow for matching arg, got %v", res.Decision)
 	}
 
 	// Test non-matching arg
-	res = engine.Evaluate("file.read", map[string]interface{}{"path": "/dangerous/path"})
+	res = engine.Evaluate("file.read", map[string]interface{}{"path": "/dangerous/path"}, Caller{})
 	if res.Decision != DecisionAsk {
 		t.Errorf("Expected Ask for non-matching arg, got %v", res.Decision)
 	}
@@ -111,44 +183,44 @@ func TestArgExistsMatching(t *testing.T) {
 	engine := NewEngine(p)
 
 	// Test with URL present
-	res := engine.Evaluate("network.request", map[string]interface{}{"url": "https://example.com"})
+	res := engine.Evaluate("network.request", map[string]interface{}{"url": "https://example.com"}, Caller{})
 	if res.Decision != DecisionAllow {
 		t.Errorf("Expected Allow when URL exists, got %v", res.Decision)
 	}
 
 	// Test without URL
-	res = engine.Evaluate("network.request", map[string]interface{}{})
+	res = engine.Evaluate("network.request", map[string]interface{}{}, Caller{})
 	if res.Decision != DecisionAsk {
 		t.Errorf("Expected Ask when URL missing, got %v", res.Decision)
 	}
 }
 
-func TestCombinedScopeAndArgMatching(t *testing.T) {
+func TestCombinedCallerAndArgMatching(t *testing.T) {
 	p := NewDefaultPolicy()
 	p.Rules = append(p.Rules, Rule{
 		Tool:        "data.export",
-		Scope:       ScopeWorkspace,
+		From:        &CallerMatch{Trust: []Trust{TrustOwner}},
 		Args:        []ArgMatcher{{Key: "format", Operator: "eq", Value: "json"}},
 		Decision:    DecisionAllow,
-		Description: "Allow JSON exports in workspace",
+		Description: "Allow JSON exports for the owner",
 	})
 	engine := NewEngine(p)
 
-	// Test matching both scope and arg
-	res := engine.Evaluate("data.export", map[string]interface{}{"_scope": "workspace", "format": "json"})
+	// Test matching both caller and arg
+	res := engine.Evaluate("data.export", map[string]interface{}{"format": "json"}, Caller{Surface: SurfaceTUI})
 	if res.Decision != DecisionAllow {
-		t.Errorf("Expected Allow for matching scope and arg, got %v", res.Decision)
+		t.Errorf("Expected Allow for matching caller and arg, got %v", res.Decision)
 	}
 
-	// Test matching scope but not arg
-	res = engine.Evaluate("data.export", map[string]interface{}{"_scope": "workspace", "format": "csv"})
+	// Test matching caller but not arg
+	res = engine.Evaluate("data.export", map[string]interface{}{"format": "csv"}, Caller{Surface: SurfaceTUI})
 	if res.Decision != DecisionAsk {
 		t.Errorf("Expected Ask when arg doesn't match, got %v", res.Decision)
 	}
 
-	// Test matching arg but not scope
-	res = engine.Evaluate("data.export", map[string]interface{}{"_scope": "network", "format": "json"})
+	// Test matching arg but not caller
+	res = engine.Evaluate("data.export", map[string]interface{}{"format": "json"}, Caller{Surface: "discord"})
 	if res.Decision != DecisionAsk {
-		t.Errorf("Expected Ask when scope doesn't match, got %v", res.Decision)
+		t.Errorf("Expected Ask when caller doesn't match, got %v", res.Decision)
 	}
 }
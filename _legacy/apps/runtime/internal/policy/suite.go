@@ -0,0 +1,137 @@
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a list of calls with the decisions the policy must reach for
+// them, for checking policy changes in CI.
+type Suite struct {
+	// Policies are the files under test, from highest to lowest
+	// precedence, relative to the suite file. They are layered over the
+	// built-in policy as the runtime does.
+	Policies []string    `json:"policies,omitempty" yaml:"policies,omitempty"`
+	Cases    []SuiteCase `json:"cases" yaml:"cases"`
+
+	dir string
+}
+
+// SuiteCase is one call and its expected outcome
+type SuiteCase struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	Tool string `json:"tool" yaml:"tool"`
+	// Provider is the implementation serving the tool, such as
+	// "bundled:git"; empty asks the engine.
+	Provider string                 `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Args     map[string]interface{} `json:"args,omitempty" yaml:"args,omitempty"`
+	Caller   Caller                 `json:"caller" yaml:"caller,omitempty"`
+	Expect   Decision               `json:"expect" yaml:"expect"`
+	// Rule, if set, is the ID of the rule that must decide the call;
+	// "default" expects no rule to match.
+	Rule string `json:"rule,omitempty" yaml:"rule,omitempty"`
+}
+
+// CaseResult is the outcome of one suite case
+type CaseResult struct {
+	Case        SuiteCase   `json:"case"`
+	Passed      bool        `json:"passed"`
+	Failure     string      `json:"failure,omitempty"`
+	Explanation Explanation `json:"explanation"`
+}
+
+// LoadSuite reads and checks a suite file.
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Suite{dir: filepath.Dir(path)}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(s); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(s.Cases) == 0 {
+		return nil, fmt.Errorf("suite %s has no cases", path)
+	}
+	var errs []error
+	for i, c := range s.Cases {
+		if c.Tool == "" {
+			errs = append(errs, fmt.Errorf("cases[%d] (%s): tool is required", i, c.Name))
+		}
+		switch c.Expect {
+		case DecisionAllow, DecisionDeny, DecisionAsk:
+		default:
+			errs = append(errs, fmt.Errorf("cases[%d] (%s): expect must be allow, deny or ask", i, c.Name))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Engine builds an engine from the suite's policy files. It returns nil
+// when the suite names none, leaving the choice of policy to the caller.
+func (s *Suite) Engine() (*Engine, error) {
+	if len(s.Policies) == 0 {
+		return nil, nil
+	}
+	layers := make([]*Policy, 0, len(s.Policies)+1)
+	for _, name := range s.Policies {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(s.dir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		p, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %w", path, err)
+		}
+		layers = append(layers, withLayer(p, name))
+	}
+	layers = append(layers, withLayer(NewDefaultPolicy(), LayerBuiltin))
+	return NewEngine(Merge(layers...)), nil
+}
+
+// Run evaluates every case against e.
+func (s *Suite) Run(e *Engine) []CaseResult {
+	results := make([]CaseResult, 0, len(s.Cases))
+	for _, c := range s.Cases {
+		provider := c.Provider
+		if provider == "" {
+			provider = e.providerOf(c.Tool)
+		}
+		x := e.explain(c.Tool, provider, c.Args, c.Caller)
+		res := CaseResult{Case: c, Passed: true, Explanation: x}
+		switch {
+		case x.Decision != c.Expect:
+			res.Failure = fmt.Sprintf("expected %s, got %s", c.Expect, x.Decision)
+		case c.Rule == "default" && !x.Default:
+			res.Failure = fmt.Sprintf("expected the default, got rule %q", x.RuleID)
+		case c.Rule != "" && c.Rule != "default" && x.RuleID != c.Rule:
+			res.Failure = fmt.Sprintf("expected rule %q, got %s", c.Rule, decidedBy(x))
+		}
+		res.Passed = res.Failure == ""
+		results = append(results, res)
+	}
+	return results
+}
+
+// decidedBy names what decided an explanation
+func decidedBy(x Explanation) string {
+	if x.Default {
+		return "the default"
+	}
+	return fmt.Sprintf("rule %q", x.RuleID)
+}
@@ -0,0 +1,98 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Validate checks that every rule can be evaluated as written. Rules that
+// fail here would otherwise silently never match.
+func (p *Policy) Validate() error {
+	var errs []error
+	switch p.Default {
+	case "", DecisionAllow, DecisionDeny, DecisionAsk:
+	default:
+		errs = append(errs, fmt.Errorf("default: unknown decision %q", p.Default))
+	}
+
+	ids := map[string]int{}
+	for i, r := range p.Rules {
+		at := fmt.Sprintf("rules[%d]", i)
+		if r.ID != "" {
+			at = fmt.Sprintf("rules[%d] (%s)", i, r.ID)
+			if j, dup := ids[r.ID]; dup {
+				errs = append(errs, fmt.Errorf("%s: id also used by rules[%d]", at, j))
+			}
+			ids[r.ID] = i
+		}
+		for _, err := range r.validate() {
+			errs = append(errs, fmt.Errorf("%s: %w", at, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r Rule) validate() []error {
+	var errs []error
+	switch {
+	case strings.TrimSpace(r.Tool) == "":
+		errs = append(errs, errors.New("tool is required"))
+	case r.Tool != "*" && !strings.HasSuffix(r.Tool, "*"):
+		if _, err := regexp.Compile(r.Tool); err != nil {
+			errs = append(errs, fmt.Errorf("tool: invalid pattern: %w", err))
+		}
+	}
+	switch r.Decision {
+	case DecisionAllow, DecisionDeny, DecisionAsk:
+	case "":
+		errs = append(errs, errors.New("decision is required"))
+	default:
+		errs = append(errs, fmt.Errorf("decision: unknown decision %q", r.Decision))
+	}
+	if r.From != nil {
+		for _, err := range r.From.validate() {
+			errs = append(errs, fmt.Errorf("from: %w", err))
+		}
+	}
+	for i, m := range r.Args {
+		if err := m.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("args[%d]: %w", i, err))
+		}
+	}
+	if len(r.Limits) > 0 && r.Decision == DecisionDeny {
+		errs = append(errs, errors.New("limits: a deny rule cannot have limits"))
+	}
+	for i, l := range r.Limits {
+		for _, err := range l.validate() {
+			errs = append(errs, fmt.Errorf("limits[%d]: %w", i, err))
+		}
+	}
+	if r.When != nil {
+		for _, err := range r.When.validate() {
+			errs = append(errs, fmt.Errorf("when: %w", err))
+		}
+	}
+	return errs
+}
+
+func (m ArgMatcher) validate() error {
+	if m.Key == "" {
+		return errors.New("key is required")
+	}
+	switch m.Operator {
+	case "exists", "not_exists":
+	case "eq", "neq", "contains":
+		if m.Value == "" {
+			return fmt.Errorf("operator %s needs a value", m.Operator)
+		}
+	case "regex":
+		if _, err := regexp.Compile(m.Value); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown operator %q", m.Operator)
+	}
+	return nil
+}
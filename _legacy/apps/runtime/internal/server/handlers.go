@@ -12,7 +12,9 @@ import (
 
 	"pryx-core/internal/auth"
 	"pryx-core/internal/config"
+	"pryx-core/internal/mcp"
 	"pryx-core/internal/memory"
+	"pryx-core/internal/policy"
 	"pryx-core/internal/skills"
 	"pryx-core/internal/validation"
 
@@ -410,7 +412,10 @@ func (s *Server) handleMCPCall(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := s.mcp.CallTool(r.Context(), strings.TrimSpace(req.SessionID), req.Tool, req.Arguments)
+	// The caller comes from the transport, never from the body: calls on
+	// the local API are the local user's
+	ctx := mcp.WithCaller(r.Context(), policy.Caller{Surface: policy.SurfaceTUI})
+	res, err := s.mcp.CallTool(ctx, strings.TrimSpace(req.SessionID), req.Tool, req.Arguments)
 	if err != nil {
 		w.WriteHeader(http.StatusBadGateway)
 		_ = json.NewEncoder(w).Encode(map[string]any{
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"pryx-core/internal/bus"
 )
 
 type AdminStats struct {
@@ -21,6 +23,9 @@ type AdminStats struct {
 	ProviderBreakdown map[string]ProviderStats `json:"provider_breakdown"`
 	PeriodStart       time.Time                `json:"period_start"`
 	PeriodEnd         time.Time                `json:"period_end"`
+	// Bus reports each bus subscriber's delivery mode, queue depth and
+	// dropped events.
+	Bus bus.Stats `json:"bus"`
 }
 
 type ProviderStats struct {
@@ -115,6 +120,7 @@ func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
 		PeriodStart:       time.Now().AddDate(0, 0, -30),
 		PeriodEnd:         time.Now(),
 		ProviderBreakdown: make(map[string]ProviderStats),
+		Bus:               s.bus.Stats(),
 	}
 
 	if err := s.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&stats.TotalUsers); err != nil && err != sql.ErrNoRows {
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"pryx-core/internal/bus"
+
 	"github.com/go-chi/chi/v5"
 )
 
@@ -132,6 +134,12 @@ func (s *Server) handleSessionDelete(w http.ResponseWriter, r *http.Request) {
 		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
+	if s.bus != nil {
+		s.bus.Publish(bus.NewEvent(bus.EventSessionEnded, sessionID, nil))
+	}
+	if s.mcp != nil {
+		_ = s.mcp.SetSessionRoots(sessionID, nil)
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
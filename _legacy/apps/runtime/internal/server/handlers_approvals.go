@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"pryx-core/internal/mcp"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ResolveApprovalRequest answers a pending tool approval
+type ResolveApprovalRequest struct {
+	Approved   bool   `json:"approved"`
+	Scope      string `json:"scope,omitempty"`
+	TTLSeconds int    `json:"ttl_seconds,omitempty"`
+}
+
+// resolution converts the request into an approval resolution
+func (req ResolveApprovalRequest) resolution() (mcp.ApprovalResolution, error) {
+	scope, err := mcp.ParseApprovalScope(strings.TrimSpace(req.Scope))
+	if err != nil {
+		return mcp.ApprovalResolution{}, err
+	}
+	res := mcp.ApprovalResolution{Approved: req.Approved, Scope: scope}
+	if req.TTLSeconds > 0 {
+		res.TTL = time.Duration(req.TTLSeconds) * time.Second
+	}
+	return res, nil
+}
+
+// handleApprovalResolve answers a pending approval, optionally remembering it
+func (s *Server) handleApprovalResolve(w http.ResponseWriter, r *http.Request) {
+	approvalID := strings.TrimSpace(chi.URLParam(r, "id"))
+
+	var req ResolveApprovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	res, err := req.resolution()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ok, err := s.mcp.ResolveApprovalWith(approvalID, res)
+	if !ok {
+		http.Error(w, "Approval not found", http.StatusNotFound)
+		return
+	}
+
+	resp := map[string]interface{}{"ok": true}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleApprovalGrantsList returns remembered approval decisions
+func (s *Server) handleApprovalGrantsList(w http.ResponseWriter, r *http.Request) {
+	grants, err := s.mcp.ListGrants(r.URL.Query().Get("session_id"))
+	if err != nil {
+		http.Error(w, "Failed to list grants: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"grants": grants,
+		"count":  len(grants),
+	})
+}
+
+// handleApprovalGrantRevoke forgets a remembered approval decision
+func (s *Server) handleApprovalGrantRevoke(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+
+	ok, err := s.mcp.RevokeGrant(id)
+	if err != nil {
+		http.Error(w, "Failed to revoke grant: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "Grant not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
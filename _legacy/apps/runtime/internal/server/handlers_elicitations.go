@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"pryx-core/internal/mcp"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ResolveElicitationRequest answers input an MCP server asked the user for
+type ResolveElicitationRequest struct {
+	Action  string                 `json:"action"`
+	Content map[string]interface{} `json:"content,omitempty"`
+}
+
+// SessionRootsRequest sets the workspace directories of a session
+type SessionRootsRequest struct {
+	Roots []string `json:"roots"`
+}
+
+// handleElicitationResolve accepts, declines or cancels a pending elicitation
+func (s *Server) handleElicitationResolve(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+
+	var req ResolveElicitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := s.mcp.ResolveElicitation(id, mcp.ElicitResult{Action: strings.TrimSpace(req.Action), Content: req.Content})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		http.Error(w, "Elicitation not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true})
+}
+
+// handleSessionRootsGet returns the workspace directories MCP servers see
+// for a session
+func (s *Server) handleSessionRootsGet(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	roots := s.mcp.SessionRoots(id)
+	if roots == nil {
+		roots = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"roots": roots})
+}
+
+// handleSessionRootsSet replaces the workspace directories of a session; an
+// empty list restores the default workspace
+func (s *Server) handleSessionRootsSet(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+
+	var req SessionRootsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := s.mcp.SetSessionRoots(id, req.Roots); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"roots": s.mcp.SessionRoots(id)})
+}
@@ -0,0 +1,262 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/mcp/security"
+	"pryx-core/internal/store"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// EventSubscriptionRequest creates or updates an event subscription. On
+// update, an empty secret keeps the current one and a missing enabled
+// leaves it as is.
+type EventSubscriptionRequest struct {
+	URL       string            `json:"url"`
+	Topics    []string          `json:"topics"`
+	Kinds     []string          `json:"kinds,omitempty"`
+	SessionID string            `json:"session_id,omitempty"`
+	Secret    string            `json:"secret,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Enabled   *bool             `json:"enabled,omitempty"`
+}
+
+// apply validates the request and copies it onto sub
+func (req EventSubscriptionRequest) apply(sub *store.EventSubscription) error {
+	var errs []error
+	u, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("url must be an http or https URL"))
+	} else if isPrivateHost(u.Hostname()) {
+		// Names that resolve to private addresses are refused when
+		// delivering
+		errs = append(errs, fmt.Errorf("url must not point at a private address"))
+	}
+	if len(req.Topics) == 0 {
+		errs = append(errs, fmt.Errorf("topics must name at least one topic or pattern"))
+	}
+	for _, t := range req.Topics {
+		if err := bus.ValidateTopic(bus.EventType(t)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, k := range req.Kinds {
+		if _, err := path.Match(k, ""); err != nil || strings.TrimSpace(k) == "" {
+			errs = append(errs, fmt.Errorf("invalid kind pattern %q", k))
+		}
+	}
+	for name := range req.Headers {
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, fmt.Errorf("empty header name"))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	sub.URL = u.String()
+	sub.Topics = req.Topics
+	sub.Kinds = req.Kinds
+	sub.SessionID = strings.TrimSpace(req.SessionID)
+	sub.Headers = req.Headers
+	if req.Secret != "" {
+		sub.Secret = req.Secret
+	}
+	if req.Enabled != nil {
+		sub.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+// isPrivateHost reports whether a URL host is localhost or a private
+// address
+func isPrivateHost(host string) bool {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && security.IsPrivateIP(ip)
+}
+
+// redacted returns sub without its secret, for listing
+func redacted(sub *store.EventSubscription) *store.EventSubscription {
+	out := *sub
+	out.Secret = ""
+	return &out
+}
+
+// reloadEventHooks applies a change to the subscriptions
+func (s *Server) reloadEventHooks() {
+	if err := s.hooks.Reload(); err != nil {
+		log.Printf("eventhooks: failed to reload subscriptions: %v", err)
+	}
+}
+
+// handleEventSubscriptionsList returns the registered webhooks, without
+// their secrets
+func (s *Server) handleEventSubscriptionsList(w http.ResponseWriter, r *http.Request) {
+	subs, err := s.store.ListEventSubscriptions()
+	if err != nil {
+		http.Error(w, "Failed to list event subscriptions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	out := make([]*store.EventSubscription, len(subs))
+	for i, sub := range subs {
+		out[i] = redacted(sub)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"subscriptions": out,
+		"count":         len(out),
+	})
+}
+
+// handleEventSubscriptionCreate registers a webhook. Deliveries carry the
+// Unix time in X-Webhook-Timestamp and are signed with HMAC-SHA256 of
+// "<timestamp>.<body>" in X-Webhook-Signature; a secret is generated when
+// none is given, and only returned here.
+func (s *Server) handleEventSubscriptionCreate(w http.ResponseWriter, r *http.Request) {
+	var req EventSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	sub := &store.EventSubscription{Enabled: true}
+	if err := req.apply(sub); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if sub.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			http.Error(w, "Failed to generate secret: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sub.Secret = hex.EncodeToString(secret)
+	}
+	if err := s.store.CreateEventSubscription(sub); err != nil {
+		http.Error(w, "Failed to create event subscription: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.reloadEventHooks()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+// handleEventSubscriptionGet returns one webhook, without its secret
+func (s *Server) handleEventSubscriptionGet(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.eventSubscription(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(redacted(sub))
+}
+
+// handleEventSubscriptionUpdate replaces a webhook's settings
+func (s *Server) handleEventSubscriptionUpdate(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.eventSubscription(w, r)
+	if !ok {
+		return
+	}
+	var req EventSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.apply(sub); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	found, err := s.store.UpdateEventSubscription(sub)
+	if err != nil {
+		http.Error(w, "Failed to update event subscription: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Event subscription not found", http.StatusNotFound)
+		return
+	}
+	s.reloadEventHooks()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(redacted(sub))
+}
+
+// handleEventSubscriptionDelete removes a webhook and its delivery log
+func (s *Server) handleEventSubscriptionDelete(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	ok, err := s.store.DeleteEventSubscription(id)
+	if err != nil {
+		http.Error(w, "Failed to delete event subscription: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "Event subscription not found", http.StatusNotFound)
+		return
+	}
+	s.reloadEventHooks()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleEventDeliveriesList returns a webhook's recent deliveries, newest
+// first
+func (s *Server) handleEventDeliveriesList(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.eventSubscription(w, r)
+	if !ok {
+		return
+	}
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, 500)
+	}
+	deliveries, err := s.store.ListEventDeliveries(sub.ID, limit)
+	if err != nil {
+		http.Error(w, "Failed to list deliveries: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*store.EventDelivery{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// eventSubscription looks up the subscription named in the URL, writing
+// the error response when there is none
+func (s *Server) eventSubscription(w http.ResponseWriter, r *http.Request) (*store.EventSubscription, bool) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	sub, err := s.store.GetEventSubscription(id)
+	if err != nil {
+		http.Error(w, "Failed to get event subscription: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if sub == nil {
+		http.Error(w, "Event subscription not found", http.StatusNotFound)
+		return nil, false
+	}
+	return sub, true
+}
@@ -0,0 +1,15 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"pryx-core/internal/bus"
+)
+
+// handleEventSchema returns the JSON schema of the events sent over /ws,
+// with one definition per event type
+func (s *Server) handleEventSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	json.NewEncoder(w).Encode(bus.Schemas())
+}
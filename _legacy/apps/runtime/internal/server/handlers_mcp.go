@@ -1,9 +1,11 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"pryx-core/internal/mcp/discovery"
@@ -207,3 +209,63 @@ func (s *Server) handleMCPDiscoveryRemoveCustom(w http.ResponseWriter, r *http.R
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// handleMCPServersList returns the connection state of every configured MCP server
+func (s *Server) handleMCPServersList(w http.ResponseWriter, r *http.Request) {
+	servers := s.mcp.ServerStates()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"servers": servers,
+		"count":   len(servers),
+	})
+}
+
+// handleMCPCacheStats returns tool result cache hits and misses per server
+func (s *Server) handleMCPCacheStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"servers": s.mcp.CacheStats(),
+	})
+}
+
+// handleMCPServerGet returns the connection state of a single MCP server
+func (s *Server) handleMCPServerGet(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(chi.URLParam(r, "name"))
+
+	state, ok := s.mcp.ServerState(name)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "server not found"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+// handleMCPServerRestart reconnects a single MCP server
+func (s *Server) handleMCPServerRestart(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(chi.URLParam(r, "name"))
+
+	if _, ok := s.mcp.ServerState(name); !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "server not found"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+	err := s.mcp.RestartServer(ctx, name)
+
+	state, _ := s.mcp.ServerState(name)
+	resp := map[string]interface{}{
+		"server": state,
+	}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
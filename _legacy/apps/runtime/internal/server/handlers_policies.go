@@ -0,0 +1,156 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/policy"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxPolicyBytes bounds policy documents accepted over HTTP
+const maxPolicyBytes = 1 << 20
+
+// reportPolicyLoad publishes the outcome of loading the policy files
+func (s *Server) reportPolicyLoad(err error, kind string) {
+	if err != nil {
+		s.bus.Publish(bus.NewEvent(bus.EventErrorOccurred, "", map[string]interface{}{
+			"kind":  "policy.invalid",
+			"error": err.Error(),
+		}))
+		return
+	}
+	s.bus.Publish(bus.NewEvent(bus.EventTraceEvent, "", map[string]interface{}{
+		"kind":  kind,
+		"rules": len(s.policies.Effective().Rules),
+	}))
+}
+
+// policyList summarises the policy layers and the effective policy
+func (s *Server) policyList() map[string]interface{} {
+	effective := s.policies.Effective()
+	return map[string]interface{}{
+		"policies": s.policies.Summaries(),
+		"effective": map[string]interface{}{
+			"rules":   len(effective.Rules),
+			"default": effective.Default,
+		},
+	}
+}
+
+// handlePoliciesList returns the policy layers in precedence order
+func (s *Server) handlePoliciesList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.policyList())
+}
+
+// handlePolicyGet returns the rules of one layer
+func (s *Server) handlePolicyGet(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "effective" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(policy.Layer{ID: id, Exists: true, Policy: s.policies.Effective()})
+		return
+	}
+	layer, ok := s.policies.Layer(id)
+	if !ok {
+		http.Error(w, "Policy not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(layer)
+}
+
+// handlePolicyUpdate replaces the file of the project or user layer with
+// the YAML or JSON body
+func (s *Server) handlePolicyUpdate(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxPolicyBytes))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := s.policies.Write(id, data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	layer, _ := s.policies.Layer(id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(layer)
+}
+
+// handlePolicyValidate checks a policy document without saving it
+func (s *Server) handlePolicyValidate(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxPolicyBytes))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	resp := map[string]interface{}{"valid": true}
+	if _, err := policy.Parse(data); err != nil {
+		resp["valid"] = false
+		resp["error"] = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handlePoliciesReload reads the policy files again
+func (s *Server) handlePoliciesReload(w http.ResponseWriter, r *http.Request) {
+	err := s.policies.Load()
+	s.reportPolicyLoad(err, "policy.reloaded")
+
+	resp := s.policyList()
+	resp["ok"] = err == nil
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handlePolicyEvaluate explains the decision the policy reaches for a tool
+// call made for a caller without making it, along with the effective
+// layered policy. With a session ID it also reports a remembered approval
+// that would answer an ask.
+func (s *Server) handlePolicyEvaluate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Tool      string                 `json:"tool"`
+		Args      map[string]interface{} `json:"args"`
+		SessionID string                 `json:"session_id"`
+		Caller    policy.Caller          `json:"caller"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxPolicyBytes)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Tool = strings.TrimSpace(req.Tool)
+	if req.Tool == "" {
+		http.Error(w, "tool is required", http.StatusBadRequest)
+		return
+	}
+
+	if req.Caller.SessionID == "" {
+		req.Caller.SessionID = req.SessionID
+	}
+	x := s.policies.Engine().Explain(req.Tool, req.Args, req.Caller)
+	resp := s.policyList()
+	resp["explanation"] = x
+	resp["policy"] = s.policies.Effective()
+	if x.Decision == policy.DecisionAsk && req.SessionID != "" && s.mcp != nil {
+		grant, err := s.mcp.FindGrant(req.SessionID, req.Caller.Surface, req.Tool, req.Args)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if grant != nil {
+			resp["grant"] = grant
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
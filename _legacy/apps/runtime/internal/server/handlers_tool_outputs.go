@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pryx-core/internal/mcp"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// handleToolOutputsList returns the retained truncated outputs, optionally
+// filtered by session
+func (s *Server) handleToolOutputsList(w http.ResponseWriter, r *http.Request) {
+	truncator := mcp.GetTruncator()
+	if truncator == nil {
+		http.Error(w, "Tool output storage not available", http.StatusServiceUnavailable)
+		return
+	}
+	outputs, err := truncator.ListOutputs(strings.TrimSpace(r.URL.Query().Get("session_id")))
+	if err != nil {
+		http.Error(w, "Failed to list tool outputs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"outputs": outputs,
+		"count":   len(outputs),
+	})
+}
+
+// handleToolOutputRead returns a window of a truncated output, selected by
+// the offset, limit and grep query parameters
+func (s *Server) handleToolOutputRead(w http.ResponseWriter, r *http.Request) {
+	truncator := mcp.GetTruncator()
+	if truncator == nil {
+		http.Error(w, "Tool output storage not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	opts := mcp.ReadOptions{Grep: q.Get("grep")}
+	for key, dst := range map[string]*int{"offset": &opts.Offset, "limit": &opts.Limit} {
+		if v := q.Get(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, "Invalid "+key, http.StatusBadRequest)
+				return
+			}
+			*dst = n
+		}
+	}
+
+	page, err := truncator.ReadOutput(strings.TrimSpace(chi.URLParam(r, "id")), opts)
+	if errors.Is(err, mcp.ErrOutputNotFound) {
+		http.Error(w, "Tool output not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// cleanupToolOutputs removes truncated outputs older than
+// mcp.RetentionPeriod, once at startup and then hourly until ctx is done
+func cleanupToolOutputs(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if err := mcp.Cleanup(); err != nil {
+			log.Printf("mcp: tool output cleanup failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
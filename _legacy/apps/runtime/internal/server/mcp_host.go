@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"path/filepath"
+
+	"pryx-core/internal/audit"
+	"pryx-core/internal/bus"
+	"pryx-core/internal/config"
+	"pryx-core/internal/keychain"
+	"pryx-core/internal/mcp"
+	"pryx-core/internal/memory"
+	"pryx-core/internal/policy"
+	"pryx-core/internal/scheduler"
+	"pryx-core/internal/store"
+)
+
+// NewMCPHost builds the part of the runtime `pryx-core mcp serve` exposes:
+// the MCP manager with pryx's native tools, policy, limits and remembered
+// grants. It usually runs next to a full runtime on the same database, so it
+// keeps no event journal, serves no routes and starts no channels, agents or
+// schedules. Nothing can answer approval prompts, so calls the policy asks
+// about are denied. Background work stops when ctx is done.
+//
+// Servers are not connected; call MCP().LoadAndConnect.
+func NewMCPHost(ctx context.Context, cfg *config.Config, db *sql.DB, kc *keychain.Keychain) *Server {
+	p := policy.NewEngine(nil)
+
+	s := &Server{
+		cfg:      cfg,
+		db:       db,
+		keychain: kc,
+		bus:      bus.New(),
+	}
+	s.store = store.NewFromDB(db)
+	p.SetCounters(s.store)
+	s.auditRepo = audit.NewAuditRepository(db)
+	go audit.NewRecorder(s.auditRepo, s.bus).Run(ctx)
+
+	s.policies = policy.NewDefaultLoader(p)
+	if err := s.policies.Load(); err != nil {
+		log.Printf("policy: load failed, using built-in policy: %v", err)
+	}
+	go s.policies.Watch(ctx, func(err error) {
+		if err != nil {
+			log.Printf("policy: reload failed: %v", err)
+		}
+	})
+
+	s.mcp = mcp.NewManager(s.bus, p, kc)
+	s.mcp.SetGrantStore(s.store)
+	s.mcp.DisableApprovals()
+	if err := s.mcp.RegisterProvider(nativeServerName, &nativeTools{s: s}); err != nil {
+		log.Printf("mcp: failed to register native tools: %v", err)
+	}
+	mcp.InitTruncator(filepath.Dir(cfg.DatabasePath))
+
+	s.scheduler = scheduler.New(db)
+	s.ragMemory = memory.NewRAGManager(db, cfg.MemoryEnabled)
+	return s
+}
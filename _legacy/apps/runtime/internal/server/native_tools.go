@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"pryx-core/internal/mcp"
+	"pryx-core/internal/memory"
+	"pryx-core/internal/scheduler"
+)
+
+// nativeServerName is the MCP server name pryx's own tools are served as
+const nativeServerName = "pryx"
+
+// nativeTools exposes runtime capabilities (memory, sessions, scheduler,
+// truncated tool output, agent spawning) as an in-process MCP provider.
+// Subsystems are looked up at call time because some of them are attached
+// after New returns.
+type nativeTools struct {
+	s *Server
+}
+
+func (p *nativeTools) ServerInfo() map[string]interface{} {
+	return map[string]interface{}{
+		"name":    "pryx-core/native",
+		"title":   "Pryx Runtime",
+		"version": "dev",
+	}
+}
+
+func (p *nativeTools) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	_ = ctx
+	return []mcp.Tool{
+		{Name: "memory_search", Title: "Search Memory", Description: "Full-text search over pryx long-term and daily memory", InputSchema: json.RawMessage(`{"type":"object","properties":{"query":{"type":"string"},"limit":{"type":"integer","minimum":1,"maximum":100}},"required":["query"],"additionalProperties":false}`)},
+		{Name: "session_history", Title: "Session History", Description: "Return the most recent messages of the calling chat session", InputSchema: json.RawMessage(`{"type":"object","properties":{"session_id":{"type":"string"},"limit":{"type":"integer","minimum":1,"maximum":500}},"additionalProperties":false}`)},
+		{Name: "scheduler_list", Title: "List Scheduled Tasks", InputSchema: json.RawMessage(`{"type":"object","properties":{"user_id":{"type":"string"}},"additionalProperties":false}`)},
+		{Name: "scheduler_create", Title: "Create Scheduled Task", InputSchema: json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"},"description":{"type":"string"},"cron_expression":{"type":"string"},"task_type":{"type":"string","enum":["message","workflow","reminder","webhook"]},"payload":{"type":"string"},"timezone":{"type":"string"}},"required":["name","cron_expression","task_type"],"additionalProperties":false}`)},
+		{Name: "read_tool_output", Title: "Read Tool Output", Description: "Page through or grep the full output of a tool call that was truncated", InputSchema: json.RawMessage(`{"type":"object","properties":{"output_id":{"type":"string"},"offset":{"type":"integer","minimum":0},"limit":{"type":"integer","minimum":1,"maximum":2000},"grep":{"type":"string"}},"required":["output_id"],"additionalProperties":false}`)},
+		{Name: "spawn_agent", Title: "Spawn Agent", Description: "Spawn a sub-agent to work on a task", InputSchema: json.RawMessage(`{"type":"object","properties":{"task":{"type":"string"},"context":{"type":"string"}},"required":["task"]}`)},
+	}, nil
+}
+
+func (p *nativeTools) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (mcp.ToolResult, error) {
+	switch name {
+	case "memory_search":
+		return p.memorySearch(ctx, arguments)
+	case "session_history":
+		return p.sessionHistory(ctx, arguments)
+	case "scheduler_list":
+		return p.schedulerList(arguments)
+	case "scheduler_create":
+		return p.schedulerCreate(arguments)
+	case "read_tool_output":
+		return p.readToolOutput(ctx, arguments)
+	case "spawn_agent":
+		return p.spawnAgent(ctx, arguments)
+	default:
+		return mcp.ToolResult{}, errors.New("unknown tool")
+	}
+}
+
+func (p *nativeTools) memorySearch(ctx context.Context, args map[string]interface{}) (mcp.ToolResult, error) {
+	if p.s.ragMemory == nil || !p.s.ragMemory.Enabled() {
+		return mcp.ToolResult{}, errors.New("memory is disabled")
+	}
+	query := strings.TrimSpace(nativeArgString(args, "query"))
+	if query == "" {
+		return mcp.ToolResult{}, errors.New("missing query")
+	}
+	results, err := p.s.ragMemory.Search(ctx, query, memory.SearchOptions{
+		Limit:      nativeArgInt(args, "limit", 10),
+		IncludeFTS: true,
+	})
+	if err != nil {
+		return mcp.ToolResult{}, err
+	}
+	return nativeResult(fmt.Sprintf("%d results", len(results)), map[string]interface{}{"results": results})
+}
+
+func (p *nativeTools) sessionHistory(ctx context.Context, args map[string]interface{}) (mcp.ToolResult, error) {
+	// Callers only see their own session's history.
+	sessionID := mcp.SessionIDFromContext(ctx)
+	if requested := strings.TrimSpace(nativeArgString(args, "session_id")); requested != "" && requested != sessionID {
+		return mcp.ToolResult{}, errors.New("session not found")
+	}
+	if sessionID == "" {
+		return mcp.ToolResult{}, errors.New("missing session_id")
+	}
+	messages, err := p.s.store.GetRecentMessages(sessionID, nativeArgInt(args, "limit", 50))
+	if err != nil {
+		return mcp.ToolResult{}, err
+	}
+	return nativeResult(fmt.Sprintf("%d messages", len(messages)), map[string]interface{}{"messages": messages})
+}
+
+func (p *nativeTools) schedulerList(args map[string]interface{}) (mcp.ToolResult, error) {
+	if p.s.scheduler == nil {
+		return mcp.ToolResult{}, errors.New("scheduler not available")
+	}
+	tasks, err := p.s.scheduler.ListTasks(nativeArgString(args, "user_id"))
+	if err != nil {
+		return mcp.ToolResult{}, err
+	}
+	return nativeResult(fmt.Sprintf("%d tasks", len(tasks)), map[string]interface{}{"tasks": tasks})
+}
+
+func (p *nativeTools) schedulerCreate(args map[string]interface{}) (mcp.ToolResult, error) {
+	if p.s.scheduler == nil {
+		return mcp.ToolResult{}, errors.New("scheduler not available")
+	}
+	expr := nativeArgString(args, "cron_expression")
+	if err := scheduler.ValidateCronExpression(expr); err != nil {
+		return mcp.ToolResult{}, fmt.Errorf("invalid cron expression: %w", err)
+	}
+	task := &scheduler.ScheduledTask{
+		Name:           nativeArgString(args, "name"),
+		Description:    nativeArgString(args, "description"),
+		CronExpression: expr,
+		TaskType:       scheduler.TaskType(nativeArgString(args, "task_type")),
+		Payload:        nativeArgString(args, "payload"),
+		Timezone:       nativeArgString(args, "timezone"),
+		Enabled:        true,
+	}
+	switch task.TaskType {
+	case scheduler.TaskTypeMessage, scheduler.TaskTypeWorkflow, scheduler.TaskTypeReminder, scheduler.TaskTypeWebhook:
+	default:
+		return mcp.ToolResult{}, fmt.Errorf("invalid task type: %s", task.TaskType)
+	}
+	if err := p.s.scheduler.CreateTask(task); err != nil {
+		return mcp.ToolResult{}, err
+	}
+	return nativeResult("created task "+task.ID, task)
+}
+
+func (p *nativeTools) readToolOutput(ctx context.Context, args map[string]interface{}) (mcp.ToolResult, error) {
+	truncator := mcp.GetTruncator()
+	if truncator == nil {
+		return mcp.ToolResult{}, errors.New("tool output storage not available")
+	}
+	id := strings.TrimSpace(nativeArgString(args, "output_id"))
+	meta, err := truncator.Meta(id)
+	if err != nil {
+		return mcp.ToolResult{}, err
+	}
+	// Outputs are only readable from the session that produced them.
+	if sessionID := mcp.SessionIDFromContext(ctx); meta.SessionID != "" && sessionID != "" && meta.SessionID != sessionID {
+		return mcp.ToolResult{}, mcp.ErrOutputNotFound
+	}
+	page, err := truncator.ReadOutput(id, mcp.ReadOptions{
+		Offset: nativeArgInt(args, "offset", 0),
+		Limit:  nativeArgInt(args, "limit", mcp.DefaultReadLimit),
+		Grep:   nativeArgString(args, "grep"),
+	})
+	if err != nil {
+		return mcp.ToolResult{}, err
+	}
+	return nativeResult(page.Text(), page)
+}
+
+func (p *nativeTools) spawnAgent(ctx context.Context, args map[string]interface{}) (mcp.ToolResult, error) {
+	if p.s.spawnTool == nil {
+		return mcp.ToolResult{}, errors.New("agent spawning not available")
+	}
+	params, err := json.Marshal(args)
+	if err != nil {
+		return mcp.ToolResult{}, err
+	}
+	res, err := p.s.spawnTool.Execute(ctx, params, mcp.SessionIDFromContext(ctx))
+	if err != nil {
+		return mcp.ToolResult{}, err
+	}
+	return nativeResult("agent spawned", res)
+}
+
+func nativeResult(summary string, structured interface{}) (mcp.ToolResult, error) {
+	b, err := json.Marshal(structured)
+	if err != nil {
+		return mcp.ToolResult{}, err
+	}
+	return mcp.ToolResult{
+		Content:           []mcp.ToolContent{{Type: "text", Text: summary}},
+		StructuredContent: b,
+	}, nil
+}
+
+func nativeArgString(args map[string]interface{}, key string) string {
+	s, _ := args[key].(string)
+	return s
+}
+
+func nativeArgInt(args map[string]interface{}, key string, def int) int {
+	switch v := args[key].(type) {
+	case float64:
+		if v > 0 {
+			return int(v)
+		}
+	case int:
+		if v > 0 {
+			return v
+		}
+	}
+	return def
+}
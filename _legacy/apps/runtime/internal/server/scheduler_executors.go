@@ -6,9 +6,22 @@ import (
 	"fmt"
 
 	"pryx-core/internal/bus"
+	"pryx-core/internal/mcp"
+	"pryx-core/internal/policy"
 	"pryx-core/internal/scheduler"
 )
 
+// schedulerCaller runs a task with the scheduler as the caller of any tool
+// it calls, so policies see scheduled work as such
+type schedulerCaller struct {
+	next scheduler.TaskExecutor
+}
+
+func (e schedulerCaller) Execute(ctx context.Context, task *scheduler.ScheduledTask) (string, error) {
+	ctx = mcp.WithCaller(ctx, policy.Caller{Surface: policy.SurfaceScheduler, SessionID: "scheduler-" + task.ID})
+	return e.next.Execute(ctx, task)
+}
+
 type taskEventExecutor struct {
 	bus *bus.Bus
 }
@@ -49,7 +62,7 @@ func (s *Server) registerSchedulerExecutors() {
 		return
 	}
 
-	executor := &taskEventExecutor{bus: s.bus}
+	executor := schedulerCaller{&taskEventExecutor{bus: s.bus}}
 	s.scheduler.RegisterExecutor(scheduler.TaskTypeMessage, executor)
 	s.scheduler.RegisterExecutor(scheduler.TaskTypeWorkflow, executor)
 	s.scheduler.RegisterExecutor(scheduler.TaskTypeReminder, executor)
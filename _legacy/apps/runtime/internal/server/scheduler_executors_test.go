@@ -0,0 +1,32 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"pryx-core/internal/mcp"
+	"pryx-core/internal/policy"
+	"pryx-core/internal/scheduler"
+)
+
+type callerRecorder struct {
+	caller policy.Caller
+}
+
+func (r *callerRecorder) Execute(ctx context.Context, task *scheduler.ScheduledTask) (string, error) {
+	r.caller = mcp.CallerFromContext(ctx, "")
+	return "", nil
+}
+
+func TestSchedulerCaller(t *testing.T) {
+	rec := &callerRecorder{}
+	if _, err := (schedulerCaller{rec}).Execute(context.Background(), &scheduler.ScheduledTask{ID: "t1"}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if rec.caller.Surface != policy.SurfaceScheduler || rec.caller.SessionID != "scheduler-t1" {
+		t.Errorf("unexpected caller: %+v", rec.caller)
+	}
+	if policy.TrustFor(rec.caller.Surface) != policy.TrustTrusted {
+		t.Errorf("expected scheduled work to be trusted")
+	}
+}
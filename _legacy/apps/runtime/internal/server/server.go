@@ -19,6 +19,7 @@ import (
 	"pryx-core/internal/channels"
 	"pryx-core/internal/config"
 	"pryx-core/internal/cost"
+	"pryx-core/internal/eventhooks"
 	"pryx-core/internal/keychain"
 	"pryx-core/internal/mcp"
 	"pryx-core/internal/mcp/discovery"
@@ -60,6 +61,7 @@ type Server struct {
 	bus          *bus.Bus
 	agentbus     *agentbus.Service
 	mcp          *mcp.Manager
+	policies     *policy.Loader
 	mcpDiscovery *discovery.DiscoveryService
 	skills       *skills.Registry
 	catalog      *models.Catalog
@@ -67,6 +69,7 @@ type Server struct {
 	ragMemory    *memory.RAGManager
 	store        *store.Store
 	auditRepo    *audit.AuditRepository
+	hooks        *eventhooks.Dispatcher
 	costService  *cost.CostService
 	channels     *channels.ChannelManager
 	scheduler    *scheduler.Scheduler
@@ -75,6 +78,12 @@ type Server struct {
 
 	httpMu     sync.Mutex
 	httpServer *http.Server
+
+	// ctx bounds the server's background loops and is cancelled by Shutdown,
+	// which then waits for the loops started with goLoop
+	ctx    context.Context
+	cancel context.CancelFunc
+	loops  sync.WaitGroup
 }
 
 // New creates a new Server instance with the provided configuration and dependencies.
@@ -95,8 +104,33 @@ func New(cfg *config.Config, db *sql.DB, kc *keychain.Keychain) *Server {
 		router:   r,
 		bus:      bus.New(),
 	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
 	s.store = store.NewFromDB(db)
+	p.SetCounters(s.store)
+	if cfg.EventJournalEnabled {
+		if err := s.bus.SetJournal(s.store); err != nil {
+			log.Printf("bus: event journal disabled: %v", err)
+		} else {
+			retention := bus.Retention{
+				MaxAge:    cfg.EventJournalMaxAge,
+				MaxEvents: cfg.EventJournalMaxEvents,
+			}
+			s.goLoop(func(ctx context.Context) { s.bus.RetainJournal(ctx, retention) })
+		}
+	}
 	s.auditRepo = audit.NewAuditRepository(db)
+	s.goLoop(audit.NewRecorder(s.auditRepo, s.bus).Run)
+	s.hooks = eventhooks.NewDispatcher(s.store, s.bus)
+	s.goLoop(s.hooks.Run)
+
+	// A policy file that fails to load leaves the built-in policy in force
+	s.policies = policy.NewDefaultLoader(p)
+	s.reportPolicyLoad(s.policies.Load(), "policy.loaded")
+	s.goLoop(func(ctx context.Context) {
+		s.policies.Watch(ctx, func(err error) {
+			s.reportPolicyLoad(err, "policy.reloaded")
+		})
+	})
 
 	pricingMgr := cost.NewPricingManager()
 	costTracker := cost.NewCostTracker(s.auditRepo, pricingMgr)
@@ -122,9 +156,14 @@ func New(cfg *config.Config, db *sql.DB, kc *keychain.Keychain) *Server {
 	}
 
 	s.mcp = mcp.NewManager(s.bus, p, kc)
+	s.mcp.SetGrantStore(s.store)
+	if err := s.mcp.RegisterProvider(nativeServerName, &nativeTools{s: s}); err != nil {
+		log.Printf("mcp: failed to register native tools: %v", err)
+	}
 
 	dataDir := filepath.Dir(cfg.DatabasePath)
 	mcp.InitTruncator(dataDir)
+	go cleanupToolOutputs(s.ctx)
 
 	// Initialize agentbus (agent connectivity hub)
 	s.agentbus = agentbus.NewService(s.bus, agentbus.HubConfig{
@@ -157,25 +196,12 @@ func New(cfg *config.Config, db *sql.DB, kc *keychain.Keychain) *Server {
 			"kind": "agentbus.started",
 		}))
 	}()
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		path, err := s.mcp.LoadAndConnect(ctx)
-		if err != nil {
-			s.bus.Publish(bus.NewEvent(bus.EventErrorOccurred, "", map[string]interface{}{
-				"kind":  "mcp.connect_failed",
-				"error": err.Error(),
-				"path":  path,
-			}))
-			return
-		}
-		if path != "" {
-			s.bus.Publish(bus.NewEvent(bus.EventTraceEvent, "", map[string]interface{}{
-				"kind": "mcp.connected",
-				"path": path,
-			}))
-		}
-	}()
+	s.goLoop(func(ctx context.Context) {
+		s.connectMCP(ctx)
+		// Servers are managed independently, so keep watching the config
+		// even when some of them failed to connect.
+		s.mcp.WatchConfig(ctx)
+	})
 
 	s.routes()
 
@@ -185,11 +211,56 @@ func New(cfg *config.Config, db *sql.DB, kc *keychain.Keychain) *Server {
 	return s
 }
 
+// connectMCP connects the configured MCP servers and reports the outcome
+func (s *Server) connectMCP(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	path, err := s.mcp.LoadAndConnect(ctx)
+	if err != nil {
+		s.bus.Publish(bus.NewEvent(bus.EventErrorOccurred, "", map[string]interface{}{
+			"kind":  "mcp.connect_failed",
+			"error": err.Error(),
+			"path":  path,
+		}))
+		return
+	}
+	if path != "" {
+		s.bus.Publish(bus.NewEvent(bus.EventTraceEvent, "", map[string]interface{}{
+			"kind": "mcp.connected",
+			"path": path,
+		}))
+	}
+}
+
 func (s *Server) routes() {
 	s.router.Get("/health", s.handleHealth)
 	s.router.Get("/ws", s.handleWS)
 	s.router.Get("/mcp/tools", s.handleMCPTools)
 	s.router.Post("/mcp/tools/call", s.handleMCPCall)
+	s.router.Get("/mcp/servers", s.handleMCPServersList)
+	s.router.Get("/mcp/servers/{name}", s.handleMCPServerGet)
+	s.router.Post("/mcp/servers/{name}/restart", s.handleMCPServerRestart)
+	s.router.Get("/mcp/cache", s.handleMCPCacheStats)
+	s.router.Post("/api/v1/approvals/{id}/resolve", s.handleApprovalResolve)
+	s.router.Get("/api/v1/approvals/grants", s.handleApprovalGrantsList)
+	s.router.Delete("/api/v1/approvals/grants/{id}", s.handleApprovalGrantRevoke)
+	s.router.Post("/api/v1/elicitations/{id}/resolve", s.handleElicitationResolve)
+	s.router.Get("/api/v1/events/schema", s.handleEventSchema)
+	s.router.Get("/api/v1/event-subscriptions", s.handleEventSubscriptionsList)
+	s.router.Post("/api/v1/event-subscriptions", s.handleEventSubscriptionCreate)
+	s.router.Get("/api/v1/event-subscriptions/{id}", s.handleEventSubscriptionGet)
+	s.router.Put("/api/v1/event-subscriptions/{id}", s.handleEventSubscriptionUpdate)
+	s.router.Delete("/api/v1/event-subscriptions/{id}", s.handleEventSubscriptionDelete)
+	s.router.Get("/api/v1/event-subscriptions/{id}/deliveries", s.handleEventDeliveriesList)
+
+	s.router.Get("/api/v1/policies", s.handlePoliciesList)
+	s.router.Post("/api/v1/policies/validate", s.handlePolicyValidate)
+	s.router.Post("/api/v1/policies/reload", s.handlePoliciesReload)
+	s.router.Post("/api/v1/policies/evaluate", s.handlePolicyEvaluate)
+	s.router.Get("/api/v1/policies/{id}", s.handlePolicyGet)
+	s.router.Put("/api/v1/policies/{id}", s.handlePolicyUpdate)
+	s.router.Get("/api/v1/tool-outputs", s.handleToolOutputsList)
+	s.router.Get("/api/v1/tool-outputs/{id}", s.handleToolOutputRead)
 	s.router.Get("/mcp/discovery/curated", s.handleMCPDiscoveryCurated)
 	s.router.Get("/mcp/discovery/categories", s.handleMCPDiscoveryCategories)
 	s.router.Get("/mcp/discovery/curated/{id}", s.handleMCPDiscoveryServer)
@@ -223,6 +294,8 @@ func (s *Server) routes() {
 	s.router.Post("/api/v1/sessions", s.handleSessionCreate)
 	s.router.Get("/api/v1/sessions/{id}", s.handleSessionGet)
 	s.router.Delete("/api/v1/sessions/{id}", s.handleSessionDelete)
+	s.router.Get("/api/v1/sessions/{id}/roots", s.handleSessionRootsGet)
+	s.router.Put("/api/v1/sessions/{id}/roots", s.handleSessionRootsSet)
 	s.router.Post("/api/v1/sessions/fork", s.handleSessionFork)
 
 	s.router.Get("/api/v1/memory", s.handleMemoryList)
@@ -284,6 +357,11 @@ func (s *Server) MCP() *mcp.Manager {
 	return s.mcp
 }
 
+// Policies returns the loader of the layered tool policy.
+func (s *Server) Policies() *policy.Loader {
+	return s.policies
+}
+
 // Agents returns the agent bus service instance.
 func (s *Server) Agents() *agentbus.Service {
 	return s.agentbus
@@ -370,6 +448,16 @@ func (s *Server) Serve(l net.Listener) error {
 
 // Shutdown gracefully shuts down the server.
 func (s *Server) Shutdown(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	// Deferred in reverse: the loops stop, then MCP, then the bus writes
+	// what it still holds for the journal before the caller closes the DB
+	defer s.bus.Close()
+	if s.mcp != nil {
+		defer s.mcp.Close()
+	}
+	defer s.waitLoops(ctx)
 	s.httpMu.Lock()
 	srv := s.httpServer
 	s.httpMu.Unlock()
@@ -379,6 +467,29 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	return srv.Shutdown(ctx)
 }
 
+// goLoop runs fn in the background with the server's context
+func (s *Server) goLoop(fn func(ctx context.Context)) {
+	s.loops.Add(1)
+	go func() {
+		defer s.loops.Done()
+		fn(s.ctx)
+	}()
+}
+
+// waitLoops waits for the background loops to return, or for ctx to end
+func (s *Server) waitLoops(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.loops.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("server: shutdown did not wait for background loops: %v", ctx.Err())
+	}
+}
+
 // SetCatalog sets the model catalog for the server.
 func (s *Server) SetCatalog(catalog *models.Catalog) {
 	s.catalog = catalog
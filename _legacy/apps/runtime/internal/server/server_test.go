@@ -17,6 +17,7 @@ import (
 
 	"pryx-core/internal/config"
 	"pryx-core/internal/keychain"
+	"pryx-core/internal/mcp"
 	"pryx-core/internal/skills"
 	"pryx-core/internal/store"
 
@@ -295,6 +296,27 @@ func TestHandleMCPTools(t *testing.T) {
 	assert.Contains(t, response, "tools")
 }
 
+func TestHandleMCPServers(t *testing.T) {
+	cfg := &config.Config{ListenAddr: ":0"}
+	s, _ := store.New(":memory:")
+	defer s.Close()
+	kc := newTestKeychain(t)
+
+	server := New(cfg, s.DB, kc)
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest("GET", "/mcp/servers", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Contains(t, response, "servers")
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest("POST", "/mcp/servers/does-not-exist/restart", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
 func TestHandleMCPCall_InvalidJSON(t *testing.T) {
 	cfg := &config.Config{ListenAddr: ":0"}
 	s, _ := store.New(":memory:")
@@ -835,3 +857,129 @@ func BenchmarkHandleHealth(b *testing.B) {
 		server.handleHealth(rec, req)
 	}
 }
+
+func TestToolOutputs(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{ListenAddr: ":0", DatabasePath: filepath.Join(dir, "pryx.db")}
+	s, err := store.New(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+	server := New(cfg, s.DB, newTestKeychain(t))
+
+	res := mcp.TruncateToolResultFor(mcp.ToolResult{Content: []mcp.ToolContent{{
+		Type: "text",
+		Text: strings.Repeat("row\n", mcp.MaxLines) + "needle",
+	}}}, mcp.OutputMeta{SessionID: "s1", Tool: "mcp.fs.read"})
+	require.Contains(t, res.Content[0].Text, "output truncated")
+
+	outputs, err := mcp.GetTruncator().ListOutputs("s1")
+	require.NoError(t, err)
+	require.Len(t, outputs, 1)
+	id := outputs[0].ID
+
+	native := &nativeTools{s: server}
+	out, err := native.CallTool(mcp.WithSessionID(context.Background(), "s1"), "read_tool_output", map[string]interface{}{"output_id": id, "grep": "needle"})
+	require.NoError(t, err)
+	assert.Contains(t, out.Content[0].Text, "needle")
+
+	_, err = native.CallTool(mcp.WithSessionID(context.Background(), "s2"), "read_tool_output", map[string]interface{}{"output_id": id})
+	assert.ErrorIs(t, err, mcp.ErrOutputNotFound)
+
+	req := httptest.NewRequest("GET", "/api/v1/tool-outputs/"+id+"?offset=2000", nil)
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page mcp.OutputPage
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Content, 1)
+	assert.Equal(t, "needle", page.Content[0].Text)
+	assert.Equal(t, 2001, page.Content[0].Number)
+
+	req = httptest.NewRequest("GET", "/api/v1/tool-outputs/tool_1_1", nil)
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestNativeSessionHistoryScoped(t *testing.T) {
+	cfg := &config.Config{ListenAddr: ":0", DatabasePath: ":memory:"}
+	st, err := store.New(":memory:")
+	require.NoError(t, err)
+	defer st.Close()
+	server := New(cfg, st.DB, newTestKeychain(t))
+
+	mine, err := st.CreateSession("mine")
+	require.NoError(t, err)
+	other, err := st.CreateSession("other")
+	require.NoError(t, err)
+	_, err = st.AddMessage(mine.ID, store.RoleUser, "hello")
+	require.NoError(t, err)
+	_, err = st.AddMessage(other.ID, store.RoleUser, "secret")
+	require.NoError(t, err)
+
+	native := &nativeTools{s: server}
+	ctx := mcp.WithSessionID(context.Background(), mine.ID)
+	out, err := native.CallTool(ctx, "session_history", map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Contains(t, string(out.StructuredContent), "hello")
+
+	_, err = native.CallTool(ctx, "session_history", map[string]interface{}{"session_id": other.ID})
+	assert.Error(t, err)
+}
+
+func TestHandleEventSubscriptions(t *testing.T) {
+	cfg := &config.Config{ListenAddr: ":0", DatabasePath: ":memory:"}
+	st, err := store.New(":memory:")
+	require.NoError(t, err)
+	defer st.Close()
+	server := New(cfg, st.DB, newTestKeychain(t))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("POST", "/api/v1/event-subscriptions", `{"url": "ftp://example.com", "topics": ["tool.[x"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "url must be")
+	assert.Contains(t, rec.Body.String(), "invalid pattern")
+
+	for _, target := range []string{"http://localhost:8080/x", "http://127.0.0.1/x", "http://10.0.0.5/x", "http://169.254.169.254/latest/meta-data", "http://[::1]/x"} {
+		rec = do("POST", "/api/v1/event-subscriptions", `{"url": "`+target+`", "topics": ["error.*"]}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+		assert.Contains(t, rec.Body.String(), "private address", target)
+	}
+	rec = do("POST", "/api/v1/event-subscriptions", `{"url": "https://ci.example.com/hook", "topics": []}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "at least one topic")
+
+	rec = do("POST", "/api/v1/event-subscriptions", `{"url": "https://ci.example.com/hook", "topics": ["approval.needed", "error.*"]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created store.EventSubscription
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.True(t, created.Enabled)
+	assert.Len(t, created.Secret, 64, "expected a generated secret")
+
+	rec = do("GET", "/api/v1/event-subscriptions/"+created.ID, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), created.Secret)
+
+	rec = do("PUT", "/api/v1/event-subscriptions/"+created.ID, `{"url": "https://ci.example.com/v2", "topics": ["trace.event"], "kinds": ["scheduler.*"], "enabled": false}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	updated, err := st.GetEventSubscription(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://ci.example.com/v2", updated.URL)
+	assert.Equal(t, []string{"scheduler.*"}, updated.Kinds)
+	assert.False(t, updated.Enabled)
+	assert.Equal(t, created.Secret, updated.Secret, "expected the secret to be kept")
+
+	rec = do("GET", "/api/v1/event-subscriptions/"+created.ID+"/deliveries", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"count":0`)
+
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/api/v1/event-subscriptions/"+created.ID, "").Code)
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/v1/event-subscriptions/"+created.ID, "").Code)
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/api/v1/event-subscriptions/"+created.ID, "").Code)
+}
@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"pryx-core/internal/bus"
+	"pryx-core/internal/mcp"
 	"pryx-core/internal/validation"
 
 	"golang.org/x/time/rate"
@@ -155,12 +157,29 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 		topics = append(topics, bus.EventType(ev))
 	}
 
+	// A reconnecting client passes the version of the last event it saw
+	// to receive what it missed from the journal
+	since := -1
+	if v := strings.TrimSpace(query.Get("since")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return
+		}
+		since = n
+	}
+
+	// Event filters may be patterns such as tool.*
+	filter := bus.Filter{Topics: topics, SessionID: sessionFilter}
 	var events <-chan bus.Event
 	var cancel func()
-	if len(topics) == 0 {
-		events, cancel = s.bus.Subscribe()
-	} else {
-		events, cancel = s.bus.Subscribe(topics...)
+	replay := false
+	if since >= 0 {
+		var err error
+		events, cancel, err = s.bus.SubscribeFilterFrom(since, filter)
+		replay = err == nil
+	}
+	if !replay {
+		events, cancel = s.bus.SubscribeFilter(filter)
 	}
 	defer cancel()
 
@@ -180,6 +199,7 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 		"kind":        "ws.connected",
 		"remote_addr": r.RemoteAddr,
 		"surface":     surface,
+		"replay":      replay,
 	}))
 
 	// Use buffered channel for event distribution
@@ -205,7 +225,15 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 				if !ok {
 					return
 				}
-				if sessionFilter != "" && evt.SessionID != sessionFilter {
+				if replay {
+					// The replay subscription makes up for its own lag
+					// from the journal, so waiting here loses nothing,
+					// while dropping would leave the client a silent gap
+					select {
+					case eventCh <- evt:
+					case <-ctx.Done():
+						return
+					}
 					continue
 				}
 				select {
@@ -271,6 +299,12 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 			Payload    map[string]interface{} `json:"payload"`
 			ApprovalID string                 `json:"approval_id"`
 			Approved   bool                   `json:"approved"`
+			Scope      string                 `json:"scope"`
+			TTLSeconds int                    `json:"ttl_seconds"`
+			// Elicitation answers
+			ElicitationID string                 `json:"elicitation_id"`
+			Action        string                 `json:"action"`
+			Content       map[string]interface{} `json:"content"`
 		}{}
 		if err := json.Unmarshal(data, &in); err != nil {
 			continue
@@ -393,13 +427,32 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 		case "approval.resolve":
 			approvalID := strings.TrimSpace(in.ApprovalID)
 			if err := validator.ValidateID("approval_id", approvalID); err == nil {
-				_ = s.mcp.ResolveApproval(approvalID, in.Approved)
+				req := ResolveApprovalRequest{Approved: in.Approved, Scope: in.Scope, TTLSeconds: in.TTLSeconds}
+				if res, err := req.resolution(); err == nil {
+					_, _ = s.mcp.ResolveApprovalWith(approvalID, res)
+				}
+			}
+		case "elicitation.respond":
+			elicitationID := strings.TrimSpace(in.ElicitationID)
+			if err := validator.ValidateID("elicitation_id", elicitationID); err != nil {
+				continue
+			}
+			res := mcp.ElicitResult{Action: strings.TrimSpace(in.Action), Content: in.Content}
+			if _, err := s.mcp.ResolveElicitation(elicitationID, res); err != nil {
+				_ = sendJSON(map[string]any{
+					"event": "error",
+					"payload": map[string]any{
+						"kind":           "elicitation.invalid",
+						"elicitation_id": elicitationID,
+						"error":          err.Error(),
+					},
+				})
 			}
 		case "chat.send":
 			if in.Payload != nil && in.Payload["content"] != nil {
 				if content, ok := in.Payload["content"].(string); ok {
 					if err := validator.ValidateChatContent(content); err == nil {
-						s.bus.Publish(bus.NewEvent(bus.EventChatRequest, sessionFilter, in.Payload))
+						s.bus.Publish(bus.NewEvent(bus.EventChatRequest, sessionFilter, bus.ChatRequest{Content: content}))
 					}
 				}
 			}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pryx-core/internal/bus"
+	"pryx-core/internal/config"
+	"pryx-core/internal/store"
+
+	"github.com/stretchr/testify/require"
+	"nhooyr.io/websocket"
+)
+
+func TestHandleWS_ReplayLongerThanBuffer(t *testing.T) {
+	st, err := store.New(":memory:")
+	require.NoError(t, err)
+	defer st.Close()
+	s := New(&config.Config{ListenAddr: ":0", EventJournalEnabled: true}, st.DB, newTestKeychain(t))
+	defer s.Shutdown(context.Background())
+
+	const replaySession = "0b5e7a8c-3d2f-4a1b-9c8d-7e6f5a4b3c2d"
+	// Large events fill the socket, so the pump outruns the writer
+	const total = 4 * WebSocketBufferSize
+	filler := strings.Repeat("x", 8*1024)
+	for i := 0; i < total; i++ {
+		s.bus.Publish(bus.NewEvent(bus.EventSessionMessage, replaySession, map[string]interface{}{"n": i, "filler": filler}))
+	}
+
+	srv := httptest.NewServer(s.router)
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?since=0&session_id=" + replaySession + "&event=" + string(bus.EventSessionMessage)
+	c, _, err := websocket.Dial(ctx, url, nil)
+	require.NoError(t, err)
+	defer c.Close(websocket.StatusNormalClosure, "")
+	c.SetReadLimit(1 << 20)
+
+	// Let the replay back up before reading any of it
+	time.Sleep(200 * time.Millisecond)
+	last := 0
+	for i := 0; i < total; i++ {
+		_, data, err := c.Read(ctx)
+		require.NoError(t, err, "after %d events", i)
+		var evt bus.Event
+		require.NoError(t, json.Unmarshal(data, &evt))
+		require.Greater(t, evt.Version, last, "events out of order")
+		if last != 0 {
+			require.Equal(t, last+1, evt.Version, "replay skipped events")
+		}
+		last = evt.Version
+	}
+}
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Approval grant scopes. A grant with ScopeSession only matches calls from
+// the same session; ScopeArgs matches the same tool called with identical
+// arguments in the same session; ScopeAlways matches every call of the tool.
+// Every grant only answers prompts on the surface it was made on.
+const (
+	GrantScopeSession = "session"
+	GrantScopeArgs    = "args"
+	GrantScopeAlways  = "always"
+)
+
+// ApprovalGrant is a remembered answer to a tool approval prompt
+type ApprovalGrant struct {
+	ID        string     `json:"id"`
+	Tool      string     `json:"tool"`
+	Scope     string     `json:"scope"`
+	SessionID string     `json:"session_id,omitempty"`
+	ArgsHash  string     `json:"args_hash,omitempty"`
+	Approved  bool       `json:"approved"`
+	Surface   string     `json:"surface,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CreateApprovalGrant stores a grant, assigning its ID and creation time
+func (s *Store) CreateApprovalGrant(g *ApprovalGrant) error {
+	if g.ID == "" {
+		g.ID = uuid.New().String()
+	}
+	if g.CreatedAt.IsZero() {
+		g.CreatedAt = time.Now().UTC()
+	}
+	_, err := s.DB.Exec(`
+		INSERT INTO approval_grants (id, tool, scope, session_id, args_hash, approved, surface, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, g.ID, g.Tool, g.Scope, g.SessionID, g.ArgsHash, g.Approved, g.Surface, g.ExpiresAt, g.CreatedAt)
+	return err
+}
+
+// FindApprovalGrant returns the newest unexpired grant matching a tool call
+// made on surface, or nil if there is none.
+func (s *Store) FindApprovalGrant(tool, sessionID, argsHash, surface string) (*ApprovalGrant, error) {
+	row := s.DB.QueryRow(`
+		SELECT id, tool, scope, session_id, args_hash, approved, surface, expires_at, created_at
+		FROM approval_grants
+		WHERE tool = ?
+		  AND COALESCE(surface, '') = ?
+		  AND (expires_at IS NULL OR expires_at > ?)
+		  AND (scope = ?
+		       OR (scope = ? AND session_id = ?)
+		       OR (scope = ? AND session_id = ? AND args_hash = ?))
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, tool, surface, time.Now().UTC(), GrantScopeAlways, GrantScopeSession, sessionID, GrantScopeArgs, sessionID, argsHash)
+
+	g, err := scanApprovalGrant(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return g, err
+}
+
+// ListApprovalGrants returns unexpired grants, newest first. A non-empty
+// sessionID limits the result to grants that apply to that session.
+func (s *Store) ListApprovalGrants(sessionID string) ([]*ApprovalGrant, error) {
+	query := `
+		SELECT id, tool, scope, session_id, args_hash, approved, surface, expires_at, created_at
+		FROM approval_grants
+		WHERE (expires_at IS NULL OR expires_at > ?)`
+	args := []interface{}{time.Now().UTC()}
+	if sessionID != "" {
+		query += ` AND (scope = ? OR session_id = ?)`
+		args = append(args, GrantScopeAlways, sessionID)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*ApprovalGrant
+	for rows.Next() {
+		g, err := scanApprovalGrant(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+// GetApprovalGrant returns a grant by ID, or nil if it does not exist
+func (s *Store) GetApprovalGrant(id string) (*ApprovalGrant, error) {
+	row := s.DB.QueryRow(`
+		SELECT id, tool, scope, session_id, args_hash, approved, surface, expires_at, created_at
+		FROM approval_grants WHERE id = ?
+	`, id)
+	g, err := scanApprovalGrant(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return g, err
+}
+
+// DeleteApprovalGrant revokes a grant. It reports whether a grant was removed.
+func (s *Store) DeleteApprovalGrant(id string) (bool, error) {
+	res, err := s.DB.Exec(`DELETE FROM approval_grants WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanApprovalGrant(row rowScanner) (*ApprovalGrant, error) {
+	var g ApprovalGrant
+	var sessionID, argsHash, surface sql.NullString
+	var expiresAt sql.NullTime
+	if err := row.Scan(&g.ID, &g.Tool, &g.Scope, &sessionID, &argsHash, &g.Approved, &surface, &expiresAt, &g.CreatedAt); err != nil {
+		return nil, err
+	}
+	g.SessionID = sessionID.String
+	g.ArgsHash = argsHash.String
+	g.Surface = surface.String
+	if expiresAt.Valid {
+		t := expiresAt.Time
+		g.ExpiresAt = &t
+	}
+	return &g, nil
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestApprovalGrants(t *testing.T) {
+	s, err := New(filepath.Join(t.TempDir(), "grants.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	past := time.Now().Add(-time.Minute).UTC()
+	grants := []*ApprovalGrant{
+		{Tool: "mcp.shell.exec", Scope: GrantScopeSession, SessionID: "s1", Approved: true},
+		{Tool: "mcp.fs.read", Scope: GrantScopeArgs, SessionID: "s2", ArgsHash: "h1", Approved: true},
+		{Tool: "mcp.fs.write", Scope: GrantScopeAlways, Approved: false},
+		{Tool: "mcp.web.fetch", Scope: GrantScopeAlways, Approved: true, ExpiresAt: &past},
+		{Tool: "mcp.shell.run", Scope: GrantScopeAlways, Approved: true, Surface: "tui"},
+	}
+	for _, g := range grants {
+		if err := s.CreateApprovalGrant(g); err != nil {
+			t.Fatalf("CreateApprovalGrant failed: %v", err)
+		}
+	}
+
+	cases := []struct {
+		tool, session, hash, surface string
+		want                         *ApprovalGrant
+	}{
+		{"mcp.shell.exec", "s1", "", "", grants[0]},
+		{"mcp.shell.exec", "s2", "", "", nil},
+		{"mcp.fs.read", "s2", "h1", "", grants[1]},
+		{"mcp.fs.read", "s3", "h1", "", nil},
+		{"mcp.fs.read", "s2", "h2", "", nil},
+		{"mcp.fs.write", "any", "", "", grants[2]},
+		{"mcp.fs.write", "any", "", "telegram", nil},
+		{"mcp.web.fetch", "any", "", "", nil},
+		{"mcp.shell.run", "any", "", "tui", grants[4]},
+		{"mcp.shell.run", "any", "", "telegram", nil},
+	}
+	for _, c := range cases {
+		got, err := s.FindApprovalGrant(c.tool, c.session, c.hash, c.surface)
+		if err != nil {
+			t.Fatalf("FindApprovalGrant(%s) failed: %v", c.tool, err)
+		}
+		if c.want == nil {
+			if got != nil {
+				t.Errorf("FindApprovalGrant(%s, %s) = %+v, want nil", c.tool, c.session, got)
+			}
+			continue
+		}
+		if got == nil || got.ID != c.want.ID || got.Approved != c.want.Approved {
+			t.Errorf("FindApprovalGrant(%s, %s) = %+v, want %s", c.tool, c.session, got, c.want.ID)
+		}
+	}
+
+	list, err := s.ListApprovalGrants("s2")
+	if err != nil {
+		t.Fatalf("ListApprovalGrants failed: %v", err)
+	}
+	if len(list) != 3 {
+		t.Errorf("Expected 3 grants for s2, got %d", len(list))
+	}
+
+	ok, err := s.DeleteApprovalGrant(grants[0].ID)
+	if err != nil || !ok {
+		t.Fatalf("DeleteApprovalGrant = %v, %v", ok, err)
+	}
+	if got, _ := s.GetApprovalGrant(grants[0].ID); got != nil {
+		t.Error("Expected grant to be deleted")
+	}
+}
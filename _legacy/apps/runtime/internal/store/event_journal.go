@@ -0,0 +1,113 @@
+package store
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"pryx-core/internal/bus"
+)
+
+// AppendEvents records published bus events in one transaction. A
+// payload that cannot be encoded is stored as null rather than losing
+// the event.
+func (s *Store) AppendEvents(events []bus.Event) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO event_journal (version, event, session_id, surface, payload, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, e := range events {
+		payload, err := json.Marshal(e.Payload)
+		if err != nil {
+			log.Printf("store: event %d (%s) payload not journaled: %v", e.Version, e.Event, err)
+			payload = []byte("null")
+		}
+		if _, err := stmt.Exec(e.Version, string(e.Event), e.SessionID, e.Surface, string(payload), e.Timestamp.UTC()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// EventsAfter returns up to limit journaled events with a version above
+// version, oldest first. Payloads come back as decoded JSON.
+func (s *Store) EventsAfter(version, limit int) ([]bus.Event, error) {
+	rows, err := s.DB.Query(`
+		SELECT version, event, session_id, surface, payload, created_at
+		FROM event_journal WHERE version > ? ORDER BY version LIMIT ?
+	`, version, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []bus.Event
+	for rows.Next() {
+		var e bus.Event
+		var event, sessionID, surface, payload *string
+		if err := rows.Scan(&e.Version, &event, &sessionID, &surface, &payload, &e.Timestamp); err != nil {
+			return nil, err
+		}
+		e.Type = bus.EventEnvelope
+		if event != nil {
+			e.Event = bus.EventType(*event)
+		}
+		if sessionID != nil {
+			e.SessionID = *sessionID
+		}
+		if surface != nil {
+			e.Surface = *surface
+		}
+		if payload != nil {
+			if err := json.Unmarshal([]byte(*payload), &e.Payload); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// LastEventVersion returns the highest journaled version, or 0.
+func (s *Store) LastEventVersion() (int, error) {
+	var v int
+	err := s.DB.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM event_journal`).Scan(&v)
+	return v, err
+}
+
+// PruneEvents deletes journaled events created before before, unless it is
+// zero, and all but the newest keep events when keep is positive.
+func (s *Store) PruneEvents(before time.Time, keep int) (int64, error) {
+	var n int64
+	if !before.IsZero() {
+		res, err := s.DB.Exec(`DELETE FROM event_journal WHERE created_at < ?`, before.UTC())
+		if err != nil {
+			return 0, err
+		}
+		deleted, _ := res.RowsAffected()
+		n += deleted
+	}
+	if keep > 0 {
+		res, err := s.DB.Exec(`
+			DELETE FROM event_journal WHERE version <= (
+				SELECT COALESCE(MAX(version), 0) - ? FROM event_journal
+			)
+		`, keep)
+		if err != nil {
+			return n, err
+		}
+		deleted, _ := res.RowsAffected()
+		n += deleted
+	}
+	return n, nil
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pryx-core/internal/bus"
+)
+
+var _ bus.Journal = (*Store)(nil)
+
+func TestEventJournal(t *testing.T) {
+	s, err := New(filepath.Join(t.TempDir(), "journal.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	if v, err := s.LastEventVersion(); err != nil || v != 0 {
+		t.Fatalf("expected an empty journal, got %d, %v", v, err)
+	}
+
+	now := time.Now().UTC()
+	var batch []bus.Event
+	for v := 1; v <= 5; v++ {
+		e := bus.NewEvent(bus.EventSessionMessage, "s1", map[string]interface{}{"n": v})
+		e.Version = v
+		e.Surface = "tui"
+		if v == 1 {
+			e.Timestamp = now.Add(-2 * time.Hour)
+		}
+		if v == 2 {
+			e.Payload = func() {} // not JSON
+		}
+		batch = append(batch, e)
+	}
+	if err := s.AppendEvents(batch); err != nil {
+		t.Fatalf("AppendEvents failed: %v", err)
+	}
+
+	// A batch is appended whole or not at all
+	next := bus.NewEvent(bus.EventSessionMessage, "s1", nil)
+	next.Version = 6
+	if err := s.AppendEvents([]bus.Event{next, batch[4]}); err == nil {
+		t.Fatal("expected a repeated version to be refused")
+	}
+
+	events, err := s.EventsAfter(1, 2)
+	if err != nil {
+		t.Fatalf("EventsAfter failed: %v", err)
+	}
+	if len(events) != 2 || events[0].Version != 2 || events[1].Version != 3 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if events[0].Payload != nil {
+		t.Errorf("expected an unencodable payload to be stored as null, got %v", events[0].Payload)
+	}
+	e := events[1]
+	if e.Type != bus.EventEnvelope || e.Event != bus.EventSessionMessage || e.SessionID != "s1" || e.Surface != "tui" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if p, ok := e.Payload.(map[string]interface{}); !ok || p["n"] != float64(3) {
+		t.Errorf("unexpected payload: %#v", e.Payload)
+	}
+
+	if v, _ := s.LastEventVersion(); v != 5 {
+		t.Errorf("expected last version 5, got %d", v)
+	}
+
+	if n, err := s.PruneEvents(now.Add(-time.Hour), 0); err != nil || n != 1 {
+		t.Fatalf("expected the old event to be pruned, got %d, %v", n, err)
+	}
+	if n, err := s.PruneEvents(time.Time{}, 2); err != nil || n != 2 {
+		t.Fatalf("expected two events over the limit to be pruned, got %d, %v", n, err)
+	}
+	events, _ = s.EventsAfter(0, 10)
+	if len(events) != 2 || events[0].Version != 4 {
+		t.Errorf("expected versions 4 and 5 to remain, got %+v", events)
+	}
+}
@@ -0,0 +1,228 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventSubscription is a webhook that receives bus events. Topics are
+// event types or topic patterns, at least one, and Kinds globs on the
+// kind of trace and error events; no kinds accept every kind.
+type EventSubscription struct {
+	ID        string            `json:"id"`
+	URL       string            `json:"url"`
+	Topics    []string          `json:"topics"`
+	Kinds     []string          `json:"kinds,omitempty"`
+	SessionID string            `json:"session_id,omitempty"`
+	Secret    string            `json:"secret,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Enabled   bool              `json:"enabled"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// EventDelivery is one attempt, with its retries, to send an event to a
+// subscription.
+type EventDelivery struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	Event          string    `json:"event"`
+	Version        int       `json:"version"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	ResponseCode   int       `json:"response_code,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     int64     `json:"duration_ms"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+const eventSubscriptionColumns = `id, url, topics, kinds, session_id, secret, headers, enabled, created_at, updated_at`
+
+// CreateEventSubscription stores a subscription, assigning its ID and
+// timestamps
+func (s *Store) CreateEventSubscription(sub *EventSubscription) error {
+	if sub.ID == "" {
+		sub.ID = uuid.New().String()
+	}
+	now := time.Now().UTC()
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+	topics, kinds, headers, err := encodeSubscriptionLists(sub)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(`
+		INSERT INTO event_subscriptions (`+eventSubscriptionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, sub.ID, sub.URL, topics, kinds, sub.SessionID, sub.Secret, headers, sub.Enabled, sub.CreatedAt, sub.UpdatedAt)
+	return err
+}
+
+// UpdateEventSubscription replaces a subscription's settings. It reports
+// whether the subscription exists.
+func (s *Store) UpdateEventSubscription(sub *EventSubscription) (bool, error) {
+	sub.UpdatedAt = time.Now().UTC()
+	topics, kinds, headers, err := encodeSubscriptionLists(sub)
+	if err != nil {
+		return false, err
+	}
+	res, err := s.DB.Exec(`
+		UPDATE event_subscriptions
+		SET url = ?, topics = ?, kinds = ?, session_id = ?, secret = ?, headers = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`, sub.URL, topics, kinds, sub.SessionID, sub.Secret, headers, sub.Enabled, sub.UpdatedAt, sub.ID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetEventSubscription returns a subscription by ID, or nil if it does not
+// exist
+func (s *Store) GetEventSubscription(id string) (*EventSubscription, error) {
+	row := s.DB.QueryRow(`SELECT `+eventSubscriptionColumns+` FROM event_subscriptions WHERE id = ?`, id)
+	sub, err := scanEventSubscription(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sub, err
+}
+
+// ListEventSubscriptions returns every subscription, oldest first
+func (s *Store) ListEventSubscriptions() ([]*EventSubscription, error) {
+	rows, err := s.DB.Query(`SELECT ` + eventSubscriptionColumns + ` FROM event_subscriptions ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*EventSubscription
+	for rows.Next() {
+		sub, err := scanEventSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sub)
+	}
+	return out, rows.Err()
+}
+
+// DeleteEventSubscription removes a subscription and its delivery log. It
+// reports whether a subscription was removed.
+func (s *Store) DeleteEventSubscription(id string) (bool, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM event_subscriptions WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+	if _, err := tx.Exec(`DELETE FROM event_deliveries WHERE subscription_id = ?`, id); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// RecordEventDelivery adds a delivery to the log, assigning its ID and
+// creation time if unset
+func (s *Store) RecordEventDelivery(d *EventDelivery) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now().UTC()
+	}
+	_, err := s.DB.Exec(`
+		INSERT INTO event_deliveries (id, subscription_id, event, version, status, attempts, response_code, error, duration_ms, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.ID, d.SubscriptionID, d.Event, d.Version, d.Status, d.Attempts, d.ResponseCode, d.Error, d.DurationMs, d.CreatedAt)
+	return err
+}
+
+// ListEventDeliveries returns a subscription's newest deliveries first, up
+// to limit
+func (s *Store) ListEventDeliveries(subscriptionID string, limit int) ([]*EventDelivery, error) {
+	rows, err := s.DB.Query(`
+		SELECT id, subscription_id, event, version, status, attempts, response_code, error, duration_ms, created_at
+		FROM event_deliveries WHERE subscription_id = ?
+		ORDER BY created_at DESC LIMIT ?
+	`, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*EventDelivery
+	for rows.Next() {
+		var d EventDelivery
+		var code, duration sql.NullInt64
+		var msg sql.NullString
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.Event, &d.Version, &d.Status, &d.Attempts, &code, &msg, &duration, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.ResponseCode = int(code.Int64)
+		d.Error = msg.String
+		d.DurationMs = duration.Int64
+		out = append(out, &d)
+	}
+	return out, rows.Err()
+}
+
+// PruneEventDeliveries deletes deliveries logged before before. It returns
+// how many were deleted.
+func (s *Store) PruneEventDeliveries(before time.Time) (int64, error) {
+	res, err := s.DB.Exec(`DELETE FROM event_deliveries WHERE created_at < ?`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func encodeSubscriptionLists(sub *EventSubscription) (topics, kinds, headers string, err error) {
+	for _, f := range []struct {
+		v   interface{}
+		out *string
+	}{{sub.Topics, &topics}, {sub.Kinds, &kinds}, {sub.Headers, &headers}} {
+		data, err := json.Marshal(f.v)
+		if err != nil {
+			return "", "", "", err
+		}
+		*f.out = string(data)
+	}
+	return topics, kinds, headers, nil
+}
+
+func scanEventSubscription(row rowScanner) (*EventSubscription, error) {
+	var sub EventSubscription
+	var topics, kinds, sessionID, secret, headers sql.NullString
+	if err := row.Scan(&sub.ID, &sub.URL, &topics, &kinds, &sessionID, &secret, &headers, &sub.Enabled, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+		return nil, err
+	}
+	sub.SessionID = sessionID.String
+	sub.Secret = secret.String
+	for _, f := range []struct {
+		raw sql.NullString
+		out interface{}
+	}{{topics, &sub.Topics}, {kinds, &sub.Kinds}, {headers, &sub.Headers}} {
+		if f.raw.Valid && f.raw.String != "" {
+			if err := json.Unmarshal([]byte(f.raw.String), f.out); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if sub.Topics == nil {
+		sub.Topics = []string{}
+	}
+	return &sub, nil
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEventSubscriptions(t *testing.T) {
+	s, err := New(filepath.Join(t.TempDir(), "hooks.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	sub := &EventSubscription{
+		URL:     "https://ci.example.com/hook",
+		Topics:  []string{"approval.needed", "error.*"},
+		Secret:  "s3cret",
+		Headers: map[string]string{"X-Team": "infra"},
+		Enabled: true,
+	}
+	if err := s.CreateEventSubscription(sub); err != nil {
+		t.Fatalf("CreateEventSubscription failed: %v", err)
+	}
+
+	got, err := s.GetEventSubscription(sub.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetEventSubscription: %v, %v", got, err)
+	}
+	if got.URL != sub.URL || len(got.Topics) != 2 || got.Topics[1] != "error.*" || got.Secret != "s3cret" ||
+		got.Headers["X-Team"] != "infra" || !got.Enabled || got.Kinds != nil {
+		t.Errorf("unexpected subscription %+v", got)
+	}
+
+	got.Enabled = false
+	got.Kinds = []string{"scheduler.*"}
+	if ok, err := s.UpdateEventSubscription(got); err != nil || !ok {
+		t.Fatalf("UpdateEventSubscription: %v, %v", ok, err)
+	}
+	list, err := s.ListEventSubscriptions()
+	if err != nil || len(list) != 1 || list[0].Enabled || list[0].Kinds[0] != "scheduler.*" {
+		t.Fatalf("unexpected list %v, %v", list, err)
+	}
+	if ok, _ := s.UpdateEventSubscription(&EventSubscription{ID: "missing"}); ok {
+		t.Error("expected updating a missing subscription to report false")
+	}
+
+	old := &EventDelivery{SubscriptionID: sub.ID, Event: "error.occurred", Version: 1, Status: "failed", Attempts: 4, Error: "HTTP 500", ResponseCode: 500, CreatedAt: time.Now().Add(-48 * time.Hour)}
+	recent := &EventDelivery{SubscriptionID: sub.ID, Event: "approval.needed", Version: 2, Status: "delivered", Attempts: 1, ResponseCode: 204}
+	for _, d := range []*EventDelivery{old, recent} {
+		if err := s.RecordEventDelivery(d); err != nil {
+			t.Fatalf("RecordEventDelivery failed: %v", err)
+		}
+	}
+	deliveries, err := s.ListEventDeliveries(sub.ID, 10)
+	if err != nil || len(deliveries) != 2 || deliveries[0].ID != recent.ID || deliveries[1].Error != "HTTP 500" {
+		t.Fatalf("unexpected deliveries %v, %v", deliveries, err)
+	}
+	if n, err := s.PruneEventDeliveries(time.Now().Add(-24 * time.Hour)); err != nil || n != 1 {
+		t.Errorf("PruneEventDeliveries: %d, %v", n, err)
+	}
+
+	if ok, err := s.DeleteEventSubscription(sub.ID); err != nil || !ok {
+		t.Fatalf("DeleteEventSubscription: %v, %v", ok, err)
+	}
+	if deliveries, _ := s.ListEventDeliveries(sub.ID, 10); len(deliveries) != 0 {
+		t.Errorf("expected the delivery log to go with the subscription, got %d", len(deliveries))
+	}
+	if got, _ := s.GetEventSubscription(sub.ID); got != nil {
+		t.Error("expected the subscription to be gone")
+	}
+}
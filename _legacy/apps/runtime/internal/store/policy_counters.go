@@ -0,0 +1,55 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"pryx-core/internal/policy"
+)
+
+// CountPolicyCalls returns the calls counted under key, or 0 once the
+// counter has expired.
+func (s *Store) CountPolicyCalls(key string) (int, error) {
+	var n int
+	err := s.DB.QueryRow(`
+		SELECT count FROM policy_counters WHERE key = ? AND expires_at > ?
+	`, key, time.Now().UTC()).Scan(&n)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return n, err
+}
+
+// TakePolicyCall counts one call under the key of every quota in one
+// transaction, unless one has already reached its max. Counters are
+// dropped once they expire. The first statement writes, so the transaction
+// holds the database's write lock while it checks and counts, and other
+// processes sharing the file cannot count in between.
+func (s *Store) TakePolicyCall(quotas []policy.Quota) (int, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM policy_counters WHERE expires_at <= ?`, time.Now().UTC()); err != nil {
+		return 0, err
+	}
+	for i, q := range quotas {
+		res, err := tx.Exec(`
+			INSERT INTO policy_counters (key, count, expires_at) VALUES (?, 1, ?)
+			ON CONFLICT(key) DO UPDATE SET count = count + 1 WHERE count < ?
+		`, q.Key, q.Expires.UTC(), q.Max)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			return i, nil
+		}
+	}
+	return -1, tx.Commit()
+}
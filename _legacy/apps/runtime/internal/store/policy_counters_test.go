@@ -0,0 +1,66 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pryx-core/internal/policy"
+)
+
+func TestPolicyCounters(t *testing.T) {
+	s, err := New(filepath.Join(t.TempDir(), "counters.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	later := time.Now().Add(time.Hour)
+	take := func(s *Store, quotas ...policy.Quota) int {
+		t.Helper()
+		full, err := s.TakePolicyCall(quotas)
+		if err != nil {
+			t.Fatalf("TakePolicyCall failed: %v", err)
+		}
+		return full
+	}
+	for i := 0; i < 3; i++ {
+		if full := take(s, policy.Quota{Key: "k1", Max: 5, Expires: later}); full != -1 {
+			t.Fatalf("call %d: quota %d reported full", i, full)
+		}
+	}
+	take(s, policy.Quota{Key: "expired", Max: 5, Expires: time.Now().Add(-time.Second)})
+
+	// A full quota stops the call from counting against any of them
+	if full := take(s, policy.Quota{Key: "k2", Max: 5, Expires: later}, policy.Quota{Key: "k1", Max: 3, Expires: later}); full != 1 {
+		t.Fatalf("expected the second quota to be full, got %d", full)
+	}
+
+	cases := map[string]int{"k1": 3, "k2": 0, "expired": 0, "unknown": 0}
+	for key, want := range cases {
+		got, err := s.CountPolicyCalls(key)
+		if err != nil {
+			t.Fatalf("CountPolicyCalls(%s) failed: %v", key, err)
+		}
+		if got != want {
+			t.Errorf("%s: got %d, want %d", key, got, want)
+		}
+	}
+
+	// Counters survive reopening the database
+	path := filepath.Join(t.TempDir(), "reopen.db")
+	s2, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	take(s2, policy.Quota{Key: "k", Max: 1, Expires: later})
+	s2.Close()
+	s3, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s3.Close()
+	if n, _ := s3.CountPolicyCalls("k"); n != 1 {
+		t.Errorf("expected the count to persist, got %d", n)
+	}
+}
@@ -137,6 +137,73 @@ CREATE TABLE IF NOT EXISTS mesh_sync_events (
 CREATE INDEX IF NOT EXISTS idx_mesh_events_source ON mesh_sync_events(source_device_id);
 CREATE INDEX IF NOT EXISTS idx_mesh_events_created ON mesh_sync_events(created_at DESC);
 
+-- Remembered approval decisions
+CREATE TABLE IF NOT EXISTS approval_grants (
+    id TEXT PRIMARY KEY,
+    tool TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    session_id TEXT,
+    args_hash TEXT,
+    approved BOOLEAN NOT NULL DEFAULT 1,
+    surface TEXT,
+    expires_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_approval_grants_tool ON approval_grants(tool);
+CREATE INDEX IF NOT EXISTS idx_approval_grants_session ON approval_grants(session_id);
+
+-- Call counts behind policy rate limits and quotas
+CREATE TABLE IF NOT EXISTS policy_counters (
+    key TEXT PRIMARY KEY,
+    count INTEGER NOT NULL DEFAULT 0,
+    expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_policy_counters_expires ON policy_counters(expires_at);
+
+-- Journal of published bus events, for replay after a reconnect
+CREATE TABLE IF NOT EXISTS event_journal (
+    version INTEGER PRIMARY KEY,
+    event TEXT NOT NULL,
+    session_id TEXT,
+    surface TEXT,
+    payload TEXT,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_journal_created ON event_journal(created_at);
+
+-- Webhooks that receive bus events, and their delivery log
+CREATE TABLE IF NOT EXISTS event_subscriptions (
+    id TEXT PRIMARY KEY,
+    url TEXT NOT NULL,
+    topics TEXT,
+    kinds TEXT,
+    session_id TEXT,
+    secret TEXT,
+    headers TEXT,
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS event_deliveries (
+    id TEXT PRIMARY KEY,
+    subscription_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_code INTEGER,
+    error TEXT,
+    duration_ms INTEGER,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_deliveries_subscription ON event_deliveries(subscription_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_event_deliveries_created ON event_deliveries(created_at);
+
 -- Scheduled tasks (cron jobs)
 CREATE TABLE IF NOT EXISTS scheduled_tasks (
     id TEXT PRIMARY KEY,